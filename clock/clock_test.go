@@ -0,0 +1,142 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package clock
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+var start = time.Date(2024, 3, 26, 12, 0, 0, 0, time.UTC)
+
+func received(c <-chan time.Time) (time.Time, bool) {
+	select {
+	case t := <-c:
+		return t, true
+	default:
+		return time.Time{}, false
+	}
+}
+
+func TestFakeNow(t *testing.T) {
+	f := NewFake(start)
+	assert.Equal(t, start, f.Now())
+
+	f.Advance(time.Minute)
+	assert.Equal(t, start.Add(time.Minute), f.Now())
+	assert.Equal(t, time.Minute, f.Since(start))
+
+	f.Set(start)
+	assert.Equal(t, start, f.Now())
+}
+
+func TestFakeTimer(t *testing.T) {
+	f := NewFake(start)
+	timer := f.NewTimer(10 * time.Second)
+
+	f.Advance(9 * time.Second)
+	_, ok := received(timer.C())
+	assert.False(t, ok)
+
+	f.Advance(5 * time.Second)
+	fired, ok := received(timer.C())
+	assert.True(t, ok)
+	assert.Equal(t, start.Add(10*time.Second), fired)
+	assert.Equal(t, 0, f.Waiters())
+	assert.False(t, timer.Stop())
+
+	assert.False(t, timer.Reset(time.Second))
+	assert.True(t, timer.Stop())
+	f.Advance(time.Hour)
+	_, ok = received(timer.C())
+	assert.False(t, ok)
+
+	_, ok = received(f.After(0))
+	assert.True(t, ok, "zero duration timers fire immediately")
+}
+
+func TestFakeTicker(t *testing.T) {
+	f := NewFake(start)
+	ticker := f.NewTicker(time.Second)
+
+	f.Advance(time.Second)
+	tick, ok := received(ticker.C())
+	assert.True(t, ok)
+	assert.Equal(t, start.Add(time.Second), tick)
+
+	// Ticks are dropped if the receiver is not keeping up.
+	f.Advance(3 * time.Second)
+	tick, ok = received(ticker.C())
+	assert.True(t, ok)
+	assert.Equal(t, start.Add(2*time.Second), tick)
+	_, ok = received(ticker.C())
+	assert.False(t, ok)
+
+	ticker.Reset(time.Minute)
+	f.Advance(59 * time.Second)
+	_, ok = received(ticker.C())
+	assert.False(t, ok)
+	f.Advance(time.Second)
+	_, ok = received(ticker.C())
+	assert.True(t, ok)
+
+	ticker.Stop()
+	assert.Equal(t, 0, f.Waiters())
+	assert.Panics(t, func() { f.NewTicker(0) })
+}
+
+func TestFakeFiresInOrder(t *testing.T) {
+	f := NewFake(start)
+	late := f.NewTimer(2 * time.Second)
+	early := f.NewTimer(time.Second)
+
+	f.Advance(time.Hour)
+	lateAt, _ := received(late.C())
+	earlyAt, _ := received(early.C())
+	assert.Equal(t, start.Add(time.Second), earlyAt)
+	assert.Equal(t, start.Add(2*time.Second), lateAt)
+	assert.Equal(t, start.Add(time.Hour), f.Now())
+}
+
+func TestFakeBlockUntil(t *testing.T) {
+	f := NewFake(start)
+	done := make(chan time.Time)
+	go func() {
+		done <- <-f.After(time.Minute)
+	}()
+
+	f.BlockUntil(1)
+	f.Advance(time.Minute)
+	assert.Equal(t, start.Add(time.Minute), <-done)
+}
+
+func TestReal(t *testing.T) {
+	c := Real()
+	before := time.Now()
+	assert.False(t, c.Now().Before(before))
+
+	timer := c.NewTimer(time.Millisecond)
+	<-timer.C()
+	ticker := c.NewTicker(time.Millisecond)
+	<-ticker.C()
+	ticker.Stop()
+	<-c.After(time.Millisecond)
+	assert.Greater(t, c.Since(before), time.Duration(0))
+}
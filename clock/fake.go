@@ -0,0 +1,229 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package clock
+
+import (
+	"sync"
+	"time"
+)
+
+// Fake is a Clock for tests. Its time only moves when Advance or Set is
+// called, firing the timers and tickers that became due, in order.
+//
+// As with time.Timer, channels have a buffer of one and values are dropped
+// when the receiver is not keeping up.
+type Fake struct {
+	mu      sync.Mutex
+	cond    *sync.Cond
+	now     time.Time
+	waiters []*fakeWaiter
+}
+
+type fakeWaiter struct {
+	clock  *Fake
+	c      chan time.Time
+	when   time.Time
+	period time.Duration // only set for tickers
+	active bool
+}
+
+// NewFake returns a Fake clock set to start.
+func NewFake(start time.Time) *Fake {
+	f := &Fake{now: start}
+	f.cond = sync.NewCond(&f.mu)
+	return f
+}
+
+// Now returns the current time of the fake clock.
+func (f *Fake) Now() time.Time {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.now
+}
+
+// Since returns the fake time elapsed since t.
+func (f *Fake) Since(t time.Time) time.Duration {
+	return f.Now().Sub(t)
+}
+
+// After returns a channel receiving the fake time once the clock has been
+// advanced by d.
+func (f *Fake) After(d time.Duration) <-chan time.Time {
+	return f.NewTimer(d).C()
+}
+
+// NewTimer returns a Timer firing once the clock has been advanced by d.
+func (f *Fake) NewTimer(d time.Duration) Timer {
+	return f.addWaiter(d, 0)
+}
+
+// NewTicker returns a Ticker firing every time the clock has been advanced
+// by d.
+func (f *Fake) NewTicker(d time.Duration) Ticker {
+	if d <= 0 {
+		panic("non-positive interval for NewTicker")
+	}
+	return fakeTicker{f.addWaiter(d, d)}
+}
+
+func (f *Fake) addWaiter(d, period time.Duration) *fakeWaiter {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	w := &fakeWaiter{
+		clock:  f,
+		c:      make(chan time.Time, 1),
+		period: period,
+	}
+	f.schedule(w, d)
+	return w
+}
+
+// schedule activates w to fire after d. f.mu must be held.
+func (f *Fake) schedule(w *fakeWaiter, d time.Duration) {
+	w.when = f.now.Add(d)
+	if !w.active {
+		w.active = true
+		f.waiters = append(f.waiters, w)
+	}
+	f.cond.Broadcast()
+	if d <= 0 && w.period == 0 {
+		f.fire(w)
+	}
+}
+
+// unschedule deactivates w. It returns true if w was active. f.mu must be
+// held.
+func (f *Fake) unschedule(w *fakeWaiter) bool {
+	if !w.active {
+		return false
+	}
+	w.active = false
+	for i, other := range f.waiters {
+		if other == w {
+			f.waiters = append(f.waiters[:i], f.waiters[i+1:]...)
+			break
+		}
+	}
+	return true
+}
+
+// fire sends the current time to w and reschedules or deactivates it.
+// f.mu must be held.
+func (f *Fake) fire(w *fakeWaiter) {
+	select {
+	case w.c <- f.now:
+	default:
+	}
+	if w.period > 0 {
+		w.when = w.when.Add(w.period)
+	} else {
+		f.unschedule(w)
+	}
+}
+
+// Advance moves the clock forward by d, firing all timers and tickers that
+// become due on the way.
+func (f *Fake) Advance(d time.Duration) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.advanceTo(f.now.Add(d))
+}
+
+// Set moves the clock to t, firing all timers and tickers that become due on
+// the way. Setting a time before the current time only changes Now.
+func (f *Fake) Set(t time.Time) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if t.Before(f.now) {
+		f.now = t
+		return
+	}
+	f.advanceTo(t)
+}
+
+func (f *Fake) advanceTo(t time.Time) {
+	for {
+		var next *fakeWaiter
+		for _, w := range f.waiters {
+			if !w.when.After(t) && (next == nil || w.when.Before(next.when)) {
+				next = w
+			}
+		}
+		if next == nil {
+			break
+		}
+		if next.when.After(f.now) {
+			f.now = next.when
+		}
+		f.fire(next)
+	}
+	f.now = t
+}
+
+// Waiters returns the number of active timers and tickers.
+func (f *Fake) Waiters() int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return len(f.waiters)
+}
+
+// BlockUntil blocks until at least n timers or tickers are active. It is
+// used to make sure the code under test is waiting on the clock before
+// advancing it.
+func (f *Fake) BlockUntil(n int) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	for len(f.waiters) < n {
+		f.cond.Wait()
+	}
+}
+
+func (w *fakeWaiter) C() <-chan time.Time {
+	return w.c
+}
+
+func (w *fakeWaiter) Stop() bool {
+	w.clock.mu.Lock()
+	defer w.clock.mu.Unlock()
+	return w.clock.unschedule(w)
+}
+
+func (w *fakeWaiter) Reset(d time.Duration) bool {
+	w.clock.mu.Lock()
+	defer w.clock.mu.Unlock()
+	wasActive := w.active
+	w.clock.schedule(w, d)
+	return wasActive
+}
+
+type fakeTicker struct {
+	w *fakeWaiter
+}
+
+func (t fakeTicker) C() <-chan time.Time { return t.w.C() }
+func (t fakeTicker) Stop()               { t.w.Stop() }
+
+func (t fakeTicker) Reset(d time.Duration) {
+	if d <= 0 {
+		panic("non-positive interval for Ticker.Reset")
+	}
+	t.w.clock.mu.Lock()
+	defer t.w.clock.mu.Unlock()
+	t.w.period = d
+	t.w.clock.schedule(t.w, d)
+}
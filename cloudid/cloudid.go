@@ -24,6 +24,7 @@ import (
 	"errors"
 	"fmt"
 	"net/url"
+	"strconv"
 	"strings"
 
 	"github.com/elastic/elastic-agent-libs/config"
@@ -32,11 +33,28 @@ import (
 
 const defaultCloudPort = "443"
 
+// Service identifies one of the services whose URL can be encoded in a cloud ID.
+type Service string
+
+const (
+	Elasticsearch      Service = "elasticsearch"
+	Kibana             Service = "kibana"
+	APM                Service = "apm"
+	Fleet              Service = "fleet"
+	IntegrationsServer Service = "integrations_server"
+)
+
+// services lists the services in the order their segments appear in the
+// decoded cloud ID, after the host segment. Only Elasticsearch and Kibana
+// are required, the other segments can be missing or empty.
+var services = []Service{Elasticsearch, Kibana, APM, Fleet, IntegrationsServer}
+
 // CloudID encapsulates the encoded (i.e. raw) and decoded parts of Elastic Cloud ID.
 type CloudID struct {
 	id     string
 	esURL  string
 	kibURL string
+	urls   map[Service]string
 
 	auth     string
 	username string
@@ -67,6 +85,32 @@ func (c *CloudID) KibanaURL() string {
 	return c.kibURL
 }
 
+// APMURL returns the APM Server URL decoded from the cloud ID, or an empty
+// string if the cloud ID has no APM segment.
+func (c *CloudID) APMURL() string {
+	return c.urls[APM]
+}
+
+// FleetURL returns the Fleet Server URL decoded from the cloud ID. Cloud IDs
+// of older deployments have no Fleet segment, in that case the second return
+// value is false and the Fleet Server URL must be configured separately.
+func (c *CloudID) FleetURL() (string, bool) {
+	return c.URL(Fleet)
+}
+
+// IntegrationsServerURL returns the Integrations Server URL decoded from the
+// cloud ID, or an empty string if the cloud ID has no Integrations Server segment.
+func (c *CloudID) IntegrationsServerURL() string {
+	return c.urls[IntegrationsServer]
+}
+
+// URL returns the URL of the given service decoded from the cloud ID. The
+// second return value is false if the cloud ID has no segment for it.
+func (c *CloudID) URL(svc Service) (string, bool) {
+	u, ok := c.urls[svc]
+	return u, ok
+}
+
 // Username returns the username decoded from the cloud auth.
 func (c *CloudID) Username() string {
 	return c.username
@@ -92,10 +136,55 @@ func (c *CloudID) decode() error {
 	return nil
 }
 
-// decodeCloudID decodes the c.id into c.esURL and c.kibURL
-func (c *CloudID) decodeCloudID() error {
-	cloudID := c.id
+// Reasons for a cloud ID to be invalid, the errors returned by Validate and
+// NewCloudID wrap one of them.
+var (
+	ErrInvalidBase64    = errors.New("base64 decoding failed")
+	ErrMissingSeparator = errors.New("expected at least 3 parts")
+	ErrEmptySegment     = errors.New("empty segment")
+	ErrSchemeConflict   = errors.New("unexpected URL scheme")
+	ErrInvalidPort      = errors.New("invalid port")
+)
+
+// ValidationError describes why a cloud ID is invalid.
+type ValidationError struct {
+	// Reason is one of the ErrInvalidBase64, ErrMissingSeparator,
+	// ErrEmptySegment, ErrSchemeConflict or ErrInvalidPort errors.
+	Reason error
+	// Detail tells which part of the cloud ID is invalid.
+	Detail string
+	// Err is the underlying error, if any.
+	Err error
+}
+
+func (e *ValidationError) Error() string {
+	msg := e.Reason.Error()
+	if e.Detail != "" {
+		msg += " " + e.Detail
+	}
+	if e.Err != nil {
+		msg += ": " + e.Err.Error()
+	}
+	return msg
+}
+
+func (e *ValidationError) Unwrap() []error {
+	if e.Err == nil {
+		return []error{e.Reason}
+	}
+	return []error{e.Reason, e.Err}
+}
 
+// Validate checks the cloud ID can be decoded. The returned error is a
+// *ValidationError telling why it cannot be decoded, it can be matched
+// against the Err* reasons with errors.Is.
+func Validate(cloudID string) error {
+	_, err := splitCloudID(cloudID)
+	return err
+}
+
+// splitCloudID decodes the cloud ID and returns its `$` separated segments.
+func splitCloudID(cloudID string) ([]string, error) {
 	// 1. Ignore anything before `:`.
 	idx := strings.LastIndex(cloudID, ":")
 	if idx >= 0 {
@@ -105,30 +194,140 @@ func (c *CloudID) decodeCloudID() error {
 	// 2. base64 decode
 	decoded, err := base64.StdEncoding.DecodeString(cloudID)
 	if err != nil {
-		return fmt.Errorf("base64 decoding failed on %s: %w", cloudID, err)
+		return nil, &ValidationError{Reason: ErrInvalidBase64, Detail: "on " + cloudID, Err: err}
 	}
 
 	// 3. separate based on `$`
 	words := strings.Split(string(decoded), "$")
 	if len(words) < 3 {
-		return fmt.Errorf("expected at least 3 parts in %s", string(decoded))
+		return nil, &ValidationError{Reason: ErrMissingSeparator, Detail: fmt.Sprintf("separated by `$` in %s", string(decoded))}
+	}
+
+	if strings.Contains(words[0], "://") {
+		return nil, &ValidationError{Reason: ErrSchemeConflict, Detail: fmt.Sprintf("in host segment %q, the https scheme is implied", words[0])}
+	}
+	for i, word := range words {
+		if i > len(services) {
+			// Segments of unknown services are ignored.
+			break
+		}
+		name := "host"
+		if i > 0 {
+			name = string(services[i-1])
+		}
+		id, port, hasPort := word, "", false
+		if idx := strings.LastIndex(word, ":"); idx >= 0 {
+			id, port, hasPort = word[:idx], word[idx+1:], true
+		}
+		if id == "" && (i < 3 || hasPort) {
+			return nil, &ValidationError{Reason: ErrEmptySegment, Detail: fmt.Sprintf("for %s in %s", name, string(decoded))}
+		}
+		if hasPort {
+			if n, err := strconv.Atoi(port); err != nil || n < 1 || n > 65535 {
+				return nil, &ValidationError{Reason: ErrInvalidPort, Detail: fmt.Sprintf("%q for %s", port, name)}
+			}
+		}
+	}
+
+	return words, nil
+}
+
+// decodeCloudID decodes the c.id into c.esURL and c.kibURL
+func (c *CloudID) decodeCloudID() error {
+	words, err := splitCloudID(c.id)
+	if err != nil {
+		return err
 	}
 
-	// 4. extract port from the ES and Kibana host, or use 443 as the default
+	// 4. extract port from the host, or use 443 as the default, and the
+	// port of each service, or use the host port as the default
 	host, port := extractPortFromName(words[0], defaultCloudPort)
-	esID, esPort := extractPortFromName(words[1], port)
-	kbID, kbPort := extractPortFromName(words[2], port)
+	c.urls = make(map[Service]string, len(services))
+	for i, svc := range services {
+		if i+1 >= len(words) || words[i+1] == "" {
+			continue
+		}
+		id, svcPort := extractPortFromName(words[i+1], port)
 
-	// 5. form the URLs
-	esURL := url.URL{Scheme: "https", Host: fmt.Sprintf("%s.%s:%s", esID, host, esPort)}
-	kibanaURL := url.URL{Scheme: "https", Host: fmt.Sprintf("%s.%s:%s", kbID, host, kbPort)}
+		// 5. form the URL
+		u := url.URL{Scheme: "https", Host: fmt.Sprintf("%s.%s:%s", id, host, svcPort)}
+		c.urls[svc] = u.String()
+	}
 
-	c.esURL = esURL.String()
-	c.kibURL = kibanaURL.String()
+	c.esURL = c.urls[Elasticsearch]
+	c.kibURL = c.urls[Kibana]
 
 	return nil
 }
 
+// Encode builds a cloud ID from the URLs of the services, it is the inverse
+// of decoding a cloud ID. The Elasticsearch and Kibana URLs are required.
+// All URLs must use the https scheme, have no path, and have a host made of
+// a service ID followed by a domain shared by all of them, like
+// https://<id>.us-east-1.aws.found.io:9243. The name is prepended to the
+// encoded value unless it is empty.
+func Encode(name string, urls map[Service]string) (string, error) {
+	var domain, port string
+	segments := make([]string, len(services))
+	last := 0
+	for i, svc := range services {
+		raw, ok := urls[svc]
+		if !ok || raw == "" {
+			if svc == Elasticsearch || svc == Kibana {
+				return "", fmt.Errorf("the %s URL is required", svc)
+			}
+			continue
+		}
+
+		u, err := url.Parse(raw)
+		if err != nil {
+			return "", fmt.Errorf("invalid %s URL: %w", svc, err)
+		}
+		if u.Scheme != "https" {
+			return "", fmt.Errorf("the %s URL must use the https scheme: %s", svc, raw)
+		}
+		if strings.Trim(u.Path, "/") != "" || u.RawQuery != "" || u.User != nil {
+			return "", fmt.Errorf("the %s URL must only have a host and a port: %s", svc, raw)
+		}
+
+		id, svcDomain, found := strings.Cut(u.Hostname(), ".")
+		if !found || id == "" || svcDomain == "" {
+			return "", fmt.Errorf("the %s URL host must be <id>.<domain>: %s", svc, raw)
+		}
+		if domain == "" {
+			domain = svcDomain
+		} else if domain != svcDomain {
+			return "", fmt.Errorf("the %s URL domain %s differs from %s", svc, svcDomain, domain)
+		}
+
+		svcPort := u.Port()
+		if svcPort == "" {
+			svcPort = defaultCloudPort
+		}
+		if svc == Elasticsearch {
+			// The Elasticsearch port is the default of the other services.
+			port = svcPort
+		}
+		segments[i] = id
+		if svcPort != port {
+			segments[i] = id + ":" + svcPort
+		}
+		last = i
+	}
+
+	host := domain
+	if port != defaultCloudPort {
+		host = domain + ":" + port
+	}
+	decoded := strings.Join(append([]string{host}, segments[:last+1]...), "$")
+
+	encoded := base64.StdEncoding.EncodeToString([]byte(decoded))
+	if name == "" {
+		return encoded, nil
+	}
+	return name + ":" + encoded, nil
+}
+
 // decodeCloudAuth splits the c.auth into c.username and c.password.
 func (c *CloudID) decodeCloudAuth() error {
 	cloudAuth := c.auth
@@ -146,6 +345,18 @@ func (c *CloudID) decodeCloudAuth() error {
 // output.elasticsearch.hosts, output.elasticsearch.username, output.elasticsearch.password,
 // setup.kibana.host settings based on values derived from the cloud.id and cloud.auth
 // settings.
+//
+// The endpoints taken from the cloud.id can be selected with the
+// cloud.endpoints.elasticsearch and cloud.endpoints.kibana settings, both
+// default to true. This allows hybrid setups, e.g. a self-managed Kibana
+// with Elasticsearch on Elastic Cloud. The precedence rules are:
+//   - an endpoint taken from the cloud.id overwrites the configured one, a
+//     warning is logged if it was configured;
+//   - an endpoint not taken from the cloud.id keeps its configured value, a
+//     warning is logged if it is not configured;
+//   - the cloud.auth credentials are always set on the Elasticsearch output,
+//     unless its endpoint is not taken from the cloud.id and another output
+//     is enabled.
 func OverwriteSettings(cfg *config.C) error {
 
 	logger := logp.NewLogger("cloudid")
@@ -168,11 +379,22 @@ func OverwriteSettings(cfg *config.C) error {
 		return fmt.Errorf("error decoding cloud.id: %w", err)
 	}
 
-	logger.Infof("Setting Elasticsearch and Kibana URLs based on the cloud id: output.elasticsearch.hosts=%s and setup.kibana.host=%s", cid.esURL, cid.kibURL)
-
-	esURLConfig, err := config.NewConfigFrom([]string{cid.ElasticsearchURL()})
-	if err != nil {
-		return err
+	endpoints := struct {
+		Elasticsearch bool `config:"elasticsearch"`
+		Kibana        bool `config:"kibana"`
+	}{Elasticsearch: true, Kibana: true}
+	if ok, _ := cfg.Has("cloud.endpoints", -1); ok {
+		child, err := cfg.Child("cloud.endpoints", -1)
+		if err != nil {
+			return fmt.Errorf("error reading cloud.endpoints: %w", err)
+		}
+		if err := child.Unpack(&endpoints); err != nil {
+			return fmt.Errorf("error reading cloud.endpoints: %w", err)
+		}
+	}
+	if !endpoints.Elasticsearch && !endpoints.Kibana && cloudAuth == "" {
+		logger.Warn("cloud.id is set, but cloud.endpoints disables all of its endpoints")
+		return nil
 	}
 
 	// Before enabling the ES output, check that no other output is enabled
@@ -182,21 +404,50 @@ func OverwriteSettings(cfg *config.C) error {
 	if err := cfg.Unpack(&tmp); err != nil {
 		return err
 	}
-	if out := tmp.Output; out.IsSet() && out.Name() != "elasticsearch" {
-		return fmt.Errorf("the cloud.id setting enables the Elasticsearch output, but you already have the %s output enabled in the config", out.Name())
-	}
+	otherOutput := tmp.Output.IsSet() && tmp.Output.Name() != "elasticsearch"
 
-	err = cfg.SetChild("output.elasticsearch.hosts", -1, esURLConfig)
-	if err != nil {
-		return err
+	if endpoints.Elasticsearch {
+		if otherOutput {
+			return fmt.Errorf("the cloud.id setting enables the Elasticsearch output, but you already have the %s output enabled in the config", tmp.Output.Name())
+		}
+
+		logger.Infof("Setting Elasticsearch URL based on the cloud id: output.elasticsearch.hosts=%s", cid.esURL)
+		if ok, _ := cfg.Has("output.elasticsearch.hosts", -1); ok {
+			logger.Warn("output.elasticsearch.hosts is overwritten by the cloud.id setting, set cloud.endpoints.elasticsearch: false to keep it")
+		}
+
+		esURLConfig, err := config.NewConfigFrom([]string{cid.ElasticsearchURL()})
+		if err != nil {
+			return err
+		}
+		err = cfg.SetChild("output.elasticsearch.hosts", -1, esURLConfig)
+		if err != nil {
+			return err
+		}
+	} else if ok, _ := cfg.Has("output.elasticsearch.hosts", -1); !ok && !otherOutput {
+		logger.Warn("cloud.endpoints.elasticsearch is false, but output.elasticsearch.hosts is not set")
 	}
 
-	err = cfg.SetString("setup.kibana.host", -1, cid.KibanaURL())
-	if err != nil {
-		return err
+	if endpoints.Kibana {
+		logger.Infof("Setting Kibana URL based on the cloud id: setup.kibana.host=%s", cid.kibURL)
+		if host, _ := cfg.String("setup.kibana.host", -1); host != "" && host != cid.KibanaURL() {
+			logger.Warnf("setup.kibana.host %s is overwritten by the cloud.id setting, set cloud.endpoints.kibana: false to keep it", host)
+		}
+
+		err = cfg.SetString("setup.kibana.host", -1, cid.KibanaURL())
+		if err != nil {
+			return err
+		}
+	} else if host, _ := cfg.String("setup.kibana.host", -1); host == "" {
+		logger.Warn("cloud.endpoints.kibana is false, but setup.kibana.host is not set")
 	}
 
 	if cloudAuth != "" {
+		if otherOutput {
+			logger.Warnf("cloud.auth is ignored, the %s output is enabled", tmp.Output.Name())
+			return nil
+		}
+
 		// cloudAuth overwrites
 		err = cfg.SetString("output.elasticsearch.username", -1, cid.Username())
 		if err != nil {
@@ -18,6 +18,7 @@
 package cloudid
 
 import (
+	"encoding/base64"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -87,6 +88,100 @@ func TestDecode(t *testing.T) {
 	}
 }
 
+func TestDecodeServices(t *testing.T) {
+	// eu-west-1.aws.elastic-cloud.com:9243$es1$kb1:9244$apm1$$is1:8443
+	cid, err := NewCloudID("services:ZXUtd2VzdC0xLmF3cy5lbGFzdGljLWNsb3VkLmNvbTo5MjQzJGVzMSRrYjE6OTI0NCRhcG0xJCRpczE6ODQ0Mw==", "")
+	assert.NoError(t, err)
+
+	assert.Equal(t, "https://es1.eu-west-1.aws.elastic-cloud.com:9243", cid.ElasticsearchURL())
+	assert.Equal(t, "https://kb1.eu-west-1.aws.elastic-cloud.com:9244", cid.KibanaURL())
+	assert.Equal(t, "https://apm1.eu-west-1.aws.elastic-cloud.com:9243", cid.APMURL())
+	assert.Equal(t, "https://is1.eu-west-1.aws.elastic-cloud.com:8443", cid.IntegrationsServerURL())
+
+	u, ok := cid.URL(Kibana)
+	assert.True(t, ok)
+	assert.Equal(t, cid.KibanaURL(), u)
+
+	_, ok = cid.URL(Fleet)
+	assert.False(t, ok, "empty segments are not decoded")
+}
+
+func TestFleetURL(t *testing.T) {
+	id, err := Encode("", map[Service]string{
+		Elasticsearch: "https://es1.eu-west-1.aws.elastic-cloud.com",
+		Kibana:        "https://kb1.eu-west-1.aws.elastic-cloud.com",
+		Fleet:         "https://fleet1.eu-west-1.aws.elastic-cloud.com:8220",
+	})
+	assert.NoError(t, err)
+
+	cid, err := NewCloudID(id, "")
+	assert.NoError(t, err)
+	fleetURL, ok := cid.FleetURL()
+	assert.True(t, ok)
+	assert.Equal(t, "https://fleet1.eu-west-1.aws.elastic-cloud.com:8220", fleetURL)
+
+	// Older deployments only have Elasticsearch and Kibana segments.
+	cid, err = NewCloudID("staging:dXMtZWFzdC0xLmF3cy5mb3VuZC5pbyRjZWM2ZjI2MWE3NGJmMjRjZTMzYmI4ODExYjg0Mjk0ZiRjNmMyY2E2ZDA0MjI0OWFmMGNjN2Q3YTllOTYyNTc0Mw==", "")
+	assert.NoError(t, err)
+	fleetURL, ok = cid.FleetURL()
+	assert.False(t, ok)
+	assert.Empty(t, fleetURL)
+}
+
+func TestEncode(t *testing.T) {
+	urls := map[Service]string{
+		Elasticsearch: "https://es1.eu-west-1.aws.elastic-cloud.com:9243",
+		Kibana:        "https://kb1.eu-west-1.aws.elastic-cloud.com:9244",
+		APM:           "https://apm1.eu-west-1.aws.elastic-cloud.com:9243",
+	}
+	id, err := Encode("my-deployment", urls)
+	assert.NoError(t, err)
+	// eu-west-1.aws.elastic-cloud.com:9243$es1$kb1:9244$apm1
+	assert.Equal(t, "my-deployment:ZXUtd2VzdC0xLmF3cy5lbGFzdGljLWNsb3VkLmNvbTo5MjQzJGVzMSRrYjE6OTI0NCRhcG0x", id)
+
+	cid, err := NewCloudID(id, "")
+	assert.NoError(t, err)
+	for svc, u := range urls {
+		decoded, ok := cid.URL(svc)
+		assert.True(t, ok, svc)
+		assert.Equal(t, u, decoded, svc)
+	}
+
+	id, err = Encode("", map[Service]string{
+		Elasticsearch: "https://cec6f261a74bf24ce33bb8811b84294f.us-east-1.aws.found.io",
+		Kibana:        "https://c6c2ca6d042249af0cc7d7a9e9625743.us-east-1.aws.found.io:443",
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, "dXMtZWFzdC0xLmF3cy5mb3VuZC5pbyRjZWM2ZjI2MWE3NGJmMjRjZTMzYmI4ODExYjg0Mjk0ZiRjNmMyY2E2ZDA0MjI0OWFmMGNjN2Q3YTllOTYyNTc0Mw==", id)
+}
+
+func TestEncodeError(t *testing.T) {
+	tests := map[string]map[Service]string{
+		"the kibana URL is required": {
+			Elasticsearch: "https://es.example.com",
+		},
+		"must use the https scheme": {
+			Elasticsearch: "http://es.example.com",
+			Kibana:        "https://kb.example.com",
+		},
+		"differs from": {
+			Elasticsearch: "https://es.example.com",
+			Kibana:        "https://kb.example.org",
+		},
+		"must only have a host and a port": {
+			Elasticsearch: "https://es.example.com/path",
+			Kibana:        "https://kb.example.com",
+		},
+	}
+
+	for msg, urls := range tests {
+		_, err := Encode("", urls)
+		if assert.Error(t, err, msg) {
+			assert.Contains(t, err.Error(), msg)
+		}
+	}
+}
+
 func TestDecodeError(t *testing.T) {
 	tests := []struct {
 		cloudID  string
@@ -109,6 +204,43 @@ func TestDecodeError(t *testing.T) {
 	}
 }
 
+func TestValidate(t *testing.T) {
+	encode := func(s string) string {
+		return "test:" + base64.StdEncoding.EncodeToString([]byte(s))
+	}
+
+	tests := []struct {
+		cloudID string
+		reason  error
+	}{
+		{cloudID: encode("us-east-1.aws.found.io$es$kb"), reason: nil},
+		{cloudID: encode("us-east-1.aws.found.io:9243$es$kb:9244$$fleet"), reason: nil},
+		{cloudID: "test:not base64!", reason: ErrInvalidBase64},
+		{cloudID: encode("us-east-1.aws.found.io$es"), reason: ErrMissingSeparator},
+		{cloudID: encode("$es$kb"), reason: ErrEmptySegment},
+		{cloudID: encode("us-east-1.aws.found.io$$kb"), reason: ErrEmptySegment},
+		{cloudID: encode("us-east-1.aws.found.io$es$:9244"), reason: ErrEmptySegment},
+		{cloudID: encode("https://us-east-1.aws.found.io$es$kb"), reason: ErrSchemeConflict},
+		{cloudID: encode("us-east-1.aws.found.io:https$es$kb"), reason: ErrInvalidPort},
+		{cloudID: encode("us-east-1.aws.found.io$es:99999$kb"), reason: ErrInvalidPort},
+	}
+
+	for _, test := range tests {
+		err := Validate(test.cloudID)
+		if test.reason == nil {
+			assert.NoError(t, err, test.cloudID)
+			continue
+		}
+
+		assert.ErrorIs(t, err, test.reason, test.cloudID)
+		var validationErr *ValidationError
+		assert.ErrorAs(t, err, &validationErr, test.cloudID)
+
+		_, err = NewCloudID(test.cloudID, "")
+		assert.ErrorIs(t, err, test.reason, "NewCloudID must return the same reason for %s", test.cloudID)
+	}
+}
+
 func TestOverwriteSettings(t *testing.T) {
 	tests := []struct {
 		name   string
@@ -175,6 +307,58 @@ func TestOverwriteSettings(t *testing.T) {
 				"cloud.id":                   "cloudidtest:dXMtZWFzdC0xLmF3cy5mb3VuZC5pbyQyNDlmM2FmMWY0ZWVlMjRhODRlM2I0MDFlNjhhMWIyYSRkNGFjNzU1OWQ0Njc0YjdjOTFhYmUxMDg1NmQ4NDMwNA==",
 			},
 		},
+		{
+			name: "only elasticsearch from cloudid, self-managed kibana",
+			inCfg: map[string]interface{}{
+				"setup.kibana.host":      "http://localhost:5601",
+				"cloud.id":               "cloudidtest:dXMtZWFzdC0xLmF3cy5mb3VuZC5pbyQyNDlmM2FmMWY0ZWVlMjRhODRlM2I0MDFlNjhhMWIyYSRkNGFjNzU1OWQ0Njc0YjdjOTFhYmUxMDg1NmQ4NDMwNA==",
+				"cloud.auth":             "elastic:changeme",
+				"cloud.endpoints.kibana": false,
+			},
+			outCfg: map[string]interface{}{
+				"output.elasticsearch.hosts":    []interface{}{"https://249f3af1f4eee24a84e3b401e68a1b2a.us-east-1.aws.found.io:443"},
+				"output.elasticsearch.username": "elastic",
+				"output.elasticsearch.password": "changeme",
+				"setup.kibana.host":             "http://localhost:5601",
+				"cloud.id":                      "cloudidtest:dXMtZWFzdC0xLmF3cy5mb3VuZC5pbyQyNDlmM2FmMWY0ZWVlMjRhODRlM2I0MDFlNjhhMWIyYSRkNGFjNzU1OWQ0Njc0YjdjOTFhYmUxMDg1NmQ4NDMwNA==",
+				"cloud.auth":                    "elastic:changeme",
+				"cloud.endpoints.kibana":        false,
+			},
+		},
+		{
+			name: "only kibana from cloudid, self-managed elasticsearch keeps cloud auth",
+			inCfg: map[string]interface{}{
+				"output.elasticsearch.hosts":    "localhost:9200",
+				"cloud.id":                      "cloudidtest:dXMtZWFzdC0xLmF3cy5mb3VuZC5pbyQyNDlmM2FmMWY0ZWVlMjRhODRlM2I0MDFlNjhhMWIyYSRkNGFjNzU1OWQ0Njc0YjdjOTFhYmUxMDg1NmQ4NDMwNA==",
+				"cloud.auth":                    "elastic:changeme",
+				"cloud.endpoints.elasticsearch": false,
+			},
+			outCfg: map[string]interface{}{
+				"output.elasticsearch.hosts":    "localhost:9200",
+				"output.elasticsearch.username": "elastic",
+				"output.elasticsearch.password": "changeme",
+				"setup.kibana.host":             "https://d4ac7559d4674b7c91abe10856d84304.us-east-1.aws.found.io:443",
+				"cloud.id":                      "cloudidtest:dXMtZWFzdC0xLmF3cy5mb3VuZC5pbyQyNDlmM2FmMWY0ZWVlMjRhODRlM2I0MDFlNjhhMWIyYSRkNGFjNzU1OWQ0Njc0YjdjOTFhYmUxMDg1NmQ4NDMwNA==",
+				"cloud.auth":                    "elastic:changeme",
+				"cloud.endpoints.elasticsearch": false,
+			},
+		},
+		{
+			name: "only kibana from cloudid with another output",
+			inCfg: map[string]interface{}{
+				"output.logstash.hosts":         "localhost:5044",
+				"cloud.id":                      "cloudidtest:dXMtZWFzdC0xLmF3cy5mb3VuZC5pbyQyNDlmM2FmMWY0ZWVlMjRhODRlM2I0MDFlNjhhMWIyYSRkNGFjNzU1OWQ0Njc0YjdjOTFhYmUxMDg1NmQ4NDMwNA==",
+				"cloud.auth":                    "elastic:changeme",
+				"cloud.endpoints.elasticsearch": false,
+			},
+			outCfg: map[string]interface{}{
+				"output.logstash.hosts":         "localhost:5044",
+				"setup.kibana.host":             "https://d4ac7559d4674b7c91abe10856d84304.us-east-1.aws.found.io:443",
+				"cloud.id":                      "cloudidtest:dXMtZWFzdC0xLmF3cy5mb3VuZC5pbyQyNDlmM2FmMWY0ZWVlMjRhODRlM2I0MDFlNjhhMWIyYSRkNGFjNzU1OWQ0Njc0YjdjOTFhYmUxMDg1NmQ4NDMwNA==",
+				"cloud.auth":                    "elastic:changeme",
+				"cloud.endpoints.elasticsearch": false,
+			},
+		},
 	}
 
 	for _, test := range tests {
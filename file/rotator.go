@@ -26,6 +26,8 @@ import (
 	"strconv"
 	"sync"
 	"time"
+
+	"github.com/elastic/elastic-agent-libs/clock"
 )
 
 const (
@@ -57,12 +59,21 @@ type Rotator struct {
 	extension       string
 	maxSizeBytes    uint
 	maxBackups      uint
+	maxTotalSize    uint64
 	interval        time.Duration
 	permissions     os.FileMode
 	log             Logger // Optional Logger (may be nil).
 	rotateOnStartup bool
 	redirectStderr  bool
-	clock           clock
+	clock           timeSource
+	onRotate        func()
+
+	rotatedPermissions os.FileMode
+	uid, gid           int // Owner of the files, -1 to keep the default.
+
+	fsync         bool
+	fsyncInterval time.Duration // Zero to sync after every write.
+	fsyncTimer    *time.Timer   // Pending sync of the written data.
 
 	file  *os.File
 	mutex sync.Mutex
@@ -101,6 +112,16 @@ func MaxBackups(n uint) RotatorOption {
 	}
 }
 
+// MaxTotalSizeBytes configures the maximum number of bytes used by the
+// active file and the rotated files together. The oldest rotated files are
+// purged to keep room for a full active file within the budget. It must be
+// at least the max file size. The default is 0, no limit.
+func MaxTotalSizeBytes(n uint64) RotatorOption {
+	return func(r *Rotator) {
+		r.maxTotalSize = n
+	}
+}
+
 // Permissions configures the file permissions to use for the file that
 // the Rotator creates. The default is 0600.
 func Permissions(m os.FileMode) RotatorOption {
@@ -141,12 +162,49 @@ func RedirectStderr(redirect bool) RotatorOption {
 	}
 }
 
-func WithClock(clock clock) RotatorOption {
+// WithClock sets the clock used to name and rotate files. Any clock.Clock,
+// including clock.Fake, can be used. Defaults to clock.Real().
+func WithClock(clock timeSource) RotatorOption {
 	return func(r *Rotator) {
 		r.clock = clock
 	}
 }
 
+// RotatedPermissions sets the permissions of the files once rotated. They
+// keep the permissions set by Permissions if it is 0.
+func RotatedPermissions(m os.FileMode) RotatorOption {
+	return func(r *Rotator) {
+		r.rotatedPermissions = m
+	}
+}
+
+// Owner sets the owner and group of the files. An id of -1 keeps the
+// default one, like os.Chown. Changing the owner is not supported on
+// Windows.
+func Owner(uid, gid int) RotatorOption {
+	return func(r *Rotator) {
+		r.uid, r.gid = uid, gid
+	}
+}
+
+// Fsync enables committing the written data to stable storage. With a zero
+// interval the file is synced after every write, otherwise the data is
+// synced at most interval after it is written, batching the syncs of all
+// the writes in between.
+func Fsync(interval time.Duration) RotatorOption {
+	return func(r *Rotator) {
+		r.fsync = true
+		r.fsyncInterval = interval
+	}
+}
+
+// OnRotate sets a function called after each rotation of the file.
+func OnRotate(f func()) RotatorOption {
+	return func(r *Rotator) {
+		r.onRotate = f
+	}
+}
+
 // NewFileRotator returns a new Rotator.
 func NewFileRotator(filename string, options ...RotatorOption) (*Rotator, error) {
 	r := &Rotator{
@@ -157,7 +215,9 @@ func NewFileRotator(filename string, options ...RotatorOption) (*Rotator, error)
 		permissions:     0600,
 		interval:        0,
 		rotateOnStartup: true,
-		clock:           &realClock{},
+		clock:           clock.Real(),
+		uid:             -1,
+		gid:             -1,
 	}
 
 	for _, opt := range options {
@@ -170,13 +230,22 @@ func NewFileRotator(filename string, options ...RotatorOption) (*Rotator, error)
 	if r.maxBackups > MaxBackupsLimit {
 		return nil, fmt.Errorf("file rotator max backups %d is greater than the limit of %v", r.maxBackups, MaxBackupsLimit)
 	}
+	if r.maxTotalSize != 0 && r.maxTotalSize < uint64(r.maxSizeBytes) {
+		return nil, fmt.Errorf("file rotator max total size %d is lower than the max file size %d", r.maxTotalSize, r.maxSizeBytes)
+	}
 	if r.permissions > os.ModePerm {
 		return nil, fmt.Errorf("file rotator permissions mask of %o is invalid", r.permissions)
 	}
+	if r.rotatedPermissions > os.ModePerm {
+		return nil, fmt.Errorf("file rotator rotated permissions mask of %o is invalid", r.rotatedPermissions)
+	}
 
 	if r.interval != 0 && r.interval < time.Second {
 		return nil, errors.New("the minimum time interval for log rotation is 1 second")
 	}
+	if r.fsyncInterval < 0 {
+		return nil, fmt.Errorf("file rotator fsync interval %v is negative", r.fsyncInterval)
+	}
 
 	r.rot = newDateRotater(r.log, filename, r.extension, r.clock)
 
@@ -193,6 +262,7 @@ func NewFileRotator(filename string, options ...RotatorOption) (*Rotator, error)
 			"extension", r.extension,
 			"max_size_bytes", r.maxSizeBytes,
 			"max_backups", r.maxBackups,
+			"max_total_size_bytes", r.maxTotalSize,
 			"permissions", r.permissions,
 		)
 	}
@@ -234,9 +304,32 @@ func (r *Rotator) Write(data []byte) (int, error) {
 		return n, fmt.Errorf("failed to write to file: %w", err)
 	}
 
+	if r.fsync {
+		if r.fsyncInterval == 0 {
+			if err := r.file.Sync(); err != nil {
+				return n, fmt.Errorf("failed to sync file: %w", err)
+			}
+		} else if r.fsyncTimer == nil {
+			r.fsyncTimer = time.AfterFunc(r.fsyncInterval, r.syncPending)
+		}
+	}
+
 	return n, nil
 }
 
+// syncPending syncs the data written since the sync timer was started.
+func (r *Rotator) syncPending() {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	r.fsyncTimer = nil
+	if r.file == nil {
+		return
+	}
+	if err := r.file.Sync(); err != nil && r.log != nil {
+		r.log.Debugw("Failed to sync file", "file", r.file.Name(), "error", err)
+	}
+}
+
 // openNew opens r's log file for the first time, creating it if it doesn't
 // exist.
 func (r *Rotator) openNew() error {
@@ -250,8 +343,14 @@ func (r *Rotator) openNew() error {
 		// check if the file has to be rotated before writing to it
 		reason, t := r.isRotationTriggered(0)
 		if reason == rotateReasonNoRotate {
+			if err = r.purgeTotalSize(); err != nil {
+				return fmt.Errorf("failed to purge unnecessary rotated files: %w", err)
+			}
 			return r.appendToFile()
 		}
+		if err = r.setRotatedPermissions(r.rot.ActiveFile()); err != nil {
+			return err
+		}
 		if err = r.rot.Rotate(reason, t); err != nil {
 			return fmt.Errorf("failed to rotate backups: %w", err)
 		}
@@ -272,6 +371,9 @@ func (r *Rotator) appendToFile() error {
 	if err != nil {
 		return fmt.Errorf("failed to append to existing file: %w", err)
 	}
+	if err := r.chown(); err != nil {
+		return err
+	}
 	if r.redirectStderr {
 		_ = RedirectStandardError(r.file)
 	}
@@ -288,6 +390,9 @@ func (r *Rotator) openFile() error {
 	if err != nil {
 		return fmt.Errorf("failed to open new file '%s': %w", r.rot.ActiveFile(), err)
 	}
+	if err := r.chown(); err != nil {
+		return err
+	}
 	if r.redirectStderr {
 		_ = RedirectStandardError(r.file)
 	}
@@ -304,15 +409,52 @@ func (r *Rotator) rotateWithTime(reason rotateReason, rotationTime time.Time) er
 	if err := r.closeFile(); err != nil {
 		return fmt.Errorf("error file closing current file: %w", err)
 	}
+	if err := r.setRotatedPermissions(r.rot.ActiveFile()); err != nil {
+		return err
+	}
 
 	if err := r.rot.Rotate(reason, rotationTime); err != nil {
 		return fmt.Errorf("failed to rotate backups: %w", err)
 	}
+	if r.onRotate != nil {
+		r.onRotate()
+	}
 
 	return r.purge()
 }
 
+// setRotatedPermissions sets the permissions of a rotated file, if the
+// rotated permissions are set.
+func (r *Rotator) setRotatedPermissions(path string) error {
+	if r.rotatedPermissions == 0 {
+		return nil
+	}
+	if err := os.Chmod(path, r.rotatedPermissions); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return fmt.Errorf("failed to set the permissions of rotated file '%s': %w", path, err)
+	}
+	return nil
+}
+
+// chown sets the owner of the active file, if it is set.
+func (r *Rotator) chown() error {
+	if r.uid == -1 && r.gid == -1 {
+		return nil
+	}
+	if err := r.file.Chown(r.uid, r.gid); err != nil {
+		_ = r.closeFile()
+		return fmt.Errorf("failed to set the owner of file '%s': %w", r.rot.ActiveFile(), err)
+	}
+	return nil
+}
+
 func (r *Rotator) purge() error {
+	if err := r.purgeBackups(); err != nil {
+		return err
+	}
+	return r.purgeTotalSize()
+}
+
+func (r *Rotator) purgeBackups() error {
 	rotatedFiles := r.rot.RotatedFiles()
 	count := uint(len(rotatedFiles))
 	if count <= r.maxBackups {
@@ -338,6 +480,41 @@ func (r *Rotator) purge() error {
 	return nil
 }
 
+// purgeTotalSize removes the oldest rotated files until they fit in the max
+// total size along with a full active file.
+func (r *Rotator) purgeTotalSize() error {
+	if r.maxTotalSize == 0 {
+		return nil
+	}
+
+	rotatedFiles := r.rot.RotatedFiles()
+	sizes := make([]uint64, len(rotatedFiles))
+	total := uint64(r.maxSizeBytes)
+	for i, name := range rotatedFiles {
+		info, err := os.Stat(name)
+		switch {
+		case err == nil:
+			sizes[i] = uint64(info.Size())
+			total += sizes[i]
+		case os.IsNotExist(err):
+		default:
+			return fmt.Errorf("failed on %v during rotation: %w", name, err)
+		}
+	}
+
+	for i, name := range rotatedFiles {
+		if total <= r.maxTotalSize {
+			break
+		}
+		if err := os.Remove(name); err != nil && !os.IsNotExist(err) {
+			return fmt.Errorf("failed to delete %v during rotation: %w", name, err)
+		}
+		total -= sizes[i]
+	}
+
+	return nil
+}
+
 func (r *Rotator) isRotationTriggered(dataLen uint) (rotateReason, time.Time) {
 	for _, t := range r.triggers {
 		reason := t.TriggerRotation(dataLen)
@@ -367,6 +544,44 @@ func (r *Rotator) Rotate() error {
 	return r.rotate(rotateReasonManualTrigger)
 }
 
+// Reopen closes the active file and opens it again, creating it if it does
+// not exist anymore. External tools, like logrotate, can then move the file
+// away and signal the process to reopen it.
+func (r *Rotator) Reopen() error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	if err := r.closeFile(); err != nil {
+		return err
+	}
+	if err := os.MkdirAll(r.dir(), r.dirMode()); err != nil {
+		return fmt.Errorf("failed to make directories for new file: %w", err)
+	}
+
+	var err error
+	r.file, err = os.OpenFile(r.rot.ActiveFile(), os.O_CREATE|os.O_WRONLY|os.O_APPEND, r.permissions)
+	if err != nil {
+		return fmt.Errorf("failed to reopen file '%s': %w", r.rot.ActiveFile(), err)
+	}
+	if err := r.chown(); err != nil {
+		return err
+	}
+	if r.redirectStderr {
+		_ = RedirectStandardError(r.file)
+	}
+
+	info, err := r.file.Stat()
+	if err != nil {
+		return fmt.Errorf("failed to stat reopened file: %w", err)
+	}
+	for _, t := range r.triggers {
+		if st, ok := t.(*sizeTrigger); ok {
+			st.size = uint(info.Size())
+		}
+	}
+	return nil
+}
+
 // Close closes the currently open file.
 func (r *Rotator) Close() error {
 	r.mutex.Lock()
@@ -393,6 +608,17 @@ func (r *Rotator) closeFile() error {
 	if r.file == nil {
 		return nil
 	}
+	if r.fsync {
+		if r.fsyncTimer != nil {
+			r.fsyncTimer.Stop()
+			r.fsyncTimer = nil
+		}
+		if err := r.file.Sync(); err != nil {
+			_ = r.file.Close()
+			r.file = nil
+			return fmt.Errorf("failed to sync active file: %w", err)
+		}
+	}
 	err := r.file.Close()
 	r.file = nil
 
@@ -405,7 +631,7 @@ func (r *Rotator) closeFile() error {
 
 type dateRotator struct {
 	log             Logger
-	clock           clock
+	clock           timeSource
 	format          string
 	filenamePrefix  string
 	currentFilename string
@@ -419,7 +645,7 @@ type dateRotator struct {
 	logOrderCache map[string]logOrder
 }
 
-func newDateRotater(log Logger, filename, extension string, clock clock) rotater {
+func newDateRotater(log Logger, filename, extension string, clock timeSource) rotater {
 	d := &dateRotator{
 		log:            log,
 		clock:          clock,
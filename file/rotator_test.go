@@ -30,6 +30,7 @@ import (
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 
+	"github.com/elastic/elastic-agent-libs/clock"
 	"github.com/elastic/elastic-agent-libs/file"
 	"github.com/elastic/elastic-agent-libs/logp"
 )
@@ -42,7 +43,7 @@ func TestFileRotator(t *testing.T) {
 
 	dir := t.TempDir()
 	logname := "sample"
-	c := &testClock{time.Date(2021, 11, 11, 0, 0, 0, 0, time.Local)}
+	c := clock.NewFake(time.Date(2021, 11, 11, 0, 0, 0, 0, time.Local))
 
 	filename := filepath.Join(dir, logname)
 	r, err := file.NewFileRotator(filename,
@@ -60,7 +61,7 @@ func TestFileRotator(t *testing.T) {
 	WriteMsg(t, r)
 	AssertDirContents(t, dir, firstFile)
 
-	c.time = time.Date(2021, 11, 12, 0, 0, 0, 0, time.Local)
+	c.Set(time.Date(2021, 11, 12, 0, 0, 0, 0, time.Local))
 
 	Rotate(t, r)
 	AssertDirContents(t, dir, firstFile)
@@ -70,7 +71,7 @@ func TestFileRotator(t *testing.T) {
 	secondFile := fmt.Sprintf("%s-%s.ndjson", logname, c.Now().Format(file.DateFormat))
 	AssertDirContents(t, dir, firstFile, secondFile)
 
-	c.time = time.Date(2021, 11, 13, 0, 0, 0, 0, time.Local)
+	c.Set(time.Date(2021, 11, 13, 0, 0, 0, 0, time.Local))
 
 	Rotate(t, r)
 	AssertDirContents(t, dir, firstFile, secondFile)
@@ -79,11 +80,11 @@ func TestFileRotator(t *testing.T) {
 	thirdFile := fmt.Sprintf("%s-%s.ndjson", logname, c.Now().Format(file.DateFormat))
 	AssertDirContents(t, dir, firstFile, secondFile, thirdFile)
 
-	c.time = time.Date(2021, 11, 14, 0, 0, 0, 0, time.Local)
+	c.Set(time.Date(2021, 11, 14, 0, 0, 0, 0, time.Local))
 	Rotate(t, r)
 	AssertDirContents(t, dir, secondFile, thirdFile)
 
-	c.time = time.Date(2021, 11, 15, 0, 0, 0, 0, time.Local)
+	c.Set(time.Date(2021, 11, 15, 0, 0, 0, 0, time.Local))
 	Rotate(t, r)
 	AssertDirContents(t, dir, secondFile, thirdFile)
 }
@@ -181,7 +182,7 @@ func TestRotateOnStartup(t *testing.T) {
 	dir := t.TempDir()
 
 	logname := "rotate_on_open"
-	c := &testClock{time.Date(2021, 11, 11, 0, 0, 0, 0, time.Local)}
+	c := clock.NewFake(time.Date(2021, 11, 11, 0, 0, 0, 0, time.Local))
 	firstFile := fmt.Sprintf("%s-%s.ndjson", logname, c.Now().Format(file.DateFormat))
 	filename := filepath.Join(dir, firstFile)
 
@@ -204,7 +205,7 @@ func TestRotateOnStartup(t *testing.T) {
 	r.Close()
 
 	// Create a second rotator with the default setting of rotateOnStartup=true
-	c = &testClock{time.Date(2021, 11, 12, 0, 0, 0, 0, time.Local)}
+	c = clock.NewFake(time.Date(2021, 11, 12, 0, 0, 0, 0, time.Local))
 	r, err = file.NewFileRotator(filepath.Join(dir, logname), file.WithClock(c))
 	if err != nil {
 		t.Fatal(err)
@@ -226,7 +227,7 @@ func TestRotate(t *testing.T) {
 	logname := "beatname"
 	filename := filepath.Join(dir, logname)
 
-	c := &testClock{time.Date(2021, 11, 11, 0, 0, 0, 0, time.Local)}
+	c := clock.NewFake(time.Date(2021, 11, 11, 0, 0, 0, 0, time.Local))
 	r, err := file.NewFileRotator(filename, file.MaxBackups(1), file.WithClock(c))
 	if err != nil {
 		t.Fatal(err)
@@ -238,7 +239,7 @@ func TestRotate(t *testing.T) {
 	firstFile := fmt.Sprintf("%s-%s.ndjson", logname, c.Now().Format(file.DateFormat))
 	AssertDirContents(t, dir, firstFile)
 
-	c.time = time.Date(2021, 11, 13, 0, 0, 0, 0, time.Local)
+	c.Set(time.Date(2021, 11, 13, 0, 0, 0, 0, time.Local))
 	secondFile := fmt.Sprintf("%s-%s.ndjson", logname, c.Now().Format(file.DateFormat))
 
 	Rotate(t, r)
@@ -246,7 +247,7 @@ func TestRotate(t *testing.T) {
 
 	AssertDirContents(t, dir, firstFile, secondFile)
 
-	c.time = time.Date(2021, 11, 15, 0, 0, 0, 0, time.Local)
+	c.Set(time.Date(2021, 11, 15, 0, 0, 0, 0, time.Local))
 	thirdFile := fmt.Sprintf("%s-%s.ndjson", logname, c.Now().Format(file.DateFormat))
 
 	Rotate(t, r)
@@ -255,6 +256,160 @@ func TestRotate(t *testing.T) {
 	AssertDirContents(t, dir, secondFile, thirdFile)
 }
 
+func TestMaxTotalSize(t *testing.T) {
+	dir := t.TempDir()
+
+	logname := "beatname"
+	filename := filepath.Join(dir, logname)
+
+	// Room for a full active file and two rotated files.
+	const maxSize = 100
+	c := clock.NewFake(time.Date(2021, 11, 11, 0, 0, 0, 0, time.Local))
+	r, err := file.NewFileRotator(filename,
+		file.WithClock(c),
+		file.MaxSizeBytes(maxSize),
+		file.MaxTotalSizeBytes(maxSize+2*uint64(len(logMessage))),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer r.Close()
+
+	var files []string
+	for day := 11; day < 15; day++ {
+		c.Set(time.Date(2021, 11, day, 0, 0, 0, 0, time.Local))
+		if day > 11 {
+			Rotate(t, r)
+		}
+		WriteMsg(t, r)
+		files = append(files, fmt.Sprintf("%s-%s.ndjson", logname, c.Now().Format(file.DateFormat)))
+	}
+
+	AssertDirContents(t, dir, files[1:]...)
+
+	_, err = file.NewFileRotator(filename, file.MaxSizeBytes(maxSize), file.MaxTotalSizeBytes(maxSize-1))
+	assert.Error(t, err)
+}
+
+func TestOnRotate(t *testing.T) {
+	dir := t.TempDir()
+
+	rotations := 0
+	r, err := file.NewFileRotator(filepath.Join(dir, "beatname"), file.OnRotate(func() { rotations++ }))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer r.Close()
+
+	WriteMsg(t, r)
+	Rotate(t, r)
+	WriteMsg(t, r)
+	Rotate(t, r)
+
+	if rotations != 2 {
+		t.Fatalf("expected 2 rotations, got %d", rotations)
+	}
+}
+
+func TestReopen(t *testing.T) {
+	dir := t.TempDir()
+
+	filename := filepath.Join(dir, "beatname")
+	r, err := file.NewFileRotator(filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer r.Close()
+
+	WriteMsg(t, r)
+	files, err := filepath.Glob(filename + "*")
+	if err != nil || len(files) != 1 {
+		t.Fatalf("expected one file, got %v: %v", files, err)
+	}
+
+	// Move the file away, like logrotate does.
+	if err := os.Rename(files[0], filepath.Join(dir, "moved")); err != nil {
+		t.Fatal(err)
+	}
+	if err := r.Reopen(); err != nil {
+		t.Fatal(err)
+	}
+	WriteMsg(t, r)
+
+	AssertDirContents(t, dir, filepath.Base(files[0]), "moved")
+}
+
+func TestFsync(t *testing.T) {
+	for name, interval := range map[string]time.Duration{
+		"every write": 0,
+		"interval":    10 * time.Millisecond,
+	} {
+		t.Run(name, func(t *testing.T) {
+			dir := t.TempDir()
+
+			filename := filepath.Join(dir, "beatname")
+			r, err := file.NewFileRotator(filename, file.Fsync(interval))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			WriteMsg(t, r)
+			time.Sleep(2 * interval)
+			WriteMsg(t, r)
+			Rotate(t, r)
+			WriteMsg(t, r)
+			if err := r.Close(); err != nil {
+				t.Fatal(err)
+			}
+
+			files, err := filepath.Glob(filename + "*")
+			if err != nil || len(files) != 2 {
+				t.Fatalf("expected two files, got %v: %v", files, err)
+			}
+		})
+	}
+
+	_, err := file.NewFileRotator(filepath.Join(t.TempDir(), "beatname"), file.Fsync(-time.Second))
+	assert.Error(t, err)
+}
+
+func TestRotatedPermissionsAndOwner(t *testing.T) {
+	dir := t.TempDir()
+
+	c := clock.NewFake(time.Date(2021, 11, 11, 0, 0, 0, 0, time.Local))
+	r, err := file.NewFileRotator(filepath.Join(dir, "beatname"),
+		file.WithClock(c),
+		file.Permissions(0640),
+		file.RotatedPermissions(0440),
+		file.Owner(os.Getuid(), os.Getgid()),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer r.Close()
+
+	WriteMsg(t, r)
+	firstFile := filepath.Join(dir, fmt.Sprintf("beatname-%s.ndjson", c.Now().Format(file.DateFormat)))
+
+	c.Set(time.Date(2021, 11, 13, 0, 0, 0, 0, time.Local))
+	Rotate(t, r)
+	WriteMsg(t, r)
+	secondFile := filepath.Join(dir, fmt.Sprintf("beatname-%s.ndjson", c.Now().Format(file.DateFormat)))
+
+	for path, expected := range map[string]os.FileMode{firstFile: 0440, secondFile: 0640} {
+		info, err := file.Stat(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if info.Mode().Perm() != expected {
+			t.Errorf("expected %s to have permissions %v, got %v", path, expected, info.Mode().Perm())
+		}
+		if uid, _ := info.UID(); uid != os.Getuid() {
+			t.Errorf("expected %s to be owned by %d, got %d", path, os.Getuid(), uid)
+		}
+	}
+}
+
 func TestRotateExtension(t *testing.T) {
 	dir := t.TempDir()
 
@@ -264,7 +419,7 @@ func TestRotateExtension(t *testing.T) {
 	)
 	filename := filepath.Join(dir, logname)
 
-	c := &testClock{time.Date(2021, 11, 11, 0, 0, 0, 0, time.Local)}
+	c := clock.NewFake(time.Date(2021, 11, 11, 0, 0, 0, 0, time.Local))
 	r, err := file.NewFileRotator(filename, file.Extension(extension), file.MaxBackups(1), file.WithClock(c))
 	if err != nil {
 		t.Fatal(err)
@@ -276,7 +431,7 @@ func TestRotateExtension(t *testing.T) {
 	firstFile := fmt.Sprintf("%s-%s."+extension, logname, c.Now().Format(file.DateFormat))
 	AssertDirContents(t, dir, firstFile)
 
-	c.time = time.Date(2021, 11, 13, 0, 0, 0, 0, time.Local)
+	c.Set(time.Date(2021, 11, 13, 0, 0, 0, 0, time.Local))
 	secondFile := fmt.Sprintf("%s-%s."+extension, logname, c.Now().Format(file.DateFormat))
 
 	Rotate(t, r)
@@ -284,7 +439,7 @@ func TestRotateExtension(t *testing.T) {
 
 	AssertDirContents(t, dir, firstFile, secondFile)
 
-	c.time = time.Date(2021, 11, 15, 0, 0, 0, 0, time.Local)
+	c.Set(time.Date(2021, 11, 15, 0, 0, 0, 0, time.Local))
 	thirdFile := fmt.Sprintf("%s-%s."+extension, logname, c.Now().Format(file.DateFormat))
 
 	Rotate(t, r)
@@ -338,11 +493,3 @@ func Rotate(t *testing.T, r *file.Rotator) {
 		t.Fatal(err)
 	}
 }
-
-type testClock struct {
-	time time.Time
-}
-
-func (t testClock) Now() time.Time {
-	return t.time
-}
@@ -52,7 +52,7 @@ type trigger interface {
 	TriggerRotation(dataLen uint) rotateReason
 }
 
-func newTriggers(rotateOnStartup bool, interval time.Duration, maxSizeBytes uint, clock clock) []trigger {
+func newTriggers(rotateOnStartup bool, interval time.Duration, maxSizeBytes uint, clock timeSource) []trigger {
 	triggers := make([]trigger, 0)
 
 	if rotateOnStartup {
@@ -98,22 +98,17 @@ func (t *sizeTrigger) TriggerRotation(dataLen uint) rotateReason {
 // intervalTrigger rotates the files after the configured interval.
 type intervalTrigger struct {
 	interval    time.Duration
-	clock       clock
+	clock       timeSource
 	lastRotate  time.Time
 	newInterval func(lastTime time.Time, currentTime time.Time) bool
 }
 
-type clock interface {
+// timeSource is the subset of clock.Clock used by the rotator.
+type timeSource interface {
 	Now() time.Time
 }
 
-type realClock struct{}
-
-func (realClock) Now() time.Time {
-	return time.Now()
-}
-
-func newIntervalTrigger(interval time.Duration, clock clock) trigger {
+func newIntervalTrigger(interval time.Duration, clock timeSource) trigger {
 	t := intervalTrigger{interval: interval, clock: clock}
 
 	switch interval {
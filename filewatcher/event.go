@@ -0,0 +1,130 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package filewatcher
+
+import (
+	"context"
+	"os"
+	"time"
+
+	"github.com/elastic/elastic-agent-libs/logp"
+)
+
+// Op describes the kind of change reported by an Event.
+type Op uint8
+
+const (
+	// Created is reported for files that did not exist in the previous scan.
+	Created Op = iota + 1
+	// Modified is reported for files whose content changed since the previous scan.
+	Modified
+	// Deleted is reported for files that no longer exist.
+	Deleted
+	// Renamed is reported for files that were moved from Event.OldPath to
+	// Event.Path. Renames are detected by comparing the file identity, so they
+	// are only reported for files moved between watched paths.
+	Renamed
+	// PermissionChanged is reported for files whose permissions changed
+	// without changes to their content.
+	PermissionChanged
+)
+
+func (o Op) String() string {
+	switch o {
+	case Created:
+		return "created"
+	case Modified:
+		return "modified"
+	case Deleted:
+		return "deleted"
+	case Renamed:
+		return "renamed"
+	case PermissionChanged:
+		return "permission_changed"
+	default:
+		return "unknown"
+	}
+}
+
+// FileID identifies a file independently of its path. On Unix it is made of
+// the device and inode numbers, on Windows of the volume serial number and
+// file index. The zero value means the identity is unknown.
+type FileID struct {
+	Device uint64 `json:"device"`
+	Inode  uint64 `json:"inode"`
+}
+
+// IsZero returns true if the file identity is unknown.
+func (id FileID) IsZero() bool {
+	return id == FileID{}
+}
+
+// Event describes a change to a single watched file.
+type Event struct {
+	Path string
+	Op   Op
+
+	// OldPath is the previous path of a renamed file, it is only set for
+	// Renamed events.
+	OldPath string
+
+	// ID, Mode, Size and ModTime describe the file after the change, for
+	// Deleted events they describe the file as last seen.
+	ID      FileID
+	Mode    os.FileMode
+	Size    int64
+	ModTime time.Time
+
+	// Target is the path a watched symlink resolves to. A change of the
+	// target is reported as a modification of the watched path.
+	Target string
+}
+
+// Watch scans all file paths every interval and sends the changes found to
+// the returned channel. Scan errors are logged. The channel is closed once
+// ctx is done.
+func (f *FileWatcher) Watch(ctx context.Context, interval time.Duration) <-chan Event {
+	ch := make(chan Event)
+	go func() {
+		defer close(ch)
+
+		ticker := f.clock.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			events, err := f.ScanEvents()
+			if err != nil {
+				logp.Err("Error scanning files: %v", err)
+			}
+			for _, e := range events {
+				select {
+				case ch <- e:
+				case <-ctx.Done():
+					return
+				}
+			}
+
+			select {
+			case <-ticker.C():
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	return ch
+}
@@ -19,10 +19,13 @@ package filewatcher
 
 import (
 	"os"
+	"path/filepath"
+	"sort"
 	"time"
 
 	"github.com/mitchellh/hashstructure"
 
+	"github.com/elastic/elastic-agent-libs/clock"
 	"github.com/elastic/elastic-agent-libs/logp"
 )
 
@@ -30,16 +33,102 @@ type FileWatcher struct {
 	files    []string
 	lastScan time.Time
 	lastHash uint64
+
+	// expand is set when the watcher was created with NewWatcher, in that
+	// case files can contain directories and glob patterns.
+	expand    bool
+	recursive bool
+	include   []string
+	exclude   []string
+
+	state map[string]fileState
+
+	// debounce is the quiet period changes are held back for, see Debounce.
+	debounce       time.Duration
+	pending        map[string]Event
+	pendingChanged bool
+	lastChange     time.Time
+	clock          clock.Clock
+}
+
+// Option configures a FileWatcher created with NewWatcher.
+type Option func(f *FileWatcher)
+
+// Recursive configures whether directories are walked recursively. By
+// default only the files directly inside a watched directory are watched.
+func Recursive(b bool) Option {
+	return func(f *FileWatcher) {
+		f.recursive = b
+	}
+}
+
+// Include configures glob patterns a file must match to be watched. If no
+// include pattern is set, all files are watched.
+// Patterns containing a path separator are matched against the path
+// relative to the watched directory (e.g. "inputs.d/*.yml"), "**" matches
+// any number of directories. Patterns without a separator are matched
+// against the file name.
+func Include(patterns ...string) Option {
+	return func(f *FileWatcher) {
+		f.include = append(f.include, patterns...)
+	}
+}
+
+// Exclude configures glob patterns for files that must not be watched.
+// Exclude patterns take precedence over include patterns and follow the
+// same syntax.
+func Exclude(patterns ...string) Option {
+	return func(f *FileWatcher) {
+		f.exclude = append(f.exclude, patterns...)
+	}
 }
 
+// Debounce configures a quiet period for change notifications. When a
+// change is detected it is held back until no further changes are seen for
+// the duration d, all changes seen in the meantime are then reported by a
+// single scan. This coalesces bursts of writes, like the ones done by
+// editors or tools saving files atomically, into one notification.
+// The quiet period is only checked when scanning, the effective delay is
+// rounded up to the scan interval. The default is 0 for disabled.
+func Debounce(d time.Duration) Option {
+	return func(f *FileWatcher) {
+		f.debounce = d
+	}
+}
+
+// Clock sets the clock used to timestamp scans, measure the debounce period
+// and drive Watch. It is meant to be used with clock.Fake in tests. The
+// default is clock.Real().
+func Clock(c clock.Clock) Option {
+	return func(f *FileWatcher) {
+		f.clock = c
+	}
+}
+
+// New returns a FileWatcher watching the given list of files.
 func New(files ...string) *FileWatcher {
 	return &FileWatcher{
 		lastScan: time.Time{},
 		lastHash: 0,
 		files:    files,
+		state:    map[string]fileState{},
+		clock:    clock.Real(),
 	}
 }
 
+// NewWatcher returns a FileWatcher for the given paths. Unlike New, each
+// path can be a regular file, a directory or a glob pattern (e.g.
+// "inputs.d/*.yml"). Directories and glob patterns are expanded on every
+// scan, so files added or removed later are reported as well.
+func NewWatcher(paths []string, opts ...Option) *FileWatcher {
+	f := New(paths...)
+	f.expand = true
+	for _, opt := range opts {
+		opt(f)
+	}
+	return f
+}
+
 // Scan scans all file paths and checks if the number of files or the modtime of the files changed
 // It returns the list of existing files, a boolean if anything in has changed and potential errors.
 // To detect changes not only modtime is compared but also the hash of the files list. This is required to
@@ -47,14 +136,43 @@ func New(files ...string) *FileWatcher {
 // Normally, the modtime is presented in seconds, so the change detection is also based on seconds.
 // When it's unclear whether something changed or not the method will return `true` to make sure potential changes are handled.
 // It is strongly recommended to call `Scan` not more than once a second.
+//
+// Deprecated: use ScanEvents or Watch, they report what changed for each file.
 func (f *FileWatcher) Scan() ([]string, bool, error) {
+	res, err := f.scan()
+	if err != nil {
+		return res.files, res.changed, err
+	}
+	res = f.debounced(res)
+	return res.files, res.changed, nil
+}
+
+// ScanEvents scans all file paths and returns one event for each file that
+// changed since the previous scan. On the first scan every existing file is
+// reported as created.
+func (f *FileWatcher) ScanEvents() ([]Event, error) {
+	res, err := f.scan()
+	if err != nil {
+		return res.events, err
+	}
+	return f.debounced(res).events, nil
+}
+
+type scanResult struct {
+	files   []string
+	changed bool
+	events  []Event
+}
+
+func (f *FileWatcher) scan() (scanResult, error) {
 	updatedFiles := false
 	files := []string{}
+	current := make(map[string]fileState, len(f.state))
 
-	lastScan := time.Now()
+	lastScan := f.clock.Now()
 	defer func() { f.lastScan = lastScan }()
 
-	for _, path := range f.files {
+	for _, path := range f.paths() {
 		info, err := os.Stat(path)
 		if err != nil {
 			logp.Err("Error getting stats for file: %s", path)
@@ -75,18 +193,247 @@ func (f *FileWatcher) Scan() ([]string, bool, error) {
 		}
 
 		files = append(files, path)
+		current[path] = newFileState(path, info)
 	}
 
+	events := diffStates(f.state, current)
+	f.state = current
+
 	hash, err := hashstructure.Hash(files, nil)
 	if err != nil {
-		return files, true, err
+		return scanResult{files: files, changed: true, events: events}, err
 	}
 	defer func() { f.lastHash = hash }()
 
 	// Check if something changed
 	if !updatedFiles && hash == f.lastHash {
-		return files, false, nil
+		return scanResult{files: files, changed: false, events: events}, nil
+	}
+
+	return scanResult{files: files, changed: true, events: events}, nil
+}
+
+// debounced holds back the changes found by a scan until the quiet period
+// configured with Debounce has passed without any new change.
+func (f *FileWatcher) debounced(res scanResult) scanResult {
+	if f.debounce <= 0 {
+		return res
+	}
+
+	now := f.clock.Now()
+	if res.changed || len(res.events) > 0 {
+		if f.pending == nil {
+			f.pending = map[string]Event{}
+		}
+		for _, e := range res.events {
+			f.addPending(e)
+		}
+		f.pendingChanged = f.pendingChanged || res.changed
+		f.lastChange = now
 	}
 
-	return files, true, nil
+	if !f.pendingChanged || now.Sub(f.lastChange) < f.debounce {
+		res.changed = false
+		res.events = nil
+		return res
+	}
+
+	res.changed = true
+	res.events = make([]Event, 0, len(f.pending))
+	for _, e := range f.pending {
+		res.events = append(res.events, e)
+	}
+	sortEvents(res.events)
+
+	f.pending = nil
+	f.pendingChanged = false
+	return res
+}
+
+// addPending merges an event into the pending events of the same file.
+func (f *FileWatcher) addPending(e Event) {
+	if e.Op == Renamed {
+		if prev, ok := f.pending[e.OldPath]; ok {
+			delete(f.pending, e.OldPath)
+			switch prev.Op {
+			case Created:
+				// The file did not exist before the quiet period started.
+				e.Op, e.OldPath = Created, ""
+			case Renamed:
+				e.OldPath = prev.OldPath
+			}
+		}
+		f.pending[e.Path] = e
+		return
+	}
+
+	prev, ok := f.pending[e.Path]
+	if !ok {
+		f.pending[e.Path] = e
+		return
+	}
+	if prev.Op == Renamed && e.Op == Deleted {
+		// The file was renamed then deleted, what is left is the deletion
+		// of its original path.
+		delete(f.pending, e.Path)
+		e.Path = prev.OldPath
+		if other, ok := f.pending[e.Path]; ok {
+			// Another file took the original path after the rename.
+			if other.Op == Created {
+				other.Op = Modified
+				f.pending[e.Path] = other
+			}
+			return
+		}
+		f.pending[e.Path] = e
+		return
+	}
+	if op, ok := coalesce(prev.Op, e.Op); ok {
+		e.Op = op
+		if op == Renamed {
+			e.OldPath = prev.OldPath
+		}
+		f.pending[e.Path] = e
+	} else {
+		delete(f.pending, e.Path)
+	}
+}
+
+// coalesce merges two consecutive operations on the same file. It returns
+// false if the operations cancel each other out.
+func coalesce(prev, next Op) (Op, bool) {
+	switch {
+	case prev == Created && next == Deleted:
+		return 0, false
+	case prev == Created:
+		return Created, true
+	case prev == Renamed && next != Deleted:
+		return Renamed, true
+	case prev == Deleted && next == Created:
+		return Modified, true
+	case prev == Modified && next == PermissionChanged:
+		return Modified, true
+	default:
+		return next, true
+	}
+}
+
+// paths returns the list of files to check. For watchers created with
+// NewWatcher directories and glob patterns are expanded.
+func (f *FileWatcher) paths() []string {
+	if !f.expand {
+		return f.files
+	}
+
+	seen := map[string]struct{}{}
+	paths := []string{}
+	add := func(path string) {
+		if _, ok := seen[path]; ok {
+			return
+		}
+		seen[path] = struct{}{}
+		paths = append(paths, path)
+	}
+
+	for _, path := range f.files {
+		for _, p := range f.expandPath(path) {
+			add(p)
+		}
+	}
+	sort.Strings(paths)
+	return paths
+}
+
+// fileState is the information kept about every file between scans.
+type fileState struct {
+	ModTime time.Time   `json:"mod_time"`
+	Size    int64       `json:"size"`
+	Mode    os.FileMode `json:"mode"`
+	ID      FileID      `json:"id"`
+	// Target is the resolved path of a symlink, it is empty for regular files.
+	Target string `json:"target,omitempty"`
+}
+
+func newFileState(path string, info os.FileInfo) fileState {
+	return fileState{
+		ModTime: info.ModTime(),
+		Size:    info.Size(),
+		Mode:    info.Mode(),
+		ID:      fileIDOf(path, info),
+		Target:  linkTarget(path),
+	}
+}
+
+func (s fileState) modified(other fileState) bool {
+	return !s.ModTime.Equal(other.ModTime) || s.Size != other.Size || s.ID != other.ID ||
+		s.Target != other.Target
+}
+
+// linkTarget returns the path a symlink resolves to, following all the links
+// in the chain. Kubernetes updates ConfigMap volumes by atomically swapping
+// such a chain of links, the file itself may look unchanged.
+// An empty string is returned if path is not a symlink.
+func linkTarget(path string) string {
+	info, err := os.Lstat(path)
+	if err != nil || info.Mode()&os.ModeSymlink == 0 {
+		return ""
+	}
+	target, err := filepath.EvalSymlinks(path)
+	if err != nil {
+		return ""
+	}
+	return target
+}
+
+func (s fileState) event(path string, op Op) Event {
+	return Event{
+		Path:    path,
+		Op:      op,
+		ID:      s.ID,
+		Mode:    s.Mode,
+		Size:    s.Size,
+		ModTime: s.ModTime,
+		Target:  s.Target,
+	}
+}
+
+// diffStates returns the events describing how to go from the previous to the current state.
+func diffStates(previous, current map[string]fileState) []Event {
+	var events []Event
+	created := map[FileID]int{}
+	for path, cur := range current {
+		prev, ok := previous[path]
+		switch {
+		case !ok:
+			if !cur.ID.IsZero() {
+				created[cur.ID] = len(events)
+			}
+			events = append(events, cur.event(path, Created))
+		case prev.modified(cur):
+			events = append(events, cur.event(path, Modified))
+		case prev.Mode != cur.Mode:
+			events = append(events, cur.event(path, PermissionChanged))
+		}
+	}
+	for path, prev := range previous {
+		if _, ok := current[path]; ok {
+			continue
+		}
+		// A file that disappeared while a file with the same identity
+		// appeared somewhere else has been renamed.
+		if i, ok := created[prev.ID]; ok {
+			events[i].Op = Renamed
+			events[i].OldPath = path
+			delete(created, prev.ID)
+			continue
+		}
+		events = append(events, prev.event(path, Deleted))
+	}
+
+	sortEvents(events)
+	return events
+}
+
+func sortEvents(events []Event) {
+	sort.Slice(events, func(i, j int) bool { return events[i].Path < events[j].Path })
 }
@@ -18,12 +18,16 @@
 package filewatcher
 
 import (
+	"context"
 	"os"
 	"path/filepath"
+	"runtime"
 	"testing"
 	"time"
 
 	"github.com/stretchr/testify/assert"
+
+	"github.com/elastic/elastic-agent-libs/clock"
 )
 
 func TestFileWatcher(t *testing.T) {
@@ -78,3 +82,291 @@ func TestFileWatcher(t *testing.T) {
 	assert.NoError(t, err)
 	assert.True(t, changed, "'changed' must be true, one file has been removed")
 }
+
+func TestMatchPattern(t *testing.T) {
+	tests := []struct {
+		pattern string
+		rel     string
+		match   bool
+	}{
+		{"*.yml", "a.yml", true},
+		{"*.yml", "inputs.d/a.yml", true},
+		{"*.yml", "a.yaml", false},
+		{"inputs.d/*.yml", "inputs.d/a.yml", true},
+		{"inputs.d/*.yml", "a.yml", false},
+		{"inputs.d/*.yml", "inputs.d/sub/a.yml", false},
+		{"inputs.d/**/*.yml", "inputs.d/a.yml", true},
+		{"inputs.d/**/*.yml", "inputs.d/sub/deep/a.yml", true},
+		{"**/a.yml", "x/y/a.yml", true},
+		{"**/a.yml", "a.yml", true},
+	}
+
+	for _, test := range tests {
+		assert.Equal(t, test.match, matchPattern(test.pattern, test.rel), "pattern %q, path %q", test.pattern, test.rel)
+	}
+}
+
+func TestNewWatcherRecursive(t *testing.T) {
+	dir := t.TempDir()
+	write := func(name string) string {
+		filename := filepath.Join(dir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(filename), 0755); err != nil {
+			t.Fatalf("could not create directory for '%s', err: %s", filename, err)
+		}
+		if err := os.WriteFile(filename, []byte("test\n"), 0644); err != nil {
+			t.Fatalf("could not create '%s' for testing, err: %s", filename, err)
+		}
+		return filename
+	}
+
+	a := write("inputs.d/a.yml")
+	b := write("inputs.d/sub/b.yml")
+	write("inputs.d/c.txt")
+	write("inputs.d/sub/ignored.yml")
+
+	watcher := NewWatcher([]string{dir},
+		Recursive(true),
+		Include("**/*.yml"),
+		Exclude("ignored.yml"),
+	)
+
+	events, err := watcher.ScanEvents()
+	assert.NoError(t, err)
+	assert.Equal(t, []Event{{Path: a, Op: Created}, {Path: b, Op: Created}}, changes(events))
+
+	events, err = watcher.ScanEvents()
+	assert.NoError(t, err)
+	assert.Empty(t, events)
+
+	c := write("inputs.d/sub/c.yml")
+	assert.NoError(t, os.Remove(a))
+
+	events, err = watcher.ScanEvents()
+	assert.NoError(t, err)
+	assert.Equal(t, []Event{{Path: a, Op: Deleted}, {Path: c, Op: Created}}, changes(events))
+
+	// Glob patterns are expanded on every scan and are not recursive by default.
+	watcher = NewWatcher([]string{filepath.Join(dir, "inputs.d", "*.yml")})
+	files, _, err := watcher.Scan()
+	assert.NoError(t, err)
+	assert.Empty(t, files)
+
+	d := write("inputs.d/d.yml")
+	files, changed, err := watcher.Scan()
+	assert.NoError(t, err)
+	assert.True(t, changed)
+	assert.Equal(t, []string{d}, files)
+}
+
+func TestDebounce(t *testing.T) {
+	dir := t.TempDir()
+	a := filepath.Join(dir, "a.yml")
+	b := filepath.Join(dir, "b.yml")
+
+	clk := clock.NewFake(time.Now())
+	watcher := NewWatcher([]string{dir}, Debounce(5*time.Second), Clock(clk))
+
+	events, err := watcher.ScanEvents()
+	assert.NoError(t, err)
+	assert.Empty(t, events)
+
+	// A burst of writes is held back while it is going on.
+	assert.NoError(t, os.WriteFile(a, []byte("a\n"), 0644))
+	events, err = watcher.ScanEvents()
+	assert.NoError(t, err)
+	assert.Empty(t, events)
+
+	clk.Advance(time.Second)
+	assert.NoError(t, os.WriteFile(a, []byte("a, longer\n"), 0644))
+	assert.NoError(t, os.WriteFile(b, []byte("b\n"), 0644))
+	events, err = watcher.ScanEvents()
+	assert.NoError(t, err)
+	assert.Empty(t, events)
+
+	clk.Advance(time.Second)
+	assert.NoError(t, os.Remove(b))
+	events, err = watcher.ScanEvents()
+	assert.NoError(t, err)
+	assert.Empty(t, events)
+
+	// Once the quiet period passed all changes are reported at once.
+	clk.Advance(5 * time.Second)
+	events, err = watcher.ScanEvents()
+	assert.NoError(t, err)
+	assert.Equal(t, []Event{{Path: a, Op: Created}}, changes(events))
+
+	events, err = watcher.ScanEvents()
+	assert.NoError(t, err)
+	assert.Empty(t, events)
+}
+
+func TestDebounceRenameThenDelete(t *testing.T) {
+	dir := t.TempDir()
+	a := filepath.Join(dir, "a.yml")
+	b := filepath.Join(dir, "b.yml")
+	assert.NoError(t, os.WriteFile(a, []byte("a\n"), 0644))
+
+	// The clock is ahead of the modification times, so the files are not
+	// seen as recently changed on every scan.
+	clk := clock.NewFake(time.Now().Add(time.Hour))
+	watcher := NewWatcher([]string{dir}, Debounce(5*time.Second), Clock(clk))
+	_, err := watcher.ScanEvents()
+	assert.NoError(t, err)
+	clk.Advance(5 * time.Second)
+	_, err = watcher.ScanEvents()
+	assert.NoError(t, err)
+
+	assert.NoError(t, os.Rename(a, b))
+	events, err := watcher.ScanEvents()
+	assert.NoError(t, err)
+	assert.Empty(t, events)
+
+	clk.Advance(time.Second)
+	assert.NoError(t, os.Remove(b))
+	events, err = watcher.ScanEvents()
+	assert.NoError(t, err)
+	assert.Empty(t, events)
+
+	// The file known by the consumer is the one at the original path.
+	clk.Advance(5 * time.Second)
+	events, err = watcher.ScanEvents()
+	assert.NoError(t, err)
+	assert.Equal(t, []Event{{Path: a, Op: Deleted}}, changes(events))
+}
+
+func TestAddPendingRenameThenDelete(t *testing.T) {
+	watcher := &FileWatcher{pending: map[string]Event{}}
+	watcher.addPending(Event{Path: "b", Op: Renamed, OldPath: "a"})
+	watcher.addPending(Event{Path: "a", Op: Created})
+	watcher.addPending(Event{Path: "b", Op: Deleted})
+
+	// The original file at a was replaced by a new one.
+	assert.Equal(t, map[string]Event{"a": {Path: "a", Op: Modified}}, watcher.pending)
+}
+
+func TestEventTypes(t *testing.T) {
+	dir := t.TempDir()
+	a := filepath.Join(dir, "a.yml")
+	b := filepath.Join(dir, "b.yml")
+	assert.NoError(t, os.WriteFile(a, []byte("a\n"), 0644))
+
+	watcher := NewWatcher([]string{dir})
+	events, err := watcher.ScanEvents()
+	assert.NoError(t, err)
+	assert.Equal(t, []Event{{Path: a, Op: Created}}, changes(events))
+	assert.Equal(t, int64(2), events[0].Size)
+
+	assert.NoError(t, os.Rename(a, b))
+	events, err = watcher.ScanEvents()
+	assert.NoError(t, err)
+	assert.Equal(t, []Event{{Path: b, Op: Renamed, OldPath: a}}, changes(events))
+	assert.False(t, events[0].ID.IsZero())
+
+	if runtime.GOOS != "windows" {
+		assert.NoError(t, os.Chmod(b, 0600))
+		events, err = watcher.ScanEvents()
+		assert.NoError(t, err)
+		assert.Equal(t, []Event{{Path: b, Op: PermissionChanged}}, changes(events))
+		assert.Equal(t, os.FileMode(0600), events[0].Mode.Perm())
+	}
+
+	assert.NoError(t, os.Remove(b))
+	events, err = watcher.ScanEvents()
+	assert.NoError(t, err)
+	assert.Equal(t, []Event{{Path: b, Op: Deleted}}, changes(events))
+}
+
+func TestWatch(t *testing.T) {
+	dir := t.TempDir()
+	a := filepath.Join(dir, "a.yml")
+	assert.NoError(t, os.WriteFile(a, []byte("a\n"), 0644))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	watcher := NewWatcher([]string{dir})
+	ch := watcher.Watch(ctx, 10*time.Millisecond)
+
+	e := <-ch
+	assert.Equal(t, Event{Path: a, Op: Created}, changes([]Event{e})[0])
+
+	assert.NoError(t, os.Remove(a))
+	e = <-ch
+	assert.Equal(t, Event{Path: a, Op: Deleted}, changes([]Event{e})[0])
+
+	cancel()
+	for range ch {
+	}
+}
+
+// changes strips the file information from events, so they can be compared.
+func changes(events []Event) []Event {
+	res := make([]Event, 0, len(events))
+	for _, e := range events {
+		res = append(res, Event{Path: e.Path, Op: e.Op, OldPath: e.OldPath})
+	}
+	return res
+}
+
+func TestSymlinkTargetChange(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("creating symlinks requires extra privileges on Windows")
+	}
+
+	// Mimic how Kubernetes updates ConfigMap volumes.
+	dir, err := filepath.EvalSymlinks(t.TempDir())
+	assert.NoError(t, err)
+	modTime := time.Now().Add(-time.Hour).Truncate(time.Second)
+	for _, d := range []string{"..v1", "..v2"} {
+		filename := filepath.Join(dir, d, "config.yml")
+		assert.NoError(t, os.MkdirAll(filepath.Dir(filename), 0755))
+		assert.NoError(t, os.WriteFile(filename, []byte("test\n"), 0644))
+		assert.NoError(t, os.Chtimes(filename, modTime, modTime))
+	}
+	assert.NoError(t, os.Symlink("..v1", filepath.Join(dir, "..data")))
+	config := filepath.Join(dir, "config.yml")
+	assert.NoError(t, os.Symlink(filepath.Join("..data", "config.yml"), config))
+
+	watcher := New(config)
+	events, err := watcher.ScanEvents()
+	assert.NoError(t, err)
+	assert.Equal(t, []Event{{Path: config, Op: Created}}, changes(events))
+	assert.Equal(t, filepath.Join(dir, "..v1", "config.yml"), events[0].Target)
+
+	tmp := filepath.Join(dir, "..data_tmp")
+	assert.NoError(t, os.Symlink("..v2", tmp))
+	assert.NoError(t, os.Rename(tmp, filepath.Join(dir, "..data")))
+
+	events, err = watcher.ScanEvents()
+	assert.NoError(t, err)
+	assert.Equal(t, []Event{{Path: config, Op: Modified}}, changes(events))
+	assert.Equal(t, filepath.Join(dir, "..v2", "config.yml"), events[0].Target)
+}
+
+func TestPersistedState(t *testing.T) {
+	dir := t.TempDir()
+	a := filepath.Join(dir, "a.yml")
+	b := filepath.Join(dir, "b.yml")
+	stateFile := filepath.Join(t.TempDir(), "state.json")
+	assert.NoError(t, os.WriteFile(a, []byte("a\n"), 0644))
+	assert.NoError(t, os.WriteFile(b, []byte("b\n"), 0644))
+
+	watcher := NewWatcher([]string{dir})
+	assert.NoError(t, watcher.LoadState(stateFile), "a missing state file is not an error")
+	events, err := watcher.ScanEvents()
+	assert.NoError(t, err)
+	assert.Len(t, events, 2)
+	assert.NoError(t, watcher.SaveState(stateFile))
+
+	// Simulate a restart while b is changed.
+	assert.NoError(t, os.WriteFile(b, []byte("b, longer\n"), 0644))
+
+	watcher = NewWatcher([]string{dir})
+	assert.NoError(t, watcher.LoadState(stateFile))
+	events, err = watcher.ScanEvents()
+	assert.NoError(t, err)
+	assert.Equal(t, []Event{{Path: b, Op: Modified}}, changes(events))
+
+	assert.NoError(t, os.WriteFile(stateFile, []byte("{"), 0600))
+	assert.Error(t, watcher.LoadState(stateFile))
+}
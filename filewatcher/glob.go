@@ -0,0 +1,167 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package filewatcher
+
+import (
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/elastic/elastic-agent-libs/logp"
+)
+
+// expandPath returns the files a configured path refers to. Glob patterns
+// are expanded and directories are walked, files are returned as is.
+func (f *FileWatcher) expandPath(p string) []string {
+	if !hasMeta(p) {
+		info, err := os.Stat(p)
+		if err != nil || !info.IsDir() {
+			// Let the scan report files that cannot be accessed.
+			if err == nil && !f.accept(filepath.Base(p)) {
+				return nil
+			}
+			return []string{p}
+		}
+		return f.walk(p)
+	}
+
+	matches, err := filepath.Glob(p)
+	if err != nil {
+		logp.Err("Error expanding glob pattern %s: %v", p, err)
+		return nil
+	}
+
+	files := []string{}
+	for _, match := range matches {
+		info, err := os.Stat(match)
+		if err != nil {
+			continue
+		}
+		if info.IsDir() {
+			files = append(files, f.walk(match)...)
+			continue
+		}
+		if f.accept(filepath.Base(match)) {
+			files = append(files, match)
+		}
+	}
+	return files
+}
+
+// walk returns all files inside root matching the include and exclude
+// patterns. Sub-directories are only visited when the watcher is recursive.
+func (f *FileWatcher) walk(root string) []string {
+	files := []string{}
+	err := filepath.WalkDir(root, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			logp.Err("Error walking %s: %v", p, err)
+			if d != nil && d.IsDir() && p != root {
+				return fs.SkipDir
+			}
+			return nil
+		}
+
+		rel, err := filepath.Rel(root, p)
+		if err != nil {
+			return nil //nolint:nilerr // p is always inside root
+		}
+		rel = filepath.ToSlash(rel)
+
+		if d.IsDir() {
+			if p == root {
+				return nil
+			}
+			if !f.recursive || matchAny(f.exclude, rel) {
+				return fs.SkipDir
+			}
+			return nil
+		}
+
+		if d.Type()&fs.ModeSymlink != 0 {
+			// Do not follow links to directories, but watch linked files.
+			if info, err := os.Stat(p); err == nil && info.IsDir() {
+				return nil
+			}
+		}
+
+		if f.accept(rel) {
+			files = append(files, p)
+		}
+		return nil
+	})
+	if err != nil {
+		logp.Err("Error walking %s: %v", root, err)
+	}
+	return files
+}
+
+// accept returns true if the file at the relative path rel must be watched.
+func (f *FileWatcher) accept(rel string) bool {
+	if matchAny(f.exclude, rel) {
+		return false
+	}
+	return len(f.include) == 0 || matchAny(f.include, rel)
+}
+
+func matchAny(patterns []string, rel string) bool {
+	for _, pattern := range patterns {
+		if matchPattern(pattern, rel) {
+			return true
+		}
+	}
+	return false
+}
+
+// matchPattern matches a slash separated relative path against a glob
+// pattern. Patterns without a separator are matched against the last
+// element of the path, "**" matches zero or more directories.
+func matchPattern(pattern, rel string) bool {
+	pattern = filepath.ToSlash(pattern)
+	if !strings.Contains(pattern, "/") {
+		ok, _ := path.Match(pattern, path.Base(rel))
+		return ok
+	}
+	return matchSegments(strings.Split(pattern, "/"), strings.Split(rel, "/"))
+}
+
+func matchSegments(pattern, parts []string) bool {
+	for len(pattern) > 0 {
+		if pattern[0] == "**" {
+			for i := 0; i <= len(parts); i++ {
+				if matchSegments(pattern[1:], parts[i:]) {
+					return true
+				}
+			}
+			return false
+		}
+		if len(parts) == 0 {
+			return false
+		}
+		if ok, _ := path.Match(pattern[0], parts[0]); !ok {
+			return false
+		}
+		pattern, parts = pattern[1:], parts[1:]
+	}
+	return len(parts) == 0
+}
+
+func hasMeta(p string) bool {
+	return strings.ContainsAny(p, `*?[`)
+}
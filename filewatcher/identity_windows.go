@@ -0,0 +1,48 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package filewatcher
+
+import (
+	"os"
+
+	"golang.org/x/sys/windows"
+)
+
+func fileIDOf(path string, _ os.FileInfo) FileID {
+	p, err := windows.UTF16PtrFromString(path)
+	if err != nil {
+		return FileID{}
+	}
+
+	h, err := windows.CreateFile(p, 0,
+		windows.FILE_SHARE_READ|windows.FILE_SHARE_WRITE|windows.FILE_SHARE_DELETE,
+		nil, windows.OPEN_EXISTING, windows.FILE_FLAG_BACKUP_SEMANTICS, 0)
+	if err != nil {
+		return FileID{}
+	}
+	defer windows.CloseHandle(h) //nolint:errcheck // read only handle
+
+	var data windows.ByHandleFileInformation
+	if err := windows.GetFileInformationByHandle(h, &data); err != nil {
+		return FileID{}
+	}
+	return FileID{
+		Device: uint64(data.VolumeSerialNumber),
+		Inode:  uint64(data.FileIndexHigh)<<32 | uint64(data.FileIndexLow),
+	}
+}
@@ -0,0 +1,210 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package filewatcher
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/elastic/elastic-agent-libs/clock"
+	"github.com/elastic/elastic-agent-libs/logp"
+)
+
+// Callback is called with the changes found when scanning a path watched by
+// a Manager. It is never called with an empty list of events.
+type Callback func(events []Event)
+
+// Manager watches a dynamic set of paths from a single goroutine. Each path
+// has its own callback and scan interval, paths can be added and removed at
+// any time.
+type Manager struct {
+	mu      sync.Mutex
+	entries map[string]*managerEntry
+	wakeup  chan struct{}
+	clock   clock.Clock
+}
+
+type managerEntry struct {
+	watcher  *FileWatcher
+	interval time.Duration
+	callback Callback
+	next     time.Time
+}
+
+// ManagerOption configures a Manager.
+type ManagerOption func(m *Manager)
+
+// ManagerClock sets the clock used to schedule scans. The clock is also
+// passed to the watchers of the added paths. It is meant to be used with
+// clock.Fake in tests. The default is clock.Real().
+func ManagerClock(c clock.Clock) ManagerOption {
+	return func(m *Manager) {
+		m.clock = c
+	}
+}
+
+// NewManager returns a new Manager, Run must be called for it to start scanning.
+func NewManager(opts ...ManagerOption) *Manager {
+	m := &Manager{
+		entries: map[string]*managerEntry{},
+		wakeup:  make(chan struct{}, 1),
+		clock:   clock.Real(),
+	}
+	for _, opt := range opts {
+		opt(m)
+	}
+	return m
+}
+
+// Add starts watching path, which can be a file, a directory or a glob
+// pattern, see NewWatcher. The path is scanned every interval and callback
+// is called with the changes found; the first scan reports all existing
+// files as created. Adding a path that is already watched returns an error.
+func (m *Manager) Add(path string, interval time.Duration, callback Callback, opts ...Option) error {
+	if interval <= 0 {
+		return fmt.Errorf("scan interval for %s must be greater than 0", path)
+	}
+	if callback == nil {
+		return fmt.Errorf("callback for %s must not be nil", path)
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if _, ok := m.entries[path]; ok {
+		return fmt.Errorf("%s is already watched", path)
+	}
+	m.entries[path] = &managerEntry{
+		watcher:  NewWatcher([]string{path}, append([]Option{Clock(m.clock)}, opts...)...),
+		interval: interval,
+		callback: callback,
+	}
+	m.notify()
+	return nil
+}
+
+// Remove stops watching path. It returns false if path was not watched.
+// The callback of path can still be running when Remove returns, but it
+// is not called afterwards.
+func (m *Manager) Remove(path string) bool {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if _, ok := m.entries[path]; !ok {
+		return false
+	}
+	delete(m.entries, path)
+	m.notify()
+	return true
+}
+
+// Paths returns the list of watched paths.
+func (m *Manager) Paths() []string {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	paths := make([]string, 0, len(m.entries))
+	for path := range m.entries {
+		paths = append(paths, path)
+	}
+	return paths
+}
+
+// Run scans the watched paths when they are due and calls their callbacks,
+// callbacks are called one at a time. It blocks until ctx is done.
+func (m *Manager) Run(ctx context.Context) {
+	timer := m.clock.NewTimer(0)
+	defer timer.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-m.wakeup:
+		case <-timer.C():
+		}
+
+		wait := m.scanDue(ctx, m.clock.Now())
+		if !timer.Stop() {
+			select {
+			case <-timer.C():
+			default:
+			}
+		}
+		timer.Reset(wait)
+	}
+}
+
+// scanDue scans all the paths that are due and returns how long to wait for
+// the next path to be due.
+func (m *Manager) scanDue(ctx context.Context, now time.Time) time.Duration {
+	m.mu.Lock()
+	due := map[string]*managerEntry{}
+	for path, e := range m.entries {
+		if !e.next.After(now) {
+			due[path] = e
+			e.next = now.Add(e.interval)
+		}
+	}
+	m.mu.Unlock()
+
+	for path, e := range due {
+		if ctx.Err() != nil {
+			break
+		}
+		events, err := e.watcher.ScanEvents()
+		if err != nil {
+			logp.Err("Error scanning %s: %v", path, err)
+		}
+		if len(events) > 0 && m.watched(path, e) {
+			e.callback(events)
+		}
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	// When nothing is watched, wait for a path to be added.
+	wait := time.Hour
+	now = m.clock.Now()
+	for _, e := range m.entries {
+		if d := e.next.Sub(now); d < wait {
+			wait = d
+		}
+	}
+	if wait < 0 {
+		wait = 0
+	}
+	return wait
+}
+
+// watched returns true if path is still watched by the entry e.
+func (m *Manager) watched(path string, e *managerEntry) bool {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.entries[path] == e
+}
+
+func (m *Manager) notify() {
+	select {
+	case m.wakeup <- struct{}{}:
+	default:
+	}
+}
@@ -0,0 +1,127 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package filewatcher
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/elastic/elastic-agent-libs/clock"
+)
+
+func TestManager(t *testing.T) {
+	dir := t.TempDir()
+	a := filepath.Join(dir, "a.yml")
+	b := filepath.Join(dir, "b.yml")
+	for _, f := range []string{a, b} {
+		require.NoError(t, os.WriteFile(f, []byte("test\n"), 0644))
+	}
+
+	var mu sync.Mutex
+	seen := map[string][]Event{}
+	callback := func(path string) Callback {
+		return func(events []Event) {
+			mu.Lock()
+			defer mu.Unlock()
+			seen[path] = append(seen[path], changes(events)...)
+		}
+	}
+	seenFor := func(path string) []Event {
+		mu.Lock()
+		defer mu.Unlock()
+		return seen[path]
+	}
+
+	m := NewManager()
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go m.Run(ctx)
+
+	require.NoError(t, m.Add(a, 10*time.Millisecond, callback(a)))
+	require.NoError(t, m.Add(b, time.Hour, callback(b)))
+	assert.Error(t, m.Add(a, time.Second, callback(a)), "a path can only be added once")
+	assert.ElementsMatch(t, []string{a, b}, m.Paths())
+
+	assert.Eventually(t, func() bool {
+		return len(seenFor(a)) == 1 && len(seenFor(b)) == 1
+	}, 5*time.Second, 10*time.Millisecond, "existing files are reported on the first scan")
+
+	// Only a is scanned again.
+	require.NoError(t, os.Remove(a))
+	require.NoError(t, os.Remove(b))
+	assert.Eventually(t, func() bool {
+		return len(seenFor(a)) == 2
+	}, 5*time.Second, 10*time.Millisecond)
+	assert.Equal(t, []Event{{Path: a, Op: Created}, {Path: a, Op: Deleted}}, seenFor(a))
+	assert.Equal(t, []Event{{Path: b, Op: Created}}, seenFor(b))
+
+	assert.True(t, m.Remove(a))
+	assert.False(t, m.Remove(a))
+	assert.Equal(t, []string{b}, m.Paths())
+}
+
+func TestManagerClock(t *testing.T) {
+	dir := t.TempDir()
+	a := filepath.Join(dir, "a.yml")
+	require.NoError(t, os.WriteFile(a, []byte("test\n"), 0644))
+
+	var mu sync.Mutex
+	var seen []Event
+	var seenAt []time.Time
+	clk := clock.NewFake(time.Date(2024, 3, 26, 12, 0, 0, 0, time.UTC))
+	start := clk.Now()
+
+	m := NewManager(ManagerClock(clk))
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go m.Run(ctx)
+	clk.BlockUntil(1)
+
+	require.NoError(t, m.Add(a, time.Minute, func(events []Event) {
+		mu.Lock()
+		defer mu.Unlock()
+		seen = append(seen, changes(events)...)
+		seenAt = append(seenAt, clk.Now())
+	}))
+	seenCount := func() int {
+		mu.Lock()
+		defer mu.Unlock()
+		return len(seen)
+	}
+	require.Eventually(t, func() bool { return seenCount() == 1 }, 5*time.Second, time.Millisecond)
+
+	// The deletion is only noticed once the clock reaches the next scan.
+	require.NoError(t, os.Remove(a))
+	require.Eventually(t, func() bool {
+		clk.Advance(time.Second)
+		return seenCount() == 2
+	}, 5*time.Second, time.Millisecond)
+
+	mu.Lock()
+	defer mu.Unlock()
+	assert.Equal(t, []Event{{Path: a, Op: Created}, {Path: a, Op: Deleted}}, seen)
+	assert.Equal(t, start, seenAt[0])
+	assert.False(t, seenAt[1].Before(start.Add(time.Minute)), "second scan must not happen before the interval")
+}
@@ -0,0 +1,93 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package filewatcher
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"time"
+
+	"github.com/elastic/elastic-agent-libs/file"
+)
+
+const stateVersion = 1
+
+// persistedState is the format of the state file written by SaveState.
+type persistedState struct {
+	Version  int                  `json:"version"`
+	LastScan time.Time            `json:"last_scan"`
+	LastHash uint64               `json:"last_hash"`
+	Files    map[string]fileState `json:"files"`
+}
+
+// SaveState writes the information collected by the last scan to the file
+// at path, the file is replaced atomically. Restoring it with LoadState
+// after a restart prevents files that did not change in the meantime from
+// being reported again.
+func (f *FileWatcher) SaveState(path string) error {
+	data, err := json.Marshal(persistedState{
+		Version:  stateVersion,
+		LastScan: f.lastScan,
+		LastHash: f.lastHash,
+		Files:    f.state,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to encode file watcher state: %w", err)
+	}
+
+	tmp := path + ".new"
+	if err := os.WriteFile(tmp, data, 0600); err != nil {
+		return fmt.Errorf("failed to write file watcher state: %w", err)
+	}
+	if err := file.SafeFileRotate(path, tmp); err != nil {
+		return fmt.Errorf("failed to replace file watcher state %s: %w", path, err)
+	}
+	return nil
+}
+
+// LoadState restores the state written by SaveState, it must be called
+// before the first scan. A missing state file is not an error, in that case
+// the first scan reports all files as created.
+func (f *FileWatcher) LoadState(path string) error {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to read file watcher state: %w", err)
+	}
+
+	var st persistedState
+	if err := json.Unmarshal(data, &st); err != nil {
+		return fmt.Errorf("failed to decode file watcher state %s: %w", path, err)
+	}
+	if st.Version != stateVersion {
+		return fmt.Errorf("unsupported file watcher state version %d in %s", st.Version, path)
+	}
+
+	f.lastScan = st.LastScan
+	f.lastHash = st.LastHash
+	f.state = st.Files
+	if f.state == nil {
+		f.state = map[string]fileState{}
+	}
+	return nil
+}
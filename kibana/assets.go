@@ -0,0 +1,206 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package kibana
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"path"
+)
+
+// ConflictResolution is how InstallAssets resolves the conflicts with the
+// objects already in Kibana.
+type ConflictResolution string
+
+const (
+	// ConflictSkip keeps the existing objects, installing the assets again
+	// doesn't fail. It is the default.
+	ConflictSkip ConflictResolution = "skip"
+	// ConflictOverwrite replaces the existing objects with the assets.
+	ConflictOverwrite ConflictResolution = "overwrite"
+	// ConflictFail returns an error if an asset is already installed.
+	ConflictFail ConflictResolution = "fail"
+)
+
+// InstallAssetsOptions are the options of InstallAssets.
+type InstallAssetsOptions struct {
+	// SpaceID is the space the assets are installed to, the space of the
+	// client if empty.
+	SpaceID string
+	// RewriteIDs prefixes the IDs of the assets, and the references between
+	// them, with the space ID, so the copies of the assets in different
+	// spaces don't conflict. The IDs are not rewritten in the default space.
+	RewriteIDs bool
+	// Conflicts is how the conflicts with the existing objects are
+	// resolved, ConflictSkip if empty.
+	Conflicts ConflictResolution
+}
+
+// InstallAssetsResult is the result of InstallAssets.
+type InstallAssetsResult struct {
+	// Installed are the installed assets.
+	Installed []SavedObjectImportResult
+	// Skipped are the assets already installed and kept, with ConflictSkip.
+	Skipped []SavedObjectReference
+}
+
+// InstallAssets installs the saved objects, e.g. dashboards and index
+// patterns, of the .json and .ndjson files in fsys, os.DirFS for a
+// directory. The .json files have a saved object or an objects list, the
+// .ndjson files have a saved object per line, as exported by ExportObjects.
+// The assets are imported in a single request, in the lexical order of
+// their files.
+func (client *Client) InstallAssets(ctx context.Context, fsys fs.FS, opts InstallAssetsOptions) (r InstallAssetsResult, err error) {
+	switch opts.Conflicts {
+	case "":
+		opts.Conflicts = ConflictSkip
+	case ConflictSkip, ConflictOverwrite, ConflictFail:
+	default:
+		return r, fmt.Errorf("invalid conflict resolution %q", opts.Conflicts)
+	}
+
+	assets, err := readAssets(fsys)
+	if err != nil {
+		return r, err
+	}
+	if len(assets) == 0 {
+		return r, nil
+	}
+
+	spaceID := opts.SpaceID
+	if spaceID == "" {
+		spaceID = client.space(ctx)
+	}
+	if opts.RewriteIDs && spaceID != "" && spaceID != "default" {
+		rewriteAssetIDs(assets, spaceID+"-")
+	}
+
+	var objects bytes.Buffer
+	enc := json.NewEncoder(&objects)
+	for _, asset := range assets {
+		if err := enc.Encode(asset); err != nil {
+			return r, fmt.Errorf("encoding asset %s/%s: %w", asset["type"], asset["id"], err)
+		}
+	}
+
+	resp, err := client.ImportObjects(ctx, ImportObjectsRequest{
+		Objects:   objects.Bytes(),
+		Overwrite: opts.Conflicts == ConflictOverwrite,
+		SpaceID:   opts.SpaceID,
+	})
+	if err != nil {
+		return r, fmt.Errorf("installing assets: %w", err)
+	}
+
+	r.Installed = resp.SuccessResults
+	var errs []error
+	for _, importErr := range resp.Errors {
+		isConflict := importErr.Error.Type == "conflict" || importErr.Error.Type == "ambiguous_conflict"
+		if isConflict && opts.Conflicts == ConflictSkip {
+			r.Skipped = append(r.Skipped, SavedObjectReference{Type: importErr.Type, ID: importErr.ID})
+			continue
+		}
+		errs = append(errs, fmt.Errorf("%s %s: %s", importErr.Type, importErr.ID, importErr.Error.Type))
+	}
+	if len(errs) > 0 {
+		return r, fmt.Errorf("failed to install %d assets: %w", len(errs), errors.Join(errs...))
+	}
+	return r, nil
+}
+
+// readAssets reads the saved objects of the .json and .ndjson files in fsys.
+func readAssets(fsys fs.FS) ([]map[string]any, error) {
+	var assets []map[string]any
+	err := fs.WalkDir(fsys, ".", func(name string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return err
+		}
+		ext := path.Ext(name)
+		if ext != ".json" && ext != ".ndjson" {
+			return nil
+		}
+
+		f, err := fsys.Open(name)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+
+		err = ReadNDJSON(f, func(value json.RawMessage) error {
+			var asset struct {
+				Objects []map[string]any `json:"objects"`
+			}
+			if err := unmarshalAsset(value, &asset); err != nil {
+				return err
+			}
+			if asset.Objects != nil {
+				assets = append(assets, asset.Objects...)
+				return nil
+			}
+			var object map[string]any
+			if err := unmarshalAsset(value, &object); err != nil {
+				return err
+			}
+			assets = append(assets, object)
+			return nil
+		})
+		if err != nil {
+			return fmt.Errorf("reading assets of %s: %w", name, err)
+		}
+		return nil
+	})
+	return assets, err
+}
+
+func unmarshalAsset(value json.RawMessage, v any) error {
+	dec := json.NewDecoder(bytes.NewReader(value))
+	dec.UseNumber()
+	return dec.Decode(v)
+}
+
+// rewriteAssetIDs prefixes the IDs of the assets and of the references
+// between them.
+func rewriteAssetIDs(assets []map[string]any, prefix string) {
+	type key struct{ typ, id string }
+	keyOf := func(object map[string]any) key {
+		typ, _ := object["type"].(string)
+		id, _ := object["id"].(string)
+		return key{typ, id}
+	}
+
+	ids := make(map[key]bool, len(assets))
+	for _, asset := range assets {
+		ids[keyOf(asset)] = true
+	}
+
+	for _, asset := range assets {
+		if id, ok := asset["id"].(string); ok {
+			asset["id"] = prefix + id
+		}
+		references, _ := asset["references"].([]any)
+		for _, ref := range references {
+			if ref, ok := ref.(map[string]any); ok && ids[keyOf(ref)] {
+				ref["id"] = prefix + keyOf(ref).id
+			}
+		}
+	}
+}
@@ -0,0 +1,138 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package kibana
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"testing"
+	"testing/fstest"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var testAssets = fstest.MapFS{
+	"index-pattern/logs.json": {Data: []byte(`{
+  "type": "index-pattern",
+  "id": "logs-*",
+  "attributes": {"title": "logs-*", "fieldCount": 10}
+}`)},
+	"dashboard/overview.json": {Data: []byte(`{"objects": [
+  {"type": "dashboard", "id": "overview", "attributes": {"title": "Overview"},
+   "references": [
+     {"type": "index-pattern", "id": "logs-*", "name": "panel_0"},
+     {"type": "index-pattern", "id": "metrics-*", "name": "panel_1"}
+   ]}
+]}`)},
+	"search/searches.ndjson": {Data: []byte(`{"type":"search","id":"errors","attributes":{"title":"Errors"}}` + "\n")},
+	"README.md":              {Data: []byte("not an asset")},
+}
+
+// importedObjects returns the objects of an import request.
+func importedObjects(t *testing.T, r *http.Request) []map[string]any {
+	file, _, err := r.FormFile("file")
+	require.NoError(t, err)
+	defer file.Close()
+	content, err := io.ReadAll(file)
+	require.NoError(t, err)
+
+	var objects []map[string]any
+	require.NoError(t, ReadNDJSON(bytes.NewReader(content), func(value json.RawMessage) error {
+		var object map[string]any
+		if err := json.Unmarshal(value, &object); err != nil {
+			return err
+		}
+		objects = append(objects, object)
+		return nil
+	}))
+	return objects
+}
+
+func TestInstallAssets(t *testing.T) {
+	handler := func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/s/marketing"+savedObjectsImportAPI, r.URL.Path)
+		assert.Empty(t, r.URL.Query().Get("overwrite"))
+
+		objects := importedObjects(t, r)
+		require.Len(t, objects, 3)
+		// The files are read in lexical order.
+		assert.Equal(t, "marketing-overview", objects[0]["id"])
+		assert.Equal(t, []any{
+			map[string]any{"type": "index-pattern", "id": "marketing-logs-*", "name": "panel_0"},
+			map[string]any{"type": "index-pattern", "id": "metrics-*", "name": "panel_1"},
+		}, objects[0]["references"])
+		assert.Equal(t, "marketing-logs-*", objects[1]["id"])
+		assert.Equal(t, map[string]any{"title": "logs-*", "fieldCount": float64(10)}, objects[1]["attributes"])
+		assert.Equal(t, "marketing-errors", objects[2]["id"])
+
+		_, _ = w.Write([]byte(`{"success":false,"successCount":2,
+"successResults":[{"type":"dashboard","id":"marketing-overview"},{"type":"search","id":"marketing-errors"}],
+"errors":[{"type":"index-pattern","id":"marketing-logs-*","error":{"type":"conflict"}}]}`))
+	}
+	client, err := createTestServerAndClient(handler)
+	require.NoError(t, err)
+
+	r, err := client.InstallAssets(context.Background(), testAssets, InstallAssetsOptions{
+		SpaceID:    "marketing",
+		RewriteIDs: true,
+	})
+	require.NoError(t, err)
+	assert.Equal(t, []SavedObjectImportResult{
+		{Type: "dashboard", ID: "marketing-overview"},
+		{Type: "search", ID: "marketing-errors"},
+	}, r.Installed)
+	assert.Equal(t, []SavedObjectReference{{Type: "index-pattern", ID: "marketing-logs-*"}}, r.Skipped)
+}
+
+func TestInstallAssetsConflicts(t *testing.T) {
+	handler := func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, savedObjectsImportAPI, r.URL.Path)
+		objects := importedObjects(t, r)
+		assert.Equal(t, "logs-*", objects[1]["id"], "the IDs are not rewritten in the default space")
+
+		if r.URL.Query().Get("overwrite") == "true" {
+			_, _ = w.Write([]byte(`{"success":true,"successCount":3}`))
+			return
+		}
+		_, _ = w.Write([]byte(`{"success":false,"successCount":1,
+"errors":[{"type":"index-pattern","id":"logs-*","error":{"type":"conflict"}},
+{"type":"dashboard","id":"overview","error":{"type":"missing_references"}}]}`))
+	}
+	client, err := createTestServerAndClient(handler)
+	require.NoError(t, err)
+	ctx := context.Background()
+
+	_, err = client.InstallAssets(ctx, testAssets, InstallAssetsOptions{RewriteIDs: true, Conflicts: ConflictOverwrite})
+	require.NoError(t, err)
+
+	_, err = client.InstallAssets(ctx, testAssets, InstallAssetsOptions{})
+	assert.EqualError(t, err, "failed to install 1 assets: dashboard overview: missing_references")
+
+	_, err = client.InstallAssets(ctx, testAssets, InstallAssetsOptions{Conflicts: ConflictFail})
+	assert.EqualError(t, err, "failed to install 2 assets: index-pattern logs-*: conflict\ndashboard overview: missing_references")
+
+	_, err = client.InstallAssets(ctx, testAssets, InstallAssetsOptions{Conflicts: "ignore"})
+	assert.EqualError(t, err, `invalid conflict resolution "ignore"`)
+
+	_, err = client.InstallAssets(ctx, fstest.MapFS{"broken.json": {Data: []byte(`{"type":`)}}, InstallAssetsOptions{})
+	assert.ErrorContains(t, err, "reading assets of broken.json")
+}
@@ -0,0 +1,221 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package kibana
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/elastic/elastic-agent-libs/clock"
+)
+
+const defaultCacheMaxEntries = 100
+
+// CacheConfig configures the in-memory cache of the responses of the GET
+// requests. A cached response is returned without sending the request for
+// TTL, then the request is sent again with the ETag of the response, if it
+// had one, so Kibana can confirm it is unchanged with a 304 Not Modified.
+// Only the 200 OK responses without Cache-Control: no-store are cached, and
+// any other request than a GET clears the cache, as it might modify what
+// Kibana returns.
+type CacheConfig struct {
+	// TTL is for how long a response is returned from the cache, the
+	// responses are not cached if it is 0.
+	TTL time.Duration `config:"ttl" yaml:"ttl,omitempty"`
+	// MaxEntries is the maximum number of cached responses, 100 if it is 0.
+	MaxEntries int `config:"max_entries" yaml:"max_entries,omitempty"`
+	// Paths are the path prefixes of the cached requests, e.g. /api/status
+	// or /api/fleet/epm/packages. All the GET requests are cached if it is
+	// empty.
+	Paths []string `config:"paths" yaml:"paths,omitempty"`
+}
+
+// Validate checks the TTL and maximum number of entries.
+func (c *CacheConfig) Validate() error {
+	if c.TTL < 0 || c.MaxEntries < 0 {
+		return errors.New("cache ttl and max_entries must be positive")
+	}
+	return nil
+}
+
+// responseCache is the cache of CacheConfig, keyed by the URL and
+// credentials of the requests.
+type responseCache struct {
+	mu         sync.Mutex
+	entries    map[string]*cacheEntry
+	ttl        time.Duration
+	maxEntries int
+	paths      []string
+	clock      clock.Clock
+}
+
+type cacheEntry struct {
+	statusCode int
+	header     http.Header
+	body       []byte
+	expires    time.Time
+}
+
+// newResponseCache returns the cache of the config, nil if the responses are
+// not cached.
+func newResponseCache(cfg CacheConfig, clk clock.Clock) *responseCache {
+	if cfg.TTL == 0 {
+		return nil
+	}
+	maxEntries := cfg.MaxEntries
+	if maxEntries == 0 {
+		maxEntries = defaultCacheMaxEntries
+	}
+	return &responseCache{
+		entries:    make(map[string]*cacheEntry),
+		ttl:        cfg.TTL,
+		maxEntries: maxEntries,
+		paths:      cfg.Paths,
+		clock:      clk,
+	}
+}
+
+// cacheable returns true if the response of r can be cached.
+func (c *responseCache) cacheable(r *http.Request) bool {
+	if r.Method != http.MethodGet {
+		return false
+	}
+	if len(c.paths) == 0 {
+		return true
+	}
+	for _, p := range c.paths {
+		if strings.HasPrefix(r.URL.Path, p) {
+			return true
+		}
+	}
+	return false
+}
+
+func cacheKey(r *http.Request) string {
+	return r.URL.String() + "\n" + r.Header.Get("Authorization")
+}
+
+// roundTrip sends r with send, unless its response is cached.
+func (c *responseCache) roundTrip(r *http.Request, send func(*http.Request) (*http.Response, error)) (*http.Response, error) {
+	if c == nil {
+		return send(r)
+	}
+	if !c.cacheable(r) {
+		if r.Method != http.MethodGet && r.Method != http.MethodHead {
+			c.clear()
+		}
+		return send(r)
+	}
+
+	key := cacheKey(r)
+	entry, fresh := c.get(key)
+	if fresh {
+		return entry.response(r), nil
+	}
+	if etag := entry.etag(); etag != "" {
+		r = r.Clone(r.Context())
+		r.Header.Set("If-None-Match", etag)
+	}
+
+	resp, err := send(r)
+	if err != nil {
+		return resp, err
+	}
+	switch {
+	case resp.StatusCode == http.StatusNotModified && entry != nil:
+		_, _ = io.Copy(io.Discard, resp.Body)
+		resp.Body.Close()
+		c.put(key, entry)
+		return entry.response(r), nil
+	case resp.StatusCode == http.StatusOK && !strings.Contains(resp.Header.Get("Cache-Control"), "no-store"):
+		body, err := io.ReadAll(resp.Body)
+		resp.Body.Close()
+		if err != nil {
+			return nil, err
+		}
+		c.put(key, &cacheEntry{statusCode: resp.StatusCode, header: resp.Header.Clone(), body: body})
+		resp.Body = io.NopCloser(bytes.NewReader(body))
+		return resp, nil
+	default:
+		return resp, nil
+	}
+}
+
+// get returns the entry of key, and true if it hasn't expired.
+func (c *responseCache) get(key string) (*cacheEntry, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	entry, ok := c.entries[key]
+	if !ok {
+		return nil, false
+	}
+	return entry, c.clock.Now().Before(entry.expires)
+}
+
+// put stores entry for the TTL, evicting the entry expiring first if the
+// cache is full.
+func (c *responseCache) put(key string, entry *cacheEntry) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if _, ok := c.entries[key]; !ok && len(c.entries) >= c.maxEntries {
+		var evict string
+		for k, e := range c.entries {
+			if evict == "" || e.expires.Before(c.entries[evict].expires) {
+				evict = k
+			}
+		}
+		delete(c.entries, evict)
+	}
+	updated := *entry
+	updated.expires = c.clock.Now().Add(c.ttl)
+	c.entries[key] = &updated
+}
+
+func (c *responseCache) clear() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	clear(c.entries)
+}
+
+func (e *cacheEntry) etag() string {
+	if e == nil {
+		return ""
+	}
+	return e.header.Get("ETag")
+}
+
+// response returns the cached response of r.
+func (e *cacheEntry) response(r *http.Request) *http.Response {
+	return &http.Response{
+		Status:        http.StatusText(e.statusCode),
+		StatusCode:    e.statusCode,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        e.header.Clone(),
+		Body:          io.NopCloser(bytes.NewReader(e.body)),
+		ContentLength: int64(len(e.body)),
+		Request:       r,
+	}
+}
@@ -0,0 +1,125 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package kibana
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/elastic/elastic-agent-libs/clock"
+	"github.com/elastic/elastic-agent-libs/config"
+)
+
+func TestCache(t *testing.T) {
+	requests := map[string]int{}
+	notModified := 0
+	kibanaTS := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests[r.Method+" "+r.URL.Path]++
+		switch r.URL.Path {
+		case "/api/status":
+			if r.Header.Get("If-None-Match") == `"v1"` {
+				notModified++
+				w.WriteHeader(http.StatusNotModified)
+				return
+			}
+			w.Header().Set("ETag", `"v1"`)
+			_, _ = w.Write([]byte(`{"status":"green"}`))
+		case "/api/nostore":
+			w.Header().Set("Cache-Control", "no-store")
+			_, _ = w.Write([]byte(`{}`))
+		default:
+			_, _ = w.Write([]byte(`{}`))
+		}
+	}))
+	defer kibanaTS.Close()
+
+	client, err := NewKibanaClient(config.MustNewConfigFrom(fmt.Sprintf(`
+protocol: http
+host: %s
+ignoreversion: true
+cache.ttl: 1m
+`, kibanaTS.Listener.Addr().String())), binaryName, v, commit, buildTime)
+	require.NoError(t, err)
+	clk := clock.NewFake(time.Now())
+	client.Connection.cache.clock = clk
+
+	get := func(path string) string {
+		code, body, err := client.Request(http.MethodGet, path, nil, nil, nil)
+		require.NoError(t, err)
+		assert.Equal(t, http.StatusOK, code)
+		return string(body)
+	}
+
+	// The response is cached for the TTL.
+	for i := 0; i < 3; i++ {
+		assert.Equal(t, `{"status":"green"}`, get("/api/status"))
+	}
+	assert.Equal(t, 1, requests["GET /api/status"])
+
+	// Then it is revalidated with its ETag.
+	clk.Advance(2 * time.Minute)
+	assert.Equal(t, `{"status":"green"}`, get("/api/status"))
+	assert.Equal(t, `{"status":"green"}`, get("/api/status"))
+	assert.Equal(t, 2, requests["GET /api/status"])
+	assert.Equal(t, 1, notModified)
+
+	// The no-store responses are not cached.
+	get("/api/nostore")
+	get("/api/nostore")
+	assert.Equal(t, 2, requests["GET /api/nostore"])
+
+	// The other requests clear the cache.
+	_, _, err = client.Request(http.MethodPost, "/api/test", nil, nil, nil)
+	require.NoError(t, err)
+	get("/api/status")
+	assert.Equal(t, 3, requests["GET /api/status"])
+}
+
+func TestCachePaths(t *testing.T) {
+	c := newResponseCache(CacheConfig{TTL: time.Minute, Paths: []string{"/api/status"}}, clock.Real())
+	status := httptest.NewRequest(http.MethodGet, "http://localhost/api/status", nil)
+	other := httptest.NewRequest(http.MethodGet, "http://localhost/api/other", nil)
+	post := httptest.NewRequest(http.MethodPost, "http://localhost/api/status", nil)
+
+	assert.True(t, c.cacheable(status))
+	assert.False(t, c.cacheable(other))
+	assert.False(t, c.cacheable(post))
+	assert.Nil(t, newResponseCache(CacheConfig{}, clock.Real()))
+}
+
+func TestCacheEviction(t *testing.T) {
+	clk := clock.NewFake(time.Now())
+	c := newResponseCache(CacheConfig{TTL: time.Minute, MaxEntries: 2}, clk)
+
+	for _, key := range []string{"a", "b", "c"} {
+		c.put(key, &cacheEntry{statusCode: http.StatusOK, header: http.Header{}})
+		clk.Advance(time.Second)
+	}
+
+	// The entry expiring first is evicted.
+	assert.Len(t, c.entries, 2)
+	assert.NotContains(t, c.entries, "a")
+	assert.Contains(t, c.entries, "b")
+	assert.Contains(t, c.entries, "c")
+}
@@ -31,9 +31,10 @@ import (
 	"net/http"
 	"net/textproto"
 	"net/url"
-	"path"
 	"strings"
+	"time"
 
+	"github.com/elastic/elastic-agent-libs/clock"
 	"github.com/elastic/elastic-agent-libs/config"
 	"github.com/elastic/elastic-agent-libs/logp"
 	"github.com/elastic/elastic-agent-libs/transport/httpcommon"
@@ -53,11 +54,45 @@ type Connection struct {
 
 	HTTP    *http.Client
 	Version version.V
+
+	// SpaceID is the Kibana space of the requests, the request paths are
+	// prefixed with /s/{SpaceID}. It can be overridden per request with
+	// WithSpace. The requests target the default space if it is empty.
+	SpaceID string
+
+	// Retry configures the retries of the failed requests, they are not
+	// retried if it is the zero value.
+	Retry RetryConfig
+
+	// Metrics collects the metrics of the requests if not nil.
+	Metrics *Metrics
+
+	// hosts fails over the requests across the hosts of the config, URL
+	// being the first one. It is nil if there is a single host.
+	hosts *hostPool
+
+	// baseTransport is the transport of HTTP before the middlewares added
+	// with Use wrap it.
+	baseTransport http.RoundTripper
+	middlewares   []RoundTripperMiddleware
+
+	// limiter limits the rate of the requests, they are not limited if it
+	// is nil.
+	limiter *rateLimiter
+
+	// cache caches the responses of the GET requests if not nil.
+	cache *responseCache
+
+	// clock times the waits between the retries, the real clock is used if
+	// it is nil.
+	clock clock.Clock
 }
 
 type Client struct {
 	Connection
 	log *logp.Logger
+
+	versionInfo VersionInfo
 }
 
 func addToURL(_url, _path string, params url.Values) string {
@@ -68,35 +103,6 @@ func addToURL(_url, _path string, params url.Values) string {
 	return strings.Join([]string{_url, _path, "?", params.Encode()}, "")
 }
 
-func extractError(result []byte) error {
-	var kibanaResult struct {
-		Message    string
-		Attributes struct {
-			Objects []struct {
-				ID    string
-				Error struct {
-					Message string
-				}
-			}
-		}
-	}
-	if err := json.Unmarshal(result, &kibanaResult); err != nil {
-		return fmt.Errorf("error extracting JSON for error response: %w", err)
-	}
-	var errs []error
-	if kibanaResult.Message != "" {
-		for _, err := range kibanaResult.Attributes.Objects {
-			errs = append(errs, fmt.Errorf("id: %s, message: %s", err.ID, err.Error.Message))
-		}
-		if len(errs) == 0 {
-			return fmt.Errorf("%s", kibanaResult.Message)
-		}
-		return fmt.Errorf("%s: %w", kibanaResult.Message, errors.Join(errs...))
-
-	}
-	return nil
-}
-
 func extractMessage(result []byte) error {
 	var kibanaResult struct {
 		Success bool
@@ -148,38 +154,45 @@ func NewClientWithConfigDefault(config *ClientConfig, defaultPort int, binaryNam
 		return nil, err
 	}
 
-	p := config.Path
-	if config.SpaceID != "" {
-		p = path.Join(p, "s", config.SpaceID)
-	}
-	kibanaURL, err := MakeURL(config.Protocol, p, config.Host, defaultPort)
-	if err != nil {
-		return nil, fmt.Errorf("invalid Kibana host: %w", err)
-	}
-
-	u, err := url.Parse(kibanaURL)
-	if err != nil {
-		return nil, fmt.Errorf("failed to parse the Kibana URL: %w", err)
+	hosts := config.Hosts
+	if len(hosts) == 0 {
+		hosts = []string{config.Host}
 	}
 
 	username := config.Username
 	password := config.Password
+	var urlUsername, urlPassword string
+	kibanaURLs := make([]string, 0, len(hosts))
+	for i, host := range hosts {
+		kibanaURL, err := MakeParsedURL(config.Protocol, config.Path, host, defaultPort)
+		if err != nil {
+			return nil, fmt.Errorf("invalid Kibana host: %w", err)
+		}
 
-	if u.User != nil {
-		username = u.User.Username()
-		password, _ = u.User.Password()
-		u.User = nil
-
-		if config.APIKey != "" && (username != "" || password != "") {
-			return nil, fmt.Errorf("cannot set api_key with username/password in Kibana URL")
+		// The credentials are shared by all the hosts of the connection, so
+		// the hosts must not set different ones in their URLs.
+		if i > 0 && (kibanaURL.Username != urlUsername || kibanaURL.Password != urlPassword) {
+			return nil, fmt.Errorf("the Kibana hosts %q and %q have different username/password in their URLs", hosts[0], host)
 		}
+		urlUsername, urlPassword = kibanaURL.Username, kibanaURL.Password
 
-		// Re-write URL without credentials.
-		kibanaURL = u.String()
+		if kibanaURL.Username != "" || kibanaURL.Password != "" {
+			username = kibanaURL.Username
+			password = kibanaURL.Password
+
+			if config.APIKey != "" {
+				return nil, fmt.Errorf("cannot set api_key with username/password in Kibana URL")
+			}
+			if config.ServiceToken != "" {
+				return nil, fmt.Errorf("cannot set service_token with username/password in Kibana URL")
+			}
+		}
+		kibanaURLs = append(kibanaURLs, kibanaURL.URL)
 	}
+	kibanaURL := kibanaURLs[0]
 
 	log := logp.NewLogger("kibana")
-	log.Infof("Kibana url: %s", kibanaURL)
+	log.Infof("Kibana url: %s", strings.Join(kibanaURLs, ", "))
 
 	headers := make(http.Header)
 	for k, v := range config.Headers {
@@ -190,11 +203,16 @@ func NewClientWithConfigDefault(config *ClientConfig, defaultPort int, binaryNam
 		binaryName = "Libbeat"
 	}
 	userAgent := useragent.UserAgent(binaryName, version, commit, buildtime)
-	rt, err := config.Transport.Client(httpcommon.WithHeaderRoundTripper(map[string]string{"User-Agent": userAgent}))
+	rt, err := config.Transport.Client(
+		httpcommon.WithLogger(log),
+		httpcommon.WithKeepaliveSettings{IdleConnTimeout: config.Transport.IdleConnTimeout},
+		httpcommon.WithHeaderRoundTripper(map[string]string{"User-Agent": userAgent}),
+	)
 	if err != nil {
 		return nil, err
 	}
 
+	clk := clock.Real()
 	client := &Client{
 		Connection: Connection{
 			URL:          kibanaURL,
@@ -204,12 +222,21 @@ func NewClientWithConfigDefault(config *ClientConfig, defaultPort int, binaryNam
 			ServiceToken: config.ServiceToken,
 			Headers:      headers,
 			HTTP:         rt,
+			Retry:        config.Retry,
+			SpaceID:      config.SpaceID,
+			Metrics:      newMetricsFromConfig(config.Metrics),
+			limiter:      newRateLimiter(config.RateLimit, clk),
+			cache:        newResponseCache(config.Cache, clk),
+			clock:        clk,
 		},
 		log: log,
 	}
+	if len(kibanaURLs) > 1 {
+		client.Connection.hosts = newHostPool(kibanaURLs, config.Retry.Backoff, clk)
+	}
 
 	if !config.IgnoreVersion {
-		if err = client.readVersion(); err != nil {
+		if err = client.readVersion(context.Background()); err != nil {
 			return nil, fmt.Errorf("fail to get the Kibana version: %w", err)
 		}
 	}
@@ -220,7 +247,14 @@ func NewClientWithConfigDefault(config *ClientConfig, defaultPort int, binaryNam
 func (conn *Connection) Request(method, extraPath string,
 	params url.Values, headers http.Header, body io.Reader) (int, []byte, error) {
 
-	resp, err := conn.Send(method, extraPath, params, headers, body)
+	return conn.RequestWithContext(context.Background(), method, extraPath, params, headers, body)
+}
+
+// RequestWithContext is like Request, sending the request with the given context.
+func (conn *Connection) RequestWithContext(ctx context.Context, method, extraPath string,
+	params url.Values, headers http.Header, body io.Reader) (int, []byte, error) {
+
+	resp, err := conn.SendWithContext(ctx, method, extraPath, params, headers, body)
 	if err != nil {
 		return 0, nil, fmt.Errorf("fail to execute the HTTP %s request: %w", method, err)
 	}
@@ -233,7 +267,7 @@ func (conn *Connection) Request(method, extraPath string,
 
 	var retError error
 	if resp.StatusCode >= 300 {
-		retError = extractError(result)
+		retError = extractError(resp.StatusCode, result)
 	} else {
 		retError = extractMessage(result)
 	}
@@ -248,10 +282,98 @@ func (conn *Connection) Send(method, extraPath string,
 }
 
 // SendWithContext sends an application/json request to Kibana with appropriate kbn headers and the given context.
+// The request is retried as configured by conn.Retry, and fails over to the
+// next host if the client has several hosts.
 func (conn *Connection) SendWithContext(ctx context.Context, method, extraPath string,
 	params url.Values, headers http.Header, body io.Reader) (*http.Response, error) {
 
-	reqURL := addToURL(conn.URL, extraPath, params)
+	if !conn.Retry.enabled() && conn.hosts == nil {
+		req, err := conn.newRequest(ctx, conn.URL, method, extraPath, params, headers, body)
+		if err != nil {
+			return nil, err
+		}
+		return conn.RoundTrip(req)
+	}
+
+	// The body is sent again on each attempt and host.
+	var data []byte
+	if body != nil {
+		var err error
+		if data, err = io.ReadAll(body); err != nil {
+			return nil, fmt.Errorf("fail to read the HTTP %s request body: %w", method, err)
+		}
+	}
+
+	var wait time.Duration
+	for attempt := 1; ; attempt++ {
+		resp, err := conn.sendToHosts(ctx, method, extraPath, params, headers, data)
+		if attempt >= conn.Retry.MaxAttempts || ctx.Err() != nil || !shouldRetry(resp, err) {
+			return resp, err
+		}
+
+		wait = conn.Retry.wait(resp, wait)
+		conn.Metrics.retry()
+		if resp != nil {
+			_, _ = io.Copy(io.Discard, resp.Body)
+			resp.Body.Close()
+		}
+
+		timer := conn.getClock().NewTimer(wait)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return nil, fmt.Errorf("HTTP %s request cancelled after %d attempts: %w", method, attempt, ctx.Err())
+		case <-timer.C():
+		}
+	}
+}
+
+// getClock returns the clock of conn, the real clock if it has none.
+func (conn *Connection) getClock() clock.Clock {
+	if conn.clock == nil {
+		return clock.Real()
+	}
+	return conn.clock
+}
+
+// sendToHosts sends a request to the hosts in the order given by conn.hosts,
+// until a host doesn't fail.
+func (conn *Connection) sendToHosts(ctx context.Context, method, extraPath string,
+	params url.Values, headers http.Header, data []byte) (*http.Response, error) {
+
+	if conn.hosts == nil {
+		req, err := conn.newRequest(ctx, conn.URL, method, extraPath, params, headers, bytes.NewReader(data))
+		if err != nil {
+			return nil, err
+		}
+		return conn.RoundTrip(req)
+	}
+
+	urls := conn.hosts.order()
+	for i, hostURL := range urls {
+		req, err := conn.newRequest(ctx, hostURL, method, extraPath, params, headers, bytes.NewReader(data))
+		if err != nil {
+			return nil, err
+		}
+		resp, err := conn.RoundTrip(req)
+		failed := hostFailed(resp, err)
+		conn.hosts.update(hostURL, failed)
+		if !failed || i == len(urls)-1 || ctx.Err() != nil {
+			return resp, err
+		}
+		if resp != nil {
+			_, _ = io.Copy(io.Discard, resp.Body)
+			resp.Body.Close()
+		}
+	}
+	return nil, errors.New("no Kibana host")
+}
+
+// newRequest creates an application/json request to the Kibana at baseURL with appropriate kbn headers.
+func (conn *Connection) newRequest(ctx context.Context, baseURL, method, extraPath string,
+	params url.Values, headers http.Header, body io.Reader) (*http.Request, error) {
+
+	reqURL := addToURL(baseURL, spacePath(conn.space(ctx), extraPath), params)
 
 	req, err := http.NewRequestWithContext(ctx, method, reqURL, body)
 	if err != nil {
@@ -262,8 +384,7 @@ func (conn *Connection) SendWithContext(ctx context.Context, method, extraPath s
 		req.SetBasicAuth(conn.Username, conn.Password)
 	}
 	if conn.APIKey != "" {
-		v := "ApiKey " + base64.StdEncoding.EncodeToString([]byte(conn.APIKey))
-		req.Header.Set("Authorization", v)
+		req.Header.Set("Authorization", "ApiKey "+encodeAPIKey(conn.APIKey))
 	}
 	if conn.ServiceToken != "" {
 		v := "Bearer " + conn.ServiceToken
@@ -281,7 +402,16 @@ func (conn *Connection) SendWithContext(ctx context.Context, method, extraPath s
 	req.Header.Set("Accept", "application/json")
 	req.Header.Set("kbn-xsrf", "1")
 
-	return conn.RoundTrip(req)
+	return req, nil
+}
+
+// encodeAPIKey base64 encodes an API key set as id:key, an API key without
+// a colon is already encoded.
+func encodeAPIKey(key string) string {
+	if !strings.Contains(key, ":") {
+		return key
+	}
+	return base64.StdEncoding.EncodeToString([]byte(key))
 }
 
 func addHeaders(out, in http.Header) {
@@ -294,19 +424,31 @@ func addHeaders(out, in http.Header) {
 
 // Implements RoundTrip interface
 func (conn *Connection) RoundTrip(r *http.Request) (*http.Response, error) {
-	return conn.HTTP.Do(r)
+	return conn.cache.roundTrip(r, conn.send)
+}
+
+// send sends a request with conn.HTTP, once the rate limit allows it.
+func (conn *Connection) send(r *http.Request) (*http.Response, error) {
+	if err := conn.limiter.wait(r.Context()); err != nil {
+		return nil, err
+	}
+	start := conn.Metrics.start()
+	resp, err := conn.HTTP.Do(r)
+	statusCode := 0
+	if resp != nil {
+		statusCode = resp.StatusCode
+	}
+	conn.Metrics.done(start, statusCode)
+	return resp, err
 }
 
-func (client *Client) readVersion() error {
+func (client *Client) readVersion(ctx context.Context) error {
 	type kibanaVersionResponse struct {
-		Name    string `json:"name"`
-		Version struct {
-			Number   string `json:"number"`
-			Snapshot bool   `json:"build_snapshot"`
-		} `json:"version"`
+		Name    string      `json:"name"`
+		Version VersionInfo `json:"version"`
 	}
 
-	code, result, err := client.Connection.Request("GET", statusAPI, nil, nil, nil)
+	code, result, err := client.Connection.RequestWithContext(ctx, "GET", statusAPI, nil, nil, nil)
 	if err != nil {
 		return fmt.Errorf("HTTP GET request to %s/api/status fails: %w (status=%d). Response: %s",
 			client.Connection.URL, err, code, truncateString(result))
@@ -338,6 +480,7 @@ func (client *Client) readVersion() error {
 	}
 
 	client.Version = *version
+	client.versionInfo = kibanaVersion.Version
 	return nil
 }
 
@@ -349,7 +492,12 @@ func (client *Client) GetVersion() version.V { return client.Version }
 // Right now we don't have an API to tell us if we're running against serverless or not, so this actual implementation is something of a hack.
 // see https://github.com/elastic/kibana/pull/164850
 func (client *Client) KibanaIsServerless() (bool, error) {
-	ret, _, err := client.Connection.Request("GET", "/api/saved_objects/_find", nil, nil, nil)
+	return client.KibanaIsServerlessWithContext(context.Background())
+}
+
+// KibanaIsServerlessWithContext is like KibanaIsServerless, sending the request with the given context.
+func (client *Client) KibanaIsServerlessWithContext(ctx context.Context) (bool, error) {
+	ret, _, err := client.Connection.RequestWithContext(ctx, "GET", "/api/saved_objects/_find", nil, nil, nil)
 	if ret > 300 && strings.Contains(err.Error(), "not available with the current configuration") {
 		return true, nil
 	} else if err != nil {
@@ -359,6 +507,11 @@ func (client *Client) KibanaIsServerless() (bool, error) {
 }
 
 func (client *Client) ImportMultiPartFormFile(url string, params url.Values, filename string, contents string) error {
+	return client.ImportMultiPartFormFileWithContext(context.Background(), url, params, filename, contents)
+}
+
+// ImportMultiPartFormFileWithContext is like ImportMultiPartFormFile, sending the requests with the given context.
+func (client *Client) ImportMultiPartFormFileWithContext(ctx context.Context, url string, params url.Values, filename string, contents string) error {
 	buf := &bytes.Buffer{}
 	w := multipart.NewWriter(buf)
 
@@ -379,10 +532,10 @@ func (client *Client) ImportMultiPartFormFile(url string, params url.Values, fil
 	// On serverless, special header is required to talk to this endpoint
 	sendHeaders := http.Header{}
 	sendHeaders.Add("Content-Type", w.FormDataContentType())
-	if serverless, _ := client.KibanaIsServerless(); serverless {
+	if serverless, _ := client.KibanaIsServerlessWithContext(ctx); serverless {
 		sendHeaders.Add("x-elastic-internal-origin", "elastic-agent-libs")
 	}
-	statusCode, response, err := client.Connection.Request("POST", url, params, sendHeaders, buf)
+	statusCode, response, err := client.Connection.RequestWithContext(ctx, "POST", url, params, sendHeaders, buf)
 	if err != nil {
 		return fmt.Errorf("returned %d to import file: %w. Response: %s", statusCode, err, response)
 	}
@@ -27,6 +27,10 @@ const elasticAPIVersionHeaderKey = "Elastic-Api-Version"
 const elasticAPIDefaultVersion = "2023-10-31"
 
 // ClientConfig to connect to Kibana
+//
+// The requests are authenticated with one of username/password, api_key or
+// service_token. The API key is either id:key or base64 encoded, as
+// displayed by Kibana, the service token is a service account token.
 type ClientConfig struct {
 	Protocol     string `config:"protocol" yaml:"protocol,omitempty"`
 	Host         string `config:"host" yaml:"host,omitempty"`
@@ -37,11 +41,38 @@ type ClientConfig struct {
 	APIKey       string `config:"api_key" yaml:"api_key,omitempty"`
 	ServiceToken string `config:"service_token" yaml:"service_token,omitempty"`
 
+	// Hosts, if set, replaces Host with several Kibana hosts. The requests
+	// are sent round-robin to the hosts and fail over to the next host when
+	// a host fails with a network error or a 5xx status. A failing host is
+	// skipped for the retry backoff, doubling on each failure, until it
+	// succeeds again.
+	Hosts []string `config:"hosts" yaml:"hosts,omitempty"`
+
 	// Headers holds headers to include in every request sent to Kibana.
 	Headers map[string]string `config:"headers" yaml:"headers,omitempty"`
 
 	IgnoreVersion bool
 
+	// Retry configures the retries of the failed requests, they are not
+	// retried by default.
+	Retry RetryConfig `config:"retry" yaml:"retry,omitempty"`
+
+	// Metrics configures the metrics of the requests, they are not
+	// collected by default.
+	Metrics MetricsConfig `config:"metrics" yaml:"metrics,omitempty"`
+
+	// RateLimit limits the rate of the requests, they are not limited by
+	// default.
+	RateLimit RateLimitConfig `config:"rate_limit" yaml:"rate_limit,omitempty"`
+
+	// Cache configures the cache of the responses of the GET requests,
+	// they are not cached by default.
+	Cache CacheConfig `config:"cache" yaml:"cache,omitempty"`
+
+	// Transport configures the HTTP client like the other HTTP clients: ssl,
+	// timeout, proxy_url, proxy_disable, proxy_headers,
+	// idle_connection_timeout, max_connection_age, ip_family and
+	// host_overrides.
 	Transport httpcommon.HTTPTransportSettings `config:",inline" yaml:",inline"`
 }
 
@@ -58,6 +89,7 @@ func DefaultClientConfig() ClientConfig {
 		ServiceToken: "",
 		Transport:    httpcommon.DefaultHTTPTransportSettings(),
 		Headers:      map[string]string{elasticAPIVersionHeaderKey: elasticAPIDefaultVersion},
+		Retry:        defaultRetryConfig(),
 	}
 }
 
@@ -65,6 +97,18 @@ func (c *ClientConfig) Validate() error {
 	if c.APIKey != "" && (c.Username != "" || c.Password != "") {
 		return fmt.Errorf("cannot set both api_key and username/password")
 	}
+	if c.ServiceToken != "" && (c.APIKey != "" || c.Username != "" || c.Password != "") {
+		return fmt.Errorf("cannot set service_token with api_key or username/password")
+	}
+	if err := c.Retry.Validate(); err != nil {
+		return err
+	}
+	if err := c.RateLimit.Validate(); err != nil {
+		return err
+	}
+	if err := c.Cache.Validate(); err != nil {
+		return err
+	}
 
 	return nil
 }
@@ -59,6 +59,26 @@ func TestClientConfigValdiate(t *testing.T) {
 			APIKey:   "apiKey",
 		},
 		err: fmt.Errorf("cannot set both api_key and username/password"),
+	}, {
+		name: "service_token and api_key",
+		c: &ClientConfig{
+			APIKey:       "apiKey",
+			ServiceToken: "service_token",
+		},
+		err: fmt.Errorf("cannot set service_token with api_key or username/password"),
+	}, {
+		name: "service_token and password",
+		c: &ClientConfig{
+			Password:     "pass",
+			ServiceToken: "service_token",
+		},
+		err: fmt.Errorf("cannot set service_token with api_key or username/password"),
+	}, {
+		name: "negative rate limit",
+		c: &ClientConfig{
+			RateLimit: RateLimitConfig{Rate: -1},
+		},
+		err: fmt.Errorf("rate_limit rate and burst must be positive"),
 	}}
 
 	for _, tt := range tests {
@@ -18,15 +18,21 @@
 package kibana
 
 import (
+	"context"
+	"encoding/base64"
 	"fmt"
+	"io"
 	"net/http"
 	"net/http/httptest"
 	"net/url"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 
+	"github.com/elastic/elastic-agent-libs/clock"
 	"github.com/elastic/elastic-agent-libs/config"
 )
 
@@ -116,6 +122,28 @@ func TestServiceToken(t *testing.T) {
 	assert.NoError(t, err)
 }
 
+func TestAPIKey(t *testing.T) {
+	encoded := base64.StdEncoding.EncodeToString([]byte("id:key"))
+
+	for _, apiKey := range []string{"id:key", encoded} {
+		kibanaTS := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			_, _ = w.Write([]byte(`{}`))
+
+			assert.Equal(t, "ApiKey "+encoded, r.Header.Get("Authorization"))
+		}))
+
+		conn := Connection{
+			URL:    kibanaTS.URL,
+			HTTP:   http.DefaultClient,
+			APIKey: apiKey,
+		}
+		code, _, err := conn.Request(http.MethodGet, "", nil, nil, nil)
+		assert.Equal(t, http.StatusOK, code)
+		assert.NoError(t, err)
+		kibanaTS.Close()
+	}
+}
+
 func TestNewKibanaClientWithSpace(t *testing.T) {
 	var (
 		testSpace      = "test-space"
@@ -193,3 +221,93 @@ headers:
 	assert.Equal(t, []string{"multipart/form-data; boundary=46bea21be603a2c2ea6f51571a5e1baf5ea3be8ebd7101199320607b36ff"}, requests[1].Header.Values("Content-Type"))
 
 }
+
+func TestRetry(t *testing.T) {
+	var attempts int
+	kibanaTS := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		attempts++
+		body, _ := io.ReadAll(r.Body)
+		assert.Equal(t, `{"name":"test"}`, string(body))
+		switch attempts {
+		case 1:
+			w.WriteHeader(http.StatusServiceUnavailable)
+		case 2:
+			w.Header().Set("Retry-After", "0")
+			w.WriteHeader(http.StatusTooManyRequests)
+		default:
+			_, _ = w.Write([]byte(`{}`))
+		}
+	}))
+	defer kibanaTS.Close()
+
+	clk := clock.NewFake(time.Now())
+	conn := Connection{
+		URL:   kibanaTS.URL,
+		HTTP:  http.DefaultClient,
+		Retry: RetryConfig{MaxAttempts: 3, Backoff: BackoffConfig{Init: time.Second, Max: time.Minute}},
+		clock: clk,
+	}
+	request := func() (int, error) {
+		type result struct {
+			code int
+			err  error
+		}
+		done := make(chan result, 1)
+		go func() {
+			code, _, err := conn.Request(http.MethodPost, "", nil, nil, strings.NewReader(`{"name":"test"}`))
+			done <- result{code, err}
+		}()
+		// The first retry waits for the backoff, the second one for the
+		// Retry-After of the response.
+		clk.BlockUntil(1)
+		clk.Advance(time.Second)
+		r := <-done
+		return r.code, r.err
+	}
+
+	code, err := request()
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusOK, code)
+	assert.Equal(t, 3, attempts)
+
+	// The last response is returned once the attempts are exhausted.
+	attempts = 0
+	conn.Retry.MaxAttempts = 2
+	code, err = request()
+	assert.Error(t, err)
+	assert.Equal(t, http.StatusTooManyRequests, code)
+	assert.Equal(t, 2, attempts)
+}
+
+func TestRetryWait(t *testing.T) {
+	retry := RetryConfig{MaxAttempts: 5, Backoff: BackoffConfig{Init: time.Second, Max: 4 * time.Second}}
+
+	assert.Equal(t, time.Second, retry.wait(nil, 0))
+	assert.Equal(t, 2*time.Second, retry.wait(nil, time.Second))
+	assert.Equal(t, 4*time.Second, retry.wait(nil, 4*time.Second))
+
+	resp := &http.Response{Header: http.Header{"Retry-After": []string{"3"}}}
+	assert.Equal(t, 3*time.Second, retry.wait(resp, 0))
+	resp.Header.Set("Retry-After", "120")
+	assert.Equal(t, 4*time.Second, retry.wait(resp, 0))
+	resp.Header.Set("Retry-After", time.Now().Add(-time.Hour).UTC().Format(http.TimeFormat))
+	assert.Equal(t, time.Duration(0), retry.wait(resp, 0))
+}
+
+func TestRequestWithContext(t *testing.T) {
+	release := make(chan struct{})
+	kibanaTS := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-release
+	}))
+	defer kibanaTS.Close()
+	defer close(release)
+
+	conn := Connection{
+		URL:  kibanaTS.URL,
+		HTTP: http.DefaultClient,
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	_, _, err := conn.RequestWithContext(ctx, http.MethodGet, "", nil, nil, nil)
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+}
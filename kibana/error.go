@@ -0,0 +1,129 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package kibana
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// APIError is an error response of the Kibana API. The errors of the
+// requests receiving an error status code wrap an *APIError, it can be
+// retrieved with errors.As:
+//
+//	var apiErr *kibana.APIError
+//	if errors.As(err, &apiErr) && apiErr.StatusCode == http.StatusConflict {
+//		...
+//	}
+type APIError struct {
+	// StatusCode is the HTTP status code of the response.
+	StatusCode int
+	// Err is the error of the response body, the text of the status code,
+	// e.g. "Not Found".
+	Err string
+	// Message describes the error. It is the response body if it isn't a
+	// Kibana error.
+	Message string
+	// Attributes are the details of the error, e.g. the objects failing a
+	// saved objects request. It is nil if the error has no attributes.
+	Attributes json.RawMessage
+
+	// objectErrs are the errors of the objects in the attributes.
+	objectErrs error
+}
+
+func (e *APIError) Error() string {
+	if e.Message == "" {
+		errText := e.Err
+		if errText == "" {
+			errText = http.StatusText(e.StatusCode)
+		}
+		return fmt.Sprintf("%d %s", e.StatusCode, errText)
+	}
+	if e.objectErrs != nil {
+		return fmt.Sprintf("%s: %s", e.Message, e.objectErrs)
+	}
+	return e.Message
+}
+
+// Unwrap returns the errors of the objects in the attributes.
+func (e *APIError) Unwrap() error {
+	return e.objectErrs
+}
+
+// IsNotFound returns true if err is an *APIError with the 404 status code.
+func IsNotFound(err error) bool {
+	return hasStatusCode(err, http.StatusNotFound)
+}
+
+// IsConflict returns true if err is an *APIError with the 409 status code,
+// e.g. on version conflicts or when creating an object that already exists.
+func IsConflict(err error) bool {
+	return hasStatusCode(err, http.StatusConflict)
+}
+
+func hasStatusCode(err error, statusCode int) bool {
+	var apiErr *APIError
+	return errors.As(err, &apiErr) && apiErr.StatusCode == statusCode
+}
+
+// extractError returns the *APIError of a response with an error status
+// code.
+func extractError(statusCode int, result []byte) error {
+	var kibanaResult struct {
+		Error      string          `json:"error"`
+		Message    string          `json:"message"`
+		Attributes json.RawMessage `json:"attributes"`
+	}
+	apiErr := &APIError{StatusCode: statusCode}
+	if err := json.Unmarshal(result, &kibanaResult); err != nil {
+		apiErr.Message = strings.TrimSpace(truncateString(result))
+		return apiErr
+	}
+	apiErr.Err = kibanaResult.Error
+	apiErr.Message = kibanaResult.Message
+	if len(kibanaResult.Attributes) > 0 && string(kibanaResult.Attributes) != "null" {
+		apiErr.Attributes = kibanaResult.Attributes
+		apiErr.objectErrs = objectErrors(kibanaResult.Attributes)
+	}
+	return apiErr
+}
+
+// objectErrors returns the errors of the objects in the attributes of an
+// error response.
+func objectErrors(attributes json.RawMessage) error {
+	var attrs struct {
+		Objects []struct {
+			ID    string
+			Error struct {
+				Message string
+			}
+		}
+	}
+	if err := json.Unmarshal(attributes, &attrs); err != nil {
+		return nil //nolint:nilerr // the attributes are not always objects
+	}
+	var errs []error
+	for _, err := range attrs.Objects {
+		errs = append(errs, fmt.Errorf("id: %s, message: %s", err.ID, err.Error.Message))
+	}
+	return errors.Join(errs...)
+}
@@ -0,0 +1,129 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package kibana
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAPIError(t *testing.T) {
+	tests := map[string]struct {
+		statusCode int
+		body       string
+		expected   APIError
+		message    string
+	}{
+		"kibana error": {
+			statusCode: http.StatusConflict,
+			body:       `{"statusCode":409,"error":"Conflict","message":"Saved object [dashboard/dash-1] conflict"}`,
+			expected: APIError{
+				StatusCode: http.StatusConflict,
+				Err:        "Conflict",
+				Message:    "Saved object [dashboard/dash-1] conflict",
+			},
+			message: "Saved object [dashboard/dash-1] conflict",
+		},
+		"attributes": {
+			statusCode: http.StatusBadRequest,
+			body:       `{"statusCode":400,"error":"Bad Request","message":"Invalid policy","attributes":{"type":"invalid_policy"}}`,
+			expected: APIError{
+				StatusCode: http.StatusBadRequest,
+				Err:        "Bad Request",
+				Message:    "Invalid policy",
+				Attributes: []byte(`{"type":"invalid_policy"}`),
+			},
+			message: "Invalid policy",
+		},
+		"no message": {
+			statusCode: http.StatusNotFound,
+			body:       `{}`,
+			expected:   APIError{StatusCode: http.StatusNotFound},
+			message:    "404 Not Found",
+		},
+		"not JSON": {
+			statusCode: http.StatusBadGateway,
+			body:       "<html>Bad Gateway</html>\n",
+			expected:   APIError{StatusCode: http.StatusBadGateway, Message: "<html>Bad Gateway</html>"},
+			message:    "<html>Bad Gateway</html>",
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			kibanaTS := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(tc.statusCode)
+				_, _ = w.Write([]byte(tc.body))
+			}))
+			defer kibanaTS.Close()
+
+			conn := Connection{
+				URL:  kibanaTS.URL,
+				HTTP: http.DefaultClient,
+			}
+			code, _, err := conn.Request(http.MethodGet, "", url.Values{}, nil, nil)
+			assert.Equal(t, tc.statusCode, code)
+
+			var apiErr *APIError
+			require.ErrorAs(t, err, &apiErr)
+			assert.Equal(t, tc.expected, *apiErr)
+			assert.EqualError(t, err, tc.message)
+		})
+	}
+}
+
+func TestAPIErrorObjects(t *testing.T) {
+	err := extractError(http.StatusUnauthorized, []byte(`{"message":"Cannot export dashboard","attributes":{"objects":[{"id":"test-*","type":"index-pattern","error":{"message":"unauthorized"}}]}}`))
+
+	var apiErr *APIError
+	require.ErrorAs(t, err, &apiErr)
+	assert.Equal(t, "Cannot export dashboard: id: test-*, message: unauthorized", err.Error())
+	assert.NotNil(t, errors.Unwrap(err))
+}
+
+func TestIsNotFoundIsConflict(t *testing.T) {
+	handler := func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodGet:
+			w.WriteHeader(http.StatusNotFound)
+			_, _ = w.Write([]byte(`{"statusCode":404,"error":"Not Found","message":"Space not found"}`))
+		default:
+			w.WriteHeader(http.StatusConflict)
+			_, _ = w.Write([]byte(`{"statusCode":409,"error":"Conflict","message":"A space with the identifier marketing already exists."}`))
+		}
+	}
+	client, err := createTestServerAndClient(handler)
+	require.NoError(t, err)
+
+	_, err = client.GetSpace(context.Background(), "missing")
+	assert.True(t, IsNotFound(err))
+	assert.False(t, IsConflict(err))
+
+	_, err = client.CreateSpace(context.Background(), Space{ID: "marketing", Name: "Marketing"})
+	assert.True(t, IsConflict(err))
+	assert.False(t, IsNotFound(err))
+
+	assert.False(t, IsNotFound(errors.New("not found")))
+}
@@ -181,19 +181,18 @@ func (client *Client) CreateDownloadSource(ctx context.Context, source DownloadS
 	defer resp.Body.Close()
 
 	if resp.StatusCode != http.StatusOK {
-		var respBody string
-		if bs, err := io.ReadAll(resp.Body); err != nil {
-			respBody = "could not read response body"
-		} else {
-			respBody = string(bs)
+		bs, err := io.ReadAll(resp.Body)
+		if err != nil {
+			return DownloadSourceResponse{},
+				fmt.Errorf("could not create download source, kibana returned %s and error reading response: %w",
+					resp.Status, err)
 		}
 
 		client.log.Errorw(
 			"could not create download source, kibana returned "+resp.Status,
-			"http.response.body.content", respBody)
+			"http.response.body.content", string(bs))
 		return DownloadSourceResponse{},
-			fmt.Errorf("could not create download source, kibana returned %s. response body: %s: %w",
-				resp.Status, respBody, err)
+			fmt.Errorf("could not create download source: %w", extractError(resp.StatusCode, bs))
 	}
 
 	body := DownloadSourceResponse{}
@@ -259,7 +258,7 @@ func (client *Client) DeletePolicy(ctx context.Context, id string) error {
 		if err != nil {
 			return fmt.Errorf("unable to delete policy; API returned status code [%d] and error reading response: %w", resp.StatusCode, err)
 		}
-		return fmt.Errorf("unable to delete policy; API returned status code [%d] and body [%s]", resp.StatusCode, string(respBody))
+		return fmt.Errorf("unable to delete policy: %w", extractError(resp.StatusCode, respBody))
 	}
 	return nil
 }
@@ -718,8 +717,11 @@ func readJSONResponse(r *http.Response, v any) error {
 		return fmt.Errorf("reading response body: %w", err)
 	}
 
-	if r.StatusCode != http.StatusOK {
-		return extractError(b)
+	if r.StatusCode >= http.StatusMultipleChoices {
+		return extractError(r.StatusCode, b)
+	}
+	if r.StatusCode != http.StatusOK && len(b) == 0 {
+		return nil
 	}
 
 	err = json.Unmarshal(b, v)
@@ -0,0 +1,287 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package kibana
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+)
+
+const (
+	fleetEPMPackagesAPI = "/api/fleet/epm/packages"
+	fleetEPMPackageAPI  = "/api/fleet/epm/packages/%s/%s"
+
+	// fleetEPMPackageMinVersion is the first version of Kibana with the
+	// packages API taking the package name and version as separate path
+	// segments.
+	fleetEPMPackageMinVersion = "8.0.0"
+)
+
+// FleetClient sends requests to the Fleet API, see Client.Fleet.
+type FleetClient struct {
+	client *Client
+}
+
+// Fleet returns a client of the Fleet API: agent policies, enrollment API
+// keys, package policies and package installation (EPM).
+func (client *Client) Fleet() *FleetClient {
+	return &FleetClient{client: client}
+}
+
+// ListOptions paginates and filters the list requests. The zero value
+// returns the first page with the default page size of Kibana.
+type ListOptions struct {
+	// Page is the page to return, starting at 1.
+	Page    int
+	PerPage int
+	// KQLQuery filters the listed items with a KQL query.
+	KQLQuery string
+}
+
+func (o ListOptions) params() url.Values {
+	params := url.Values{}
+	if o.Page > 0 {
+		params.Set("page", strconv.Itoa(o.Page))
+	}
+	if o.PerPage > 0 {
+		params.Set("perPage", strconv.Itoa(o.PerPage))
+	}
+	if o.KQLQuery != "" {
+		params.Set("kuery", o.KQLQuery)
+	}
+	return params
+}
+
+// ListMeta is the pagination of a list response.
+type ListMeta struct {
+	Total   int `json:"total"`
+	Page    int `json:"page"`
+	PerPage int `json:"perPage"`
+}
+
+// list sends a list request and decodes its response into r.
+func (f *FleetClient) list(ctx context.Context, apiPath string, opts ListOptions, r any) error {
+	resp, err := f.client.Connection.SendWithContext(ctx, http.MethodGet, apiPath, opts.params(), nil, nil)
+	if err != nil {
+		return fmt.Errorf("error calling list API %s: %w", apiPath, err)
+	}
+	defer resp.Body.Close()
+	return readJSONResponse(resp, r)
+}
+
+//
+// Agent Policies
+//
+
+// ListAgentPoliciesResponse is a page of agent policies.
+type ListAgentPoliciesResponse struct {
+	Items    []PolicyResponse `json:"items"`
+	ListMeta `json:",inline"`
+}
+
+// ListAgentPolicies returns a page of agent policies.
+func (f *FleetClient) ListAgentPolicies(ctx context.Context, opts ListOptions) (r ListAgentPoliciesResponse, err error) {
+	err = f.list(ctx, fleetAgentPoliciesAPI, opts, &r)
+	return r, err
+}
+
+// CreateAgentPolicy creates a new agent policy, see Client.CreatePolicy.
+func (f *FleetClient) CreateAgentPolicy(ctx context.Context, request AgentPolicy) (PolicyResponse, error) {
+	return f.client.CreatePolicy(ctx, request)
+}
+
+// GetAgentPolicy returns an agent policy, see Client.GetPolicy.
+func (f *FleetClient) GetAgentPolicy(ctx context.Context, id string) (PolicyResponse, error) {
+	return f.client.GetPolicy(ctx, id)
+}
+
+// UpdateAgentPolicy updates an agent policy, see Client.UpdatePolicy.
+func (f *FleetClient) UpdateAgentPolicy(ctx context.Context, id string, request AgentPolicyUpdateRequest) (PolicyResponse, error) {
+	return f.client.UpdatePolicy(ctx, id, request)
+}
+
+// DeleteAgentPolicy deletes an agent policy, see Client.DeletePolicy.
+func (f *FleetClient) DeleteAgentPolicy(ctx context.Context, id string) error {
+	return f.client.DeletePolicy(ctx, id)
+}
+
+//
+// Enrollment API Keys
+//
+
+// EnrollmentAPIKey is an enrollment API key, or enrollment token.
+type EnrollmentAPIKey struct {
+	ID        string    `json:"id"`
+	Name      string    `json:"name"`
+	Active    bool      `json:"active"`
+	APIKey    string    `json:"api_key"`
+	APIKeyID  string    `json:"api_key_id"`
+	PolicyID  string    `json:"policy_id"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// ListEnrollmentAPIKeysResponse is a page of enrollment API keys.
+type ListEnrollmentAPIKeysResponse struct {
+	Items    []EnrollmentAPIKey `json:"items"`
+	ListMeta `json:",inline"`
+}
+
+// ListEnrollmentAPIKeys returns a page of enrollment API keys.
+func (f *FleetClient) ListEnrollmentAPIKeys(ctx context.Context, opts ListOptions) (r ListEnrollmentAPIKeysResponse, err error) {
+	err = f.list(ctx, fleetEnrollmentAPIKeysAPI, opts, &r)
+	return r, err
+}
+
+// CreateEnrollmentAPIKey creates an enrollment API key, see
+// Client.CreateEnrollmentAPIKey.
+func (f *FleetClient) CreateEnrollmentAPIKey(ctx context.Context, request CreateEnrollmentAPIKeyRequest) (CreateEnrollmentAPIKeyResponse, error) {
+	return f.client.CreateEnrollmentAPIKey(ctx, request)
+}
+
+//
+// Package Policies
+//
+
+// ListPackagePoliciesResponse is a page of package policies.
+type ListPackagePoliciesResponse struct {
+	Items    []PackagePolicy `json:"items"`
+	ListMeta `json:",inline"`
+}
+
+// ListPackagePolicies returns a page of package policies.
+func (f *FleetClient) ListPackagePolicies(ctx context.Context, opts ListOptions) (r ListPackagePoliciesResponse, err error) {
+	err = f.list(ctx, fleetPackagePoliciesAPI, opts, &r)
+	return r, err
+}
+
+// CreatePackagePolicy adds an integration to an agent policy, see
+// Client.InstallFleetPackage.
+func (f *FleetClient) CreatePackagePolicy(ctx context.Context, request PackagePolicyRequest) (PackagePolicyResponse, error) {
+	return f.client.InstallFleetPackage(ctx, request)
+}
+
+// DeletePackagePolicy removes an integration from its agent policy, see
+// Client.DeleteFleetPackage.
+func (f *FleetClient) DeletePackagePolicy(ctx context.Context, packagePolicyID string) (DeletePackagePolicyResponse, error) {
+	return f.client.DeleteFleetPackage(ctx, packagePolicyID)
+}
+
+//
+// Packages (EPM)
+//
+
+// Package is an integration package of the registry.
+type Package struct {
+	Name        string `json:"name"`
+	Version     string `json:"version"`
+	Title       string `json:"title"`
+	Description string `json:"description"`
+	// Status is installed, not_installed, installing or install_failed.
+	Status string `json:"status"`
+}
+
+// PackageAsset is an asset installed by a package, e.g. an index template
+// or a dashboard.
+type PackageAsset struct {
+	ID   string `json:"id"`
+	Type string `json:"type"`
+}
+
+// ListPackages returns the packages of the registry.
+func (f *FleetClient) ListPackages(ctx context.Context) ([]Package, error) {
+	var r struct {
+		Items []Package `json:"items"`
+	}
+	if err := f.list(ctx, fleetEPMPackagesAPI, ListOptions{}, &r); err != nil {
+		return nil, err
+	}
+	return r.Items, nil
+}
+
+// GetPackage returns a package, its latest version if version is empty.
+func (f *FleetClient) GetPackage(ctx context.Context, name, version string) (r Package, err error) {
+	if version != "" {
+		if err := f.client.requireVersion("getting a package version", fleetEPMPackageMinVersion); err != nil {
+			return r, err
+		}
+	}
+	apiURL, err := url.JoinPath(fleetEPMPackagesAPI, name, version)
+	if err != nil {
+		return r, err
+	}
+	resp, err := f.client.Connection.SendWithContext(ctx, http.MethodGet, apiURL, nil, nil, nil)
+	if err != nil {
+		return r, fmt.Errorf("error calling get package API: %w", err)
+	}
+	defer resp.Body.Close()
+
+	var pkgResp struct {
+		Item Package `json:"item"`
+	}
+	err = readJSONResponse(resp, &pkgResp)
+	return pkgResp.Item, err
+}
+
+// InstallPackage installs a package and returns its assets. Force installs
+// it even if it is already installed or if its version is not supported.
+func (f *FleetClient) InstallPackage(ctx context.Context, name, version string, force bool) ([]PackageAsset, error) {
+	if err := f.client.requireVersion("installing a package", fleetEPMPackageMinVersion); err != nil {
+		return nil, err
+	}
+	reqBody, err := json.Marshal(map[string]bool{"force": force})
+	if err != nil {
+		return nil, fmt.Errorf("unable to marshal install package request into JSON: %w", err)
+	}
+	apiURL := fmt.Sprintf(fleetEPMPackageAPI, url.PathEscape(name), url.PathEscape(version))
+	resp, err := f.client.Connection.SendWithContext(ctx, http.MethodPost, apiURL, nil, nil, bytes.NewReader(reqBody))
+	if err != nil {
+		return nil, fmt.Errorf("error calling install package API: %w", err)
+	}
+	defer resp.Body.Close()
+
+	var installResp struct {
+		Items []PackageAsset `json:"items"`
+	}
+	err = readJSONResponse(resp, &installResp)
+	return installResp.Items, err
+}
+
+// UninstallPackage uninstalls a package and returns the removed assets.
+func (f *FleetClient) UninstallPackage(ctx context.Context, name, version string) ([]PackageAsset, error) {
+	if err := f.client.requireVersion("uninstalling a package", fleetEPMPackageMinVersion); err != nil {
+		return nil, err
+	}
+	apiURL := fmt.Sprintf(fleetEPMPackageAPI, url.PathEscape(name), url.PathEscape(version))
+	resp, err := f.client.Connection.SendWithContext(ctx, http.MethodDelete, apiURL, nil, nil, nil)
+	if err != nil {
+		return nil, fmt.Errorf("error calling uninstall package API: %w", err)
+	}
+	defer resp.Body.Close()
+
+	var uninstallResp struct {
+		Items []PackageAsset `json:"items"`
+	}
+	err = readJSONResponse(resp, &uninstallResp)
+	return uninstallResp.Items, err
+}
@@ -0,0 +1,113 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package kibana
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/elastic/elastic-agent-libs/version"
+)
+
+func TestFleetClientListAgentPolicies(t *testing.T) {
+	handler := func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, fleetAgentPoliciesAPI, r.URL.Path)
+		assert.Equal(t, "2", r.URL.Query().Get("page"))
+		assert.Equal(t, "1", r.URL.Query().Get("perPage"))
+		assert.Equal(t, "name:test", r.URL.Query().Get("kuery"))
+		_, _ = w.Write([]byte(`{"items":[{"id":"policy-2","name":"test 2","namespace":"default","revision":3}],"total":2,"page":2,"perPage":1}`))
+	}
+	client, err := createTestServerAndClient(handler)
+	require.NoError(t, err)
+
+	resp, err := client.Fleet().ListAgentPolicies(context.Background(), ListOptions{Page: 2, PerPage: 1, KQLQuery: "name:test"})
+	require.NoError(t, err)
+	assert.Equal(t, ListMeta{Total: 2, Page: 2, PerPage: 1}, resp.ListMeta)
+	require.Len(t, resp.Items, 1)
+	assert.Equal(t, "policy-2", resp.Items[0].ID)
+	assert.Equal(t, 3, resp.Items[0].Revision)
+}
+
+func TestFleetClientListEnrollmentAPIKeys(t *testing.T) {
+	handler := func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, fleetEnrollmentAPIKeysAPI, r.URL.Path)
+		assert.Empty(t, r.URL.RawQuery)
+		_, _ = w.Write([]byte(`{"items":[{"id":"key-1","name":"Default","active":true,"api_key":"secret","policy_id":"policy-1","created_at":"2024-01-02T03:04:05.000Z"}],"total":1,"page":1,"perPage":20}`))
+	}
+	client, err := createTestServerAndClient(handler)
+	require.NoError(t, err)
+
+	resp, err := client.Fleet().ListEnrollmentAPIKeys(context.Background(), ListOptions{})
+	require.NoError(t, err)
+	require.Len(t, resp.Items, 1)
+	assert.Equal(t, "secret", resp.Items[0].APIKey)
+	assert.Equal(t, "policy-1", resp.Items[0].PolicyID)
+	assert.Equal(t, 2024, resp.Items[0].CreatedAt.Year())
+}
+
+func TestFleetClientPackages(t *testing.T) {
+	handler := func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.Method == http.MethodGet && r.URL.Path == fleetEPMPackagesAPI+"/system":
+			_, _ = w.Write([]byte(`{"item":{"name":"system","version":"1.2.3","title":"System","status":"not_installed"}}`))
+		case r.Method == http.MethodPost && r.URL.Path == fleetEPMPackagesAPI+"/system/1.2.3":
+			var body map[string]bool
+			assert.NoError(t, json.NewDecoder(r.Body).Decode(&body))
+			assert.Equal(t, map[string]bool{"force": true}, body)
+			_, _ = w.Write([]byte(`{"items":[{"id":"logs-system.syslog","type":"index_template"}]}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+			_, _ = w.Write([]byte(`{"message":"not found"}`))
+		}
+	}
+	client, err := createTestServerAndClient(handler)
+	require.NoError(t, err)
+	client.Version = *version.MustNew("8.13.0")
+	fleet := client.Fleet()
+
+	pkg, err := fleet.GetPackage(context.Background(), "system", "")
+	require.NoError(t, err)
+	assert.Equal(t, Package{Name: "system", Version: "1.2.3", Title: "System", Status: "not_installed"}, pkg)
+
+	assets, err := fleet.InstallPackage(context.Background(), pkg.Name, pkg.Version, true)
+	require.NoError(t, err)
+	assert.Equal(t, []PackageAsset{{ID: "logs-system.syslog", Type: "index_template"}}, assets)
+
+	_, err = fleet.UninstallPackage(context.Background(), "unknown", "1.0.0")
+	assert.EqualError(t, err, "not found")
+}
+
+func TestFleetClientPackagesVersion(t *testing.T) {
+	handler := func(w http.ResponseWriter, r *http.Request) {
+		t.Errorf("unexpected request %s %s", r.Method, r.URL.Path)
+	}
+	client, err := createTestServerAndClient(handler)
+	require.NoError(t, err)
+	client.Version = *version.MustNew("7.17.0")
+
+	_, err = client.Fleet().InstallPackage(context.Background(), "system", "1.2.3", false)
+	var versionErr *IncompatibleVersionError
+	require.ErrorAs(t, err, &versionErr)
+	assert.Equal(t, "installing a package", versionErr.Feature)
+	assert.Equal(t, "installing a package is not supported by Kibana 7.17.0, it must be 8.0.0 or newer", err.Error())
+}
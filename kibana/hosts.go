@@ -0,0 +1,113 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package kibana
+
+import (
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/elastic/elastic-agent-libs/clock"
+)
+
+// hostPool selects the Kibana hosts of the requests. The requests are sent
+// round-robin to the healthy hosts, and fail over to the next hosts if a
+// host fails with a network error or a 5xx status. A failing host is
+// unhealthy for a backoff doubling on each failure, and healthy again after
+// its next successful request.
+type hostPool struct {
+	mu      sync.Mutex
+	hosts   []*hostState
+	next    int
+	backoff BackoffConfig
+	clock   clock.Clock
+}
+
+type hostState struct {
+	url      string
+	failures int
+	retryAt  time.Time
+}
+
+func newHostPool(urls []string, backoff BackoffConfig, clk clock.Clock) *hostPool {
+	p := &hostPool{backoff: backoff, clock: clk}
+	for _, u := range urls {
+		p.hosts = append(p.hosts, &hostState{url: u})
+	}
+	return p
+}
+
+// order returns the URLs of the hosts in the order a request tries them:
+// the healthy hosts, starting with the next one in the round-robin, then
+// the unhealthy hosts, the ones with the earliest retry first.
+func (p *hostPool) order() []string {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	now := p.clock.Now()
+	var healthy, unhealthy []*hostState
+	for i := range p.hosts {
+		h := p.hosts[(p.next+i)%len(p.hosts)]
+		if h.retryAt.After(now) {
+			unhealthy = append(unhealthy, h)
+		} else {
+			healthy = append(healthy, h)
+		}
+	}
+	p.next = (p.next + 1) % len(p.hosts)
+	sort.SliceStable(unhealthy, func(i, j int) bool {
+		return unhealthy[i].retryAt.Before(unhealthy[j].retryAt)
+	})
+
+	urls := make([]string, 0, len(p.hosts))
+	for _, h := range append(healthy, unhealthy...) {
+		urls = append(urls, h.url)
+	}
+	return urls
+}
+
+// update records the result of a request sent to the host at url.
+func (p *hostPool) update(url string, failed bool) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	for _, h := range p.hosts {
+		if h.url != url {
+			continue
+		}
+		if !failed {
+			h.failures = 0
+			h.retryAt = time.Time{}
+			return
+		}
+		backoff := p.backoff.Init
+		for i := 0; i < h.failures && backoff < p.backoff.Max; i++ {
+			backoff *= 2
+		}
+		h.failures++
+		h.retryAt = p.clock.Now().Add(min(backoff, p.backoff.Max))
+		return
+	}
+}
+
+// hostFailed returns true if the request that returned resp and err failed
+// because of its host.
+func hostFailed(resp *http.Response, err error) bool {
+	return err != nil || resp.StatusCode >= http.StatusInternalServerError
+}
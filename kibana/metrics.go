@@ -0,0 +1,141 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package kibana
+
+import (
+	"strconv"
+	"time"
+
+	"github.com/rcrowley/go-metrics"
+
+	"github.com/elastic/elastic-agent-libs/monitoring"
+	"github.com/elastic/elastic-agent-libs/monitoring/adapter"
+)
+
+const defaultMetricsRegistry = "kibana"
+
+// MetricsConfig configures the metrics of the requests sent to Kibana.
+type MetricsConfig struct {
+	// Namespace is the monitoring namespace of the metrics, e.g. stats. The
+	// metrics are not collected if it is empty.
+	Namespace string `config:"namespace" yaml:"namespace,omitempty"`
+	// Registry is the name of the registry of the metrics in the namespace,
+	// kibana by default. The clients with the same registry share their
+	// metrics.
+	Registry string `config:"registry" yaml:"registry,omitempty"`
+}
+
+// Metrics are the metrics of the requests sent to Kibana:
+//
+//	requests.total      number of requests sent, one per attempt
+//	requests.in_flight  number of requests waiting for a response
+//	requests.retries    number of retried requests
+//	responses.errors    number of requests failing without a response
+//	responses.Nxx       number of responses by status class, 1xx to 5xx
+//	latency.histogram   latency of the requests in milliseconds
+type Metrics struct {
+	requests  *monitoring.Uint
+	inFlight  *monitoring.Int
+	retries   *monitoring.Uint
+	errors    *monitoring.Uint
+	responses [5]*monitoring.Uint
+	latency   metrics.Histogram
+}
+
+// NewMetrics registers the metrics of the requests in reg. The metrics
+// already registered in reg are reused.
+func NewMetrics(reg *monitoring.Registry) *Metrics {
+	m := &Metrics{
+		requests: getOrNewUint(reg, "requests.total"),
+		inFlight: getOrNewInt(reg, "requests.in_flight"),
+		retries:  getOrNewUint(reg, "requests.retries"),
+		errors:   getOrNewUint(reg, "responses.errors"),
+	}
+	for i := range m.responses {
+		m.responses[i] = getOrNewUint(reg, "responses."+strconv.Itoa(i+1)+"xx")
+	}
+	m.latency = adapter.GetGoMetrics(reg, "latency", adapter.Accept).GetOrRegister("histogram", func() interface{} {
+		return metrics.NewHistogram(metrics.NewUniformSample(1024))
+	}).(metrics.Histogram)
+	return m
+}
+
+// newMetricsFromConfig returns the metrics of the config, nil if they are
+// not collected.
+func newMetricsFromConfig(cfg MetricsConfig) *Metrics {
+	if cfg.Namespace == "" {
+		return nil
+	}
+	name := cfg.Registry
+	if name == "" {
+		name = defaultMetricsRegistry
+	}
+	parent := monitoring.GetNamespace(cfg.Namespace).GetRegistry()
+	reg := parent.GetRegistry(name)
+	if reg == nil {
+		reg = parent.NewRegistry(name)
+	}
+	return NewMetrics(reg)
+}
+
+func getOrNewUint(reg *monitoring.Registry, name string) *monitoring.Uint {
+	if v, ok := reg.Get(name).(*monitoring.Uint); ok {
+		return v
+	}
+	return monitoring.NewUint(reg, name)
+}
+
+func getOrNewInt(reg *monitoring.Registry, name string) *monitoring.Int {
+	if v, ok := reg.Get(name).(*monitoring.Int); ok {
+		return v
+	}
+	return monitoring.NewInt(reg, name)
+}
+
+// start records a request being sent and returns its start time. It is a
+// no-op if m is nil, like all the methods of Metrics.
+func (m *Metrics) start() time.Time {
+	if m == nil {
+		return time.Time{}
+	}
+	m.requests.Inc()
+	m.inFlight.Inc()
+	return time.Now()
+}
+
+// done records the response of a request started at start, statusCode is 0
+// if the request failed without a response.
+func (m *Metrics) done(start time.Time, statusCode int) {
+	if m == nil {
+		return
+	}
+	m.inFlight.Dec()
+	m.latency.Update(time.Since(start).Milliseconds())
+	if class := statusCode / 100; class >= 1 && class <= len(m.responses) {
+		m.responses[class-1].Inc()
+	} else {
+		m.errors.Inc()
+	}
+}
+
+func (m *Metrics) retry() {
+	if m == nil {
+		return
+	}
+	m.retries.Inc()
+}
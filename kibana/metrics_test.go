@@ -0,0 +1,114 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package kibana
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/elastic/elastic-agent-libs/config"
+	"github.com/elastic/elastic-agent-libs/monitoring"
+)
+
+func TestMetrics(t *testing.T) {
+	attempts := 0
+	kibanaTS := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case statusAPI:
+			_, _ = w.Write([]byte(`{"version":{"number":"8.13.0"}}`))
+		case "/unavailable":
+			attempts++
+			if attempts == 1 {
+				w.WriteHeader(http.StatusServiceUnavailable)
+				return
+			}
+			_, _ = w.Write([]byte(`{}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer kibanaTS.Close()
+
+	// The namespace is global, remove the registry so that the test can run
+	// several times in the same process.
+	namespace := monitoring.GetNamespace("kibana_metrics_test").GetRegistry()
+	t.Cleanup(func() { namespace.Remove(defaultMetricsRegistry) })
+
+	cfg := fmt.Sprintf(`
+protocol: http
+host: %s
+retry.max_attempts: 2
+retry.backoff.init: 1ms
+metrics.namespace: kibana_metrics_test
+`, kibanaTS.Listener.Addr().String())
+	client, err := NewKibanaClient(config.MustNewConfigFrom(cfg), binaryName, v, commit, buildTime)
+	require.NoError(t, err)
+
+	_, _, err = client.Request(http.MethodGet, "/unavailable", nil, nil, nil)
+	require.NoError(t, err)
+	_, _, err = client.Request(http.MethodGet, "/missing", nil, nil, nil)
+	require.Error(t, err)
+
+	reg := namespace.GetRegistry(defaultMetricsRegistry)
+	require.NotNil(t, reg)
+	snapshot := monitoring.CollectFlatSnapshot(reg, monitoring.Full, false)
+	for name, expected := range map[string]int64{
+		"requests.total":          4,
+		"requests.in_flight":      0,
+		"requests.retries":        1,
+		"responses.errors":        0,
+		"responses.1xx":           0,
+		"responses.2xx":           2,
+		"responses.3xx":           0,
+		"responses.4xx":           1,
+		"responses.5xx":           1,
+		"latency.histogram.count": 4,
+	} {
+		assert.Equal(t, expected, snapshot.Ints[name], name)
+	}
+
+	// A second client shares the metrics of the registry.
+	client2, err := NewKibanaClient(config.MustNewConfigFrom(cfg), binaryName, v, commit, buildTime)
+	require.NoError(t, err)
+	assert.Equal(t, uint64(5), reg.Get("requests.total").(*monitoring.Uint).Get())
+	assert.Equal(t, int64(5), client2.Metrics.latency.Count())
+}
+
+func TestMetricsNetworkError(t *testing.T) {
+	kibanaTS := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	kibanaTS.Close()
+
+	reg := monitoring.NewRegistry()
+	conn := Connection{
+		URL:     kibanaTS.URL,
+		HTTP:    http.DefaultClient,
+		Metrics: NewMetrics(reg),
+	}
+	_, _, err := conn.Request(http.MethodGet, "", nil, nil, nil)
+	require.Error(t, err)
+
+	assert.Equal(t, uint64(1), reg.Get("requests.total").(*monitoring.Uint).Get())
+	assert.Equal(t, uint64(1), reg.Get("responses.errors").(*monitoring.Uint).Get())
+	assert.Equal(t, int64(0), reg.Get("requests.in_flight").(*monitoring.Int).Get())
+	assert.Equal(t, int64(1), conn.Metrics.latency.Count())
+}
@@ -0,0 +1,65 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package kibana
+
+import "net/http"
+
+// RoundTripperMiddleware wraps the http.RoundTripper sending the requests of
+// a client, e.g. to add headers, log or sign the requests. The middlewares
+// must not modify the requests they receive, but clone them with
+// http.Request.Clone first.
+type RoundTripperMiddleware func(http.RoundTripper) http.RoundTripper
+
+// RoundTripperFunc is a function implementing http.RoundTripper.
+type RoundTripperFunc func(*http.Request) (*http.Response, error)
+
+// RoundTrip calls f(r).
+func (f RoundTripperFunc) RoundTrip(r *http.Request) (*http.Response, error) {
+	return f(r)
+}
+
+// Use adds middlewares to the requests sent by the connection. The
+// middlewares see the requests in the order they are added, after the
+// headers of the connection are set and before the transport of
+// conn.HTTP. The requests going through the middlewares are the attempts
+// sent by the connection, so a retried request goes through them each time.
+//
+// Use is not safe to call concurrently with requests, the middlewares should
+// be added before sending any. It doesn't modify the http.Client given to
+// the connection, but a copy of it.
+func (conn *Connection) Use(middlewares ...RoundTripperMiddleware) {
+	if conn.baseTransport == nil {
+		conn.baseTransport = http.DefaultTransport
+		if conn.HTTP != nil && conn.HTTP.Transport != nil {
+			conn.baseTransport = conn.HTTP.Transport
+		}
+	}
+	conn.middlewares = append(conn.middlewares, middlewares...)
+
+	rt := conn.baseTransport
+	for i := len(conn.middlewares) - 1; i >= 0; i-- {
+		rt = conn.middlewares[i](rt)
+	}
+
+	httpClient := &http.Client{}
+	if conn.HTTP != nil {
+		*httpClient = *conn.HTTP
+	}
+	httpClient.Transport = rt
+	conn.HTTP = httpClient
+}
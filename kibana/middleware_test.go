@@ -0,0 +1,64 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package kibana
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestUse(t *testing.T) {
+	kibanaTS := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "trace-1", r.Header.Get("traceparent"))
+		assert.Equal(t, "signed", r.Header.Get("X-Signature"))
+		_, _ = w.Write([]byte(`{}`))
+	}))
+	defer kibanaTS.Close()
+
+	conn := Connection{
+		URL:  kibanaTS.URL,
+		HTTP: http.DefaultClient,
+	}
+
+	var calls []string
+	middleware := func(name string, header string) RoundTripperMiddleware {
+		return func(next http.RoundTripper) http.RoundTripper {
+			return RoundTripperFunc(func(r *http.Request) (*http.Response, error) {
+				calls = append(calls, name)
+				// The headers set by the connection are already there.
+				assert.Equal(t, "1", r.Header.Get("kbn-xsrf"))
+				r = r.Clone(r.Context())
+				r.Header.Set(header, name)
+				return next.RoundTrip(r)
+			})
+		}
+	}
+	conn.Use(middleware("trace-1", "traceparent"))
+	conn.Use(middleware("signed", "X-Signature"))
+
+	code, _, err := conn.Request(http.MethodGet, "/api/test", nil, nil, nil)
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusOK, code)
+	assert.Equal(t, []string{"trace-1", "signed"}, calls)
+
+	assert.Nil(t, http.DefaultClient.Transport, "the given http.Client must not be modified")
+}
@@ -0,0 +1,180 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package kibana
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+)
+
+// ErrStopPagination can be returned by the function given to Paginate to
+// stop requesting pages without failing.
+var ErrStopPagination = errors.New("stop pagination")
+
+// Page is a page of a paginated response.
+type Page struct {
+	// Number is the page number, starting at 1. It is 0 for the APIs
+	// paginated with searchAfter.
+	Number int
+	// Total is the total number of items, -1 if the API doesn't report it.
+	Total int
+	// Items are the items of the page, read from the items or saved_objects
+	// field of the response.
+	Items []json.RawMessage
+}
+
+// pageResponse reads the pagination fields of the Kibana APIs. The Fleet
+// APIs use perPage and the saved objects APIs use per_page.
+type pageResponse struct {
+	Items           []json.RawMessage `json:"items"`
+	SavedObjects    []json.RawMessage `json:"saved_objects"`
+	Total           *int              `json:"total"`
+	Page            int               `json:"page"`
+	PerPage         int               `json:"perPage"`
+	PerPageSnake    int               `json:"per_page"`
+	NextSearchAfter json.RawMessage   `json:"nextSearchAfter"`
+}
+
+func (r pageResponse) items() []json.RawMessage {
+	if r.Items != nil {
+		return r.Items
+	}
+	return r.SavedObjects
+}
+
+func (r pageResponse) perPage() int {
+	if r.PerPage > 0 {
+		return r.PerPage
+	}
+	return r.PerPageSnake
+}
+
+// searchAfter returns the searchAfter parameter of the next page, empty if
+// the response has no next page.
+func (r pageResponse) searchAfter() string {
+	if len(r.NextSearchAfter) == 0 || string(r.NextSearchAfter) == "null" {
+		return ""
+	}
+	var s string
+	if err := json.Unmarshal(r.NextSearchAfter, &s); err == nil {
+		return s
+	}
+	return string(r.NextSearchAfter)
+}
+
+// Paginate sends GET requests to the paginated API at path, calling fn for
+// every page until the last one. The pages are requested with the page
+// parameter, starting at the page in params or 1, until all the items given
+// by the total of the responses are read. If the responses have a
+// nextSearchAfter field, the next pages are requested with the searchAfter
+// parameter instead. The page size is set by the caller in params, e.g.
+// perPage for the Fleet APIs.
+//
+// Paginate stops without error if fn returns ErrStopPagination.
+func (client *Client) Paginate(path string, params url.Values, fn func(Page) error) error {
+	return client.PaginateWithContext(context.Background(), path, params, fn)
+}
+
+// PaginateWithContext is like Paginate, sending the requests with the given context.
+func (client *Client) PaginateWithContext(ctx context.Context, path string, params url.Values, fn func(Page) error) error {
+	params = cloneValues(params)
+	page := 1
+	if p := params.Get("page"); p != "" {
+		var err error
+		if page, err = strconv.Atoi(p); err != nil || page < 1 {
+			return fmt.Errorf("invalid page parameter %q", p)
+		}
+	}
+
+	firstPageSize := 0
+	for {
+		r, err := client.requestPage(ctx, path, params)
+		if err != nil {
+			return err
+		}
+		items := r.items()
+
+		total := -1
+		if r.Total != nil {
+			total = *r.Total
+		}
+		searchAfter := r.searchAfter()
+		number := page
+		if searchAfter != "" || params.Has("searchAfter") {
+			number = 0
+		}
+		if err := fn(Page{Number: number, Total: total, Items: items}); err != nil {
+			if errors.Is(err, ErrStopPagination) {
+				return nil
+			}
+			return err
+		}
+
+		if len(items) == 0 {
+			return nil
+		}
+		if number == 0 {
+			if searchAfter == "" {
+				return nil
+			}
+			params.Del("page")
+			params.Set("searchAfter", searchAfter)
+			continue
+		}
+
+		perPage := r.perPage()
+		if perPage == 0 {
+			if firstPageSize == 0 {
+				firstPageSize = len(items)
+			}
+			perPage = firstPageSize
+		}
+		if len(items) < perPage || (total >= 0 && page*perPage >= total) {
+			return nil
+		}
+		page++
+		params.Set("page", strconv.Itoa(page))
+	}
+}
+
+func (client *Client) requestPage(ctx context.Context, path string, params url.Values) (pageResponse, error) {
+	var r pageResponse
+	resp, err := client.Connection.SendWithContext(ctx, http.MethodGet, path, params, nil, nil)
+	if err != nil {
+		return r, fmt.Errorf("error calling paginated API %s: %w", path, err)
+	}
+	defer resp.Body.Close()
+
+	if err := readJSONResponse(resp, &r); err != nil {
+		return r, fmt.Errorf("error reading page of %s: %w", path, err)
+	}
+	return r, nil
+}
+
+func cloneValues(params url.Values) url.Values {
+	out := make(url.Values, len(params))
+	for k, vs := range params {
+		out[k] = append([]string(nil), vs...)
+	}
+	return out
+}
@@ -0,0 +1,169 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package kibana
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPaginate(t *testing.T) {
+	items := []string{`"a"`, `"b"`, `"c"`, `"d"`, `"e"`}
+	handler := func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "2", r.URL.Query().Get("perPage"))
+		page := 1
+		if p := r.URL.Query().Get("page"); p != "" {
+			var err error
+			page, err = strconv.Atoi(p)
+			require.NoError(t, err)
+		}
+		end := page * 2
+		if end > len(items) {
+			end = len(items)
+		}
+		body, err := json.Marshal(map[string]interface{}{
+			"items":   json.RawMessage("[" + strings.Join(items[(page-1)*2:end], ",") + "]"),
+			"total":   len(items),
+			"page":    page,
+			"perPage": 2,
+		})
+		require.NoError(t, err)
+		_, _ = w.Write(body)
+	}
+	client, err := createTestServerAndClient(handler)
+	require.NoError(t, err)
+
+	params := url.Values{"perPage": []string{"2"}}
+	var pages []int
+	var read []string
+	err = client.Paginate("/api/fleet/agents", params, func(p Page) error {
+		pages = append(pages, p.Number)
+		assert.Equal(t, 5, p.Total)
+		for _, item := range p.Items {
+			read = append(read, string(item))
+		}
+		return nil
+	})
+	require.NoError(t, err)
+	assert.Equal(t, []int{1, 2, 3}, pages)
+	assert.Equal(t, items, read)
+	assert.Equal(t, url.Values{"perPage": []string{"2"}}, params, "params must not be modified")
+
+	pages = nil
+	err = client.Paginate("/api/fleet/agents", url.Values{"perPage": []string{"2"}, "page": []string{"2"}}, func(p Page) error {
+		pages = append(pages, p.Number)
+		return ErrStopPagination
+	})
+	require.NoError(t, err)
+	assert.Equal(t, []int{2}, pages)
+
+	errFailed := errors.New("failed")
+	err = client.Paginate("/api/fleet/agents", url.Values{"perPage": []string{"2"}}, func(p Page) error {
+		return errFailed
+	})
+	assert.ErrorIs(t, err, errFailed)
+}
+
+func TestPaginateSavedObjects(t *testing.T) {
+	var requested []string
+	handler := func(w http.ResponseWriter, r *http.Request) {
+		page := r.URL.Query().Get("page")
+		requested = append(requested, page)
+		if page == "" {
+			page = "1"
+		}
+		fmt.Fprintf(w, `{"saved_objects":[{"id":%q}],"total":2,"page":%s,"per_page":1}`, page, page)
+	}
+	client, err := createTestServerAndClient(handler)
+	require.NoError(t, err)
+
+	var ids []string
+	err = client.Paginate("/api/saved_objects/_find", url.Values{"per_page": []string{"1"}}, func(p Page) error {
+		for _, item := range p.Items {
+			var obj struct {
+				ID string `json:"id"`
+			}
+			require.NoError(t, json.Unmarshal(item, &obj))
+			ids = append(ids, obj.ID)
+		}
+		return nil
+	})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"", "2"}, requested)
+	assert.Equal(t, []string{"1", "2"}, ids)
+}
+
+func TestPaginateSearchAfter(t *testing.T) {
+	var requested []string
+	handler := func(w http.ResponseWriter, r *http.Request) {
+		searchAfter := r.URL.Query().Get("searchAfter")
+		requested = append(requested, searchAfter)
+		assert.Empty(t, r.URL.Query().Get("page"))
+		switch searchAfter {
+		case "":
+			_, _ = w.Write([]byte(`{"items":["a","b"],"total":3,"nextSearchAfter":"[1,\"b\"]"}`))
+		case `[1,"b"]`:
+			_, _ = w.Write([]byte(`{"items":["c"],"total":3}`))
+		default:
+			t.Errorf("unexpected searchAfter %q", searchAfter)
+		}
+	}
+	client, err := createTestServerAndClient(handler)
+	require.NoError(t, err)
+
+	var read []string
+	err = client.Paginate("/api/fleet/agents", nil, func(p Page) error {
+		assert.Zero(t, p.Number)
+		for _, item := range p.Items {
+			read = append(read, string(item))
+		}
+		return nil
+	})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"", `[1,"b"]`}, requested)
+	assert.Equal(t, []string{`"a"`, `"b"`, `"c"`}, read)
+}
+
+func TestPaginateError(t *testing.T) {
+	handler := func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadRequest)
+		_, _ = w.Write([]byte(`{"statusCode":400,"error":"Bad Request","message":"invalid kuery"}`))
+	}
+	client, err := createTestServerAndClient(handler)
+	require.NoError(t, err)
+
+	err = client.Paginate("/api/fleet/agents", nil, func(p Page) error {
+		t.Error("no page expected")
+		return nil
+	})
+	assert.ErrorContains(t, err, "invalid kuery")
+
+	err = client.Paginate("/api/fleet/agents", url.Values{"page": []string{"0"}}, func(p Page) error {
+		return nil
+	})
+	assert.EqualError(t, err, `invalid page parameter "0"`)
+}
@@ -0,0 +1,121 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package kibana
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/elastic/elastic-agent-libs/clock"
+)
+
+// RateLimitConfig limits the rate of the requests sent to Kibana with a
+// token bucket: up to Burst requests are sent at once, then Rate requests
+// per second. The requests over the limit wait for their turn, or until
+// their context is done. Every attempt of a retried request counts.
+type RateLimitConfig struct {
+	// Rate is the number of requests per second, the requests are not
+	// limited if it is 0.
+	Rate float64 `config:"rate" yaml:"rate,omitempty"`
+	// Burst is the number of requests sent at once, 1 if it is 0.
+	Burst int `config:"burst" yaml:"burst,omitempty"`
+}
+
+// Validate checks the rate and burst.
+func (c *RateLimitConfig) Validate() error {
+	if c.Rate < 0 || c.Burst < 0 {
+		return errors.New("rate_limit rate and burst must be positive")
+	}
+	return nil
+}
+
+// rateLimiter is the token bucket of RateLimitConfig. The tokens are taken
+// when a request is sent or reserved when it waits, so the bucket can have
+// less than zero tokens.
+type rateLimiter struct {
+	mu     sync.Mutex
+	rate   float64
+	burst  float64
+	tokens float64
+	last   time.Time
+	clock  clock.Clock
+}
+
+// newRateLimiter returns the limiter of the config, nil if the requests are
+// not limited.
+func newRateLimiter(cfg RateLimitConfig, clk clock.Clock) *rateLimiter {
+	if cfg.Rate == 0 {
+		return nil
+	}
+	burst := float64(max(cfg.Burst, 1))
+	return &rateLimiter{
+		rate:   cfg.Rate,
+		burst:  burst,
+		tokens: burst,
+		last:   clk.Now(),
+		clock:  clk,
+	}
+}
+
+// reserve takes a token from the bucket and returns the time to wait until
+// it is available.
+func (l *rateLimiter) reserve() time.Duration {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := l.clock.Now()
+	l.tokens = min(l.burst, l.tokens+now.Sub(l.last).Seconds()*l.rate)
+	l.last = now
+	l.tokens--
+	if l.tokens >= 0 {
+		return 0
+	}
+	return time.Duration(-l.tokens / l.rate * float64(time.Second))
+}
+
+// cancel gives back a token reserved by a request not sent.
+func (l *rateLimiter) cancel() {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.tokens = min(l.burst, l.tokens+1)
+}
+
+// wait blocks until a request can be sent or ctx is done. It doesn't block
+// if l is nil.
+func (l *rateLimiter) wait(ctx context.Context) error {
+	if l == nil {
+		return nil
+	}
+	delay := l.reserve()
+	if delay <= 0 {
+		return nil
+	}
+
+	timer := l.clock.NewTimer(delay)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		l.cancel()
+		return fmt.Errorf("waiting for the rate limit: %w", ctx.Err())
+	case <-timer.C():
+		return nil
+	}
+}
@@ -0,0 +1,101 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package kibana
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/elastic/elastic-agent-libs/clock"
+	"github.com/elastic/elastic-agent-libs/config"
+)
+
+func TestRateLimiter(t *testing.T) {
+	assert.Nil(t, newRateLimiter(RateLimitConfig{}, clock.Real()))
+
+	clk := clock.NewFake(time.Now())
+	l := newRateLimiter(RateLimitConfig{Rate: 2, Burst: 3}, clk)
+
+	// The burst is sent at once, then a request every 500ms.
+	for i := 0; i < 3; i++ {
+		assert.Zero(t, l.reserve())
+	}
+	assert.Equal(t, 500*time.Millisecond, l.reserve())
+	assert.Equal(t, time.Second, l.reserve())
+
+	// A cancelled request gives its token back.
+	l.cancel()
+	assert.Equal(t, time.Second, l.reserve())
+
+	// The bucket is refilled up to the burst.
+	clk.Advance(time.Hour)
+	for i := 0; i < 3; i++ {
+		assert.Zero(t, l.reserve())
+	}
+	assert.Equal(t, 500*time.Millisecond, l.reserve())
+}
+
+func TestRateLimiterWait(t *testing.T) {
+	clk := clock.NewFake(time.Now())
+	l := newRateLimiter(RateLimitConfig{Rate: 1, Burst: 1}, clk)
+	require.NoError(t, l.wait(context.Background()))
+
+	done := make(chan error)
+	go func() { done <- l.wait(context.Background()) }()
+	clk.BlockUntil(1)
+	clk.Advance(time.Second)
+	require.NoError(t, <-done)
+}
+
+func TestRateLimit(t *testing.T) {
+	requests := 0
+	kibanaTS := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		_, _ = w.Write([]byte(`{}`))
+	}))
+	defer kibanaTS.Close()
+
+	client, err := NewKibanaClient(config.MustNewConfigFrom(fmt.Sprintf(`
+protocol: http
+host: %s
+ignoreversion: true
+rate_limit.rate: 0.001
+rate_limit.burst: 2
+`, kibanaTS.Listener.Addr().String())), binaryName, v, commit, buildTime)
+	require.NoError(t, err)
+
+	for i := 0; i < 2; i++ {
+		_, _, err := client.Request(http.MethodGet, "/api/test", nil, nil, nil)
+		require.NoError(t, err)
+	}
+
+	// The next request waits for more than its context allows.
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	_, _, err = client.RequestWithContext(ctx, http.MethodGet, "/api/test", nil, nil, nil)
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+	assert.ErrorContains(t, err, "waiting for the rate limit")
+	assert.Equal(t, 2, requests)
+}
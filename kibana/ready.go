@@ -0,0 +1,140 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package kibana
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+)
+
+// readyBackoff is the wait between two status requests of WaitForReady.
+var readyBackoff = BackoffConfig{Init: time.Second, Max: 10 * time.Second}
+
+// StatusResponse is the overall status of Kibana, as reported by its status
+// API.
+type StatusResponse struct {
+	Status struct {
+		Overall struct {
+			// Level is the status of Kibana 8.x: available, degraded,
+			// unavailable or critical.
+			Level string `json:"level"`
+			// State is the status of Kibana 7.x: green, yellow or red.
+			State   string `json:"state"`
+			Summary string `json:"summary"`
+		} `json:"overall"`
+	} `json:"status"`
+}
+
+// Available returns true if Kibana is available.
+func (s StatusResponse) Available() bool {
+	overall := s.Status.Overall
+	return overall.Level == "available" || overall.State == "green"
+}
+
+// WaitForReady polls the status API of Kibana until it reports Kibana is
+// available, for up to timeout, or until ctx is done. The status is
+// requested every second at first, then backing off up to every 10
+// seconds. The error returned if Kibana isn't ready has the last status
+// received.
+func (client *Client) WaitForReady(ctx context.Context, timeout time.Duration) error {
+	clk := client.getClock()
+
+	// The timeout is timed by the clock of the client rather than with
+	// context.WithTimeout, so it follows the same time as the waits.
+	ctx, cancel := context.WithCancelCause(ctx)
+	defer cancel(nil)
+	deadline := clk.NewTimer(timeout)
+	defer deadline.Stop()
+	go func() {
+		select {
+		case <-deadline.C():
+			cancel(context.DeadlineExceeded)
+		case <-ctx.Done():
+		}
+	}()
+
+	var wait time.Duration
+	var lastErr error
+	for attempt := 1; ; attempt++ {
+		err := client.checkReady(ctx)
+		if err == nil {
+			return nil
+		}
+		// Keep the last status rather than the error of the request
+		// cancelled by the timeout.
+		if ctx.Err() == nil || lastErr == nil {
+			lastErr = err
+		}
+		client.log.Debugf("Kibana is not ready yet (attempt %d): %v", attempt, err)
+
+		wait = min(max(2*wait, readyBackoff.Init), readyBackoff.Max)
+		timer := clk.NewTimer(wait)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return fmt.Errorf("timed out waiting for Kibana to be ready after %d attempts: %w", attempt, errors.Join(context.Cause(ctx), lastErr))
+		case <-timer.C():
+		}
+	}
+}
+
+// checkReady returns an error with the status of Kibana if it isn't
+// available. The status is requested once, bypassing the response cache
+// and the retries, so each call sees the current status.
+func (client *Client) checkReady(ctx context.Context) error {
+	conn := &client.Connection
+	baseURL := conn.URL
+	if conn.hosts != nil {
+		baseURL = conn.hosts.order()[0]
+	}
+	req, err := conn.newRequest(ctx, baseURL, http.MethodGet, statusAPI, nil, nil, nil)
+	if err != nil {
+		return err
+	}
+	resp, err := conn.send(req)
+	if conn.hosts != nil {
+		conn.hosts.update(baseURL, hostFailed(resp, err))
+	}
+	if err != nil {
+		return fmt.Errorf("error calling status API: %w", err)
+	}
+	defer resp.Body.Close()
+
+	b, err := io.ReadAll(io.LimitReader(resp.Body, maxErrorBodySize))
+	if err != nil {
+		return fmt.Errorf("reading response body: %w", err)
+	}
+
+	var status StatusResponse
+	if err := json.Unmarshal(b, &status); err != nil {
+		return fmt.Errorf("status API returned %d: %s", resp.StatusCode, truncateString(b))
+	}
+	if !status.Available() {
+		overall := status.Status.Overall.Level
+		if overall == "" {
+			overall = status.Status.Overall.State
+		}
+		return fmt.Errorf("status API returned %d, overall status %q: %s", resp.StatusCode, overall, truncateString(b))
+	}
+	return nil
+}
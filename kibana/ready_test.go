@@ -0,0 +1,135 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package kibana
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/elastic/elastic-agent-libs/clock"
+	"github.com/elastic/elastic-agent-libs/logp"
+)
+
+func TestWaitForReady(t *testing.T) {
+	responses := []struct {
+		code int
+		body string
+	}{
+		{http.StatusServiceUnavailable, `Kibana server is not ready yet`},
+		{http.StatusServiceUnavailable, `{"status":{"overall":{"level":"unavailable","summary":"Kibana is starting"}}}`},
+		{http.StatusOK, `{"status":{"overall":{"level":"degraded"}}}`},
+		{http.StatusOK, `{"status":{"overall":{"level":"available"}}}`},
+	}
+	requests := 0
+	kibanaTS := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, statusAPI, r.URL.Path)
+		resp := responses[min(requests, len(responses)-1)]
+		requests++
+		w.WriteHeader(resp.code)
+		_, _ = w.Write([]byte(resp.body))
+	}))
+	defer kibanaTS.Close()
+
+	clk := clock.NewFake(time.Now())
+	client := &Client{
+		Connection: Connection{URL: kibanaTS.URL, HTTP: http.DefaultClient, clock: clk},
+		log:        logp.NewLogger("kibana"),
+	}
+	done := waitForReady(client, time.Minute)
+	// The timeout and the backoff are both waiting on the clock.
+	for _, wait := range []time.Duration{time.Second, 2 * time.Second, 4 * time.Second} {
+		clk.BlockUntil(2)
+		clk.Advance(wait)
+	}
+	require.NoError(t, <-done)
+	assert.Equal(t, 4, requests)
+}
+
+func TestWaitForReadyBypassesCache(t *testing.T) {
+	requests := 0
+	kibanaTS := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		if requests < 3 {
+			_, _ = w.Write([]byte(`{"status":{"overall":{"level":"unavailable"}}}`))
+			return
+		}
+		_, _ = w.Write([]byte(`{"status":{"overall":{"level":"available"}}}`))
+	}))
+	defer kibanaTS.Close()
+
+	clk := clock.NewFake(time.Now())
+	client := &Client{
+		Connection: Connection{
+			URL:   kibanaTS.URL,
+			HTTP:  http.DefaultClient,
+			cache: newResponseCache(CacheConfig{TTL: time.Hour, Paths: []string{statusAPI}}, clk),
+			clock: clk,
+		},
+		log: logp.NewLogger("kibana"),
+	}
+	done := waitForReady(client, time.Minute)
+	for i := 0; i < 2; i++ {
+		clk.BlockUntil(2)
+		clk.Advance(readyBackoff.Max)
+	}
+	require.NoError(t, <-done)
+	assert.Equal(t, 3, requests, "each poll must request the current status")
+}
+
+func TestWaitForReadyTimeout(t *testing.T) {
+	kibanaTS := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`{"status":{"overall":{"state":"red"}}}`))
+	}))
+	defer kibanaTS.Close()
+
+	clk := clock.NewFake(time.Now())
+	client := &Client{
+		Connection: Connection{URL: kibanaTS.URL, HTTP: http.DefaultClient, clock: clk},
+		log:        logp.NewLogger("kibana"),
+	}
+	done := waitForReady(client, time.Minute)
+	clk.BlockUntil(2)
+	clk.Advance(time.Minute)
+	err := <-done
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+	assert.ErrorContains(t, err, "timed out waiting for Kibana to be ready")
+	assert.ErrorContains(t, err, `status API returned 200, overall status "red": {"status":{"overall":{"state":"red"}}}`)
+}
+
+// waitForReady calls client.WaitForReady in a goroutine, the clock of the
+// client must be advanced for it to return.
+func waitForReady(client *Client, timeout time.Duration) <-chan error {
+	done := make(chan error, 1)
+	go func() {
+		done <- client.WaitForReady(context.Background(), timeout)
+	}()
+	return done
+}
+
+func TestStatusAvailable(t *testing.T) {
+	var status StatusResponse
+	assert.False(t, status.Available())
+	status.Status.Overall.State = "green"
+	assert.True(t, status.Available())
+}
@@ -0,0 +1,100 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package kibana
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// RetryConfig configures the retries of the requests failing with a network
+// error, a 429 Too Many Requests or a 5xx status. The wait between two
+// attempts starts at Backoff.Init and doubles up to Backoff.Max, unless the
+// response sets a Retry-After, which is then used, capped at Backoff.Max.
+// The requests are not retried if MaxAttempts is lower than 2.
+type RetryConfig struct {
+	MaxAttempts int           `config:"max_attempts" yaml:"max_attempts,omitempty"`
+	Backoff     BackoffConfig `config:"backoff" yaml:"backoff,omitempty"`
+}
+
+// BackoffConfig configures an exponential backoff.
+type BackoffConfig struct {
+	Init time.Duration `config:"init" yaml:"init,omitempty"`
+	Max  time.Duration `config:"max" yaml:"max,omitempty"`
+}
+
+func defaultRetryConfig() RetryConfig {
+	return RetryConfig{
+		MaxAttempts: 1,
+		Backoff: BackoffConfig{
+			Init: time.Second,
+			Max:  time.Minute,
+		},
+	}
+}
+
+// Validate checks the backoff.
+func (c *RetryConfig) Validate() error {
+	if c.Backoff.Init < 0 || c.Backoff.Max < c.Backoff.Init {
+		return errors.New("retry backoff.init must be positive and not greater than backoff.max")
+	}
+	return nil
+}
+
+func (c *RetryConfig) enabled() bool {
+	return c.MaxAttempts > 1
+}
+
+// shouldRetry returns true if the request that returned resp and err can
+// be retried.
+func shouldRetry(resp *http.Response, err error) bool {
+	if err != nil {
+		return true
+	}
+	return resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode >= http.StatusInternalServerError
+}
+
+// wait returns the time to wait before the attempt following the one that
+// returned resp, after waiting previous before it.
+func (c *RetryConfig) wait(resp *http.Response, previous time.Duration) time.Duration {
+	wait := min(max(2*previous, c.Backoff.Init), c.Backoff.Max)
+	if resp == nil {
+		return wait
+	}
+	if retryAfter, ok := parseRetryAfter(resp.Header.Get("Retry-After")); ok {
+		return min(retryAfter, c.Backoff.Max)
+	}
+	return wait
+}
+
+// parseRetryAfter parses a Retry-After header, either a number of seconds
+// or an HTTP date.
+func parseRetryAfter(value string) (time.Duration, bool) {
+	if value == "" {
+		return 0, false
+	}
+	if seconds, err := strconv.Atoi(value); err == nil {
+		return max(time.Duration(seconds)*time.Second, 0), true
+	}
+	if date, err := http.ParseTime(value); err == nil {
+		return max(time.Until(date), 0), true
+	}
+	return 0, false
+}
@@ -0,0 +1,263 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package kibana
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/textproto"
+	"net/url"
+)
+
+const (
+	savedObjectsExportAPI              = "/api/saved_objects/_export"
+	savedObjectsImportAPI              = "/api/saved_objects/_import"
+	savedObjectsResolveImportErrorsAPI = "/api/saved_objects/_resolve_import_errors"
+)
+
+// SavedObjectReference identifies a saved object.
+type SavedObjectReference struct {
+	Type string `json:"type"`
+	ID   string `json:"id"`
+}
+
+// ImportObjectsRequest is the request of ImportObjects.
+type ImportObjectsRequest struct {
+	// Objects are the saved objects to import, in NDJSON, as exported by
+	// ExportObjects.
+	Objects []byte
+	// Overwrite overwrites the existing objects with the same ID.
+	Overwrite bool
+	// CreateNewCopies creates the objects with new IDs, it can't be set
+	// with Overwrite.
+	CreateNewCopies bool
+	// SpaceID is the space the objects are imported to, the space of the
+	// client if empty.
+	SpaceID string
+}
+
+// ImportObjectsResponse is the response of ImportObjects and
+// ResolveImportErrors.
+type ImportObjectsResponse struct {
+	Success        bool                      `json:"success"`
+	SuccessCount   int                       `json:"successCount"`
+	SuccessResults []SavedObjectImportResult `json:"successResults"`
+	Errors         []SavedObjectImportError  `json:"errors"`
+}
+
+// SavedObjectImportResult is an imported saved object.
+type SavedObjectImportResult struct {
+	Type          string `json:"type"`
+	ID            string `json:"id"`
+	DestinationID string `json:"destinationId,omitempty"`
+	Overwrite     bool   `json:"overwrite,omitempty"`
+}
+
+// SavedObjectImportError is a saved object that failed to import.
+type SavedObjectImportError struct {
+	Type  string `json:"type"`
+	ID    string `json:"id"`
+	Title string `json:"title"`
+	Error struct {
+		// Type is the type of the error, e.g. conflict or
+		// missing_references.
+		Type       string                 `json:"type"`
+		Message    string                 `json:"message,omitempty"`
+		References []SavedObjectReference `json:"references,omitempty"`
+	} `json:"error"`
+}
+
+// ImportObjects imports saved objects. The objects that failed to import are
+// listed in the Errors of the response, their errors can be resolved with
+// ResolveImportErrors.
+func (client *Client) ImportObjects(ctx context.Context, request ImportObjectsRequest) (r ImportObjectsResponse, err error) {
+	body, contentType, err := savedObjectsMultipart(request.Objects, nil)
+	if err != nil {
+		return r, err
+	}
+
+	params := url.Values{}
+	if request.Overwrite {
+		params.Set("overwrite", "true")
+	}
+	if request.CreateNewCopies {
+		params.Set("createNewCopies", "true")
+	}
+	headers := http.Header{"Content-Type": []string{contentType}}
+
+	resp, err := client.Connection.SendWithContext(requestSpace(ctx, request.SpaceID), http.MethodPost, savedObjectsImportAPI, params, headers, body)
+	if err != nil {
+		return r, fmt.Errorf("error calling import saved objects API: %w", err)
+	}
+	defer resp.Body.Close()
+	err = readJSONResponse(resp, &r)
+	return r, err
+}
+
+// ExportObjectsRequest is the request of ExportObjects. Either Types or
+// Objects must be set.
+type ExportObjectsRequest struct {
+	// Types are the types of the objects to export, e.g. dashboard.
+	Types []string `json:"type,omitempty"`
+	// Objects are the objects to export.
+	Objects []SavedObjectReference `json:"objects,omitempty"`
+	// IncludeReferencesDeep exports the objects referenced by the exported
+	// objects too.
+	IncludeReferencesDeep bool `json:"includeReferencesDeep,omitempty"`
+	// ExcludeExportDetails omits the summary line at the end of the export.
+	ExcludeExportDetails bool `json:"excludeExportDetails,omitempty"`
+	// SpaceID is the space the objects are exported from, the space of the
+	// client if empty.
+	SpaceID string `json:"-"`
+}
+
+// ExportObjects exports saved objects, it returns them in NDJSON.
+func (client *Client) ExportObjects(ctx context.Context, request ExportObjectsRequest) ([]byte, error) {
+	reqBody, err := json.Marshal(request)
+	if err != nil {
+		return nil, fmt.Errorf("unable to marshal export saved objects request into JSON: %w", err)
+	}
+
+	resp, err := client.Connection.SendWithContext(requestSpace(ctx, request.SpaceID), http.MethodPost, savedObjectsExportAPI, nil, nil, bytes.NewReader(reqBody))
+	if err != nil {
+		return nil, fmt.Errorf("error calling export saved objects API: %w", err)
+	}
+	defer resp.Body.Close()
+
+	b, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("reading response body: %w", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("export saved objects API returned %d: %w", resp.StatusCode, extractError(resp.StatusCode, b))
+	}
+	return b, nil
+}
+
+// ExportObjectsStream is like ExportObjects, returning the NDJSON stream of
+// the exported objects instead of reading it. The caller must close it.
+// ReadNDJSON reads the objects one at a time. Unlike ExportObjects, the
+// request is neither retried nor failed over to another host.
+func (client *Client) ExportObjectsStream(ctx context.Context, request ExportObjectsRequest) (io.ReadCloser, error) {
+	reqBody, err := json.Marshal(request)
+	if err != nil {
+		return nil, fmt.Errorf("unable to marshal export saved objects request into JSON: %w", err)
+	}
+
+	objects, err := client.Connection.SendStream(requestSpace(ctx, request.SpaceID), http.MethodPost, savedObjectsExportAPI, nil, nil, bytes.NewReader(reqBody))
+	if err != nil {
+		return nil, fmt.Errorf("error calling export saved objects API: %w", err)
+	}
+	return objects, nil
+}
+
+// ResolveImportErrorsRequest is the request of ResolveImportErrors.
+type ResolveImportErrorsRequest struct {
+	// Objects are the saved objects of the import, in NDJSON.
+	Objects []byte
+	// Retries are the objects to import again and how.
+	Retries []SavedObjectImportRetry
+	// CreateNewCopies creates the objects with new IDs.
+	CreateNewCopies bool
+	// SpaceID is the space the objects are imported to, the space of the
+	// client if empty.
+	SpaceID string
+}
+
+// SavedObjectImportRetry is an object to import again by
+// ResolveImportErrors.
+type SavedObjectImportRetry struct {
+	Type string `json:"type"`
+	ID   string `json:"id"`
+	// Overwrite overwrites the existing object with the same ID.
+	Overwrite bool `json:"overwrite,omitempty"`
+	// DestinationID is the ID to import the object with.
+	DestinationID string `json:"destinationId,omitempty"`
+	// ReplaceReferences replaces the missing references of the object.
+	ReplaceReferences []SavedObjectReferenceReplacement `json:"replaceReferences,omitempty"`
+	// IgnoreMissingReferences imports the object despite its missing
+	// references.
+	IgnoreMissingReferences bool `json:"ignoreMissingReferences,omitempty"`
+}
+
+// SavedObjectReferenceReplacement replaces the reference to the object
+// with the type and FromID by a reference to the object with ToID.
+type SavedObjectReferenceReplacement struct {
+	Type   string `json:"type"`
+	FromID string `json:"from"`
+	ToID   string `json:"to"`
+}
+
+// ResolveImportErrors imports again the objects that failed to import, as
+// described by the retries.
+func (client *Client) ResolveImportErrors(ctx context.Context, request ResolveImportErrorsRequest) (r ImportObjectsResponse, err error) {
+	retries, err := json.Marshal(request.Retries)
+	if err != nil {
+		return r, fmt.Errorf("unable to marshal import retries into JSON: %w", err)
+	}
+	body, contentType, err := savedObjectsMultipart(request.Objects, map[string]string{"retries": string(retries)})
+	if err != nil {
+		return r, err
+	}
+
+	params := url.Values{}
+	if request.CreateNewCopies {
+		params.Set("createNewCopies", "true")
+	}
+	headers := http.Header{"Content-Type": []string{contentType}}
+
+	resp, err := client.Connection.SendWithContext(requestSpace(ctx, request.SpaceID), http.MethodPost, savedObjectsResolveImportErrorsAPI, params, headers, body)
+	if err != nil {
+		return r, fmt.Errorf("error calling resolve import errors API: %w", err)
+	}
+	defer resp.Body.Close()
+	err = readJSONResponse(resp, &r)
+	return r, err
+}
+
+// savedObjectsMultipart returns the multipart form with the objects as the
+// file and the fields, and its content type.
+func savedObjectsMultipart(objects []byte, fields map[string]string) (*bytes.Buffer, string, error) {
+	buf := &bytes.Buffer{}
+	w := multipart.NewWriter(buf)
+
+	pHeaders := textproto.MIMEHeader{}
+	pHeaders.Add("Content-Disposition", `form-data; name="file"; filename="objects.ndjson"`)
+	pHeaders.Add("Content-Type", "application/ndjson")
+	p, err := w.CreatePart(pHeaders)
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to create multipart writer for payload: %w", err)
+	}
+	if _, err := p.Write(objects); err != nil {
+		return nil, "", fmt.Errorf("failed to copy the saved objects: %w", err)
+	}
+	for name, value := range fields {
+		if err := w.WriteField(name, value); err != nil {
+			return nil, "", fmt.Errorf("failed to write the %s field: %w", name, err)
+		}
+	}
+	if err := w.Close(); err != nil {
+		return nil, "", fmt.Errorf("failed to close multipart writer: %w", err)
+	}
+	return buf, w.FormDataContentType(), nil
+}
@@ -0,0 +1,126 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package kibana
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testSavedObjects = `{"type":"dashboard","id":"dash-1","attributes":{"title":"Overview"}}` + "\n"
+
+func TestImportObjects(t *testing.T) {
+	handler := func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/s/test-space"+savedObjectsImportAPI, r.URL.Path)
+		assert.Equal(t, "true", r.URL.Query().Get("overwrite"))
+
+		file, header, err := r.FormFile("file")
+		require.NoError(t, err)
+		defer file.Close()
+		assert.Equal(t, "application/ndjson", header.Header.Get("Content-Type"))
+		content, err := io.ReadAll(file)
+		require.NoError(t, err)
+		assert.Equal(t, testSavedObjects, string(content))
+
+		_, _ = w.Write([]byte(`{"success":false,"successCount":0,"errors":[{"id":"dash-1","type":"dashboard","title":"Overview","error":{"type":"conflict"}}]}`))
+	}
+	client, err := createTestServerAndClient(handler)
+	require.NoError(t, err)
+
+	resp, err := client.ImportObjects(context.Background(), ImportObjectsRequest{
+		Objects:   []byte(testSavedObjects),
+		Overwrite: true,
+		SpaceID:   "test-space",
+	})
+	require.NoError(t, err)
+	assert.False(t, resp.Success)
+	require.Len(t, resp.Errors, 1)
+	assert.Equal(t, "dash-1", resp.Errors[0].ID)
+	assert.Equal(t, "conflict", resp.Errors[0].Error.Type)
+}
+
+func TestExportObjects(t *testing.T) {
+	handler := func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, savedObjectsExportAPI, r.URL.Path)
+		var request map[string]interface{}
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&request))
+		assert.Equal(t, map[string]interface{}{
+			"objects":               []interface{}{map[string]interface{}{"type": "dashboard", "id": "dash-1"}},
+			"includeReferencesDeep": true,
+		}, request)
+
+		_, _ = w.Write([]byte(testSavedObjects))
+	}
+	client, err := createTestServerAndClient(handler)
+	require.NoError(t, err)
+
+	objects, err := client.ExportObjects(context.Background(), ExportObjectsRequest{
+		Objects:               []SavedObjectReference{{Type: "dashboard", ID: "dash-1"}},
+		IncludeReferencesDeep: true,
+	})
+	require.NoError(t, err)
+	assert.Equal(t, testSavedObjects, string(objects))
+}
+
+func TestExportObjectsRetry(t *testing.T) {
+	attempts := 0
+	handler := func(w http.ResponseWriter, r *http.Request) {
+		attempts++
+		if attempts == 1 {
+			w.Header().Set("Retry-After", "0")
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		_, _ = w.Write([]byte(testSavedObjects))
+	}
+	client, err := createTestServerAndClient(handler)
+	require.NoError(t, err)
+	client.Connection.Retry = RetryConfig{MaxAttempts: 2, Backoff: BackoffConfig{Init: time.Millisecond, Max: time.Millisecond}}
+
+	objects, err := client.ExportObjects(context.Background(), ExportObjectsRequest{Types: []string{"dashboard"}})
+	require.NoError(t, err)
+	assert.Equal(t, testSavedObjects, string(objects))
+	assert.Equal(t, 2, attempts)
+}
+
+func TestResolveImportErrors(t *testing.T) {
+	handler := func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, savedObjectsResolveImportErrorsAPI, r.URL.Path)
+		require.NoError(t, r.ParseMultipartForm(1024*1024))
+		assert.JSONEq(t, `[{"type":"dashboard","id":"dash-1","overwrite":true}]`, r.FormValue("retries"))
+
+		_, _ = w.Write([]byte(`{"success":true,"successCount":1,"successResults":[{"id":"dash-1","type":"dashboard","overwrite":true}]}`))
+	}
+	client, err := createTestServerAndClient(handler)
+	require.NoError(t, err)
+
+	resp, err := client.ResolveImportErrors(context.Background(), ResolveImportErrorsRequest{
+		Objects: []byte(testSavedObjects),
+		Retries: []SavedObjectImportRetry{{Type: "dashboard", ID: "dash-1", Overwrite: true}},
+	})
+	require.NoError(t, err)
+	assert.True(t, resp.Success)
+	assert.Equal(t, []SavedObjectImportResult{{Type: "dashboard", ID: "dash-1", Overwrite: true}}, resp.SuccessResults)
+}
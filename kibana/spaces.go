@@ -0,0 +1,142 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package kibana
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+)
+
+const (
+	spacesAPI = "/api/spaces/space"
+	spaceAPI  = "/api/spaces/space/%s"
+)
+
+type spaceKey struct{}
+
+// WithSpace returns a context sending the requests in the Kibana space,
+// instead of the space of the connection. The requests target the default
+// space if spaceID is empty.
+func WithSpace(ctx context.Context, spaceID string) context.Context {
+	return context.WithValue(ctx, spaceKey{}, spaceID)
+}
+
+// requestSpace returns ctx sending the requests in the space if spaceID is
+// set, or ctx otherwise.
+func requestSpace(ctx context.Context, spaceID string) context.Context {
+	if spaceID == "" {
+		return ctx
+	}
+	return WithSpace(ctx, spaceID)
+}
+
+// space returns the space of the requests sent with ctx.
+func (conn *Connection) space(ctx context.Context) string {
+	if spaceID, ok := ctx.Value(spaceKey{}).(string); ok {
+		return spaceID
+	}
+	return conn.SpaceID
+}
+
+// spacePath returns the path of the API in the space, or apiPath if spaceID
+// is empty.
+func spacePath(spaceID, apiPath string) string {
+	if spaceID == "" {
+		return apiPath
+	}
+	return "/s/" + url.PathEscape(spaceID) + apiPath
+}
+
+// Space is a Kibana space.
+type Space struct {
+	ID               string   `json:"id"`
+	Name             string   `json:"name"`
+	Description      string   `json:"description,omitempty"`
+	Color            string   `json:"color,omitempty"`
+	Initials         string   `json:"initials,omitempty"`
+	ImageURL         string   `json:"imageUrl,omitempty"`
+	DisabledFeatures []string `json:"disabledFeatures,omitempty"`
+}
+
+// ListSpaces returns the spaces.
+func (client *Client) ListSpaces(ctx context.Context) (r []Space, err error) {
+	err = client.sendSpaceRequest(ctx, http.MethodGet, spacesAPI, nil, &r)
+	return r, err
+}
+
+// GetSpace returns the space with the given ID.
+func (client *Client) GetSpace(ctx context.Context, id string) (r Space, err error) {
+	err = client.sendSpaceRequest(ctx, http.MethodGet, fmt.Sprintf(spaceAPI, url.PathEscape(id)), nil, &r)
+	return r, err
+}
+
+// CreateSpace creates a space.
+func (client *Client) CreateSpace(ctx context.Context, space Space) (r Space, err error) {
+	err = client.sendSpaceRequest(ctx, http.MethodPost, spacesAPI, space, &r)
+	return r, err
+}
+
+// UpdateSpace updates the space with the ID of space.
+func (client *Client) UpdateSpace(ctx context.Context, space Space) (r Space, err error) {
+	err = client.sendSpaceRequest(ctx, http.MethodPut, fmt.Sprintf(spaceAPI, url.PathEscape(space.ID)), space, &r)
+	return r, err
+}
+
+// DeleteSpace deletes the space with the given ID and all its objects.
+func (client *Client) DeleteSpace(ctx context.Context, id string) error {
+	return client.sendSpaceRequest(ctx, http.MethodDelete, fmt.Sprintf(spaceAPI, url.PathEscape(id)), nil, nil)
+}
+
+// sendSpaceRequest sends a request to the spaces API, which is not in a
+// space, and decodes its response into r, if not nil.
+func (client *Client) sendSpaceRequest(ctx context.Context, method, apiPath string, request, r any) error {
+	var body io.Reader
+	if request != nil {
+		reqBody, err := json.Marshal(request)
+		if err != nil {
+			return fmt.Errorf("unable to marshal space request into JSON: %w", err)
+		}
+		body = bytes.NewReader(reqBody)
+	}
+
+	resp, err := client.Connection.SendWithContext(WithSpace(ctx, ""), method, apiPath, nil, nil, body)
+	if err != nil {
+		return fmt.Errorf("error calling spaces API: %w", err)
+	}
+	defer resp.Body.Close()
+
+	b, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return fmt.Errorf("reading response body: %w", err)
+	}
+	if resp.StatusCode >= http.StatusMultipleChoices {
+		return fmt.Errorf("spaces API returned %d: %w", resp.StatusCode, extractError(resp.StatusCode, b))
+	}
+	if r == nil {
+		return nil
+	}
+	if err := json.Unmarshal(b, r); err != nil {
+		return fmt.Errorf("unmarshalling response json: %w", err)
+	}
+	return nil
+}
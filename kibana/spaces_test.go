@@ -0,0 +1,119 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package kibana
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRequestSpace(t *testing.T) {
+	var paths []string
+	handler := func(w http.ResponseWriter, r *http.Request) {
+		paths = append(paths, r.URL.Path)
+		_, _ = w.Write([]byte(`{}`))
+	}
+	client, err := createTestServerAndClient(handler)
+	require.NoError(t, err)
+	client.Connection.SpaceID = "client-space"
+
+	ctx := context.Background()
+	_, _, err = client.Connection.RequestWithContext(ctx, http.MethodGet, "/api/test", nil, nil, nil)
+	require.NoError(t, err)
+	_, _, err = client.Connection.RequestWithContext(WithSpace(ctx, "other space"), http.MethodGet, "/api/test", nil, nil, nil)
+	require.NoError(t, err)
+	_, _, err = client.Connection.RequestWithContext(WithSpace(ctx, ""), http.MethodGet, "/api/test", nil, nil, nil)
+	require.NoError(t, err)
+
+	assert.Equal(t, []string{
+		"/s/client-space/api/test",
+		"/s/other space/api/test",
+		"/api/test",
+	}, paths)
+}
+
+func TestSpaces(t *testing.T) {
+	var requests []string
+	handler := func(w http.ResponseWriter, r *http.Request) {
+		requests = append(requests, r.Method+" "+r.URL.Path)
+		switch r.Method {
+		case http.MethodGet:
+			if r.URL.Path == spacesAPI {
+				_, _ = w.Write([]byte(`[{"id":"default","name":"Default"},{"id":"marketing","name":"Marketing"}]`))
+				return
+			}
+			_, _ = w.Write([]byte(`{"id":"marketing","name":"Marketing","disabledFeatures":["dev_tools"]}`))
+		case http.MethodPost, http.MethodPut:
+			var space Space
+			require.NoError(t, json.NewDecoder(r.Body).Decode(&space))
+			assert.Equal(t, "marketing", space.ID)
+			_ = json.NewEncoder(w).Encode(space)
+		case http.MethodDelete:
+			w.WriteHeader(http.StatusNoContent)
+		}
+	}
+	client, err := createTestServerAndClient(handler)
+	require.NoError(t, err)
+	client.Connection.SpaceID = "client-space"
+
+	ctx := context.Background()
+	spaces, err := client.ListSpaces(ctx)
+	require.NoError(t, err)
+	require.Len(t, spaces, 2)
+	assert.Equal(t, "marketing", spaces[1].ID)
+
+	space, err := client.GetSpace(ctx, "marketing")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"dev_tools"}, space.DisabledFeatures)
+
+	space, err = client.CreateSpace(ctx, Space{ID: "marketing", Name: "Marketing"})
+	require.NoError(t, err)
+	assert.Equal(t, "Marketing", space.Name)
+
+	space, err = client.UpdateSpace(ctx, Space{ID: "marketing", Name: "Marketing team"})
+	require.NoError(t, err)
+	assert.Equal(t, "Marketing team", space.Name)
+
+	require.NoError(t, client.DeleteSpace(ctx, "marketing"))
+
+	assert.Equal(t, []string{
+		"GET /api/spaces/space",
+		"GET /api/spaces/space/marketing",
+		"POST /api/spaces/space",
+		"PUT /api/spaces/space/marketing",
+		"DELETE /api/spaces/space/marketing",
+	}, requests)
+}
+
+func TestSpacesError(t *testing.T) {
+	handler := func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+		_, _ = w.Write([]byte(`{"statusCode":404,"error":"Not Found","message":"Saved object [space/missing] not found"}`))
+	}
+	client, err := createTestServerAndClient(handler)
+	require.NoError(t, err)
+
+	_, err = client.GetSpace(context.Background(), "missing")
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "spaces API returned 404")
+}
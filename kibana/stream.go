@@ -0,0 +1,87 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package kibana
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+)
+
+// maxErrorBodySize is the maximum size of the error responses read by
+// SendStream.
+const maxErrorBodySize = 64 * 1024
+
+// SendStream sends a request to Kibana like SendWithContext, without
+// buffering the request body, and returns the body of the response, which
+// must be closed by the caller. As the request body is streamed, the request
+// is neither retried nor failed over to another host. If the response has an
+// error status, SendStream returns an *APIError.
+func (conn *Connection) SendStream(ctx context.Context, method, extraPath string,
+	params url.Values, headers http.Header, body io.Reader) (io.ReadCloser, error) {
+
+	baseURL := conn.URL
+	if conn.hosts != nil {
+		baseURL = conn.hosts.order()[0]
+	}
+	req, err := conn.newRequest(ctx, baseURL, method, extraPath, params, headers, body)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := conn.RoundTrip(req)
+	if conn.hosts != nil {
+		conn.hosts.update(baseURL, hostFailed(resp, err))
+	}
+	if err != nil {
+		return nil, fmt.Errorf("fail to execute the HTTP %s request: %w", method, err)
+	}
+
+	if resp.StatusCode >= http.StatusMultipleChoices {
+		defer resp.Body.Close()
+		b, err := io.ReadAll(io.LimitReader(resp.Body, maxErrorBodySize))
+		if err != nil {
+			return nil, fmt.Errorf("fail to read response: %w", err)
+		}
+		return nil, extractError(resp.StatusCode, b)
+	}
+	return resp.Body, nil
+}
+
+// ReadNDJSON calls fn with each JSON value of the NDJSON stream r, until the
+// end of r or until fn returns an error. The values are read one at a time,
+// so r is never loaded fully into memory. NDJSON streams are written with a
+// json.Encoder.
+func ReadNDJSON(r io.Reader, fn func(json.RawMessage) error) error {
+	dec := json.NewDecoder(r)
+	for {
+		var value json.RawMessage
+		if err := dec.Decode(&value); err != nil {
+			if errors.Is(err, io.EOF) {
+				return nil
+			}
+			return fmt.Errorf("reading NDJSON: %w", err)
+		}
+		if err := fn(value); err != nil {
+			return err
+		}
+	}
+}
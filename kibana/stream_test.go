@@ -0,0 +1,125 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package kibana
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSendStream(t *testing.T) {
+	handler := func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/api/stream":
+			// The request body is streamed, without a length.
+			assert.Equal(t, int64(-1), r.ContentLength)
+			body, err := io.ReadAll(r.Body)
+			require.NoError(t, err)
+			_, _ = w.Write(body)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+			_, _ = w.Write([]byte(`{"statusCode":404,"error":"Not Found","message":"Not Found"}`))
+		}
+	}
+	client, err := createTestServerAndClient(handler)
+	require.NoError(t, err)
+
+	pr, pw := io.Pipe()
+	go func() {
+		enc := json.NewEncoder(pw)
+		for i := 0; i < 3; i++ {
+			_ = enc.Encode(map[string]int{"id": i})
+		}
+		pw.Close()
+	}()
+
+	body, err := client.SendStream(context.Background(), http.MethodPost, "/api/stream", nil, nil, pr)
+	require.NoError(t, err)
+	defer body.Close()
+
+	var ids []int
+	err = ReadNDJSON(body, func(value json.RawMessage) error {
+		var obj struct {
+			ID int `json:"id"`
+		}
+		require.NoError(t, json.Unmarshal(value, &obj))
+		ids = append(ids, obj.ID)
+		return nil
+	})
+	require.NoError(t, err)
+	assert.Equal(t, []int{0, 1, 2}, ids)
+
+	_, err = client.SendStream(context.Background(), http.MethodGet, "/api/missing", nil, nil, nil)
+	assert.True(t, IsNotFound(err))
+}
+
+func TestReadNDJSON(t *testing.T) {
+	stream := "{\"id\":1}\n{\"id\":2}\n\n{\"id\":3}\n"
+
+	var values []string
+	require.NoError(t, ReadNDJSON(strings.NewReader(stream), func(value json.RawMessage) error {
+		values = append(values, string(value))
+		return nil
+	}))
+	assert.Equal(t, []string{`{"id":1}`, `{"id":2}`, `{"id":3}`}, values)
+
+	errStop := errors.New("stop")
+	calls := 0
+	err := ReadNDJSON(strings.NewReader(stream), func(value json.RawMessage) error {
+		calls++
+		return errStop
+	})
+	assert.ErrorIs(t, err, errStop)
+	assert.Equal(t, 1, calls)
+
+	err = ReadNDJSON(strings.NewReader("{\"id\":1}\n{\"id\""), func(value json.RawMessage) error {
+		return nil
+	})
+	assert.ErrorContains(t, err, "reading NDJSON")
+}
+
+func TestExportObjectsStream(t *testing.T) {
+	handler := func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/s/test-space"+savedObjectsExportAPI, r.URL.Path)
+		_, _ = w.Write([]byte(testSavedObjects + `{"exportedCount":1,"missingRefCount":0}` + "\n"))
+	}
+	client, err := createTestServerAndClient(handler)
+	require.NoError(t, err)
+
+	objects, err := client.ExportObjectsStream(context.Background(), ExportObjectsRequest{
+		Types:   []string{"dashboard"},
+		SpaceID: "test-space",
+	})
+	require.NoError(t, err)
+	defer objects.Close()
+
+	var count int
+	require.NoError(t, ReadNDJSON(objects, func(json.RawMessage) error {
+		count++
+		return nil
+	}))
+	assert.Equal(t, 2, count)
+}
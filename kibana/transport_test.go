@@ -0,0 +1,89 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package kibana
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/elastic/elastic-agent-libs/config"
+	"github.com/elastic/elastic-agent-libs/testing/certutil"
+)
+
+func TestTransportProxy(t *testing.T) {
+	var proxied []string
+	proxy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		proxied = append(proxied, r.RequestURI)
+		_, _ = w.Write([]byte(`{"version":{"number":"8.13.0"}}`))
+	}))
+	defer proxy.Close()
+
+	client, err := NewKibanaClient(config.MustNewConfigFrom(map[string]interface{}{
+		"host":                    "kibana.example:5601",
+		"proxy_url":               proxy.URL,
+		"idle_connection_timeout": "15s",
+	}), binaryName, v, commit, buildTime)
+	require.NoError(t, err)
+	assert.Equal(t, "8.13.0", client.Version.String())
+	assert.Equal(t, []string{"http://kibana.example:5601" + statusAPI}, proxied)
+}
+
+func TestTransportTLSClientCertificate(t *testing.T) {
+	caKey, caCert, caPair, err := certutil.NewRootCA()
+	require.NoError(t, err)
+	serverCert, _, err := certutil.GenerateChildCert("localhost", []net.IP{net.ParseIP("127.0.0.1")}, caKey, caCert)
+	require.NoError(t, err)
+	_, clientPair, err := certutil.GenerateChildCert("client", nil, caKey, caCert)
+	require.NoError(t, err)
+
+	clientCAs := x509.NewCertPool()
+	clientCAs.AddCert(caCert)
+	kibanaTS := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if assert.Len(t, r.TLS.PeerCertificates, 1) {
+			assert.Equal(t, "client", r.TLS.PeerCertificates[0].Subject.CommonName)
+		}
+		_, _ = w.Write([]byte(`{"version":{"number":"8.13.0"}}`))
+	}))
+	kibanaTS.TLS = &tls.Config{
+		Certificates: []tls.Certificate{*serverCert},
+		ClientAuth:   tls.RequireAndVerifyClientCert,
+		ClientCAs:    clientCAs,
+		MinVersion:   tls.VersionTLS12,
+	}
+	kibanaTS.StartTLS()
+	defer kibanaTS.Close()
+
+	client, err := NewKibanaClient(config.MustNewConfigFrom(map[string]interface{}{
+		"protocol": "https",
+		"host":     kibanaTS.Listener.Addr().String(),
+		"ssl": map[string]interface{}{
+			"certificate_authorities": []string{string(caPair.Cert)},
+			"certificate":             string(clientPair.Cert),
+			"key":                     string(clientPair.Key),
+		},
+	}), binaryName, v, commit, buildTime)
+	require.NoError(t, err)
+	assert.Equal(t, "8.13.0", client.Version.String())
+}
@@ -18,6 +18,7 @@
 package kibana
 
 import (
+	"errors"
 	"fmt"
 	"net"
 	"net/url"
@@ -82,6 +83,66 @@ func MakeURL(defaultScheme string, defaultPath string, rawURL string, defaultPor
 	return addr.String(), nil
 }
 
+// ParsedURL is a URL with its credentials separated, so it can be logged.
+type ParsedURL struct {
+	// URL is the URL without credentials.
+	URL      string
+	Username string
+	Password string
+}
+
+// String returns the URL without credentials.
+func (u ParsedURL) String() string {
+	return u.URL
+}
+
+// MakeParsedURL is like MakeURL, separating the credentials of the URL.
+// The errors don't contain the password of rawURL.
+func MakeParsedURL(defaultScheme string, defaultPath string, rawURL string, defaultPort int) (ParsedURL, error) {
+	fullURL, err := MakeURL(defaultScheme, defaultPath, rawURL, defaultPort)
+	if err != nil {
+		return ParsedURL{}, redactURLError(err)
+	}
+	u, err := url.Parse(fullURL)
+	if err != nil {
+		return ParsedURL{}, redactURLError(err)
+	}
+
+	var parsed ParsedURL
+	if u.User != nil {
+		parsed.Username = u.User.Username()
+		parsed.Password, _ = u.User.Password()
+		u.User = nil
+	}
+	parsed.URL = u.String()
+	return parsed, nil
+}
+
+// RedactURL returns rawURL with its password replaced by xxxxx, so it can be
+// logged. The URL doesn't need a scheme.
+func RedactURL(rawURL string) string {
+	if hasScheme.MatchString(rawURL) {
+		if u, err := url.Parse(rawURL); err == nil {
+			return u.Redacted()
+		}
+	} else if u, err := url.Parse("http://" + rawURL); err == nil {
+		return strings.TrimPrefix(u.Redacted(), "http://")
+	}
+	// Not a valid URL, redact anything looking like credentials.
+	return userInfoPassword.ReplaceAllString(rawURL, "${1}:xxxxx@")
+}
+
+var userInfoPassword = regexp.MustCompile(`([^:/@]*):[^@/]*@`)
+
+// redactURLError redacts the URL of a *url.Error.
+func redactURLError(err error) error {
+	var urlErr *url.Error
+	if errors.As(err, &urlErr) {
+		return &url.Error{Op: urlErr.Op, URL: RedactURL(urlErr.URL), Err: urlErr.Err}
+	}
+	return err
+}
+
 func EncodeURLParams(url string, params url.Values) string {
 	if len(params) == 0 {
 		return url
@@ -90,9 +151,12 @@ func EncodeURLParams(url string, params url.Values) string {
 	return strings.Join([]string{url, "?", params.Encode()}, "")
 }
 
+// ParseHint normalizes a raw URL before ParseURL parses it. The raw URL
+// might have no scheme.
 type ParseHint func(raw string) string
 
-// ParseURL tries to parse a URL and return the parsed result.
+// ParseURL tries to parse a URL and return the parsed result. The hints are
+// applied in order, the default scheme is http if no hints are given.
 func ParseURL(raw string, hints ...ParseHint) (*url.URL, error) {
 	if raw == "" {
 		return nil, nil
@@ -102,10 +166,8 @@ func ParseURL(raw string, hints ...ParseHint) (*url.URL, error) {
 		hints = append(hints, WithDefaultScheme("http"))
 	}
 
-	if !strings.Contains(raw, "://") {
-		for _, hint := range hints {
-			raw = hint(raw)
-		}
+	for _, hint := range hints {
+		raw = hint(raw)
 	}
 
 	return url.Parse(raw)
@@ -119,3 +181,44 @@ func WithDefaultScheme(scheme string) ParseHint {
 		return raw
 	}
 }
+
+// WithDefaultPort adds the port to the URLs without a port.
+func WithDefaultPort(port int) ParseHint {
+	return func(raw string) string {
+		return modifyURL(raw, func(u *url.URL) {
+			if u.Host != "" && u.Port() == "" {
+				u.Host = net.JoinHostPort(u.Hostname(), strconv.Itoa(port))
+			}
+		})
+	}
+}
+
+// WithBasePath sets the path of the URLs without a path, e.g. /kibana for
+// a Kibana served under /kibana by a reverse proxy.
+func WithBasePath(basePath string) ParseHint {
+	if !strings.HasPrefix(basePath, "/") {
+		basePath = "/" + basePath
+	}
+	return func(raw string) string {
+		return modifyURL(raw, func(u *url.URL) {
+			if u.Path == "" || u.Path == "/" {
+				u.Path = basePath
+			}
+		})
+	}
+}
+
+// modifyURL applies modify to the raw URL, which might have no scheme. The
+// raw URL is returned unmodified if it is invalid, for ParseURL to report it.
+func modifyURL(raw string, modify func(*url.URL)) string {
+	prefix := ""
+	if !strings.Contains(raw, "://") {
+		prefix = "http://"
+	}
+	u, err := url.Parse(prefix + raw)
+	if err != nil {
+		return raw
+	}
+	modify(u)
+	return strings.TrimPrefix(u.String(), prefix)
+}
@@ -0,0 +1,99 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package kibana
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/elastic/elastic-agent-libs/version"
+)
+
+// VersionInfo is the version and build of Kibana, as reported by its status
+// API.
+type VersionInfo struct {
+	Number      string `json:"number"`
+	BuildHash   string `json:"build_hash"`
+	BuildNumber int    `json:"build_number"`
+	Snapshot    bool   `json:"build_snapshot"`
+	BuildFlavor string `json:"build_flavor"`
+}
+
+// IncompatibleVersionError is returned when Kibana is older than the version
+// required by a request.
+type IncompatibleVersionError struct {
+	// Feature is the feature requiring the version, it can be empty.
+	Feature string
+	// Required is the minimum version of Kibana.
+	Required string
+	// Actual is the version of Kibana.
+	Actual version.V
+}
+
+func (e *IncompatibleVersionError) Error() string {
+	if e.Feature == "" {
+		return fmt.Sprintf("Kibana %s is not supported, it must be %s or newer", e.Actual.String(), e.Required)
+	}
+	return fmt.Sprintf("%s is not supported by Kibana %s, it must be %s or newer", e.Feature, e.Actual.String(), e.Required)
+}
+
+// GetVersionInfo returns the version and build read from Kibana. It is not
+// set if IgnoreVersion was set when creating the client.
+func (client *Client) GetVersionInfo() VersionInfo { return client.versionInfo }
+
+// CheckCompatibility returns an *IncompatibleVersionError if Kibana is older
+// than minVersion. The version of Kibana is read if it wasn't when creating
+// the client.
+func (client *Client) CheckCompatibility(minVersion string) error {
+	return client.CheckCompatibilityWithContext(context.Background(), minVersion)
+}
+
+// CheckCompatibilityWithContext is like CheckCompatibility, sending the
+// request reading the version with the given context.
+func (client *Client) CheckCompatibilityWithContext(ctx context.Context, minVersion string) error {
+	if !client.Version.IsValid() {
+		if err := client.readVersion(ctx); err != nil {
+			return err
+		}
+	}
+	return client.checkVersion("", minVersion)
+}
+
+// requireVersion returns an *IncompatibleVersionError if the feature needs a
+// newer Kibana. The check is skipped if the version of Kibana is unknown.
+func (client *Client) requireVersion(feature, minVersion string) error {
+	if !client.Version.IsValid() {
+		return nil
+	}
+	return client.checkVersion(feature, minVersion)
+}
+
+func (client *Client) checkVersion(feature, minVersion string) error {
+	constraint, err := version.NewConstraint(">=" + minVersion)
+	if err != nil {
+		return fmt.Errorf("invalid minimum version %q: %w", minVersion, err)
+	}
+	if !constraint.Check(&client.Version) {
+		return &IncompatibleVersionError{
+			Feature:  feature,
+			Required: minVersion,
+			Actual:   client.Version,
+		}
+	}
+	return nil
+}
@@ -0,0 +1,72 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package kibana
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/elastic/elastic-agent-libs/config"
+)
+
+func TestCheckCompatibility(t *testing.T) {
+	statusRequests := 0
+	kibanaTS := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == statusAPI {
+			statusRequests++
+			_, _ = w.Write([]byte(`{"version":{"number":"8.13.0","build_hash":"abc123","build_number":71234,"build_snapshot":true,"build_flavor":"traditional"}}`))
+		}
+	}))
+	defer kibanaTS.Close()
+
+	client, err := NewKibanaClient(config.MustNewConfigFrom(fmt.Sprintf(`
+protocol: http
+host: %s
+ignoreversion: true
+`, kibanaTS.Listener.Addr().String())), binaryName, v, commit, buildTime)
+	require.NoError(t, err)
+	assert.False(t, client.Version.IsValid())
+
+	// The version is read by the first check.
+	require.NoError(t, client.CheckCompatibility("8.13.0"))
+	assert.Equal(t, 1, statusRequests)
+	assert.Equal(t, "8.13.0-SNAPSHOT", client.Version.String())
+	assert.Equal(t, VersionInfo{
+		Number:      "8.13.0",
+		BuildHash:   "abc123",
+		BuildNumber: 71234,
+		Snapshot:    true,
+		BuildFlavor: "traditional",
+	}, client.GetVersionInfo())
+
+	require.NoError(t, client.CheckCompatibility("8.12"))
+
+	err = client.CheckCompatibility("8.14.0")
+	var versionErr *IncompatibleVersionError
+	require.ErrorAs(t, err, &versionErr)
+	assert.Equal(t, "8.14.0", versionErr.Required)
+	assert.Equal(t, "Kibana 8.13.0-SNAPSHOT is not supported, it must be 8.14.0 or newer", err.Error())
+	assert.Equal(t, 1, statusRequests)
+
+	assert.Error(t, client.CheckCompatibility("latest"))
+}
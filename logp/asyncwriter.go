@@ -0,0 +1,174 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package logp
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"sync"
+	"sync/atomic"
+
+	"go.uber.org/zap/zapcore"
+)
+
+// AsyncConfig contains the configuration options for writing log files
+// asynchronously. Entries are queued and written by a background goroutine
+// so logging is not blocked by the disk latency.
+type AsyncConfig struct {
+	Enabled bool `config:"enabled" yaml:"enabled"`
+	// QueueSize is the maximum number of entries waiting to be written.
+	QueueSize int `config:"queue_size" yaml:"queue_size"`
+	// Policy is what to do when the queue is full: block waits for space in
+	// the queue, drop discards the entry. The number of discarded entries
+	// is reported by DroppedEntries.
+	Policy string `config:"policy" yaml:"policy"`
+}
+
+const (
+	asyncPolicyBlock = "block"
+	asyncPolicyDrop  = "drop"
+
+	defaultAsyncQueueSize = 1024
+)
+
+// Validate checks the policy.
+func (c *AsyncConfig) Validate() error {
+	switch c.Policy {
+	case "", asyncPolicyBlock, asyncPolicyDrop:
+		return nil
+	default:
+		return fmt.Errorf("unknown async policy '%s', must be one of %s or %s", c.Policy, asyncPolicyBlock, asyncPolicyDrop)
+	}
+}
+
+var errAsyncWriterClosed = errors.New("async writer closed")
+
+// droppedEntries counts the entries dropped by all the async writers.
+var droppedEntries atomic.Uint64
+
+// DroppedEntries returns the number of log entries dropped because the
+// queue of an asynchronous output was full, or because the otel output
+// failed to send them.
+func DroppedEntries() uint64 {
+	return droppedEntries.Load()
+}
+
+type asyncItem struct {
+	data []byte
+	// synced is closed once the items before it are written and the writer
+	// is synced.
+	synced chan error
+}
+
+// asyncWriter is a zapcore.WriteSyncer writing to another one from a
+// background goroutine.
+type asyncWriter struct {
+	out  zapcore.WriteSyncer
+	drop bool
+
+	// mu protects closed, Close waits for the in-flight writes before
+	// closing the queue.
+	mu     sync.RWMutex
+	closed bool
+	queue  chan asyncItem
+	done   chan struct{}
+}
+
+func newAsyncWriter(out zapcore.WriteSyncer, cfg AsyncConfig) *asyncWriter {
+	size := cfg.QueueSize
+	if size <= 0 {
+		size = defaultAsyncQueueSize
+	}
+	w := &asyncWriter{
+		out:   out,
+		drop:  cfg.Policy == asyncPolicyDrop,
+		queue: make(chan asyncItem, size),
+		done:  make(chan struct{}),
+	}
+	go w.run()
+	return w
+}
+
+func (w *asyncWriter) run() {
+	defer close(w.done)
+	for item := range w.queue {
+		if item.synced != nil {
+			item.synced <- w.out.Sync()
+			continue
+		}
+		// There is no one to report the error to, like zap does when
+		// it fails to write an entry.
+		_, _ = w.out.Write(item.data)
+	}
+}
+
+// Write queues a copy of p. It blocks or drops the entry if the queue is
+// full, depending on the policy.
+func (w *asyncWriter) Write(p []byte) (int, error) {
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+	if w.closed {
+		return 0, errAsyncWriterClosed
+	}
+
+	item := asyncItem{data: append([]byte(nil), p...)}
+	if !w.drop {
+		w.queue <- item
+		return len(p), nil
+	}
+	select {
+	case w.queue <- item:
+	default:
+		droppedEntries.Add(1)
+	}
+	return len(p), nil
+}
+
+// Sync waits for the queued entries to be written, then syncs the
+// underlying writer.
+func (w *asyncWriter) Sync() error {
+	w.mu.RLock()
+	if w.closed {
+		w.mu.RUnlock()
+		return nil
+	}
+	synced := make(chan error, 1)
+	w.queue <- asyncItem{synced: synced}
+	w.mu.RUnlock()
+	return <-synced
+}
+
+// Close writes the queued entries, then closes the underlying writer if
+// it implements io.Closer.
+func (w *asyncWriter) Close() error {
+	w.mu.Lock()
+	if w.closed {
+		w.mu.Unlock()
+		return nil
+	}
+	w.closed = true
+	close(w.queue)
+	w.mu.Unlock()
+
+	<-w.done
+	if closer, ok := w.out.(io.Closer); ok {
+		return closer.Close()
+	}
+	return nil
+}
@@ -0,0 +1,134 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package logp
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// blockingWriter blocks the writes until unblock is closed.
+type blockingWriter struct {
+	unblock chan struct{}
+	mu      sync.Mutex
+	buf     bytes.Buffer
+	synced  int
+	closed  bool
+}
+
+func (w *blockingWriter) Write(p []byte) (int, error) {
+	<-w.unblock
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.buf.Write(p)
+}
+
+func (w *blockingWriter) Sync() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.synced++
+	return nil
+}
+
+func (w *blockingWriter) Close() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.closed = true
+	return nil
+}
+
+func TestAsyncWriterDrop(t *testing.T) {
+	out := &blockingWriter{unblock: make(chan struct{})}
+	w := newAsyncWriter(out, AsyncConfig{QueueSize: 2, Policy: asyncPolicyDrop})
+
+	before := DroppedEntries()
+	// The first write is taken by the background goroutine and blocks, the
+	// next two fill the queue.
+	for _, line := range []string{"1\n", "2\n", "3\n", "4\n", "5\n"} {
+		n, err := w.Write([]byte(line))
+		require.NoError(t, err)
+		assert.Equal(t, len(line), n)
+	}
+	dropped := DroppedEntries() - before
+	assert.GreaterOrEqual(t, dropped, uint64(2))
+
+	close(out.unblock)
+	require.NoError(t, w.Sync())
+	assert.Equal(t, 1, out.synced)
+	assert.Equal(t, 5-int(dropped), strings.Count(out.buf.String(), "\n"))
+
+	require.NoError(t, w.Close())
+	assert.True(t, out.closed)
+	_, err := w.Write([]byte("6\n"))
+	assert.ErrorIs(t, err, errAsyncWriterClosed)
+}
+
+func TestAsyncWriterBlock(t *testing.T) {
+	out := &blockingWriter{unblock: make(chan struct{})}
+	w := newAsyncWriter(out, AsyncConfig{QueueSize: 1, Policy: asyncPolicyBlock})
+
+	written := make(chan struct{})
+	go func() {
+		defer close(written)
+		for i := 0; i < 10; i++ {
+			_, _ = w.Write([]byte{'0' + byte(i), '\n'})
+		}
+	}()
+
+	select {
+	case <-written:
+		t.Fatal("writes must block while the queue is full")
+	default:
+	}
+	close(out.unblock)
+	<-written
+
+	require.NoError(t, w.Close())
+	assert.Equal(t, "0\n1\n2\n3\n4\n5\n6\n7\n8\n9\n", out.buf.String())
+}
+
+func TestAsyncFileOutput(t *testing.T) {
+	defer SaveGlobalLogger()()
+
+	dir := t.TempDir()
+	cfg := DefaultConfig(DefaultEnvironment)
+	cfg.Beat = "testbeat"
+	cfg.Files.Path = dir
+	cfg.Files.Async.Enabled = true
+	require.NoError(t, Configure(cfg))
+
+	for i := 0; i < 100; i++ {
+		L().Infow("async entry", "i", i)
+	}
+	require.NoError(t, L().Sync())
+
+	files, err := filepath.Glob(filepath.Join(dir, "testbeat*.ndjson"))
+	require.NoError(t, err)
+	require.Len(t, files, 1)
+	data, err := os.ReadFile(files[0])
+	require.NoError(t, err)
+	assert.Equal(t, 100, strings.Count(string(data), "async entry"))
+	require.NoError(t, L().Close())
+}
@@ -0,0 +1,114 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package logp
+
+import (
+	"os"
+	"strings"
+
+	"go.uber.org/zap/buffer"
+	"go.uber.org/zap/zapcore"
+)
+
+const (
+	colorReset = "\x1b[0m"
+	colorDim   = "\x1b[2m"
+	colorRed   = "\x1b[31m"
+)
+
+// colorConsoleEncoderConfig adds the level colors and dims the selectors
+// of a console encoder configuration.
+func colorConsoleEncoderConfig(cfg zapcore.EncoderConfig) zapcore.EncoderConfig {
+	cfg.EncodeLevel = zapcore.CapitalColorLevelEncoder
+	cfg.EncodeName = func(loggerName string, enc zapcore.PrimitiveArrayEncoder) {
+		enc.AppendString(colorDim + "[" + loggerName + "]" + colorReset)
+	}
+	return cfg
+}
+
+// colorEncoder highlights the error fields of the entries. They are moved
+// from the fields encoded by the console encoder to the end of the line.
+type colorEncoder struct {
+	zapcore.Encoder
+	lineEnding string
+}
+
+func newColorEncoder(cfg zapcore.EncoderConfig) zapcore.Encoder {
+	lineEnding := cfg.LineEnding
+	if lineEnding == "" {
+		lineEnding = zapcore.DefaultLineEnding
+	}
+	return colorEncoder{Encoder: zapcore.NewConsoleEncoder(cfg), lineEnding: lineEnding}
+}
+
+func (e colorEncoder) Clone() zapcore.Encoder {
+	return colorEncoder{Encoder: e.Encoder.Clone(), lineEnding: e.lineEnding}
+}
+
+func (e colorEncoder) EncodeEntry(entry zapcore.Entry, fields []zapcore.Field) (*buffer.Buffer, error) {
+	var (
+		errs  []zapcore.Field
+		other = fields
+	)
+	for i, field := range fields {
+		if _, ok := fieldError(field); ok {
+			if errs == nil {
+				other = append(make([]zapcore.Field, 0, len(fields)), fields[:i]...)
+			}
+			errs = append(errs, field)
+		} else if errs != nil {
+			other = append(other, field)
+		}
+	}
+
+	buf, err := e.Encoder.EncodeEntry(entry, other)
+	if err != nil || len(errs) == 0 {
+		return buf, err
+	}
+
+	line := strings.TrimSuffix(buf.String(), e.lineEnding)
+	buf.Reset()
+	buf.AppendString(line)
+	for _, field := range errs {
+		fieldErr, _ := fieldError(field)
+		buf.AppendString("\t" + colorRed + field.Key + ": " + fieldErr.Error() + colorReset)
+	}
+	buf.AppendString(e.lineEnding)
+	return buf, nil
+}
+
+// fieldError returns the error of the fields created by Error, NamedError
+// or zap.Error.
+func fieldError(field zapcore.Field) (error, bool) {
+	switch v := field.Interface.(type) {
+	case ecsError:
+		return v.err, field.Type == zapcore.ObjectMarshalerType
+	case error:
+		return v, field.Type == zapcore.ErrorType
+	}
+	return nil, false
+}
+
+// isTerminal returns true if f is a terminal.
+func isTerminal(f *os.File) bool {
+	info, err := f.Stat()
+	if err != nil {
+		return false
+	}
+	return info.Mode()&os.ModeCharDevice != 0
+}
@@ -18,7 +18,10 @@
 package logp
 
 import (
+	"fmt"
 	"time"
+
+	"github.com/elastic/elastic-agent-libs/clock"
 )
 
 // Config contains the configuration options for the logger. To create a Config
@@ -30,20 +33,62 @@ type Config struct {
 
 	toObserver  bool
 	toIODiscard bool
+	toNop       bool
 	ToStderr    bool `config:"to_stderr" yaml:"to_stderr"`
 	ToSyslog    bool `config:"to_syslog" yaml:"to_syslog"`
 	ToFiles     bool `config:"to_files" yaml:"to_files"`
 	ToEventLog  bool `config:"to_eventlog" yaml:"to_eventlog"`
+	ToJournald  bool `config:"to_journald" yaml:"to_journald"`
+	ToSocket    bool `config:"to_socket" yaml:"to_socket"`
+
+	Files      FileConfig       `config:"files"`
+	Syslog     SyslogConfig     `config:"syslog"`
+	Socket     SocketConfig     `config:"socket"`
+	Encoders   EncodersConfig   `config:"encoders"`
+	Metrics    MetricsConfig    `config:"metrics"`
+	Sampling   SamplingConfig   `config:"sampling"`
+	Dedup      DedupConfig      `config:"dedup"`
+	Throttle   ThrottleConfigs  `config:"throttle"`
+	RingBuffer RingBufferConfig `config:"ring_buffer"`
+	OTel       OTelConfig       `config:"otel"`
+	Routes     []RouteConfig    `config:"routes"`
+	Fallback   FallbackConfig   `config:"fallback"`
+	UserEvents UserEventsConfig `config:"user_events"`
+	Schema     SchemaConfig     `config:"schema"`
 
-	Files   FileConfig    `config:"files"`
-	Metrics MetricsConfig `config:"metrics"`
+	// StacktraceLevel is the minimum level of the entries logged with a
+	// stack trace, for the outputs that do not set their own. Stack traces
+	// are not logged by default.
+	StacktraceLevel *Level `config:"stack_trace_level" yaml:"stack_trace_level"`
+	// DevelopmentStacktraceLevel replaces StacktraceLevel in development
+	// mode, e.g. to capture the stack traces of warnings in development
+	// builds only.
+	DevelopmentStacktraceLevel *Level `config:"development_stack_trace_level" yaml:"development_stack_trace_level"`
+	// DisableStacktrace disables the stack traces of all the outputs.
+	DisableStacktrace bool `config:"disable_stacktrace" yaml:"disable_stacktrace"`
+
+	// TimestampFormat is the time format of the outputs that do not set
+	// their own, see EncoderConfig.TimeFormat. It defaults to iso8601.
+	TimestampFormat string `config:"timestamp_format" yaml:"timestamp_format"`
+	// Timezone is the timezone of the timestamps, utc or local. They are
+	// in the local timezone by default. The ndjson format ignores both
+	// settings, its timestamps are always in UTC.
+	Timezone string `config:"timezone" yaml:"timezone"`
 
 	environment Environment
 	addCaller   bool // Adds package and line number info to messages.
 	development bool // Controls how DPanic behaves.
+	clock       clock.Clock
+	reopeners   *fileReopeners // Collects the file outputs, see Reopen.
+	exporters   *otelExporters // Collects the otel exporters, closed when the logger is replaced.
 }
 
 // FileConfig contains the configuration options for the file output.
+//
+// Files are rotated when they reach MaxSize and, if Interval is set, on a
+// schedule. Intervals of one second, minute, hour, day (24h), week (168h),
+// month (720h) and year (8760h) are aligned to the calendar, e.g. an
+// interval of 24h produces one file per day, rotated at midnight.
 type FileConfig struct {
 	Path            string        `config:"path" yaml:"path"`
 	Name            string        `config:"name" yaml:"name"`
@@ -53,6 +98,62 @@ type FileConfig struct {
 	Interval        time.Duration `config:"interval"`
 	RotateOnStartup bool          `config:"rotateonstartup"`
 	RedirectStderr  bool          `config:"redirect_stderr" yaml:"redirect_stderr"`
+	Async           AsyncConfig   `config:"async" yaml:"async"`
+
+	// MaxTotalSize is the maximum number of bytes used by the active file
+	// and the rotated files, the oldest rotated files are removed to stay
+	// within it. There is no limit if it is 0.
+	MaxTotalSize uint64 `config:"max_total_size" yaml:"max_total_size"`
+	// RotatedPermissions are set on the files once rotated, they keep
+	// Permissions if it is 0.
+	RotatedPermissions uint32 `config:"rotated_permissions" yaml:"rotated_permissions"`
+	// Owner and Group of the files, as a name or a numeric id. They are
+	// not supported on Windows.
+	Owner string `config:"owner" yaml:"owner"`
+	Group string `config:"group" yaml:"group"`
+	// Fsync commits the entries to stable storage once written.
+	Fsync FsyncConfig `config:"fsync" yaml:"fsync"`
+}
+
+// FsyncConfig configures committing the log files to stable storage. It
+// is unpacked from a boolean, true syncing the file after every write, or
+// from an interval, e.g. 5s, batching the syncs of the entries written in
+// the interval.
+type FsyncConfig struct {
+	Enabled  bool          `yaml:"enabled"`
+	Interval time.Duration `yaml:"interval"`
+}
+
+// Unpack unpacks a boolean or an interval into an FsyncConfig.
+func (c *FsyncConfig) Unpack(in interface{}) error {
+	switch v := in.(type) {
+	case bool:
+		*c = FsyncConfig{Enabled: v}
+	case string:
+		interval, err := time.ParseDuration(v)
+		if err != nil {
+			return fmt.Errorf("invalid fsync interval '%s': %w", v, err)
+		}
+		if interval <= 0 {
+			return fmt.Errorf("fsync interval '%s' must be positive", v)
+		}
+		*c = FsyncConfig{Enabled: true, Interval: interval}
+	default:
+		return fmt.Errorf("fsync must be a boolean or an interval, got %T", in)
+	}
+	return nil
+}
+
+// Validate checks the timestamp format and the timezone.
+func (c *Config) Validate() error {
+	if err := validateTimeFormat(c.TimestampFormat); err != nil {
+		return err
+	}
+	switch c.Timezone {
+	case "", timezoneLocal, timezoneUTC:
+		return nil
+	}
+	return fmt.Errorf("unknown timezone '%s', must be %s or %s", c.Timezone, timezoneUTC, timezoneLocal)
 }
 
 // MetricsConfig contains configuration used by the monitor to output metrics into the logstream.
@@ -63,8 +164,22 @@ type MetricsConfig struct {
 	Period  time.Duration `config:"period"`
 }
 
+// SamplingConfig contains the configuration options for sampling log
+// entries, capping the number of identical entries logged per Tick. Entries
+// are identical if they have the same level and message. Within each Tick,
+// the first Initial entries are logged, then only every Thereafter-th entry
+// is; all other entries are dropped if Thereafter is 0.
+//
+// Sampling is disabled if Initial is 0.
+type SamplingConfig struct {
+	Initial    int           `config:"initial" yaml:"initial"`
+	Thereafter int           `config:"thereafter" yaml:"thereafter"`
+	Tick       time.Duration `config:"tick" yaml:"tick"`
+}
+
 const (
-	defaultLevel = InfoLevel
+	defaultLevel        = InfoLevel
+	defaultSamplingTick = time.Second
 )
 
 // DefaultConfig returns the default config options for a given environment the
@@ -79,11 +194,35 @@ func DefaultConfig(environment Environment) Config {
 			Permissions:     0600,
 			Interval:        0,
 			RotateOnStartup: true,
+			Async: AsyncConfig{
+				QueueSize: defaultAsyncQueueSize,
+				Policy:    asyncPolicyBlock,
+			},
 		},
 		Metrics: MetricsConfig{
 			Enabled: true,
 			Period:  30 * time.Second,
 		},
+		Sampling: SamplingConfig{
+			Tick: defaultSamplingTick,
+		},
+		RingBuffer: RingBufferConfig{
+			Size:  defaultRingBufferSize,
+			Level: DebugLevel,
+		},
+		Socket: defaultSocketConfig(),
+		UserEvents: UserEventsConfig{
+			Files: FileConfig{
+				MaxSize:         10 * 1024 * 1024,
+				MaxBackups:      7,
+				Permissions:     0600,
+				RotateOnStartup: true,
+			},
+		},
+		Schema: SchemaConfig{
+			ECS: true,
+		},
+		OTel:        defaultOTelConfig(),
 		environment: environment,
 		addCaller:   true,
 	}
@@ -113,6 +252,16 @@ func DefaultEventConfig(environment Environment) Config {
 	}
 }
 
+// NewNopConfig returns a configuration discarding all the entries. Unlike
+// ToDiscardOutput, the entries are not even checked or encoded, so
+// benchmarks can measure code paths without the logging overhead.
+func NewNopConfig() Config {
+	return Config{
+		Level: InfoLevel,
+		toNop: true,
+	}
+}
+
 // LogFilename returns the base filename to which logs will be written for
 // the "files" log output. If another log output is used, or `logging.files.name`
 // is unspecified, then the beat name will be returned.
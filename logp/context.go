@@ -0,0 +1,118 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package logp
+
+import (
+	"context"
+	"sync"
+
+	"go.elastic.co/apm/v2"
+	"go.uber.org/zap"
+)
+
+type loggerContextKey struct{}
+
+// TraceContext identifies the trace, transaction and span an entry was
+// logged in. Empty fields are not logged.
+type TraceContext struct {
+	TraceID       string
+	TransactionID string
+	SpanID        string
+}
+
+// TraceExtractor returns the trace context of ctx, or false if ctx has none.
+type TraceExtractor func(ctx context.Context) (TraceContext, bool)
+
+var traceExtractors = struct {
+	sync.RWMutex
+	list []TraceExtractor
+}{list: []TraceExtractor{apmTraceContext}}
+
+// RegisterTraceExtractor adds an extractor used by FromContext to correlate
+// the entries with traces. The Elastic APM extractor is registered by
+// default. For OpenTelemetry, register an extractor based on
+// trace.SpanContextFromContext:
+//
+//	logp.RegisterTraceExtractor(func(ctx context.Context) (logp.TraceContext, bool) {
+//		sc := trace.SpanContextFromContext(ctx)
+//		if !sc.IsValid() {
+//			return logp.TraceContext{}, false
+//		}
+//		return logp.TraceContext{TraceID: sc.TraceID().String(), SpanID: sc.SpanID().String()}, true
+//	})
+func RegisterTraceExtractor(extractor TraceExtractor) {
+	traceExtractors.Lock()
+	defer traceExtractors.Unlock()
+	traceExtractors.list = append(traceExtractors.list, extractor)
+}
+
+// NewContext returns a copy of ctx holding logger.
+func NewContext(ctx context.Context, logger *Logger) context.Context {
+	return context.WithValue(ctx, loggerContextKey{}, logger)
+}
+
+// FromContext returns the logger held by ctx, or the global logger if it
+// holds none. If ctx has a trace context, the trace.id, transaction.id and
+// span.id fields are added to the logger.
+func FromContext(ctx context.Context) *Logger {
+	logger, ok := ctx.Value(loggerContextKey{}).(*Logger)
+	if !ok {
+		logger = L()
+	}
+
+	traceExtractors.RLock()
+	defer traceExtractors.RUnlock()
+	for _, extract := range traceExtractors.list {
+		if tc, ok := extract(ctx); ok {
+			return logger.WithOptions(zap.Fields(tc.fields()...))
+		}
+	}
+	return logger
+}
+
+func (tc TraceContext) fields() []zap.Field {
+	fields := make([]zap.Field, 0, 3)
+	if tc.TraceID != "" {
+		fields = append(fields, zap.String("trace.id", tc.TraceID))
+	}
+	if tc.TransactionID != "" {
+		fields = append(fields, zap.String("transaction.id", tc.TransactionID))
+	}
+	if tc.SpanID != "" {
+		fields = append(fields, zap.String("span.id", tc.SpanID))
+	}
+	return fields
+}
+
+// apmTraceContext returns the trace context of the Elastic APM transaction
+// and span of ctx.
+func apmTraceContext(ctx context.Context) (TraceContext, bool) {
+	tx := apm.TransactionFromContext(ctx)
+	if tx == nil {
+		return TraceContext{}, false
+	}
+	txCtx := tx.TraceContext()
+	tc := TraceContext{
+		TraceID:       txCtx.Trace.String(),
+		TransactionID: txCtx.Span.String(),
+	}
+	if span := apm.SpanFromContext(ctx); span != nil {
+		tc.SpanID = span.TraceContext().Span.String()
+	}
+	return tc, true
+}
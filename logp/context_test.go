@@ -0,0 +1,84 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package logp
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.elastic.co/apm/v2"
+	"go.elastic.co/apm/v2/apmtest"
+)
+
+func TestFromContext(t *testing.T) {
+	log, logs := NewInMemoryLogger()
+	ctx := NewContext(context.Background(), log.Named("ctx"))
+
+	FromContext(ctx).Info("without trace")
+
+	tracer := apmtest.NewRecordingTracer()
+	defer tracer.Close()
+	tx, spans, _ := tracer.WithTransaction(func(ctx context.Context) {
+		ctx = NewContext(ctx, log.Named("ctx"))
+		FromContext(ctx).Info("in transaction")
+
+		span, ctx := apm.StartSpan(ctx, "span", "test")
+		defer span.End()
+		FromContext(ctx).Info("in span")
+	})
+	require.Len(t, spans, 1)
+
+	entries := logs.TakeAll()
+	require.Len(t, entries, 3)
+	for _, e := range entries {
+		assert.Equal(t, "ctx", e.LoggerName)
+	}
+	assert.Empty(t, entries[0].ContextMap())
+
+	traceID := apm.TraceID(tx.TraceID).String()
+	txID := apm.SpanID(tx.ID).String()
+	assert.Equal(t, map[string]interface{}{
+		"trace.id":       traceID,
+		"transaction.id": txID,
+	}, entries[1].ContextMap())
+	assert.Equal(t, map[string]interface{}{
+		"trace.id":       traceID,
+		"transaction.id": txID,
+		"span.id":        apm.SpanID(spans[0].ID).String(),
+	}, entries[2].ContextMap())
+}
+
+type testTraceKey struct{}
+
+func TestRegisterTraceExtractor(t *testing.T) {
+	RegisterTraceExtractor(func(ctx context.Context) (TraceContext, bool) {
+		tc, ok := ctx.Value(testTraceKey{}).(TraceContext)
+		return tc, ok
+	})
+
+	log, logs := NewInMemoryLogger()
+	ctx := NewContext(context.Background(), log)
+	ctx = context.WithValue(ctx, testTraceKey{}, TraceContext{TraceID: "abc", SpanID: "def"})
+	FromContext(ctx).Info("with custom trace")
+
+	entries := logs.TakeAll()
+	require.Len(t, entries, 1)
+	assert.Equal(t, map[string]interface{}{"trace.id": "abc", "span.id": "def"}, entries[0].ContextMap())
+}
@@ -50,7 +50,7 @@ func init() {
 		selectors:    map[string]struct{}{},
 		rootLogger:   zap.NewNop(),
 		globalLogger: zap.NewNop(),
-		level:        zap.NewAtomicLevel(),
+		levels:       newLevels(zapcore.InfoLevel),
 		logger:       newLogger(zap.NewNop(), ""),
 	})
 }
@@ -60,8 +60,11 @@ type coreLogger struct {
 	rootLogger   *zap.Logger            // Root logger without any options configured.
 	globalLogger *zap.Logger            // Logger used by legacy global functions (e.g. logp.Info).
 	logger       *Logger                // Logger that is the basis for all logp.Loggers.
-	level        zap.AtomicLevel        // The minimum level being printed
+	levels       *levels                // The minimum levels being printed
 	observedLogs *observer.ObservedLogs // Contains events generated while in observation mode (a testing mode).
+	ringBuffer   *ringBuffer            // Contains the most recent events, if enabled.
+	reopeners    *fileReopeners         // Rotators of the file outputs, see Reopen.
+	exporters    *otelExporters         // Exporters of the otel output, closed when the logger is replaced.
 }
 
 type closerCore struct {
@@ -82,15 +85,14 @@ func Configure(cfg Config) error {
 	return ConfigureWithOutputs(cfg)
 }
 
-func createSink(defaultLoggerCfg Config, outputs ...zapcore.Core) (zapcore.Core, zap.AtomicLevel, *observer.ObservedLogs, map[string]struct{}, error) {
+func createSink(defaultLoggerCfg Config, outputs ...zapcore.Core) (zapcore.Core, *levels, *observer.ObservedLogs, map[string]struct{}, error) {
 	var (
 		sink         zapcore.Core
 		observedLogs *observer.ObservedLogs
 		err          error
-		level        zap.AtomicLevel
 	)
 
-	level = zap.NewAtomicLevelAt(defaultLoggerCfg.Level.ZapLevel())
+	level := newLevels(defaultLoggerCfg.Level.ZapLevel())
 	// Build a single output (stderr has priority if more than one are enabled).
 	if defaultLoggerCfg.toObserver {
 		sink, observedLogs = observer.New(level)
@@ -100,6 +102,30 @@ func createSink(defaultLoggerCfg Config, outputs ...zapcore.Core) (zapcore.Core,
 	if err != nil {
 		return nil, level, nil, nil, fmt.Errorf("failed to build log output: %w", err)
 	}
+	sink, err = fallbackWrapper(sink, defaultLoggerCfg, level)
+	if err != nil {
+		return nil, level, nil, nil, fmt.Errorf("failed to build log fallback: %w", err)
+	}
+	sink, err = routingWrapper(sink, defaultLoggerCfg, level)
+	if err != nil {
+		return nil, level, nil, nil, fmt.Errorf("failed to build log routes: %w", err)
+	}
+	if defaultLoggerCfg.OTel.Enabled {
+		exporter, err := newOTelExporter(defaultLoggerCfg.Beat, defaultLoggerCfg.OTel)
+		if err != nil {
+			return nil, level, nil, nil, fmt.Errorf("failed to build otel log output: %w", err)
+		}
+		if defaultLoggerCfg.exporters != nil {
+			defaultLoggerCfg.exporters.add(exporter)
+		}
+		sink = newMultiCore(sink, newOTelCore(exporter, level))
+	}
+	if !defaultLoggerCfg.toObserver {
+		sink, err = userEventsWrapper(sink, defaultLoggerCfg, level)
+		if err != nil {
+			return nil, level, nil, nil, err
+		}
+	}
 
 	// Default logger is always discard, debug level below will
 	// possibly re-enable it.
@@ -125,30 +151,58 @@ func createSink(defaultLoggerCfg Config, outputs ...zapcore.Core) (zapcore.Core,
 			golog.SetOutput(_defaultGoLog)
 		}
 
-		sink = selectiveWrapper(sink, selectors)
+		sink = selectiveWrapper(sink, selectors, level)
 	}
 
+	// The caller supplied outputs keep their own LevelEnabler, only the
+	// output built from the configuration follows the runtime levels.
+	sink = newLevelCore(sink, level)
 	sink = newMultiCore(append(outputs, sink)...)
+	sink = dedupWrapper(sink, defaultLoggerCfg.Dedup)
+	sink = throttleWrapper(sink, defaultLoggerCfg.Throttle)
+	sink = samplingWrapper(sink, defaultLoggerCfg.Sampling)
+	sink = schemaWrapper(sink, defaultLoggerCfg.Schema)
 
 	return sink, level, observedLogs, selectors, err
 }
 
+// samplingWrapper wraps core with a sampler if sampling is enabled.
+func samplingWrapper(core zapcore.Core, cfg SamplingConfig) zapcore.Core {
+	if cfg.Initial <= 0 {
+		return core
+	}
+	tick := cfg.Tick
+	if tick <= 0 {
+		tick = defaultSamplingTick
+	}
+	return zapcore.NewSamplerWithOptions(core, tick, cfg.Initial, cfg.Thereafter)
+}
+
 // ConfigureWithOutputs configures the global logger to use an output created
 // from `defaultLoggerCfg` and all the outputs passed by `outputs`.
 // This function needs to be exported because it's used by `logp/configure`
 func ConfigureWithOutputs(defaultLoggerCfg Config, outputs ...zapcore.Core) error {
+	reopeners, exporters := &fileReopeners{}, &otelExporters{}
+	defaultLoggerCfg.reopeners, defaultLoggerCfg.exporters = reopeners, exporters
 	sink, level, observedLogs, selectors, err := createSink(defaultLoggerCfg, outputs...)
 	if err != nil {
+		_ = exporters.close()
 		return err
 	}
+	sink = withGlobalFields(withStats(sink))
+	sink, ring := withRingBuffer(sink, defaultLoggerCfg.RingBuffer)
+
 	root := zap.New(sink, makeOptions(defaultLoggerCfg)...)
 	storeLogger(&coreLogger{
 		selectors:    selectors,
 		rootLogger:   root,
 		globalLogger: root.WithOptions(zap.AddCallerSkip(1)),
 		logger:       newLogger(root, ""),
-		level:        level,
+		levels:       level,
 		observedLogs: observedLogs,
+		ringBuffer:   ring,
+		reopeners:    reopeners,
+		exporters:    exporters,
 	})
 	return nil
 }
@@ -171,8 +225,12 @@ func ConfigureWithOutputs(defaultLoggerCfg Config, outputs ...zapcore.Core) erro
 // If `defaultLoggerCfg.toObserver` is true, then `typedLoggerCfg` is ignored
 // and a single sink is used so all logs can be observed.
 func ConfigureWithTypedOutput(defaultLoggerCfg, typedLoggerCfg Config, key, value string, outputs ...zapcore.Core) error {
+	reopeners, exporters := &fileReopeners{}, &otelExporters{}
+	defaultLoggerCfg.reopeners, typedLoggerCfg.reopeners = reopeners, reopeners
+	defaultLoggerCfg.exporters = exporters
 	sink, level, observedLogs, selectors, err := createSink(defaultLoggerCfg, outputs...)
 	if err != nil {
+		_ = exporters.close()
 		return err
 	}
 
@@ -181,9 +239,11 @@ func ConfigureWithTypedOutput(defaultLoggerCfg, typedLoggerCfg Config, key, valu
 		typedCore = sink
 	} else {
 		typedCore, err = createLogOutput(typedLoggerCfg, level)
-	}
-	if err != nil {
-		return fmt.Errorf("could not create typed logger output: %w", err)
+		if err != nil {
+			_ = exporters.close()
+			return fmt.Errorf("could not create typed logger output: %w", err)
+		}
+		typedCore = newLevelCore(typedCore, level)
 	}
 
 	sink = &typedLoggerCore{
@@ -193,7 +253,9 @@ func ConfigureWithTypedOutput(defaultLoggerCfg, typedLoggerCfg Config, key, valu
 		value:       value,
 	}
 
-	sink = selectiveWrapper(sink, selectors)
+	sink = selectiveWrapper(sink, selectors, level)
+	sink = withGlobalFields(withStats(sink))
+	sink, ring := withRingBuffer(sink, defaultLoggerCfg.RingBuffer)
 
 	root := zap.New(sink, makeOptions(defaultLoggerCfg)...)
 	storeLogger(&coreLogger{
@@ -201,14 +263,19 @@ func ConfigureWithTypedOutput(defaultLoggerCfg, typedLoggerCfg Config, key, valu
 		rootLogger:   root,
 		globalLogger: root.WithOptions(zap.AddCallerSkip(1)),
 		logger:       newLogger(root, ""),
-		level:        level,
+		levels:       level,
 		observedLogs: observedLogs,
+		ringBuffer:   ring,
+		reopeners:    reopeners,
+		exporters:    exporters,
 	})
 	return nil
 }
 
 func createLogOutput(cfg Config, enab zapcore.LevelEnabler) (zapcore.Core, error) {
 	switch {
+	case cfg.toNop:
+		return zapcore.NewNopCore(), nil
 	case cfg.toIODiscard:
 		return makeDiscardOutput(cfg, enab)
 	case cfg.ToStderr:
@@ -217,6 +284,10 @@ func createLogOutput(cfg Config, enab zapcore.LevelEnabler) (zapcore.Core, error
 		return makeSyslogOutput(cfg, enab)
 	case cfg.ToEventLog:
 		return makeEventLogOutput(cfg, enab)
+	case cfg.ToJournald:
+		return makeJournaldOutput(cfg, enab)
+	case cfg.ToSocket:
+		return makeSocketOutput(cfg, enab)
 	case cfg.ToFiles:
 		return makeFileOutput(cfg, enab)
 	}
@@ -263,6 +334,25 @@ func ObserverLogs() *observer.ObservedLogs {
 	return loadLogger().observedLogs
 }
 
+// SaveGlobalLogger returns a function restoring the global logger, and the
+// output of the standard library logger, to their current configuration.
+// It is meant to be used by tests temporarily reconfiguring the global
+// logger.
+func SaveGlobalLogger() (restore func()) {
+	saved := loadLogger()
+	if saved.exporters != nil {
+		saved.exporters.save()
+	}
+	goLogOutput := golog.Writer()
+	return func() {
+		storeLogger(saved)
+		if saved.exporters != nil {
+			saved.exporters.restored()
+		}
+		golog.SetOutput(goLogOutput)
+	}
+}
+
 // Sync flushes any buffered log entries. Applications should take care to call
 // Sync before exiting.
 func Sync() error {
@@ -270,13 +360,19 @@ func Sync() error {
 }
 
 func makeOptions(cfg Config) []zap.Option {
-	var options []zap.Option
+	options := []zap.Option{
+		zap.WithFatalHook(terminalHook{next: zapcore.WriteThenFatal}),
+		zap.WithPanicHook(terminalHook{next: zapcore.WriteThenPanic}),
+	}
 	if cfg.addCaller {
 		options = append(options, zap.AddCaller())
 	}
 	if cfg.development {
 		options = append(options, zap.Development())
 	}
+	if level, ok := cfg.minStacktraceLevel(); ok {
+		options = append(options, zap.AddStacktrace(level))
+	}
 	if cfg.Beat != "" {
 		fields := []zap.Field{
 			zap.String("service.name", cfg.Beat),
@@ -288,7 +384,12 @@ func makeOptions(cfg Config) []zap.Option {
 
 func makeStderrOutput(cfg Config, enab zapcore.LevelEnabler) (zapcore.Core, error) {
 	stderr := zapcore.Lock(os.Stderr)
-	return newCore(buildEncoder(cfg), stderr, enab), nil
+	enc := cfg.Encoders.Stderr
+	if enc.Color && !isTerminal(os.Stderr) {
+		enc.Color = false
+	}
+	core := newCore(buildOutputEncoder(cfg, enc), stderr, enab)
+	return stacktraceWrapper(core, cfg, enc), nil
 }
 
 func makeDiscardOutput(cfg Config, enab zapcore.LevelEnabler) (zapcore.Core, error) {
@@ -297,15 +398,32 @@ func makeDiscardOutput(cfg Config, enab zapcore.LevelEnabler) (zapcore.Core, err
 }
 
 func makeSyslogOutput(cfg Config, enab zapcore.LevelEnabler) (zapcore.Core, error) {
-	core, err := newSyslog(buildEncoder(cfg), enab)
+	if cfg.Syslog.Host != "" {
+		core, err := newRemoteSyslog(cfg.Syslog, enab)
+		if err != nil {
+			return nil, err
+		}
+		return wrappedCore(core), nil
+	}
+
+	core, err := newSyslog(buildOutputEncoder(cfg, cfg.Encoders.Syslog), enab)
 	if err != nil {
 		return nil, err
 	}
-	return wrappedCore(core), nil
+	return stacktraceWrapper(wrappedCore(core), cfg, cfg.Encoders.Syslog), nil
 }
 
 func makeEventLogOutput(cfg Config, enab zapcore.LevelEnabler) (zapcore.Core, error) {
-	core, err := newEventLog(cfg.Beat, buildEncoder(cfg), enab)
+	core, err := newEventLog(cfg.Beat, buildOutputEncoder(cfg, cfg.Encoders.EventLog), enab)
+	// nolint: staticcheck,nolintlint // the implementation is OS-specific and some implementations always return errors
+	if err != nil {
+		return nil, err
+	}
+	return stacktraceWrapper(wrappedCore(core), cfg, cfg.Encoders.EventLog), nil
+}
+
+func makeJournaldOutput(cfg Config, enab zapcore.LevelEnabler) (zapcore.Core, error) {
+	core, err := newJournald(cfg.Beat, enab)
 	// nolint: staticcheck,nolintlint // the implementation is OS-specific and some implementations always return errors
 	if err != nil {
 		return nil, err
@@ -316,27 +434,61 @@ func makeEventLogOutput(cfg Config, enab zapcore.LevelEnabler) (zapcore.Core, er
 func makeFileOutput(cfg Config, enab zapcore.LevelEnabler) (zapcore.Core, error) {
 	filename := paths.Resolve(paths.Logs, filepath.Join(cfg.Files.Path, cfg.LogFilename()))
 
-	rotator, err := file.NewFileRotator(filename,
+	options := []file.RotatorOption{
 		file.MaxSizeBytes(cfg.Files.MaxSize),
 		file.MaxBackups(cfg.Files.MaxBackups),
 		file.Permissions(os.FileMode(cfg.Files.Permissions)),
 		file.Interval(cfg.Files.Interval),
 		file.RotateOnStartup(cfg.Files.RotateOnStartup),
 		file.RedirectStderr(cfg.Files.RedirectStderr),
-	)
+		file.OnRotate(countRotation),
+	}
+	if cfg.Files.MaxTotalSize != 0 {
+		options = append(options, file.MaxTotalSizeBytes(cfg.Files.MaxTotalSize))
+	}
+	if cfg.Files.RotatedPermissions != 0 {
+		options = append(options, file.RotatedPermissions(os.FileMode(cfg.Files.RotatedPermissions)))
+	}
+	if cfg.Files.Owner != "" || cfg.Files.Group != "" {
+		uid, gid, err := lookupFileOwner(cfg.Files.Owner, cfg.Files.Group)
+		if err != nil {
+			return nil, fmt.Errorf("failed to resolve log file owner: %w", err)
+		}
+		options = append(options, file.Owner(uid, gid))
+	}
+	if cfg.Files.Fsync.Enabled {
+		options = append(options, file.Fsync(cfg.Files.Fsync.Interval))
+	}
+	if cfg.clock != nil {
+		options = append(options, file.WithClock(cfg.clock))
+	}
+
+	rotator, err := file.NewFileRotator(filename, options...)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create file rotator: %w", err)
 	}
+	if cfg.reopeners != nil {
+		cfg.reopeners.add(rotator)
+	}
+
+	var (
+		ws     zapcore.WriteSyncer = rotator
+		closer io.Closer           = rotator
+	)
+	if cfg.Files.Async.Enabled {
+		async := newAsyncWriter(rotator, cfg.Files.Async)
+		ws, closer = async, async
+	}
 
 	// Keep the same behaviour from before we introduced the closerCore.
-	core, err := newCore(buildEncoder(cfg), rotator, enab), nil
+	core, err := newCore(buildOutputEncoder(cfg, cfg.Encoders.File), ws, enab), nil
 	if err != nil {
 		return core, err
 	}
 
 	cc := closerCore{
-		Core:   core,
-		Closer: rotator,
+		Core:   stacktraceWrapper(core, cfg, cfg.Encoders.File),
+		Closer: closer,
 	}
 
 	return &cc, err
@@ -371,18 +523,13 @@ func loadLogger() *coreLogger {
 func storeLogger(l *coreLogger) {
 	if old := loadLogger(); old != nil {
 		_ = old.rootLogger.Sync()
+		if old.exporters != nil && old != l {
+			_ = old.exporters.closeUnlessSaved()
+		}
 	}
 	atomic.StorePointer(&_log, unsafe.Pointer(l))
 }
 
-func SetLevel(lvl zapcore.Level) {
-	loadLogger().level.SetLevel(lvl)
-}
-
-func GetLevel() zapcore.Level {
-	return loadLogger().level.Level()
-}
-
 // newMultiCore creates a sink that sends to multiple cores.
 func newMultiCore(cores ...zapcore.Core) zapcore.Core {
 	return &multiCore{cores}
@@ -27,11 +27,15 @@ import (
 	"path/filepath"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 	"go.uber.org/zap"
 	"go.uber.org/zap/zapcore"
+
+	"github.com/elastic/elastic-agent-libs/clock"
+	"github.com/elastic/elastic-agent-libs/config"
 )
 
 func TestLogger(t *testing.T) {
@@ -642,3 +646,172 @@ func strField(key, val string) zapcore.Field {
 func skipField() zapcore.Field {
 	return zapcore.Field{Type: zapcore.SkipType}
 }
+
+func TestFileOutputWithClock(t *testing.T) {
+	dir := t.TempDir()
+	c := clock.NewFake(time.Date(2024, 3, 26, 12, 0, 0, 0, time.Local))
+
+	cfg := DefaultConfig(DefaultEnvironment)
+	cfg.ToFiles = true
+	cfg.ToStderr = false
+	cfg.Files.Name = "clock"
+	cfg.Files.Path = dir
+	cfg.Files.Interval = 24 * time.Hour
+	cfg.Files.RotateOnStartup = false
+	WithClock(c)(&cfg)
+
+	out, err := createLogOutput(cfg, zapcore.InfoLevel)
+	require.NoError(t, err)
+	logger := NewLogger("clock").WithOptions(zap.WrapCore(func(zapcore.Core) zapcore.Core { return out }))
+	t.Cleanup(func() { _ = logger.Close() })
+
+	logger.Info("first day")
+	c.Advance(24 * time.Hour)
+	logger.Info("second day")
+	require.NoError(t, logger.Sync())
+
+	assert.FileExists(t, filepath.Join(dir, "clock-20240326.ndjson"))
+	assert.FileExists(t, filepath.Join(dir, "clock-20240327.ndjson"))
+}
+
+func TestFsyncConfig(t *testing.T) {
+	for value, expected := range map[interface{}]FsyncConfig{
+		true:  {Enabled: true},
+		false: {},
+		"5s":  {Enabled: true, Interval: 5 * time.Second},
+	} {
+		cfg := DefaultConfig(DefaultEnvironment)
+		c := config.MustNewConfigFrom(map[string]interface{}{"files.fsync": value})
+		require.NoError(t, c.Unpack(&cfg))
+		assert.Equal(t, expected, cfg.Files.Fsync, "fsync: %v", value)
+	}
+
+	for _, value := range []interface{}{"never", "-1s"} {
+		cfg := DefaultConfig(DefaultEnvironment)
+		c := config.MustNewConfigFrom(map[string]interface{}{"files.fsync": value})
+		assert.Error(t, c.Unpack(&cfg), "fsync: %v", value)
+	}
+
+	dir := t.TempDir()
+	cfg := DefaultConfig(DefaultEnvironment)
+	cfg.ToFiles = true
+	cfg.ToStderr = false
+	cfg.Files.Name = "fsync"
+	cfg.Files.Path = dir
+	cfg.Files.Fsync = FsyncConfig{Enabled: true}
+
+	out, err := createLogOutput(cfg, zapcore.InfoLevel)
+	require.NoError(t, err)
+	logger := NewLogger("fsync").WithOptions(zap.WrapCore(func(zapcore.Core) zapcore.Core { return out }))
+	logger.Info("synced")
+	require.NoError(t, logger.Close())
+
+	files, err := filepath.Glob(filepath.Join(dir, "fsync*.ndjson"))
+	require.NoError(t, err)
+	require.Len(t, files, 1)
+	data, err := os.ReadFile(files[0])
+	require.NoError(t, err)
+	assert.Contains(t, string(data), "synced")
+}
+
+func TestSampling(t *testing.T) {
+	defer SaveGlobalLogger()()
+
+	cfg := Config{
+		Level: InfoLevel,
+		Sampling: SamplingConfig{
+			Initial:    2,
+			Thereafter: 3,
+			Tick:       time.Minute,
+		},
+	}
+	ToObserverOutput()(&cfg)
+	require.NoError(t, Configure(cfg))
+
+	log := NewLogger("sampling")
+	for i := 0; i < 10; i++ {
+		log.Info("retrying")
+	}
+	log.Warn("retrying")
+	log.Info("other message")
+
+	// 2 initial entries, then every 3rd one: the 5th and 8th.
+	assert.Equal(t, 4, ObserverLogs().FilterMessage("retrying").FilterLevelExact(zapcore.InfoLevel).Len())
+	assert.Equal(t, 1, ObserverLogs().FilterMessage("retrying").FilterLevelExact(zapcore.WarnLevel).Len())
+	assert.Equal(t, 1, ObserverLogs().FilterMessage("other message").Len())
+}
+
+func TestSamplingDisabledByDefault(t *testing.T) {
+	defer SaveGlobalLogger()()
+
+	cfg := DefaultConfig(DefaultEnvironment)
+	assert.Zero(t, cfg.Sampling.Initial)
+	ToObserverOutput()(&cfg)
+	require.NoError(t, Configure(cfg))
+
+	for i := 0; i < 200; i++ {
+		L().Info("flood")
+	}
+	assert.Equal(t, 200, ObserverLogs().Len())
+}
+
+func TestRingBuffer(t *testing.T) {
+	defer SaveGlobalLogger()()
+
+	cfg := Config{
+		Level:      InfoLevel,
+		RingBuffer: RingBufferConfig{Enabled: true, Size: 3, Level: DebugLevel},
+	}
+	ToObserverOutput()(&cfg)
+	require.NoError(t, Configure(cfg))
+
+	log := NewLogger("ring").With("component", "test")
+	for i := 0; i < 5; i++ {
+		log.Debugw("debug entry", "i", i)
+	}
+	log.Info("info entry")
+
+	assert.Equal(t, 1, ObserverLogs().Len(), "other outputs must keep their level")
+
+	entries := RecentEntries()
+	require.Len(t, entries, 3)
+	assert.Equal(t, "debug entry", entries[0].Message)
+	assert.Equal(t, int64(3), entries[0].ContextMap()["i"])
+	assert.Equal(t, "test", entries[0].ContextMap()["component"])
+	assert.Equal(t, "info entry", entries[2].Message)
+
+	var buf strings.Builder
+	require.NoError(t, WriteRecentEntries(&buf))
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	require.Len(t, lines, 3)
+	var decoded map[string]interface{}
+	require.NoError(t, json.Unmarshal([]byte(lines[2]), &decoded))
+	assert.Equal(t, "info entry", decoded["message"])
+}
+
+func TestRingBufferDisabled(t *testing.T) {
+	defer SaveGlobalLogger()()
+
+	cfg := DefaultConfig(DefaultEnvironment)
+	ToObserverOutput()(&cfg)
+	require.NoError(t, Configure(cfg))
+
+	L().Info("not kept")
+	assert.Empty(t, RecentEntries())
+}
+
+func TestNopConfig(t *testing.T) {
+	defer SaveGlobalLogger()()
+	require.NoError(t, Configure(NewNopConfig()))
+
+	logger := NewLogger("nop")
+	entry := zapcore.Entry{Level: zapcore.ErrorLevel, LoggerName: "nop", Message: "msg"}
+	assert.Nil(t, logger.Core().Check(entry, nil))
+
+	allocs := testing.AllocsPerRun(100, func() {
+		logger.Info("msg")
+		logger.Errorw("msg", "key", "value")
+		logger.Debugf("msg %d", 1)
+	})
+	assert.Zero(t, allocs)
+}
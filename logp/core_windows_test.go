@@ -22,7 +22,11 @@ package logp
 import (
 	"io"
 	"testing"
+	"time"
 
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
 	"go.uber.org/zap/zapcore"
 )
 
@@ -44,3 +48,23 @@ func TestEventLogOutputCanBeClosed(t *testing.T) {
 		t.Fatalf("Close must not return any error, got: %s", err)
 	}
 }
+
+func TestEventLogWithFields(t *testing.T) {
+	cfg := DefaultConfig(DefaultEnvironment)
+	core := &eventLogCore{
+		LevelEnabler: zapcore.DebugLevel,
+		encoder:      buildOutputEncoder(cfg, cfg.Encoders.EventLog),
+	}
+	child := core.With([]zapcore.Field{zap.String("component", "test")}).(*eventLogCore)
+
+	entry := zapcore.Entry{Level: zapcore.InfoLevel, Time: time.Now(), Message: "hello"}
+	msg, err := child.encode(entry, []zapcore.Field{zap.Int("count", 42)})
+	require.NoError(t, err)
+	assert.Contains(t, msg, "hello")
+	assert.Regexp(t, `"component": ?"test"`, msg)
+	assert.Regexp(t, `"count": ?42`, msg)
+
+	msg, err = core.encode(entry, nil)
+	require.NoError(t, err)
+	assert.NotContains(t, msg, "component", "the fields must only be added to the child core")
+}
@@ -0,0 +1,142 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package logp
+
+import (
+	"io"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// DedupConfig contains the configuration options for collapsing identical
+// consecutive entries. Entries are identical if they have the same level,
+// selector and message. Within Window of the first entry, the identical
+// entries following it are dropped; once the window ends, or a different
+// entry is logged, a copy of the entry is logged with the number of dropped
+// entries in the repeated field.
+//
+// Deduplication is disabled if Window is 0.
+type DedupConfig struct {
+	Window time.Duration `config:"window" yaml:"window"`
+}
+
+// dedupState is shared by a dedupCore and the cores created by its With,
+// so entries are collapsed whatever the logger fields.
+type dedupState struct {
+	window time.Duration
+
+	mu       sync.Mutex
+	last     *zapcore.Entry
+	core     zapcore.Core // Core that checked last.
+	repeated int
+	timer    *time.Timer
+}
+
+type dedupCore struct {
+	zapcore.Core
+	state *dedupState
+}
+
+// dedupWrapper wraps core to collapse identical consecutive entries if
+// deduplication is enabled.
+func dedupWrapper(core zapcore.Core, cfg DedupConfig) zapcore.Core {
+	if cfg.Window <= 0 {
+		return core
+	}
+	return &dedupCore{Core: core, state: &dedupState{window: cfg.Window}}
+}
+
+func (c *dedupCore) With(fields []zapcore.Field) zapcore.Core {
+	return &dedupCore{Core: c.Core.With(fields), state: c.state}
+}
+
+func (c *dedupCore) Check(entry zapcore.Entry, checked *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	if !c.Enabled(entry.Level) {
+		return checked
+	}
+
+	s := c.state
+	s.mu.Lock()
+	if s.last != nil && sameEntry(*s.last, entry) && entry.Time.Sub(s.last.Time) < s.window {
+		s.repeated++
+		if s.timer == nil {
+			s.timer = time.AfterFunc(s.window-entry.Time.Sub(s.last.Time), s.flush)
+		}
+		s.mu.Unlock()
+		return checked
+	}
+	flush := s.take()
+	s.last, s.core = &entry, c.Core
+	s.mu.Unlock()
+
+	flush()
+	return c.Core.Check(entry, checked)
+}
+
+// Sync logs the pending repeated entry before syncing.
+func (c *dedupCore) Sync() error {
+	c.state.flush()
+	return c.Core.Sync()
+}
+
+// Close logs the pending repeated entry, then closes the wrapped core if it
+// implements io.Closer.
+func (c *dedupCore) Close() error {
+	c.state.flush()
+	if closer, ok := c.Core.(io.Closer); ok {
+		return closer.Close()
+	}
+	return nil
+}
+
+func (s *dedupState) flush() {
+	s.mu.Lock()
+	flush := s.take()
+	s.last, s.core = nil, nil
+	s.mu.Unlock()
+	flush()
+}
+
+// take returns a function logging the last entry with the number of times
+// it was repeated, if it was, and resets the count. It must be called with
+// the lock held, while the returned function must be called without it.
+func (s *dedupState) take() func() {
+	if s.timer != nil {
+		s.timer.Stop()
+		s.timer = nil
+	}
+	if s.repeated == 0 {
+		return func() {}
+	}
+
+	entry, core, repeated := *s.last, s.core, s.repeated
+	s.repeated = 0
+	entry.Time = time.Now()
+	return func() {
+		if ce := core.Check(entry, nil); ce != nil {
+			ce.Write(zap.Int("repeated", repeated))
+		}
+	}
+}
+
+func sameEntry(a, b zapcore.Entry) bool {
+	return a.Level == b.Level && a.LoggerName == b.LoggerName && a.Message == b.Message
+}
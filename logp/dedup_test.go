@@ -0,0 +1,75 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package logp
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDedup(t *testing.T) {
+	defer SaveGlobalLogger()()
+
+	cfg := Config{Level: InfoLevel, Dedup: DedupConfig{Window: time.Hour}}
+	ToObserverOutput()(&cfg)
+	require.NoError(t, Configure(cfg))
+
+	log := NewLogger("dedup")
+	for i := 0; i < 5; i++ {
+		log.With("attempt", i).Warn("connection failed")
+	}
+	log.Info("connected")
+	log.Info("connected")
+	require.NoError(t, log.Sync())
+
+	entries := ObserverLogs().TakeAll()
+	require.Len(t, entries, 4)
+	assert.Equal(t, "connection failed", entries[0].Message)
+	assert.NotContains(t, entries[0].ContextMap(), "repeated")
+	assert.Equal(t, "connection failed", entries[1].Message)
+	assert.Equal(t, int64(4), entries[1].ContextMap()["repeated"])
+	assert.Equal(t, "connected", entries[2].Message)
+	assert.Equal(t, "connected", entries[3].Message)
+	assert.Equal(t, int64(1), entries[3].ContextMap()["repeated"], "Sync must log the pending repeated entry")
+}
+
+func TestDedupWindow(t *testing.T) {
+	defer SaveGlobalLogger()()
+
+	cfg := Config{Level: InfoLevel, Dedup: DedupConfig{Window: 50 * time.Millisecond}}
+	ToObserverOutput()(&cfg)
+	require.NoError(t, Configure(cfg))
+
+	log := NewLogger("dedup")
+	log.Warn("retrying")
+	log.Warn("retrying")
+	log.Warn("retrying")
+
+	assert.Eventually(t, func() bool {
+		return ObserverLogs().Len() == 2
+	}, 5*time.Second, 10*time.Millisecond, "the repeated entry must be logged at the end of the window")
+	entries := ObserverLogs().TakeAll()
+	assert.Equal(t, int64(2), entries[1].ContextMap()["repeated"])
+
+	// The window is over, the next entry starts a new one.
+	log.Warn("retrying")
+	assert.Equal(t, 1, ObserverLogs().Len())
+}
@@ -0,0 +1,191 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package logp
+
+import (
+	"fmt"
+	"reflect"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// Error returns a field expanding err into the ECS error fields:
+// error.message, error.type and, if err or an error it wraps has one,
+// error.stack_trace. The type is the one of the innermost error of the
+// chain of wrapped errors. If err wraps other errors, the chain is added to
+// error.chain, from err to the innermost error, and the innermost error to
+// error.root_cause, both with their message and type. The errors of joined
+// errors and multierrors are added to error.cause.
+//
+// The chains of wrapped errors and the nested errors of error.cause are cut
+// after 32 errors, in case an error wraps itself.
+//
+// A nil error returns a no-op field.
+func Error(err error) zap.Field {
+	return NamedError("error", err)
+}
+
+// NamedError is like Error, using key instead of error.
+func NamedError(key string, err error) zap.Field {
+	if err == nil {
+		return zap.Skip()
+	}
+	return zap.Object(key, ecsError{err: err})
+}
+
+// maxErrorDepth is the maximum length of the chains of wrapped errors and
+// nesting of the joined errors, so an error wrapping itself doesn't loop
+// forever.
+const maxErrorDepth = 32
+
+type ecsError struct {
+	err   error
+	depth int // Nesting of err in the error.cause of the logged error.
+}
+
+// errorGroup is implemented by go.uber.org/multierr and other multierror
+// packages.
+type errorGroup interface {
+	Errors() []error
+}
+
+func (e ecsError) MarshalLogObject(enc zapcore.ObjectEncoder) error {
+	chain := errorChain(e.err)
+	root := chain[len(chain)-1]
+	enc.AddString("message", e.err.Error())
+	enc.AddString("type", fmt.Sprintf("%T", root))
+
+	if st, ok := stackTrace(chain); ok {
+		enc.AddString("stack_trace", st)
+	}
+
+	if len(chain) > 1 {
+		if err := enc.AddArray("chain", chainErrors(chain)); err != nil {
+			return err
+		}
+		if err := enc.AddObject("root_cause", chainError{root}); err != nil {
+			return err
+		}
+	}
+
+	if e.depth >= maxErrorDepth {
+		return nil
+	}
+	if causes := joinedErrors(e.err); len(causes) > 0 {
+		return enc.AddArray("cause", ecsErrors{causes, e.depth + 1})
+	}
+	return nil
+}
+
+// stackTrace returns the stack trace of the first error of chain having
+// one, formatted with %+v. The errors of github.com/pkg/errors, and of the
+// packages following its convention, have a StackTrace method whose result
+// type is specific to each package, so the method is found by reflection.
+func stackTrace(chain []error) (string, bool) {
+	for _, err := range chain {
+		v := reflect.ValueOf(err)
+		if v.Kind() == reflect.Pointer && v.IsNil() {
+			continue
+		}
+		m := v.MethodByName("StackTrace")
+		if m.IsValid() && m.Type().NumIn() == 0 && m.Type().NumOut() == 1 {
+			return fmt.Sprintf("%+v", m.Call(nil)[0].Interface()), true
+		}
+	}
+	return "", false
+}
+
+type ecsErrors struct {
+	errs  []error
+	depth int
+}
+
+func (errs ecsErrors) MarshalLogArray(enc zapcore.ArrayEncoder) error {
+	for _, err := range errs.errs {
+		if err == nil {
+			continue
+		}
+		if err := enc.AppendObject(ecsError{err: err, depth: errs.depth}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// chainError is an error of a chain of wrapped errors, logged with its
+// message and type only.
+type chainError struct {
+	err error
+}
+
+func (e chainError) MarshalLogObject(enc zapcore.ObjectEncoder) error {
+	enc.AddString("message", e.err.Error())
+	enc.AddString("type", fmt.Sprintf("%T", e.err))
+	return nil
+}
+
+type chainErrors []error
+
+func (errs chainErrors) MarshalLogArray(enc zapcore.ArrayEncoder) error {
+	for _, err := range errs {
+		if err := enc.AppendObject(chainError{err}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// errorChain returns err followed by the errors it wraps, down to the
+// innermost one, stopping at joined errors or after maxErrorDepth errors.
+func errorChain(err error) []error {
+	chain := []error{err}
+	for len(chain) < maxErrorDepth {
+		var next error
+		switch e := err.(type) { //nolint:errorlint // Unwrapping one level at a time.
+		case interface{ Unwrap() error }:
+			next = e.Unwrap()
+		case interface{ Cause() error }:
+			next = e.Cause()
+		}
+		if next == nil {
+			return chain
+		}
+		chain = append(chain, next)
+		err = next
+	}
+	return chain
+}
+
+// joinedErrors returns the errors joined by the first multierror found
+// while unwrapping err, up to maxErrorDepth times.
+func joinedErrors(err error) []error {
+	for i := 0; err != nil && i < maxErrorDepth; i++ {
+		switch e := err.(type) { //nolint:errorlint // Unwrapping one level at a time.
+		case interface{ Unwrap() []error }:
+			return e.Unwrap()
+		case errorGroup:
+			return e.Errors()
+		case interface{ Unwrap() error }:
+			err = e.Unwrap()
+		default:
+			return nil
+		}
+	}
+	return nil
+}
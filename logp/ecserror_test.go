@@ -0,0 +1,147 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package logp
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestError(t *testing.T) {
+	t.Parallel()
+
+	_, statErr := os.Stat("/does/not/exist")
+	require.Error(t, statErr)
+
+	tests := map[string]struct {
+		err      error
+		expected map[string]interface{}
+	}{
+		"wrapped": {
+			err: fmt.Errorf("could not load config: %w", statErr),
+			expected: map[string]interface{}{
+				"message": "could not load config: stat /does/not/exist: no such file or directory",
+				"type":    "syscall.Errno",
+				"chain": []interface{}{
+					map[string]interface{}{"message": "could not load config: stat /does/not/exist: no such file or directory", "type": "*fmt.wrapError"},
+					map[string]interface{}{"message": "stat /does/not/exist: no such file or directory", "type": "*fs.PathError"},
+					map[string]interface{}{"message": "no such file or directory", "type": "syscall.Errno"},
+				},
+				"root_cause": map[string]interface{}{"message": "no such file or directory", "type": "syscall.Errno"},
+			},
+		},
+		"joined": {
+			err: fmt.Errorf("failed: %w", errors.Join(errors.New("first"), &fs.PathError{Op: "open", Path: "x", Err: fs.ErrNotExist})),
+			expected: map[string]interface{}{
+				"message": "failed: first\nopen x: file does not exist",
+				"type":    "*errors.joinError",
+				"chain": []interface{}{
+					map[string]interface{}{"message": "failed: first\nopen x: file does not exist", "type": "*fmt.wrapError"},
+					map[string]interface{}{"message": "first\nopen x: file does not exist", "type": "*errors.joinError"},
+				},
+				"root_cause": map[string]interface{}{"message": "first\nopen x: file does not exist", "type": "*errors.joinError"},
+				"cause": []interface{}{
+					map[string]interface{}{"message": "first", "type": "*errors.errorString"},
+					map[string]interface{}{
+						"message": "open x: file does not exist",
+						"type":    "*errors.errorString",
+						"chain": []interface{}{
+							map[string]interface{}{"message": "open x: file does not exist", "type": "*fs.PathError"},
+							map[string]interface{}{"message": "file does not exist", "type": "*errors.errorString"},
+						},
+						"root_cause": map[string]interface{}{"message": "file does not exist", "type": "*errors.errorString"},
+					},
+				},
+			},
+		},
+	}
+
+	for name, tc := range tests {
+		tc := tc
+		t.Run(name, func(t *testing.T) {
+			log, logs := NewInMemoryLogger()
+			log.Errorw("operation failed", Error(tc.err))
+
+			entries := logs.TakeAll()
+			require.Len(t, entries, 1)
+			assert.Equal(t, tc.expected, entries[0].ContextMap()["error"])
+		})
+	}
+}
+
+func TestErrorStackTrace(t *testing.T) {
+	t.Parallel()
+
+	log, logs := NewInMemoryLogger()
+	withStack := &stackError{msg: "with stack", stack: []string{"logp.TestErrorStackTrace"}}
+	log.Errorw("operation failed", Error(fmt.Errorf("wrapped: %w", withStack)))
+	log.Errorw("no error", Error(nil))
+
+	entries := logs.TakeAll()
+	require.Len(t, entries, 2)
+	fields := entries[0].ContextMap()["error"].(map[string]interface{})
+	assert.Equal(t, "*logp.stackError", fields["type"])
+	assert.Equal(t, "[logp.TestErrorStackTrace]", fields["stack_trace"])
+	assert.Len(t, fields["chain"], 2)
+	assert.Equal(t, map[string]interface{}{"message": "with stack", "type": "*logp.stackError"}, fields["root_cause"])
+	assert.Empty(t, entries[1].ContextMap())
+}
+
+func TestErrorWrappingItself(t *testing.T) {
+	t.Parallel()
+
+	log, logs := NewInMemoryLogger()
+	log.Errorw("wrapped", Error(&loopError{}))
+	log.Errorw("joined", Error(&loopJoinError{}))
+
+	entries := logs.TakeAll()
+	require.Len(t, entries, 2)
+	fields := entries[0].ContextMap()["error"].(map[string]interface{})
+	assert.Len(t, fields["chain"], maxErrorDepth)
+
+	depth := 0
+	for fields := entries[1].ContextMap()["error"].(map[string]interface{}); fields["cause"] != nil; depth++ {
+		fields = fields["cause"].([]interface{})[0].(map[string]interface{})
+	}
+	assert.Equal(t, maxErrorDepth, depth)
+}
+
+// stackError has a stack trace like the errors of github.com/pkg/errors.
+type stackError struct {
+	msg   string
+	stack []string
+}
+
+func (e *stackError) Error() string        { return e.msg }
+func (e *stackError) StackTrace() []string { return e.stack }
+
+type loopError struct{}
+
+func (e *loopError) Error() string { return "loop" }
+func (e *loopError) Unwrap() error { return e }
+
+type loopJoinError struct{}
+
+func (e *loopJoinError) Error() string   { return "loop" }
+func (e *loopJoinError) Unwrap() []error { return []error{e} }
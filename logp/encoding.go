@@ -18,6 +18,12 @@
 package logp
 
 import (
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
 	"go.uber.org/zap/zapcore"
 
 	"go.elastic.co/ecszap"
@@ -38,21 +44,248 @@ var baseEncodingConfig = zapcore.EncoderConfig{
 	EncodeName:     zapcore.FullNameEncoder,
 }
 
+// EncoderConfig contains the configuration options for the encoder of an
+// output.
+type EncoderConfig struct {
+	// Format is one of json, ndjson or console. It defaults to console for
+	// the syslog output and to json otherwise. The ndjson format writes the
+	// entries like the Beats write their events, to be ingested by Filebeat.
+	Format string `config:"format" yaml:"format"`
+	// TimeFormat is one of iso8601, iso8601_nanos, rfc3339, rfc3339nano,
+	// epoch, epoch_millis, epoch_nanos or a Go time layout. It defaults to
+	// the timestamp_format of the logging configuration.
+	TimeFormat string `config:"time_format" yaml:"time_format"`
+	// Caller adds the file and line of the log call, it defaults to true.
+	Caller *bool `config:"caller" yaml:"caller"`
+	// StacktraceLevel is the minimum level of the entries logged with a
+	// stack trace. It defaults to the stack_trace_level of the logging
+	// configuration.
+	StacktraceLevel *Level `config:"stacktrace_level" yaml:"stacktrace_level"`
+	// Color colors the levels, dims the selectors and highlights the error
+	// fields of the console format. It is only supported by the stderr
+	// output, and is disabled if stderr is not a terminal.
+	Color bool `config:"color" yaml:"color"`
+}
+
+// EncodersConfig contains the encoder configuration of each output. The
+// journald output and the remote syslog output use a structured format
+// and do not use an encoder.
+type EncodersConfig struct {
+	File     EncoderConfig `config:"file" yaml:"file"`
+	Stderr   EncoderConfig `config:"stderr" yaml:"stderr"`
+	Syslog   EncoderConfig `config:"syslog" yaml:"syslog"`
+	EventLog EncoderConfig `config:"eventlog" yaml:"eventlog"`
+}
+
+// Validate checks that only the stderr output is colored.
+func (c *EncodersConfig) Validate() error {
+	switch {
+	case c.File.Color:
+		return errors.New("color is not supported by the file output")
+	case c.Syslog.Color:
+		return errors.New("color is not supported by the syslog output")
+	case c.EventLog.Color:
+		return errors.New("color is not supported by the eventlog output")
+	}
+	return nil
+}
+
+const (
+	encoderFormatJSON    = "json"
+	encoderFormatNDJSON  = "ndjson"
+	encoderFormatConsole = "console"
+)
+
+var timeEncoders = map[string]zapcore.TimeEncoder{
+	"iso8601":      zapcore.ISO8601TimeEncoder,
+	"rfc3339":      zapcore.RFC3339TimeEncoder,
+	"rfc3339nano":  zapcore.RFC3339NanoTimeEncoder,
+	"epoch":        zapcore.EpochTimeEncoder,
+	"epoch_millis": zapcore.EpochMillisTimeEncoder,
+	"epoch_nanos":  zapcore.EpochNanosTimeEncoder,
+
+	"iso8601_nanos": zapcore.TimeEncoderOfLayout("2006-01-02T15:04:05.000000000Z0700"),
+}
+
+const (
+	timezoneLocal = "local"
+	timezoneUTC   = "utc"
+)
+
+// validateTimeFormat checks that format is empty, a known time format or a
+// Go time layout, which must contain the reference year.
+func validateTimeFormat(format string) error {
+	if _, ok := timeEncoders[format]; format == "" || ok || strings.Contains(format, "2006") {
+		return nil
+	}
+	return fmt.Errorf("unknown time format '%s'", format)
+}
+
+// timeEncoder returns the time encoder of an output using the encoder
+// configuration enc, or false to keep the default one. The time format of
+// the output takes precedence over the global one.
+func (cfg Config) timeEncoder(enc EncoderConfig) (zapcore.TimeEncoder, bool) {
+	format := enc.TimeFormat
+	if format == "" {
+		format = cfg.TimestampFormat
+	}
+	timeEncoder, ok := timeEncoders[format]
+	if !ok && format != "" {
+		timeEncoder, ok = zapcore.TimeEncoderOfLayout(format), true
+	}
+
+	var location func(time.Time) time.Time
+	switch cfg.Timezone {
+	case timezoneUTC:
+		location = time.Time.UTC
+	case timezoneLocal:
+		location = time.Time.Local
+	default:
+		return timeEncoder, ok
+	}
+	if !ok {
+		timeEncoder = zapcore.ISO8601TimeEncoder
+	}
+	return func(t time.Time, enc zapcore.PrimitiveArrayEncoder) {
+		timeEncoder(location(t), enc)
+	}, true
+}
+
+// Validate checks the format and the time format.
+func (c *EncoderConfig) Validate() error {
+	switch c.Format {
+	case "", encoderFormatJSON, encoderFormatNDJSON, encoderFormatConsole:
+	default:
+		return fmt.Errorf("unknown encoder format '%s', must be one of %s, %s or %s",
+			c.Format, encoderFormatJSON, encoderFormatNDJSON, encoderFormatConsole)
+	}
+	if c.Format == encoderFormatNDJSON && c.TimeFormat != "" {
+		return fmt.Errorf("time_format is not supported by the %s format", encoderFormatNDJSON)
+	}
+	return validateTimeFormat(c.TimeFormat)
+}
+
+// stacktraceLevel returns the stack trace level of an output using the
+// encoder configuration enc, or false if the output logs no stack traces.
+// The level of the output takes precedence over the development level,
+// used in development mode, which takes precedence over the global one.
+func (cfg Config) stacktraceLevel(enc EncoderConfig) (zapcore.Level, bool) {
+	switch {
+	case cfg.DisableStacktrace:
+		return 0, false
+	case enc.StacktraceLevel != nil:
+		return enc.StacktraceLevel.ZapLevel(), true
+	case cfg.development && cfg.DevelopmentStacktraceLevel != nil:
+		return cfg.DevelopmentStacktraceLevel.ZapLevel(), true
+	case cfg.StacktraceLevel != nil:
+		return cfg.StacktraceLevel.ZapLevel(), true
+	}
+	return 0, false
+}
+
+// minStacktraceLevel returns the lowest stack trace level of the outputs,
+// or false if none logs stack traces.
+func (cfg Config) minStacktraceLevel() (zapcore.Level, bool) {
+	var (
+		level zapcore.Level
+		found bool
+	)
+	// The outputs without an encoder configuration use the global level.
+	encoders := []EncoderConfig{cfg.Encoders.File, cfg.Encoders.Stderr, cfg.Encoders.Syslog, cfg.Encoders.EventLog, {}}
+	for _, enc := range encoders {
+		if l, ok := cfg.stacktraceLevel(enc); ok && (!found || l < level) {
+			level, found = l, true
+		}
+	}
+	return level, found
+}
+
 type encoderCreator func(cfg zapcore.EncoderConfig) zapcore.Encoder
 
 func buildEncoder(cfg Config) zapcore.Encoder {
+	return buildOutputEncoder(cfg, EncoderConfig{})
+}
+
+// buildOutputEncoder builds the encoder of an output using its encoder
+// configuration.
+func buildOutputEncoder(cfg Config, enc EncoderConfig) zapcore.Encoder {
 	var encCfg zapcore.EncoderConfig
 	var encCreator encoderCreator
-	if cfg.ToSyslog {
+	format := enc.Format
+	if format == "" && cfg.ToSyslog {
+		format = encoderFormatConsole
+	}
+	switch {
+	case format == encoderFormatConsole && cfg.ToSyslog:
 		encCfg = SyslogEncoderConfig()
 		encCreator = zapcore.NewConsoleEncoder
-	} else {
+	case format == encoderFormatConsole && enc.Color:
+		encCfg = colorConsoleEncoderConfig(ConsoleEncoderConfig())
+		encCreator = newColorEncoder
+	case format == encoderFormatConsole:
+		encCfg = ConsoleEncoderConfig()
+		encCreator = zapcore.NewConsoleEncoder
+	case format == encoderFormatNDJSON:
+		encCfg = JSONEncoderConfig()
+		encCreator = newNDJSONEncoder
+	default:
 		encCfg = JSONEncoderConfig()
 		encCreator = zapcore.NewJSONEncoder
 	}
+	if enc.Caller != nil && !*enc.Caller {
+		encCfg.CallerKey = ""
+	}
 
 	encCfg = ecszap.ECSCompatibleEncoderConfig(encCfg)
-	return encCreator(encCfg)
+	if timeEncoder, ok := cfg.timeEncoder(enc); ok && format != encoderFormatNDJSON {
+		encCfg.EncodeTime = timeEncoder
+	}
+	return countingEncoder{encCreator(encCfg)}
+}
+
+// stacktraceWrapper removes the stack trace of the entries below the stack
+// trace level of the output. If the level of the output is not set but the
+// one of another output is, it removes all the stack traces captured for the
+// other output.
+func stacktraceWrapper(core zapcore.Core, cfg Config, enc EncoderConfig) zapcore.Core {
+	level := zapcore.FatalLevel + 1
+	if l, ok := cfg.stacktraceLevel(enc); ok {
+		level = l
+	} else if _, ok := cfg.minStacktraceLevel(); !ok {
+		return core
+	}
+	return &stacktraceCore{Core: core, level: level}
+}
+
+type stacktraceCore struct {
+	zapcore.Core
+	level zapcore.Level
+}
+
+func (c *stacktraceCore) With(fields []zapcore.Field) zapcore.Core {
+	return &stacktraceCore{Core: c.Core.With(fields), level: c.level}
+}
+
+func (c *stacktraceCore) Check(entry zapcore.Entry, checked *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	if c.Enabled(entry.Level) {
+		return checked.AddCore(entry, c)
+	}
+	return checked
+}
+
+func (c *stacktraceCore) Write(entry zapcore.Entry, fields []zapcore.Field) error {
+	if entry.Level < c.level {
+		entry.Stack = ""
+	}
+	return c.Core.Write(entry, fields)
+}
+
+// Close calls Close on the wrapped core if it implements io.Closer.
+func (c *stacktraceCore) Close() error {
+	if closer, ok := c.Core.(io.Closer); ok {
+		return closer.Close()
+	}
+	return nil
 }
 
 func JSONEncoderConfig() zapcore.EncoderConfig {
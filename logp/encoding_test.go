@@ -0,0 +1,280 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package logp
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap/zapcore"
+
+	"github.com/elastic/elastic-agent-libs/config"
+)
+
+func TestBuildOutputEncoder(t *testing.T) {
+	entry := zapcore.Entry{
+		Level:      zapcore.InfoLevel,
+		Time:       time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC),
+		LoggerName: "enc",
+		Message:    "hello",
+		Caller:     zapcore.NewEntryCaller(0, "/src/pkg/file.go", 42, true),
+	}
+	noCaller := false
+
+	tests := map[string]struct {
+		cfg      Config
+		enc      EncoderConfig
+		expected string
+	}{
+		"default": {
+			expected: `{"log.level":"info","@timestamp":"2024-01-02T03:04:05.000Z","log.logger":"enc","log.origin":{"file.name":"pkg/file.go","file.line":42},"message":"hello"}`,
+		},
+		"console": {
+			enc:      EncoderConfig{Format: "console"},
+			expected: "2024-01-02T03:04:05.000Z\tINFO\t[enc]\tmap[file.line:42 file.name:pkg/file.go]\thello",
+		},
+		"syslog defaults to console": {
+			cfg:      Config{ToSyslog: true},
+			expected: "2024-01-02T03:04:05.000Z\tINFO\t[enc]\tmap[file.line:42 file.name:pkg/file.go]\thello",
+		},
+		"syslog json": {
+			cfg:      Config{ToSyslog: true},
+			enc:      EncoderConfig{Format: "json", Caller: &noCaller},
+			expected: `{"log.level":"info","@timestamp":"2024-01-02T03:04:05.000Z","log.logger":"enc","message":"hello"}`,
+		},
+		"time format without caller": {
+			enc:      EncoderConfig{TimeFormat: "rfc3339", Caller: &noCaller},
+			expected: `{"log.level":"info","@timestamp":"2024-01-02T03:04:05Z","log.logger":"enc","message":"hello"}`,
+		},
+	}
+
+	for name, tc := range tests {
+		tc := tc
+		t.Run(name, func(t *testing.T) {
+			buf, err := buildOutputEncoder(tc.cfg, tc.enc).EncodeEntry(entry, nil)
+			require.NoError(t, err)
+			assert.Equal(t, tc.expected, strings.TrimSpace(buf.String()))
+		})
+	}
+}
+
+func TestEncoderConfigValidate(t *testing.T) {
+	assert.NoError(t, (&EncoderConfig{}).Validate())
+	assert.NoError(t, (&EncoderConfig{Format: "console", TimeFormat: "epoch_millis"}).Validate())
+	assert.Error(t, (&EncoderConfig{Format: "logfmt"}).Validate())
+	assert.Error(t, (&EncoderConfig{TimeFormat: "unix"}).Validate())
+
+	c := config.MustNewConfigFrom(map[string]interface{}{
+		"encoders.stderr": map[string]interface{}{"format": "console", "stacktrace_level": "error"},
+	})
+	cfg := DefaultConfig(DefaultEnvironment)
+	require.NoError(t, c.Unpack(&cfg))
+	assert.Equal(t, "console", cfg.Encoders.Stderr.Format)
+	require.NotNil(t, cfg.Encoders.Stderr.StacktraceLevel)
+	assert.Equal(t, ErrorLevel, *cfg.Encoders.Stderr.StacktraceLevel)
+	assert.Nil(t, cfg.Encoders.File.StacktraceLevel)
+}
+
+func TestColorEncoder(t *testing.T) {
+	entry := zapcore.Entry{
+		Level:      zapcore.ErrorLevel,
+		Time:       time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC),
+		LoggerName: "enc",
+		Message:    "failed",
+	}
+	noCaller := false
+	enc := buildOutputEncoder(Config{}, EncoderConfig{Format: "console", Caller: &noCaller, Color: true})
+
+	buf, err := enc.EncodeEntry(entry, []zapcore.Field{String("host.name", "test"), Error(errors.New("boom"))})
+	require.NoError(t, err)
+	assert.Equal(t,
+		"2024-01-02T03:04:05.000Z\t\x1b[31mERROR\x1b[0m\t\x1b[2m[enc]\x1b[0m\tfailed\t{\"host.name\": \"test\"}\t\x1b[31merror: boom\x1b[0m\n",
+		buf.String())
+
+	c := config.MustNewConfigFrom(map[string]interface{}{
+		"encoders.file.color": true,
+	})
+	cfg := DefaultConfig(DefaultEnvironment)
+	assert.Error(t, c.Unpack(&cfg))
+
+	f, err := os.CreateTemp(t.TempDir(), "color")
+	require.NoError(t, err)
+	defer f.Close()
+	assert.False(t, isTerminal(f))
+}
+
+func TestNDJSONEncoder(t *testing.T) {
+	entry := zapcore.Entry{
+		Level:      zapcore.InfoLevel,
+		Time:       time.Date(2024, 1, 2, 4, 4, 5, 0, time.FixedZone("CET", 3600)),
+		LoggerName: "enc",
+		Message:    "line\n<tag> & \xff",
+	}
+	noCaller := false
+	enc := buildOutputEncoder(Config{}, EncoderConfig{Format: "ndjson", Caller: &noCaller})
+
+	buf, err := enc.EncodeEntry(entry, []zapcore.Field{String("host.name", "test")})
+	require.NoError(t, err)
+	assert.Equal(t,
+		`{"@timestamp":"2024-01-02T03:04:05.000Z","log.level":"info","log.logger":"enc","message":"line\n<tag> & \ufffd","host.name":"test"}`+"\n",
+		buf.String())
+
+	c := config.MustNewConfigFrom(map[string]interface{}{
+		"encoders.file.format":      "ndjson",
+		"encoders.file.time_format": "epoch",
+	})
+	cfg := DefaultConfig(DefaultEnvironment)
+	assert.Error(t, c.Unpack(&cfg))
+}
+
+func TestTimestampFormat(t *testing.T) {
+	entry := zapcore.Entry{
+		Level:   zapcore.InfoLevel,
+		Time:    time.Date(2024, 1, 2, 4, 4, 5, 7, time.FixedZone("CET", 3600)),
+		Message: "msg",
+	}
+	noCaller := false
+	encode := func(cfg Config, enc EncoderConfig) string {
+		enc.Caller = &noCaller
+		buf, err := buildOutputEncoder(cfg, enc).EncodeEntry(entry, nil)
+		require.NoError(t, err)
+		var doc map[string]interface{}
+		require.NoError(t, json.Unmarshal(buf.Bytes(), &doc))
+		return fmt.Sprint(doc["@timestamp"])
+	}
+
+	assert.Equal(t, "2024-01-02T04:04:05.000+0100", encode(Config{}, EncoderConfig{}))
+	assert.Equal(t, "2024-01-02T03:04:05.000Z", encode(Config{Timezone: "utc"}, EncoderConfig{}))
+	assert.Equal(t, "2024-01-02T03:04:05.000000007Z",
+		encode(Config{TimestampFormat: "iso8601_nanos", Timezone: "utc"}, EncoderConfig{}))
+	assert.Equal(t, "2024/01/02 03:04:05",
+		encode(Config{TimestampFormat: "2006/01/02 15:04:05", Timezone: "utc"}, EncoderConfig{}))
+	assert.Equal(t, "1.704164645e+09",
+		encode(Config{TimestampFormat: "iso8601_nanos"}, EncoderConfig{TimeFormat: "epoch"}),
+		"the output time format takes precedence")
+
+	for key, value := range map[string]string{
+		"timestamp_format":          "unknown",
+		"timezone":                  "Europe/Paris",
+		"encoders.file.time_format": "unknown",
+	} {
+		c := config.MustNewConfigFrom(map[string]interface{}{key: value})
+		cfg := DefaultConfig(DefaultEnvironment)
+		assert.Error(t, c.Unpack(&cfg), "%s: %s", key, value)
+	}
+}
+
+func TestFileOutputStacktraceLevel(t *testing.T) {
+	defer SaveGlobalLogger()()
+
+	dir := t.TempDir()
+	errorLevel := ErrorLevel
+	cfg := DefaultConfig(DefaultEnvironment)
+	cfg.Beat = "testbeat"
+	cfg.Files.Path = dir
+	cfg.Encoders.File.StacktraceLevel = &errorLevel
+	require.NoError(t, Configure(cfg))
+
+	L().Warn("warning")
+	L().Error("error")
+	require.NoError(t, L().Sync())
+
+	files, err := filepath.Glob(filepath.Join(dir, "testbeat*.ndjson"))
+	require.NoError(t, err)
+	require.Len(t, files, 1)
+	data, err := os.ReadFile(files[0])
+	require.NoError(t, err)
+
+	lines := strings.Split(strings.TrimSpace(string(data)), "\n")
+	require.Len(t, lines, 2)
+	var warning, errorEntry map[string]interface{}
+	require.NoError(t, json.Unmarshal([]byte(lines[0]), &warning))
+	require.NoError(t, json.Unmarshal([]byte(lines[1]), &errorEntry))
+	assert.NotContains(t, warning, "log.origin.stack_trace")
+	assert.Contains(t, errorEntry, "log.origin.stack_trace")
+	require.NoError(t, L().Close())
+}
+
+func TestStacktraceLevel(t *testing.T) {
+	warn, errorLevel, info := WarnLevel, ErrorLevel, InfoLevel
+
+	tests := map[string]struct {
+		cfg      Config
+		enc      EncoderConfig
+		expected zapcore.Level
+		ok       bool
+	}{
+		"none": {},
+		"global": {
+			cfg:      Config{StacktraceLevel: &errorLevel},
+			expected: zapcore.ErrorLevel, ok: true,
+		},
+		"output takes precedence": {
+			cfg:      Config{StacktraceLevel: &errorLevel},
+			enc:      EncoderConfig{StacktraceLevel: &info},
+			expected: zapcore.InfoLevel, ok: true,
+		},
+		"development level ignored in production": {
+			cfg:      Config{StacktraceLevel: &errorLevel, DevelopmentStacktraceLevel: &warn},
+			expected: zapcore.ErrorLevel, ok: true,
+		},
+		"development level": {
+			cfg:      Config{StacktraceLevel: &errorLevel, DevelopmentStacktraceLevel: &warn, development: true},
+			expected: zapcore.WarnLevel, ok: true,
+		},
+		"disabled": {
+			cfg: Config{StacktraceLevel: &errorLevel, DisableStacktrace: true},
+			enc: EncoderConfig{StacktraceLevel: &info},
+		},
+	}
+
+	for name, tc := range tests {
+		tc := tc
+		t.Run(name, func(t *testing.T) {
+			level, ok := tc.cfg.stacktraceLevel(tc.enc)
+			assert.Equal(t, tc.ok, ok)
+			if tc.ok {
+				assert.Equal(t, tc.expected, level)
+			}
+		})
+	}
+}
+
+func TestDevelopmentStacktraceLevel(t *testing.T) {
+	defer SaveGlobalLogger()()
+
+	warn := WarnLevel
+	require.NoError(t, DevelopmentSetup(ToObserverOutput(), func(cfg *Config) {
+		cfg.DevelopmentStacktraceLevel = &warn
+	}))
+
+	L().Info("info")
+	L().Warn("warning")
+	logs := ObserverLogs().TakeAll()
+	require.Len(t, logs, 2)
+	assert.Empty(t, logs[0].Stack)
+	assert.NotEmpty(t, logs[1].Stack)
+}
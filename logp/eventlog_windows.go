@@ -69,9 +69,14 @@ func newEventLog(appName string, encoder zapcore.Encoder, enab zapcore.LevelEnab
 	}, nil
 }
 
+// With returns a copy of the core including the given fields in all the
+// entries it writes.
 func (c *eventLogCore) With(fields []zapcore.Field) zapcore.Core {
 	clone := c.Clone()
 	clone.fields = append(clone.fields, fields...)
+	for _, field := range fields {
+		field.AddTo(clone.encoder)
+	}
 	return clone
 }
 
@@ -83,12 +88,11 @@ func (c *eventLogCore) Check(entry zapcore.Entry, checked *zapcore.CheckedEntry)
 }
 
 func (c *eventLogCore) Write(entry zapcore.Entry, fields []zapcore.Field) error {
-	buffer, err := c.encoder.EncodeEntry(entry, fields)
+	msg, err := c.encode(entry, fields)
 	if err != nil {
-		return fmt.Errorf("failed to encode entry: %w", err)
+		return err
 	}
 
-	msg := buffer.String()
 	switch entry.Level {
 	case zapcore.DebugLevel, zapcore.InfoLevel:
 		return c.log.Info(eventID, msg)
@@ -101,6 +105,16 @@ func (c *eventLogCore) Write(entry zapcore.Entry, fields []zapcore.Field) error
 	}
 }
 
+// encode returns the message of the event, with the fields added with With.
+func (c *eventLogCore) encode(entry zapcore.Entry, fields []zapcore.Field) (string, error) {
+	buffer, err := c.encoder.EncodeEntry(entry, fields)
+	if err != nil {
+		return "", fmt.Errorf("failed to encode entry: %w", err)
+	}
+	defer buffer.Free()
+	return buffer.String(), nil
+}
+
 func (c *eventLogCore) Sync() error {
 	return nil
 }
@@ -0,0 +1,70 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package logp
+
+import (
+	"fmt"
+
+	"go.uber.org/zap/zapcore"
+)
+
+// UserEventsConfig contains the configuration options for the output of
+// the loggers created by NewEventLogger. If it is disabled, their entries
+// go to the default output.
+type UserEventsConfig struct {
+	Enabled bool `config:"enabled" yaml:"enabled"`
+	// Files configures the file the user events are written to, with its
+	// own rotation and retention. The name defaults to the name of the
+	// default log file followed by -events.
+	Files FileConfig `config:"files" yaml:"files"`
+}
+
+// NewEventLogger returns a new Logger for the events the user may act
+// upon, like a failing input or an invalid policy, as opposed to the
+// internal debug logs. Its entries have the log.type field set to
+// UserEventType and are written to the user events output, if enabled.
+func NewEventLogger(selector string, options ...LogOption) *Logger {
+	return NewLogger(selector, options...).With(TypeKey, UserEventType)
+}
+
+// userEventsWrapper sends the entries of the event loggers to the user
+// events output, if it is enabled.
+func userEventsWrapper(core zapcore.Core, cfg Config, enab zapcore.LevelEnabler) (zapcore.Core, error) {
+	if !cfg.UserEvents.Enabled {
+		return core, nil
+	}
+
+	eventsCfg := withoutOutputs(cfg)
+	eventsCfg.ToFiles = true
+	eventsCfg.Files = cfg.UserEvents.Files
+	eventsCfg.Files.RedirectStderr = false
+	if eventsCfg.Files.Name == "" {
+		eventsCfg.Files.Name = cfg.LogFilename() + "-events"
+	}
+
+	eventsCore, err := createLogOutput(eventsCfg, enab)
+	if err != nil {
+		return nil, fmt.Errorf("failed to build the user events output: %w", err)
+	}
+	return &typedLoggerCore{
+		defaultCore: core,
+		typedCore:   eventsCore,
+		key:         TypeKey,
+		value:       UserEventType,
+	}, nil
+}
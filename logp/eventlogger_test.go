@@ -0,0 +1,62 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package logp
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEventLogger(t *testing.T) {
+	defer SaveGlobalLogger()()
+
+	dir := t.TempDir()
+	cfg := DefaultConfig(DefaultEnvironment)
+	cfg.Beat = "testbeat"
+	cfg.Files.Path = dir
+	cfg.UserEvents.Enabled = true
+	cfg.UserEvents.Files.Path = dir
+	require.NoError(t, Configure(cfg))
+
+	NewEventLogger("input").With("input.id", "logs").Warn("input failed")
+	NewLogger("input").Info("internal")
+	require.NoError(t, L().Sync())
+
+	read := func(pattern string) string {
+		files, err := filepath.Glob(filepath.Join(dir, pattern))
+		require.NoError(t, err)
+		require.Len(t, files, 1)
+		data, err := os.ReadFile(files[0])
+		require.NoError(t, err)
+		return string(data)
+	}
+
+	events := read("testbeat-events-*.ndjson")
+	assert.Contains(t, events, `"message":"input failed"`)
+	assert.Contains(t, events, `"log.type":"user_event"`)
+	assert.NotContains(t, events, "internal")
+
+	logs := read("testbeat-2*.ndjson")
+	assert.Contains(t, logs, "internal")
+	assert.NotContains(t, logs, "input failed")
+	require.NoError(t, L().Close())
+}
@@ -0,0 +1,178 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package logp
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"sync"
+	"time"
+
+	"go.uber.org/zap/zapcore"
+
+	"github.com/elastic/elastic-agent-libs/config"
+)
+
+// FallbackConfig contains the configuration options for the output used
+// when the default output fails to write an entry, e.g. because the disk
+// is full or the syslog daemon is gone. While it fails, a notice with the
+// number of failures is logged to the fallback output every
+// NoticeInterval. There is no fallback if Output is empty.
+type FallbackConfig struct {
+	// Output is one of file, stderr, syslog, eventlog, journald, socket or
+	// discard.
+	Output string `config:"output" yaml:"output"`
+	// File configures the file output, it defaults to the default file
+	// settings but Name must be set.
+	File           FileConfig    `config:"file" yaml:"file"`
+	NoticeInterval time.Duration `config:"notice_interval" yaml:"notice_interval"`
+}
+
+const defaultFallbackNoticeInterval = time.Minute
+
+// Unpack unpacks the fallback configuration, using the default file output
+// settings for the missing file settings.
+func (c *FallbackConfig) Unpack(cfg *config.C) error {
+	type fallbackConfig FallbackConfig
+	tmp := fallbackConfig{
+		File:           DefaultConfig(DefaultEnvironment).Files,
+		NoticeInterval: defaultFallbackNoticeInterval,
+	}
+	if err := cfg.Unpack(&tmp); err != nil {
+		return err
+	}
+	fallback := FallbackConfig(tmp)
+	if err := fallback.Validate(); err != nil {
+		return err
+	}
+	*c = fallback
+	return nil
+}
+
+// Validate checks the fallback output.
+func (c *FallbackConfig) Validate() error {
+	if c.Output == "" {
+		return nil
+	}
+	return validateNamedOutput(c.Output, c.File)
+}
+
+// fallbackState is shared by a fallbackCore and the cores created by its
+// With.
+type fallbackState struct {
+	output   string
+	interval time.Duration
+
+	mu         sync.Mutex
+	failures   int // Since the last notice.
+	lastNotice time.Time
+}
+
+// notice returns the notice to log to the fallback output after a failure,
+// or false if one was logged less than the interval ago.
+func (s *fallbackState) notice(now time.Time, err error) (zapcore.Entry, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.failures++
+	if !s.lastNotice.IsZero() && now.Sub(s.lastNotice) < s.interval {
+		return zapcore.Entry{}, false
+	}
+	notice := zapcore.Entry{
+		Level:      zapcore.ErrorLevel,
+		Time:       now,
+		LoggerName: "logp",
+		Message: fmt.Sprintf("Failed to write %d entries to the log output, writing them to the %s output: %v",
+			s.failures, s.output, err),
+	}
+	s.failures, s.lastNotice = 0, now
+	return notice, true
+}
+
+// fallbackCore writes the entries to the fallback core when the wrapped
+// core fails to write them.
+type fallbackCore struct {
+	zapcore.Core
+	fallback zapcore.Core
+	state    *fallbackState
+}
+
+// fallbackWrapper builds the fallback output and wraps core with it, if a
+// fallback output is configured.
+func fallbackWrapper(core zapcore.Core, cfg Config, enab zapcore.LevelEnabler) (zapcore.Core, error) {
+	fallbackCfg := cfg.Fallback
+	if fallbackCfg.Output == "" {
+		return core, nil
+	}
+	if err := fallbackCfg.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid fallback output: %w", err)
+	}
+	if fallbackCfg.NoticeInterval <= 0 {
+		fallbackCfg.NoticeInterval = defaultFallbackNoticeInterval
+	}
+
+	fallback, err := createNamedOutput(cfg, fallbackCfg.Output, fallbackCfg.File, enab)
+	if err != nil {
+		return nil, fmt.Errorf("failed to build the fallback output: %w", err)
+	}
+	return &fallbackCore{
+		Core:     core,
+		fallback: fallback,
+		state:    &fallbackState{output: fallbackCfg.Output, interval: fallbackCfg.NoticeInterval},
+	}, nil
+}
+
+func (c *fallbackCore) With(fields []zapcore.Field) zapcore.Core {
+	return &fallbackCore{Core: c.Core.With(fields), fallback: c.fallback.With(fields), state: c.state}
+}
+
+func (c *fallbackCore) Check(entry zapcore.Entry, checked *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	if c.Enabled(entry.Level) {
+		return checked.AddCore(entry, c)
+	}
+	return checked
+}
+
+func (c *fallbackCore) Write(entry zapcore.Entry, fields []zapcore.Field) error {
+	err := c.Core.Write(entry, fields)
+	if err == nil {
+		return nil
+	}
+
+	stats.writeFailures.Add(1)
+	if notice, ok := c.state.notice(time.Now(), err); ok {
+		_ = c.fallback.Write(notice, nil)
+	}
+	return c.fallback.Write(entry, fields)
+}
+
+func (c *fallbackCore) Sync() error {
+	return errors.Join(c.Core.Sync(), c.fallback.Sync())
+}
+
+// Close calls Close on the wrapped and fallback cores if they implement
+// io.Closer.
+func (c *fallbackCore) Close() error {
+	var errs []error
+	for _, core := range []zapcore.Core{c.Core, c.fallback} {
+		if closer, ok := core.(io.Closer); ok {
+			errs = append(errs, closer.Close())
+		}
+	}
+	return errors.Join(errs...)
+}
@@ -0,0 +1,99 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package logp
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+
+	"github.com/elastic/elastic-agent-libs/config"
+)
+
+type failingCore struct {
+	zapcore.Core
+	fail bool
+}
+
+func (c *failingCore) With([]zapcore.Field) zapcore.Core { return c }
+
+func (c *failingCore) Write(entry zapcore.Entry, fields []zapcore.Field) error {
+	if c.fail {
+		return errors.New("no space left on device")
+	}
+	return c.Core.Write(entry, fields)
+}
+
+func TestFallback(t *testing.T) {
+	primary, primaryLogs := observer.New(zapcore.DebugLevel)
+	failing := &failingCore{Core: primary}
+	fallback, fallbackLogs := observer.New(zapcore.DebugLevel)
+	core := &fallbackCore{
+		Core:     failing,
+		fallback: fallback,
+		state:    &fallbackState{output: "stderr", interval: time.Hour},
+	}
+	logger := zap.New(core)
+
+	logger.Info("written")
+	assert.Equal(t, 1, primaryLogs.Len())
+	assert.Zero(t, fallbackLogs.Len())
+
+	failuresBefore := GetStats().WriteFailures
+	failing.fail = true
+	logger.Info("first")
+	logger.Info("second", zap.String("key", "value"))
+
+	entries := fallbackLogs.TakeAll()
+	require.Len(t, entries, 3)
+	assert.Equal(t, zapcore.ErrorLevel, entries[0].Level)
+	assert.Contains(t, entries[0].Message, "Failed to write 1 entries")
+	assert.Contains(t, entries[0].Message, "no space left on device")
+	assert.Equal(t, "first", entries[1].Message)
+	assert.Equal(t, "second", entries[2].Message)
+	assert.Equal(t, "value", entries[2].ContextMap()["key"])
+	assert.Equal(t, failuresBefore+2, GetStats().WriteFailures)
+
+	// The next notice counts the failures since the previous one.
+	core.state.lastNotice = time.Now().Add(-2 * time.Hour)
+	logger.Info("third")
+	entries = fallbackLogs.TakeAll()
+	require.Len(t, entries, 2)
+	assert.Contains(t, entries[0].Message, "Failed to write 2 entries")
+}
+
+func TestFallbackConfigUnpack(t *testing.T) {
+	cfg := DefaultConfig(DefaultEnvironment)
+	c := config.MustNewConfigFrom(map[string]interface{}{
+		"fallback": map[string]interface{}{"output": "stderr"},
+	})
+	require.NoError(t, c.Unpack(&cfg))
+	assert.Equal(t, "stderr", cfg.Fallback.Output)
+	assert.Equal(t, defaultFallbackNoticeInterval, cfg.Fallback.NoticeInterval)
+
+	c = config.MustNewConfigFrom(map[string]interface{}{
+		"fallback": map[string]interface{}{"output": "file"},
+	})
+	require.Error(t, c.Unpack(&cfg))
+}
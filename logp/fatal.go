@@ -0,0 +1,79 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package logp
+
+import (
+	"sync"
+
+	"go.uber.org/zap/zapcore"
+)
+
+var fatalHooks = struct {
+	sync.Mutex
+	next  int
+	hooks map[int]func(zapcore.Entry)
+}{hooks: map[int]func(zapcore.Entry){}}
+
+// OnFatal registers a hook called with the entry when a logger configured
+// by the package logs at the Fatal or Panic level (or DPanic in development
+// mode). Hooks run after the entry is written and before the process exits
+// or panics, letting embedders flush their state, write a crash marker or
+// notify a supervisor. A hook that panics does not prevent the others from
+// running. Calling remove unregisters the hook.
+func OnFatal(hook func(entry zapcore.Entry)) (remove func()) {
+	fatalHooks.Lock()
+	defer fatalHooks.Unlock()
+	id := fatalHooks.next
+	fatalHooks.next++
+	fatalHooks.hooks[id] = hook
+
+	return func() {
+		fatalHooks.Lock()
+		defer fatalHooks.Unlock()
+		delete(fatalHooks.hooks, id)
+	}
+}
+
+func runFatalHooks(entry zapcore.Entry) {
+	fatalHooks.Lock()
+	hooks := make([]func(zapcore.Entry), 0, len(fatalHooks.hooks))
+	for id := 0; id < fatalHooks.next; id++ {
+		if hook, ok := fatalHooks.hooks[id]; ok {
+			hooks = append(hooks, hook)
+		}
+	}
+	fatalHooks.Unlock()
+
+	for _, hook := range hooks {
+		func() {
+			defer func() { _ = recover() }()
+			hook(entry)
+		}()
+	}
+}
+
+// terminalHook runs the hooks registered with OnFatal before the action
+// zap takes after writing a Fatal or Panic entry.
+type terminalHook struct {
+	next zapcore.CheckWriteHook
+}
+
+func (h terminalHook) OnWrite(ce *zapcore.CheckedEntry, fields []zapcore.Field) {
+	runFatalHooks(ce.Entry)
+	h.next.OnWrite(ce, fields)
+}
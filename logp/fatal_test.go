@@ -0,0 +1,75 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package logp
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+func TestOnFatalPanic(t *testing.T) {
+	defer SaveGlobalLogger()()
+
+	cfg := Config{Level: InfoLevel}
+	ToObserverOutput()(&cfg)
+	require.NoError(t, Configure(cfg))
+
+	var entries []zapcore.Entry
+	remove := OnFatal(func(entry zapcore.Entry) {
+		// The entry is written before the hooks run.
+		assert.Equal(t, 1, ObserverLogs().FilterMessage("boom").Len())
+		entries = append(entries, entry)
+	})
+	defer OnFatal(func(zapcore.Entry) { panic("faulty hook") })()
+
+	assert.PanicsWithValue(t, "boom", func() { NewLogger("fatal").Panic("boom") })
+	NewLogger("fatal").Error("not fatal")
+	require.Len(t, entries, 1)
+	assert.Equal(t, "boom", entries[0].Message)
+	assert.Equal(t, zapcore.PanicLevel, entries[0].Level)
+
+	remove()
+	assert.Panics(t, func() { NewLogger("fatal").Panic("again") })
+	assert.Len(t, entries, 1)
+}
+
+func TestOnFatalFatal(t *testing.T) {
+	var called bool
+	remove := OnFatal(func(entry zapcore.Entry) {
+		called = entry.Level == zapcore.FatalLevel && entry.Message == "fatal"
+	})
+	defer remove()
+
+	core, logs := observer.New(zapcore.InfoLevel)
+	logger := zap.New(core, zap.WithFatalHook(terminalHook{next: zapcore.WriteThenGoexit}))
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		logger.Fatal("fatal")
+	}()
+	<-done
+
+	assert.True(t, called)
+	assert.Equal(t, 1, logs.Len())
+}
@@ -18,7 +18,10 @@
 package logp
 
 import (
+	"sync"
+
 	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
 )
 
 // Field types for structured logging. Most fields are lazily marshaled so it
@@ -37,7 +40,6 @@ var (
 	Complex128s = zap.Complex128s
 	Duration    = zap.Duration
 	Durations   = zap.Durations
-	Error       = zap.Error
 	Errors      = zap.Errors
 	Float32     = zap.Float32
 	Float32s    = zap.Float32s
@@ -74,3 +76,25 @@ var (
 	Uintptr     = zap.Uintptr
 	Uintptrs    = zap.Uintptrs
 )
+
+// Lazy returns a field computed by f only when the entry is written, once
+// it passed the level and selector checks. It avoids expensive values, like
+// large marshalled configurations, being computed for disabled debug
+// entries. f is called at most once, whatever the number of outputs.
+func Lazy(f func() zapcore.Field) zapcore.Field {
+	return zap.Inline(&lazyField{f: f})
+}
+
+type lazyField struct {
+	once  sync.Once
+	f     func() zapcore.Field
+	field zapcore.Field
+}
+
+func (l *lazyField) MarshalLogObject(enc zapcore.ObjectEncoder) error {
+	l.once.Do(func() {
+		l.field = l.f()
+	})
+	l.field.AddTo(enc)
+	return nil
+}
@@ -0,0 +1,47 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package logp
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+func TestLazy(t *testing.T) {
+	logger, logs := NewInMemoryLogger(zap.IncreaseLevel(zapcore.InfoLevel))
+
+	calls := 0
+	config := func() zapcore.Field {
+		calls++
+		return String("config", "expensive")
+	}
+
+	logger.Debugw("disabled", Lazy(config))
+	assert.Zero(t, calls)
+
+	logger.Infow("enabled", Lazy(config))
+	entries := logs.TakeAll()
+	require.Len(t, entries, 1)
+	assert.Equal(t, "expensive", entries[0].ContextMap()["config"])
+	assert.Equal(t, "expensive", entries[0].ContextMap()["config"])
+	assert.Equal(t, 1, calls, "the field is computed once")
+}
@@ -0,0 +1,96 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+//go:build !windows
+
+package logp
+
+import (
+	"os"
+	"os/user"
+	"path/filepath"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+
+	"github.com/elastic/elastic-agent-libs/clock"
+	"github.com/elastic/elastic-agent-libs/file"
+)
+
+func TestLookupFileOwner(t *testing.T) {
+	current, err := user.Current()
+	require.NoError(t, err)
+
+	uid, gid, err := lookupFileOwner("", "")
+	require.NoError(t, err)
+	assert.Equal(t, -1, uid)
+	assert.Equal(t, -1, gid)
+
+	uid, gid, err = lookupFileOwner(current.Username, current.Gid)
+	require.NoError(t, err)
+	assert.Equal(t, current.Uid, strconv.Itoa(uid))
+	assert.Equal(t, current.Gid, strconv.Itoa(gid))
+
+	_, _, err = lookupFileOwner("no-such-user-for-logp", "")
+	assert.Error(t, err)
+}
+
+func TestFileOutputRotatedPermissionsAndOwner(t *testing.T) {
+	dir := t.TempDir()
+	c := clock.NewFake(time.Date(2024, 3, 26, 12, 0, 0, 0, time.Local))
+
+	cfg := DefaultConfig(DefaultEnvironment)
+	cfg.ToFiles = true
+	cfg.ToStderr = false
+	cfg.Files.Name = "perms"
+	cfg.Files.Path = dir
+	cfg.Files.Interval = 24 * time.Hour
+	cfg.Files.RotateOnStartup = false
+	cfg.Files.Permissions = 0o640
+	cfg.Files.RotatedPermissions = 0o440
+	cfg.Files.Owner = strconv.Itoa(os.Getuid())
+	cfg.Files.Group = strconv.Itoa(os.Getgid())
+	WithClock(c)(&cfg)
+
+	out, err := createLogOutput(cfg, zapcore.InfoLevel)
+	require.NoError(t, err)
+	logger := NewLogger("perms").WithOptions(zap.WrapCore(func(zapcore.Core) zapcore.Core { return out }))
+	t.Cleanup(func() { _ = logger.Close() })
+
+	logger.Info("first day")
+	c.Advance(24 * time.Hour)
+	logger.Info("second day")
+	require.NoError(t, logger.Sync())
+
+	for name, perm := range map[string]os.FileMode{
+		"perms-20240326.ndjson": 0o440,
+		"perms-20240327.ndjson": 0o640,
+	} {
+		info, err := file.Stat(filepath.Join(dir, name))
+		require.NoError(t, err)
+		assert.Equal(t, perm, info.Mode().Perm(), name)
+
+		uid, err := info.UID()
+		require.NoError(t, err)
+		assert.Equal(t, os.Getuid(), uid, name)
+	}
+}
@@ -0,0 +1,153 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package logp
+
+import (
+	"io"
+	"sync"
+	"time"
+
+	"go.uber.org/zap/zapcore"
+)
+
+// globalFieldsProvider returns fields added to every entry, caching them
+// for ttl if it is set.
+type globalFieldsProvider struct {
+	provide func() []zapcore.Field
+	ttl     time.Duration
+
+	mu      sync.Mutex
+	fields  []zapcore.Field
+	expires time.Time
+}
+
+func (p *globalFieldsProvider) get(now time.Time) []zapcore.Field {
+	if p.ttl <= 0 {
+		return p.provide()
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.fields == nil || !now.Before(p.expires) {
+		p.fields = p.provide()
+		p.expires = now.Add(p.ttl)
+	}
+	return p.fields
+}
+
+var globalFieldsProviders = struct {
+	sync.RWMutex
+	next      int
+	providers map[int]*globalFieldsProvider
+}{providers: map[int]*globalFieldsProvider{}}
+
+// AddGlobalFieldsProvider registers a function called for each entry logged
+// by the loggers configured by the package, adding the fields it returns to
+// the entry. It lets embedders add dynamic fields, like the policy revision
+// or the leader status, to every log line. The provider is only called for
+// the entries that are logged, it must be cheap and safe for concurrent
+// use. Calling remove unregisters it.
+func AddGlobalFieldsProvider(provider func() []zapcore.Field) (remove func()) {
+	return addGlobalFieldsProvider(&globalFieldsProvider{provide: provider})
+}
+
+// AddCachedGlobalFieldsProvider is like AddGlobalFieldsProvider, reusing
+// the fields returned by provider for ttl.
+func AddCachedGlobalFieldsProvider(provider func() []zapcore.Field, ttl time.Duration) (remove func()) {
+	return addGlobalFieldsProvider(&globalFieldsProvider{provide: provider, ttl: ttl})
+}
+
+func addGlobalFieldsProvider(p *globalFieldsProvider) (remove func()) {
+	globalFieldsProviders.Lock()
+	defer globalFieldsProviders.Unlock()
+	id := globalFieldsProviders.next
+	globalFieldsProviders.next++
+	globalFieldsProviders.providers[id] = p
+
+	return func() {
+		globalFieldsProviders.Lock()
+		defer globalFieldsProviders.Unlock()
+		delete(globalFieldsProviders.providers, id)
+	}
+}
+
+// globalFields returns the fields of all the providers, in registration
+// order.
+func globalFields(now time.Time) []zapcore.Field {
+	globalFieldsProviders.RLock()
+	defer globalFieldsProviders.RUnlock()
+	if len(globalFieldsProviders.providers) == 0 {
+		return nil
+	}
+	var fields []zapcore.Field
+	for id := 0; id < globalFieldsProviders.next; id++ {
+		if p, ok := globalFieldsProviders.providers[id]; ok {
+			fields = append(fields, p.get(now)...)
+		}
+	}
+	return fields
+}
+
+// globalFieldsCore adds the fields of the global fields providers to the
+// entries logged by the wrapped core.
+type globalFieldsCore struct {
+	zapcore.Core
+}
+
+func withGlobalFields(core zapcore.Core) zapcore.Core {
+	return &globalFieldsCore{Core: core}
+}
+
+func (c *globalFieldsCore) With(fields []zapcore.Field) zapcore.Core {
+	return &globalFieldsCore{Core: c.Core.With(fields)}
+}
+
+// Check checks the entry with the wrapped core, so the providers are only
+// called for the entries it logs.
+func (c *globalFieldsCore) Check(entry zapcore.Entry, checked *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	inner := c.Core.Check(entry, nil)
+	if inner == nil {
+		return checked
+	}
+	return checked.AddCore(entry, &globalFieldsWriter{Core: c.Core, checked: inner})
+}
+
+// globalFieldsWriter writes an entry checked by the wrapped core, with the
+// fields of the global fields providers.
+type globalFieldsWriter struct {
+	zapcore.Core
+	checked *zapcore.CheckedEntry
+}
+
+func (w *globalFieldsWriter) Write(entry zapcore.Entry, fields []zapcore.Field) error {
+	if extra := globalFields(entry.Time); len(extra) > 0 {
+		fields = append(fields[:len(fields):len(fields)], extra...)
+	}
+	// The caller and the stack trace are added to the entry after the
+	// check.
+	w.checked.Entry = entry
+	w.checked.Write(fields...)
+	return nil
+}
+
+// Close calls Close on the wrapped core if it implements io.Closer.
+func (c *globalFieldsCore) Close() error {
+	if closer, ok := c.Core.(io.Closer); ok {
+		return closer.Close()
+	}
+	return nil
+}
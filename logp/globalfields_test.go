@@ -0,0 +1,66 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package logp
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap/zapcore"
+)
+
+func TestGlobalFieldsProvider(t *testing.T) {
+	defer SaveGlobalLogger()()
+	require.NoError(t, DevelopmentSetup(ToObserverOutput(), WithLevel(InfoLevel)))
+
+	revision := 1
+	calls := 0
+	remove := AddGlobalFieldsProvider(func() []zapcore.Field {
+		calls++
+		return []zapcore.Field{Int("policy.revision", revision)}
+	})
+	defer remove()
+	defer AddCachedGlobalFieldsProvider(func() []zapcore.Field {
+		return []zapcore.Field{String("container.id", "abc")}
+	}, time.Hour)()
+
+	logger := NewLogger("fields")
+	logger.Debug("dropped")
+	assert.Zero(t, calls, "providers are not called for dropped entries")
+	assert.False(t, logger.IsDebug())
+
+	logger.Info("first")
+	revision = 2
+	logger.With("key", "value").Info("second")
+
+	logs := ObserverLogs().TakeAll()
+	require.Len(t, logs, 2)
+	assert.Equal(t, int64(1), logs[0].ContextMap()["policy.revision"])
+	assert.Equal(t, int64(2), logs[1].ContextMap()["policy.revision"])
+	assert.Equal(t, "abc", logs[1].ContextMap()["container.id"])
+	assert.Equal(t, "value", logs[1].ContextMap()["key"])
+	assert.Equal(t, "logp/globalfields_test.go", logs[0].Caller.TrimmedPath()[:len("logp/globalfields_test.go")])
+
+	remove()
+	logger.Info("third")
+	logs = ObserverLogs().TakeAll()
+	require.Len(t, logs, 1)
+	assert.NotContains(t, logs[0].ContextMap(), "policy.revision")
+}
@@ -0,0 +1,84 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package logp
+
+import (
+	"go.uber.org/zap"
+)
+
+// GRPCLogger writes the logs of gRPC through a Logger. It implements the
+// grpclog.LoggerV2 interface, install it with:
+//
+//	grpclog.SetLoggerV2(logp.NewGRPCLogger(logger, 0))
+//
+// gRPC info messages are logged at debug level, as they are only useful
+// when troubleshooting connection issues.
+type GRPCLogger struct {
+	logger    *zap.SugaredLogger
+	verbosity int
+}
+
+// NewGRPCLogger returns a GRPCLogger logging through logger. Verbose logs up
+// to the verbosity level are enabled, see V.
+func NewGRPCLogger(logger *Logger, verbosity int) *GRPCLogger {
+	return &GRPCLogger{
+		logger:    logger.sugar,
+		verbosity: verbosity,
+	}
+}
+
+// Info logs to the debug level.
+func (l *GRPCLogger) Info(args ...interface{}) { l.logger.Debug(args...) }
+
+// Infoln logs to the debug level.
+func (l *GRPCLogger) Infoln(args ...interface{}) { l.logger.Debugln(args...) }
+
+// Infof logs to the debug level.
+func (l *GRPCLogger) Infof(format string, args ...interface{}) { l.logger.Debugf(format, args...) }
+
+// Warning logs to the warning level.
+func (l *GRPCLogger) Warning(args ...interface{}) { l.logger.Warn(args...) }
+
+// Warningln logs to the warning level.
+func (l *GRPCLogger) Warningln(args ...interface{}) { l.logger.Warnln(args...) }
+
+// Warningf logs to the warning level.
+func (l *GRPCLogger) Warningf(format string, args ...interface{}) { l.logger.Warnf(format, args...) }
+
+// Error logs to the error level.
+func (l *GRPCLogger) Error(args ...interface{}) { l.logger.Error(args...) }
+
+// Errorln logs to the error level.
+func (l *GRPCLogger) Errorln(args ...interface{}) { l.logger.Errorln(args...) }
+
+// Errorf logs to the error level.
+func (l *GRPCLogger) Errorf(format string, args ...interface{}) { l.logger.Errorf(format, args...) }
+
+// Fatal logs to the fatal level, then calls os.Exit(1).
+func (l *GRPCLogger) Fatal(args ...interface{}) { l.logger.Fatal(args...) }
+
+// Fatalln logs to the fatal level, then calls os.Exit(1).
+func (l *GRPCLogger) Fatalln(args ...interface{}) { l.logger.Fatalln(args...) }
+
+// Fatalf logs to the fatal level, then calls os.Exit(1).
+func (l *GRPCLogger) Fatalf(format string, args ...interface{}) { l.logger.Fatalf(format, args...) }
+
+// V returns true if the verbosity level l is enabled.
+func (l *GRPCLogger) V(level int) bool {
+	return level <= l.verbosity
+}
@@ -0,0 +1,110 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package logp
+
+import (
+	"reflect"
+	"strings"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+// LogStore holds the entries written by a logger created with
+// NewInMemoryLogger. The embedded ObservedLogs gives access to the entries
+// and can be used to filter them.
+type LogStore struct {
+	*observer.ObservedLogs
+}
+
+// NewInMemoryLogger returns a logger at debug level that keeps its entries
+// in memory, along with the store to query them. Unlike TestingSetup it
+// does not change the global logger, so it can be used by tests running in
+// parallel.
+func NewInMemoryLogger(options ...LogOption) (*Logger, *LogStore) {
+	core, logs := observer.New(zapcore.DebugLevel)
+	return newLogger(zap.New(core), "", options...), &LogStore{ObservedLogs: logs}
+}
+
+// ContainsMessage returns true if the message of an entry contains msg.
+func (s *LogStore) ContainsMessage(msg string) bool {
+	for _, e := range s.All() {
+		if strings.Contains(e.Message, msg) {
+			return true
+		}
+	}
+	return false
+}
+
+// FieldEquals returns true if an entry has the field key set to value. Keys
+// of nested objects are separated by dots. Numbers are compared by value,
+// regardless of their type.
+func (s *LogStore) FieldEquals(key string, value interface{}) bool {
+	for _, e := range s.All() {
+		if v, ok := lookupField(e.ContextMap(), key); ok && valuesEqual(v, value) {
+			return true
+		}
+	}
+	return false
+}
+
+// CountAtLevel returns the number of entries at the given level.
+func (s *LogStore) CountAtLevel(level Level) int {
+	return s.FilterLevelExact(level.ZapLevel()).Len()
+}
+
+func lookupField(fields map[string]interface{}, key string) (interface{}, bool) {
+	if v, ok := fields[key]; ok {
+		return v, true
+	}
+	for i := 0; i < len(key); i++ {
+		if key[i] != '.' {
+			continue
+		}
+		if nested, ok := fields[key[:i]].(map[string]interface{}); ok {
+			if v, ok := lookupField(nested, key[i+1:]); ok {
+				return v, true
+			}
+		}
+	}
+	return nil, false
+}
+
+func valuesEqual(a, b interface{}) bool {
+	if reflect.DeepEqual(a, b) {
+		return true
+	}
+	fa, okA := toFloat(a)
+	fb, okB := toFloat(b)
+	return okA && okB && fa == fb
+}
+
+func toFloat(v interface{}) (float64, bool) {
+	rv := reflect.ValueOf(v)
+	switch rv.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return float64(rv.Int()), true
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return float64(rv.Uint()), true
+	case reflect.Float32, reflect.Float64:
+		return rv.Float(), true
+	default:
+		return 0, false
+	}
+}
@@ -0,0 +1,51 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package logp
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+)
+
+func TestInMemoryLogger(t *testing.T) {
+	t.Parallel()
+
+	log, logs := NewInMemoryLogger()
+	log.Named("store").With("component", "test").Debugw("first entry", "count", 3)
+	log.Infow("second entry", "nested", map[string]interface{}{"id": "abc"})
+	log.Warn("third entry")
+	log.Infow("fourth entry", zap.Namespace("ns"), zap.String("key", "value"))
+
+	assert.Equal(t, 4, logs.Len())
+	assert.True(t, logs.ContainsMessage("second"))
+	assert.False(t, logs.ContainsMessage("fifth"))
+
+	assert.True(t, logs.FieldEquals("component", "test"))
+	assert.True(t, logs.FieldEquals("count", 3))
+	assert.True(t, logs.FieldEquals("count", 3.0))
+	assert.False(t, logs.FieldEquals("count", 4))
+	assert.True(t, logs.FieldEquals("nested.id", "abc"))
+	assert.True(t, logs.FieldEquals("ns.key", "value"))
+	assert.False(t, logs.FieldEquals("missing", "value"))
+
+	assert.Equal(t, 1, logs.CountAtLevel(DebugLevel))
+	assert.Equal(t, 2, logs.CountAtLevel(InfoLevel))
+	assert.Equal(t, 0, logs.CountAtLevel(ErrorLevel))
+}
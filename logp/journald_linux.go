@@ -0,0 +1,222 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+//go:build linux
+
+package logp
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"syscall"
+
+	"go.uber.org/zap/zapcore"
+	"golang.org/x/sys/unix"
+)
+
+// journaldSocket is the socket of the journald native protocol.
+var journaldSocket = "/run/systemd/journal/socket"
+
+// journaldCore writes entries to journald using its native protocol, so that
+// zap fields become journald structured fields.
+type journaldCore struct {
+	zapcore.LevelEnabler
+	identifier string
+	conn       *net.UnixConn
+	addr       *net.UnixAddr
+	fields     []zapcore.Field
+}
+
+// newJournald returns a new Core that outputs to journald.
+func newJournald(identifier string, enab zapcore.LevelEnabler) (zapcore.Core, error) {
+	if identifier == "" {
+		identifier = filepath.Base(os.Args[0])
+	}
+
+	conn, err := net.ListenUnixgram("unixgram", &net.UnixAddr{Net: "unixgram"})
+	if err != nil {
+		return nil, fmt.Errorf("failed to create journald socket: %w", err)
+	}
+
+	return &journaldCore{
+		LevelEnabler: enab,
+		identifier:   identifier,
+		conn:         conn,
+		addr:         &net.UnixAddr{Name: journaldSocket, Net: "unixgram"},
+	}, nil
+}
+
+func (c *journaldCore) With(fields []zapcore.Field) zapcore.Core {
+	clone := *c
+	clone.fields = make([]zapcore.Field, 0, len(c.fields)+len(fields))
+	clone.fields = append(clone.fields, c.fields...)
+	clone.fields = append(clone.fields, fields...)
+	return &clone
+}
+
+func (c *journaldCore) Check(entry zapcore.Entry, checked *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	if c.Enabled(entry.Level) {
+		return checked.AddCore(entry, c)
+	}
+	return checked
+}
+
+func (c *journaldCore) Write(entry zapcore.Entry, fields []zapcore.Field) error {
+	var buf bytes.Buffer
+	appendJournaldField(&buf, "MESSAGE", entry.Message)
+	appendJournaldField(&buf, "PRIORITY", strconv.Itoa(journaldPriority(entry.Level)))
+	appendJournaldField(&buf, "SYSLOG_IDENTIFIER", c.identifier)
+	if entry.LoggerName != "" {
+		appendJournaldField(&buf, "LOGGER", entry.LoggerName)
+	}
+	if entry.Caller.Defined {
+		appendJournaldField(&buf, "CODE_FILE", entry.Caller.File)
+		appendJournaldField(&buf, "CODE_LINE", strconv.Itoa(entry.Caller.Line))
+		if entry.Caller.Function != "" {
+			appendJournaldField(&buf, "CODE_FUNC", entry.Caller.Function)
+		}
+	}
+	if entry.Stack != "" {
+		appendJournaldField(&buf, "STACK_TRACE", entry.Stack)
+	}
+
+	enc := zapcore.NewMapObjectEncoder()
+	for _, field := range c.fields {
+		field.AddTo(enc)
+	}
+	for _, field := range fields {
+		field.AddTo(enc)
+	}
+	for key, value := range enc.Fields {
+		name := journaldFieldName(key)
+		if name == "" {
+			continue
+		}
+		appendJournaldField(&buf, name, journaldValue(value))
+	}
+
+	return c.send(buf.Bytes())
+}
+
+// send writes a datagram to journald. Datagrams too large for the socket
+// are written to a temporary file whose descriptor is sent instead.
+func (c *journaldCore) send(data []byte) error {
+	_, _, err := c.conn.WriteMsgUnix(data, nil, c.addr)
+	if err == nil {
+		return nil
+	}
+	if !errors.Is(err, syscall.EMSGSIZE) && !errors.Is(err, syscall.ENOBUFS) {
+		return fmt.Errorf("failed to write to journald: %w", err)
+	}
+
+	f, err := os.CreateTemp("/dev/shm", "journal.")
+	if err != nil {
+		return fmt.Errorf("failed to write large entry to journald: %w", err)
+	}
+	defer f.Close()
+	_ = os.Remove(f.Name())
+	if _, err := f.Write(data); err != nil {
+		return fmt.Errorf("failed to write large entry to journald: %w", err)
+	}
+
+	rights := unix.UnixRights(int(f.Fd()))
+	if _, _, err := c.conn.WriteMsgUnix(nil, rights, c.addr); err != nil {
+		return fmt.Errorf("failed to write large entry to journald: %w", err)
+	}
+	return nil
+}
+
+func (c *journaldCore) Sync() error {
+	return nil
+}
+
+// Close closes the socket used to write to journald.
+func (c *journaldCore) Close() error {
+	return c.conn.Close()
+}
+
+// journaldPriority maps zap levels to syslog priorities.
+func journaldPriority(level zapcore.Level) int {
+	switch level {
+	case zapcore.DebugLevel:
+		return 7
+	case zapcore.InfoLevel:
+		return 6
+	case zapcore.WarnLevel:
+		return 4
+	case zapcore.ErrorLevel:
+		return 3
+	default:
+		return 2
+	}
+}
+
+// appendJournaldField appends a field using the native protocol encoding.
+// Values containing new lines are written with their length as prefix.
+func appendJournaldField(buf *bytes.Buffer, name, value string) {
+	buf.WriteString(name)
+	if !strings.Contains(value, "\n") {
+		buf.WriteByte('=')
+		buf.WriteString(value)
+		buf.WriteByte('\n')
+		return
+	}
+
+	buf.WriteByte('\n')
+	_ = binary.Write(buf, binary.LittleEndian, uint64(len(value)))
+	buf.WriteString(value)
+	buf.WriteByte('\n')
+}
+
+// journaldFieldName converts a zap field key to a valid journald field name:
+// upper case letters, digits and underscores, not starting with an
+// underscore or a digit, and at most 64 characters long.
+func journaldFieldName(key string) string {
+	name := []byte(strings.ToUpper(key))
+	for i, b := range name {
+		if (b < 'A' || b > 'Z') && (b < '0' || b > '9') {
+			name[i] = '_'
+		}
+	}
+	trimmed := strings.TrimLeft(string(name), "_0123456789")
+	if len(trimmed) > 64 {
+		trimmed = trimmed[:64]
+	}
+	return trimmed
+}
+
+func journaldValue(value interface{}) string {
+	switch v := value.(type) {
+	case string:
+		return v
+	case fmt.Stringer:
+		return v.String()
+	}
+	b, err := json.Marshal(value)
+	if err != nil {
+		return fmt.Sprint(value)
+	}
+	return string(b)
+}
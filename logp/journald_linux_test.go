@@ -0,0 +1,99 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+//go:build linux
+
+package logp
+
+import (
+	"bytes"
+	"encoding/binary"
+	"net"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+func TestJournaldOutput(t *testing.T) {
+	socket := filepath.Join(t.TempDir(), "journal.sock")
+	server, err := net.ListenUnixgram("unixgram", &net.UnixAddr{Name: socket, Net: "unixgram"})
+	require.NoError(t, err)
+	defer server.Close()
+
+	defer func(old string) { journaldSocket = old }(journaldSocket)
+	journaldSocket = socket
+
+	cfg := DefaultConfig(DefaultEnvironment)
+	cfg.Beat = "testbeat"
+	cfg.ToJournald = true
+	out, err := createLogOutput(cfg, zapcore.DebugLevel)
+	require.NoError(t, err)
+	logger := NewLogger("journald").WithOptions(zap.WrapCore(func(zapcore.Core) zapcore.Core { return out }))
+	t.Cleanup(func() { _ = logger.Close() })
+
+	logger.With("component.id", "filestream-1").Warnw("multi\nline", "message_id", "a1b2", "count", 3)
+
+	buf := make([]byte, 64*1024)
+	n, err := server.Read(buf)
+	require.NoError(t, err)
+	fields := parseJournaldDatagram(t, buf[:n])
+
+	assert.Equal(t, "multi\nline", fields["MESSAGE"])
+	assert.Equal(t, "4", fields["PRIORITY"])
+	assert.Equal(t, "testbeat", fields["SYSLOG_IDENTIFIER"])
+	assert.Equal(t, "journald", fields["LOGGER"])
+	assert.Equal(t, "a1b2", fields["MESSAGE_ID"])
+	assert.Equal(t, "3", fields["COUNT"])
+	assert.Equal(t, "filestream-1", fields["COMPONENT_ID"])
+}
+
+func TestJournaldFieldName(t *testing.T) {
+	assert.Equal(t, "COMPONENT_ID", journaldFieldName("component.id"))
+	assert.Equal(t, "KEY", journaldFieldName("_1key"))
+	assert.Equal(t, "", journaldFieldName("__"))
+	assert.Len(t, journaldFieldName(strings.Repeat("a", 100)), 64)
+}
+
+// parseJournaldDatagram decodes a native protocol datagram.
+func parseJournaldDatagram(t *testing.T, data []byte) map[string]string {
+	t.Helper()
+	fields := map[string]string{}
+	for len(data) > 0 {
+		line := data
+		if i := bytes.IndexByte(data, '\n'); i >= 0 {
+			line = data[:i]
+		}
+		if name, value, ok := bytes.Cut(line, []byte("=")); ok {
+			fields[string(name)] = string(value)
+			data = data[len(line)+1:]
+			continue
+		}
+
+		// Binary encoded value: NAME\n<uint64 length><value>\n
+		data = data[len(line)+1:]
+		require.GreaterOrEqual(t, len(data), 8)
+		size := binary.LittleEndian.Uint64(data[:8])
+		fields[string(line)] = string(data[8 : 8+size])
+		data = data[8+size+1:]
+	}
+	return fields
+}
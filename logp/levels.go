@@ -0,0 +1,226 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package logp
+
+import (
+	"io"
+	"runtime"
+	"sync"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// levels holds the global logging level and the per selector levels set at
+// runtime. As a zapcore.LevelEnabler it enables a level if the global level,
+// any of the selector levels or any of the levels set with Logger.WithLevel
+// enables it, so the cores let through all the entries that may be logged;
+// levelCore then filters the entries by logger name.
+type levels struct {
+	global zap.AtomicLevel
+
+	mu        sync.RWMutex
+	selectors map[string]zapcore.Level
+	overrides map[zapcore.Level]int // Number of live overrides by level.
+	lowest    *zapcore.Level        // Lowest of the selector and override levels.
+}
+
+func newLevels(global zapcore.Level) *levels {
+	return &levels{
+		global:    zap.NewAtomicLevelAt(global),
+		selectors: map[string]zapcore.Level{},
+		overrides: map[zapcore.Level]int{},
+	}
+}
+
+// Enabled implements zapcore.LevelEnabler.
+func (l *levels) Enabled(lvl zapcore.Level) bool {
+	if l.global.Enabled(lvl) {
+		return true
+	}
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+	return l.lowest != nil && l.lowest.Enabled(lvl)
+}
+
+// enabledFor returns true if lvl is enabled for the logger with the given
+// name and level override. The selector level, if any, takes precedence
+// over the override, which takes precedence over the global level.
+func (l *levels) enabledFor(name string, o *override, lvl zapcore.Level) bool {
+	if selectorLevel, ok := l.selectorLevel(name); ok {
+		return selectorLevel.Enabled(lvl)
+	}
+	if o != nil {
+		return o.level.Enabled(lvl)
+	}
+	return l.global.Enabled(lvl)
+}
+
+func (l *levels) selectorLevel(name string) (zapcore.Level, bool) {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+	lvl, ok := l.selectors[name]
+	return lvl, ok
+}
+
+func (l *levels) setSelector(name string, lvl zapcore.Level) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.selectors[name] = lvl
+	l.updateLowest()
+}
+
+func (l *levels) resetSelector(name string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	delete(l.selectors, name)
+	l.updateLowest()
+}
+
+// override is a level set with Logger.WithLevel, shared by the cores of the
+// logger and of its children. levels enables the level until the override
+// is garbage collected, so the loggers not in use anymore don't keep the
+// cores enabling their level.
+type override struct {
+	level  zapcore.Level
+	levels *levels
+}
+
+// newOverride returns an override of lvl, enabling lvl until the override
+// is garbage collected.
+func (l *levels) newOverride(lvl zapcore.Level) *override {
+	l.mu.Lock()
+	l.overrides[lvl]++
+	l.updateLowest()
+	l.mu.Unlock()
+
+	o := &override{level: lvl, levels: l}
+	runtime.SetFinalizer(o, func(o *override) { o.levels.release(o.level) })
+	return o
+}
+
+func (l *levels) release(lvl zapcore.Level) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.overrides[lvl]--; l.overrides[lvl] <= 0 {
+		delete(l.overrides, lvl)
+	}
+	l.updateLowest()
+}
+
+// updateLowest recomputes the lowest of the selector and override levels.
+// l.mu must be held.
+func (l *levels) updateLowest() {
+	l.lowest = nil
+	lower := func(lvl zapcore.Level) {
+		if l.lowest == nil || lvl < *l.lowest {
+			l.lowest = &lvl
+		}
+	}
+	for _, lvl := range l.selectors {
+		lower(lvl)
+	}
+	for lvl := range l.overrides {
+		lower(lvl)
+	}
+}
+
+// levelOverrideKey is the key of the field set by Logger.WithLevel. The
+// field is skipped by the encoders.
+const levelOverrideKey = "logp.level_override"
+
+func levelOverrideField(lvl zapcore.Level) zapcore.Field {
+	return zapcore.Field{Key: levelOverrideKey, Type: zapcore.SkipType, Integer: int64(lvl)}
+}
+
+// levelOverride returns the last level set by Logger.WithLevel in fields.
+func levelOverride(fields []zapcore.Field) (*zapcore.Level, bool) {
+	for i := len(fields) - 1; i >= 0; i-- {
+		if fields[i].Type == zapcore.SkipType && fields[i].Key == levelOverrideKey {
+			lvl := zapcore.Level(fields[i].Integer)
+			return &lvl, true
+		}
+	}
+	return nil, false
+}
+
+// levelCore filters entries using the level of the logger that created them.
+type levelCore struct {
+	zapcore.Core
+	levels   *levels
+	override *override // Set by Logger.WithLevel.
+}
+
+func newLevelCore(core zapcore.Core, levels *levels) zapcore.Core {
+	return &levelCore{Core: core, levels: levels}
+}
+
+// Enabled returns true if the level may be logged by any logger.
+func (c *levelCore) Enabled(lvl zapcore.Level) bool {
+	return c.levels.Enabled(lvl) && c.Core.Enabled(lvl)
+}
+
+// With adds structured context to the Core.
+func (c *levelCore) With(fields []zapcore.Field) zapcore.Core {
+	override := c.override
+	if lvl, ok := levelOverride(fields); ok {
+		override = c.levels.newOverride(*lvl)
+	}
+	return &levelCore{Core: c.Core.With(fields), levels: c.levels, override: override}
+}
+
+// Check drops the entries below the level of their logger before
+// delegating to the wrapped core.
+func (c *levelCore) Check(ent zapcore.Entry, ce *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	if !c.levels.enabledFor(ent.LoggerName, c.override, ent.Level) {
+		return ce
+	}
+	return c.Core.Check(ent, ce)
+}
+
+// Close calls Close on the wrapped core if it implements io.Closer.
+func (c *levelCore) Close() error {
+	if closer, ok := c.Core.(io.Closer); ok {
+		return closer.Close()
+	}
+	return nil
+}
+
+// SetLevel sets the global logging level at runtime.
+func SetLevel(lvl zapcore.Level) {
+	loadLogger().levels.global.SetLevel(lvl)
+}
+
+// GetLevel returns the global logging level.
+func GetLevel() zapcore.Level {
+	return loadLogger().levels.global.Level()
+}
+
+// SetSelectorLevel sets the logging level of the loggers named selector at
+// runtime, taking precedence over the global level. Debug entries of the
+// selector are logged even if the selector is not enabled in the
+// configuration.
+func SetSelectorLevel(selector string, lvl zapcore.Level) {
+	loadLogger().levels.setSelector(selector, lvl)
+}
+
+// ResetSelectorLevel removes the level set by SetSelectorLevel, so the
+// loggers named selector use the global level again.
+func ResetSelectorLevel(selector string) {
+	loadLogger().levels.resetSelector(selector)
+}
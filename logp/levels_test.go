@@ -0,0 +1,174 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package logp
+
+import (
+	"runtime"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+func TestSetSelectorLevel(t *testing.T) {
+	defer SaveGlobalLogger()()
+	require.NoError(t, DevelopmentSetup(ToObserverOutput(), WithLevel(InfoLevel)))
+
+	verbose := NewLogger("verbose")
+	quiet := NewLogger("quiet")
+	other := NewLogger("other")
+
+	SetSelectorLevel("verbose", zap.DebugLevel)
+	SetSelectorLevel("quiet", zap.ErrorLevel)
+
+	verbose.Debug("verbose debug")
+	quiet.Warn("quiet warning")
+	quiet.Error("quiet error")
+	other.Debug("other debug")
+	other.Info("other info")
+
+	var messages []string
+	for _, entry := range ObserverLogs().TakeAll() {
+		messages = append(messages, entry.Message)
+	}
+	assert.Equal(t, []string{"verbose debug", "quiet error", "other info"}, messages)
+
+	ResetSelectorLevel("verbose")
+	verbose.Debug("verbose debug")
+	verbose.Info("verbose info")
+	logs := ObserverLogs().TakeAll()
+	require.Len(t, logs, 1)
+	assert.Equal(t, "verbose info", logs[0].Message)
+}
+
+func TestSetSelectorLevelWithSelectors(t *testing.T) {
+	defer SaveGlobalLogger()()
+	require.NoError(t, DevelopmentSetup(ToObserverOutput(), WithSelectors("enabled")))
+
+	NewLogger("disabled").Debug("dropped")
+	assert.Zero(t, ObserverLogs().Len())
+
+	SetSelectorLevel("disabled", zap.DebugLevel)
+	NewLogger("disabled").Debug("logged")
+	NewLogger("enabled").Debug("logged")
+	assert.Equal(t, 2, ObserverLogs().FilterMessage("logged").Len())
+}
+
+func TestSetLevelKeepsCores(t *testing.T) {
+	defer SaveGlobalLogger()()
+	require.NoError(t, DevelopmentSetup(ToObserverOutput()))
+
+	logger := NewLogger("tester")
+	SetLevel(zap.WarnLevel)
+	assert.Equal(t, zap.WarnLevel, GetLevel())
+	logger.Info("dropped")
+	SetLevel(zap.DebugLevel)
+	logger.Debug("logged")
+
+	logs := ObserverLogs().TakeAll()
+	require.Len(t, logs, 1)
+	assert.Equal(t, "logged", logs[0].Message)
+}
+
+func TestLoggerWithLevel(t *testing.T) {
+	defer SaveGlobalLogger()()
+	require.NoError(t, DevelopmentSetup(ToObserverOutput(), WithLevel(InfoLevel)))
+
+	parent := NewLogger("parent")
+	verbose := parent.Named("verbose").WithLevel(zap.DebugLevel)
+	quiet := parent.Named("quiet").WithLevel(zap.ErrorLevel)
+	child := verbose.With("key", "value").Named("child")
+
+	parent.Debug("parent debug")
+	parent.Info("parent info")
+	verbose.Debug("verbose debug")
+	child.Debug("child debug")
+	quiet.Warn("quiet warning")
+	quiet.Error("quiet error")
+
+	var messages []string
+	for _, entry := range ObserverLogs().TakeAll() {
+		messages = append(messages, entry.Message)
+		assert.NotContains(t, entry.ContextMap(), levelOverrideKey)
+	}
+	assert.Equal(t, []string{"parent info", "verbose debug", "child debug", "quiet error"}, messages)
+	assert.True(t, verbose.IsDebug())
+	assert.False(t, parent.IsDebug())
+
+	// The selector level takes precedence.
+	SetSelectorLevel("parent.verbose", zap.WarnLevel)
+	verbose.Info("dropped")
+	assert.Zero(t, ObserverLogs().Len())
+}
+
+func TestLoggerWithLevelAndSelectors(t *testing.T) {
+	defer SaveGlobalLogger()()
+	require.NoError(t, DevelopmentSetup(ToObserverOutput(), WithSelectors("enabled")))
+
+	NewLogger("disabled").WithLevel(zap.DebugLevel).Debug("logged")
+	NewLogger("disabled").Debug("dropped")
+	assert.Equal(t, 1, ObserverLogs().FilterMessage("logged").Len())
+	assert.Zero(t, ObserverLogs().FilterMessage("dropped").Len())
+}
+
+func TestLevelsLowestLevel(t *testing.T) {
+	defer SaveGlobalLogger()()
+	require.NoError(t, DevelopmentSetup(ToObserverOutput(), WithLevel(InfoLevel)))
+	levels := loadLogger().levels
+
+	SetSelectorLevel("verbose", zap.DebugLevel)
+	assert.True(t, levels.Enabled(zap.DebugLevel))
+	ResetSelectorLevel("verbose")
+	assert.False(t, levels.Enabled(zap.DebugLevel), "the selector level is not enabled once reset")
+
+	verbose := NewLogger("verbose").WithLevel(zap.DebugLevel)
+	verbose.Debug("logged")
+	SetSelectorLevel("other", zap.DebugLevel)
+	ResetSelectorLevel("other")
+	assert.True(t, levels.Enabled(zap.DebugLevel), "the override is still in use")
+	runtime.KeepAlive(verbose)
+
+	// The override is released once its logger is garbage collected.
+	verbose = nil
+	assert.Eventually(t, func() bool {
+		runtime.GC()
+		return !levels.Enabled(zap.DebugLevel)
+	}, 5*time.Second, 10*time.Millisecond)
+	assert.Equal(t, 1, ObserverLogs().FilterMessage("logged").Len())
+}
+
+func TestLevelsKeepOutputsLevel(t *testing.T) {
+	defer SaveGlobalLogger()()
+	output, logs := observer.New(zap.DebugLevel)
+	cfg := DefaultConfig(DefaultEnvironment)
+	cfg.Level = InfoLevel
+	cfg.toObserver = true
+	require.NoError(t, ConfigureWithOutputs(cfg, output))
+
+	logger := NewLogger("tester")
+	logger.Debug("debug")
+	logger.Info("info")
+
+	assert.Equal(t, 2, logs.Len(), "the extra output must keep its own level")
+	observed := ObserverLogs().TakeAll()
+	require.Len(t, observed, 1)
+	assert.Equal(t, "info", observed[0].Message)
+}
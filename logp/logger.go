@@ -41,6 +41,7 @@ func newLogger(rootLogger *zap.Logger, selector string, options ...LogOption) *L
 		WithOptions(zap.AddCallerSkip(1)).
 		WithOptions(options...).
 		Named(selector)
+	registerSelector(log.Name())
 	return &Logger{log, log.Sugar()}
 }
 
@@ -98,6 +99,17 @@ func (l *Logger) With(args ...interface{}) *Logger {
 // periods.
 func (l *Logger) Named(name string) *Logger {
 	logger := l.logger.Named(name)
+	registerSelector(logger.Name())
+	return &Logger{logger, logger.Sugar()}
+}
+
+// WithLevel returns a clone of l logging at lvl instead of the global
+// level, lvl may be lower or higher. The clone's children created with
+// With or Named inherit the level. A level set with SetSelectorLevel for
+// the name of a logger still takes precedence, so the level remains
+// controlled centrally.
+func (l *Logger) WithLevel(lvl zapcore.Level) *Logger {
+	logger := l.logger.With(levelOverrideField(lvl))
 	return &Logger{logger, logger.Sugar()}
 }
 
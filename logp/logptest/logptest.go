@@ -0,0 +1,162 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+// Package logptest provides helpers to capture and check the logs written
+// through logp during a test.
+//
+// Combined with leaktest, a test can check it leaves neither errors nor
+// goroutines behind:
+//
+//	func TestSomething(t *testing.T) {
+//		leaktest.Check(t)
+//		logs := logptest.Capture(t)
+//		logs.ExpectErrors("connection refused")
+//		...
+//	}
+package logptest
+
+import (
+	"strings"
+	"sync"
+	"testing"
+
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+
+	"github.com/elastic/elastic-agent-libs/logp"
+)
+
+// Logs holds the log entries captured during a test. The embedded
+// ObservedLogs gives access to the entries and can be used to filter them.
+type Logs struct {
+	*observer.ObservedLogs
+
+	t        testing.TB
+	mu       sync.Mutex
+	expected []string
+	anyError bool
+}
+
+// Capture configures the global logger to capture every entry, at debug
+// level and with all selectors enabled, until the end of the test. It
+// captures the logs of all goroutines, so tests running in parallel must
+// not use it. Options can be used to change the level or the selectors.
+//
+// At the end of the test the previous global logger is restored and the
+// test fails if an entry at error level or above was logged, unless it was
+// expected, see ExpectErrors and AllowErrors.
+func Capture(t testing.TB, opts ...logp.Option) *Logs {
+	t.Helper()
+
+	cfg := logp.Config{
+		Level:     logp.DebugLevel,
+		Selectors: []string{"*"},
+	}
+	logp.ToObserverOutput()(&cfg)
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+
+	restore := logp.SaveGlobalLogger()
+	if err := logp.Configure(cfg); err != nil {
+		t.Fatalf("could not configure the logger to capture logs: %v", err)
+	}
+
+	l := &Logs{ObservedLogs: logp.ObserverLogs(), t: t}
+	t.Cleanup(func() {
+		restore()
+		if !t.Failed() {
+			l.AssertNoErrors()
+		}
+	})
+	return l
+}
+
+// ExpectErrors allows entries at error level or above whose message
+// contains one of the given substrings.
+func (l *Logs) ExpectErrors(substrings ...string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.expected = append(l.expected, substrings...)
+}
+
+// AllowErrors disables the check for entries at error level or above.
+func (l *Logs) AllowErrors() {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.anyError = true
+}
+
+// Errors returns the entries at error level or above that were not
+// expected.
+func (l *Logs) Errors() []observer.LoggedEntry {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.anyError {
+		return nil
+	}
+
+	var unexpected []observer.LoggedEntry
+	for _, e := range l.All() {
+		if e.Level < zapcore.ErrorLevel || l.isExpected(e.Message) {
+			continue
+		}
+		unexpected = append(unexpected, e)
+	}
+	return unexpected
+}
+
+func (l *Logs) isExpected(message string) bool {
+	for _, s := range l.expected {
+		if strings.Contains(message, s) {
+			return true
+		}
+	}
+	return false
+}
+
+// AssertNoErrors fails the test if unexpected entries at error level or
+// above were logged. It returns true if there were none.
+func (l *Logs) AssertNoErrors() bool {
+	l.t.Helper()
+	errs := l.Errors()
+	if len(errs) == 0 {
+		return true
+	}
+
+	var b strings.Builder
+	for _, e := range errs {
+		b.WriteString("\n\t")
+		b.WriteString(e.Level.CapitalString())
+		if e.LoggerName != "" {
+			b.WriteString(" [" + e.LoggerName + "]")
+		}
+		b.WriteString(" " + e.Message)
+	}
+	l.t.Errorf("%d unexpected error log entries:%s", len(errs), b.String())
+	return false
+}
+
+// Messages returns the messages of all captured entries, in order.
+func (l *Logs) Messages() []string {
+	entries := l.All()
+	messages := make([]string, 0, len(entries))
+	for _, e := range entries {
+		messages = append(messages, e.Message)
+	}
+	return messages
+}
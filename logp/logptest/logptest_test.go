@@ -0,0 +1,102 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package logptest
+
+import (
+	"fmt"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/elastic/elastic-agent-libs/logp"
+	"github.com/elastic/elastic-agent-libs/testing/leaktest"
+)
+
+// recorder records failures instead of failing the test.
+type recorder struct {
+	testing.TB
+	errors []string
+}
+
+func (r *recorder) Helper() {}
+
+func (r *recorder) Errorf(format string, args ...interface{}) {
+	r.errors = append(r.errors, fmt.Sprintf(format, args...))
+}
+
+func TestCaptureFromGoroutines(t *testing.T) {
+	leaktest.Check(t)
+	logs := Capture(t)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			logp.NewLogger("worker").Debugf("worker %d done", i)
+		}(i)
+	}
+	wg.Wait()
+
+	assert.Equal(t, 10, logs.FilterMessageSnippet("done").Len())
+	assert.Empty(t, logs.Errors())
+}
+
+func TestCaptureErrors(t *testing.T) {
+	logs := Capture(t)
+	log := logp.NewLogger("test")
+
+	log.Warn("just a warning")
+	log.Error("connection refused by host")
+	log.Errorw("unexpected failure", "attempt", 3)
+
+	r := &recorder{TB: t}
+	logs.t = r
+	logs.ExpectErrors("connection refused")
+	assert.False(t, logs.AssertNoErrors())
+	require.Len(t, r.errors, 1)
+	assert.Contains(t, r.errors[0], "1 unexpected error log entries")
+	assert.Contains(t, r.errors[0], "ERROR [test] unexpected failure")
+
+	logs.AllowErrors()
+	assert.True(t, logs.AssertNoErrors())
+	assert.Equal(t, []string{"just a warning", "connection refused by host", "unexpected failure"}, logs.Messages())
+}
+
+func TestCaptureRestoresGlobalLogger(t *testing.T) {
+	var outer *Logs
+	t.Run("outer", func(t *testing.T) {
+		outer = Capture(t)
+		t.Run("inner", func(t *testing.T) {
+			inner := Capture(t)
+			logp.NewLogger("inner").Info("inner message")
+			assert.Equal(t, []string{"inner message"}, inner.Messages())
+		})
+		logp.NewLogger("outer").Info("outer message")
+	})
+	assert.Equal(t, []string{"outer message"}, outer.Messages())
+}
+
+func TestCaptureOptions(t *testing.T) {
+	logs := Capture(t, logp.WithLevel(logp.InfoLevel))
+	logp.NewLogger("test").Debug("hidden")
+	logp.NewLogger("test").Info("shown")
+	assert.Equal(t, []string{"shown"}, logs.Messages())
+}
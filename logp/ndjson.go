@@ -0,0 +1,68 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package logp
+
+import (
+	"go.uber.org/zap/buffer"
+	"go.uber.org/zap/zapcore"
+)
+
+// ndjsonTimeLayout is the layout of the @timestamp of the Beats events.
+const ndjsonTimeLayout = "2006-01-02T15:04:05.000Z"
+
+var ndjsonPool = buffer.NewPool()
+
+// ndjsonEncoder encodes the entries like the Beats encode their events, so
+// they can be ingested by the Filebeat ndjson parser without processors:
+// one JSON document per line starting with @timestamp, in UTC with a
+// millisecond precision, followed by log.level, log.logger, log.origin and
+// message, always in this order, then the fields. Like the Beats JSON
+// codec, strings are not HTML escaped and invalid UTF-8 is replaced by
+// U+FFFD.
+type ndjsonEncoder struct {
+	zapcore.Encoder
+}
+
+func newNDJSONEncoder(cfg zapcore.EncoderConfig) zapcore.Encoder {
+	// The JSON encoder writes the level before the time, the time is
+	// written by EncodeEntry instead.
+	cfg.TimeKey = zapcore.OmitKey
+	cfg.LineEnding = zapcore.DefaultLineEnding
+	return ndjsonEncoder{zapcore.NewJSONEncoder(cfg)}
+}
+
+func (e ndjsonEncoder) Clone() zapcore.Encoder {
+	return ndjsonEncoder{e.Encoder.Clone()}
+}
+
+func (e ndjsonEncoder) EncodeEntry(entry zapcore.Entry, fields []zapcore.Field) (*buffer.Buffer, error) {
+	encoded, err := e.Encoder.EncodeEntry(entry, fields)
+	if err != nil {
+		return nil, err
+	}
+	defer encoded.Free()
+
+	buf := ndjsonPool.Get()
+	buf.AppendString(`{"@timestamp":"`)
+	buf.AppendTime(entry.Time.UTC(), ndjsonTimeLayout)
+	buf.AppendByte('"')
+	// The document always has the log.level key, skip its opening brace.
+	buf.AppendByte(',')
+	_, _ = buf.Write(encoded.Bytes()[1:])
+	return buf, nil
+}
@@ -17,6 +17,8 @@
 
 package logp
 
+import "github.com/elastic/elastic-agent-libs/clock"
+
 // Option configures the logp package behavior.
 type Option func(cfg *Config)
 
@@ -52,3 +54,12 @@ func ToDiscardOutput() Option {
 		cfg.ToStderr = false
 	}
 }
+
+// WithClock sets the clock used to name and rotate log files. It is meant
+// to be used with clock.Fake in tests. Defaults to clock.Real(). To use it
+// with Configure, apply it to the Config: logp.WithClock(c)(&cfg).
+func WithClock(c clock.Clock) Option {
+	return func(cfg *Config) {
+		cfg.clock = c
+	}
+}
@@ -0,0 +1,503 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package logp
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+	"sync"
+	"time"
+
+	"go.uber.org/zap/zapcore"
+)
+
+// OTelConfig contains the configuration options for sending logs to an
+// OpenTelemetry collector using the OTLP/HTTP protocol with JSON encoding.
+// OTLP/gRPC is not supported.
+type OTelConfig struct {
+	Enabled bool `config:"enabled" yaml:"enabled"`
+	// Endpoint is the URL of the collector. The /v1/logs path is added if
+	// the URL has no path.
+	Endpoint string `config:"endpoint" yaml:"endpoint"`
+	// Protocol must be http/json, the only protocol supported.
+	Protocol string            `config:"protocol" yaml:"protocol"`
+	Headers  map[string]string `config:"headers" yaml:"headers"`
+	// ResourceAttributes are added to the resource of all the log records,
+	// in addition to service.name.
+	ResourceAttributes map[string]string `config:"resource_attributes" yaml:"resource_attributes"`
+	Timeout            time.Duration     `config:"timeout" yaml:"timeout"`
+	// BatchSize is the maximum number of log records sent in a single
+	// request. Records are sent when the batch is full or every
+	// FlushInterval.
+	BatchSize     int           `config:"batch_size" yaml:"batch_size"`
+	FlushInterval time.Duration `config:"flush_interval" yaml:"flush_interval"`
+	// MaxQueueSize is the maximum number of records waiting to be sent.
+	// Records are dropped when the queue is full. Records that could not
+	// be sent are dropped too, see DroppedEntries.
+	MaxQueueSize int `config:"max_queue_size" yaml:"max_queue_size"`
+}
+
+const otelProtocolHTTPJSON = "http/json"
+
+func defaultOTelConfig() OTelConfig {
+	return OTelConfig{
+		Endpoint:      "http://localhost:4318",
+		Protocol:      otelProtocolHTTPJSON,
+		Timeout:       10 * time.Second,
+		BatchSize:     512,
+		FlushInterval: 5 * time.Second,
+		MaxQueueSize:  4096,
+	}
+}
+
+// Validate checks the protocol and the endpoint.
+func (c *OTelConfig) Validate() error {
+	if !c.Enabled {
+		return nil
+	}
+	if c.Protocol != "" && c.Protocol != otelProtocolHTTPJSON {
+		return fmt.Errorf("unsupported otel protocol '%s', only %s is supported", c.Protocol, otelProtocolHTTPJSON)
+	}
+	if _, err := otelLogsURL(c.Endpoint); err != nil {
+		return err
+	}
+	return nil
+}
+
+func otelLogsURL(endpoint string) (string, error) {
+	u, err := url.Parse(endpoint)
+	if err != nil {
+		return "", fmt.Errorf("invalid otel endpoint: %w", err)
+	}
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return "", fmt.Errorf("invalid otel endpoint '%s': the scheme must be http or https", endpoint)
+	}
+	if u.Path == "" || u.Path == "/" {
+		u.Path = "/v1/logs"
+	}
+	return u.String(), nil
+}
+
+// otelExporter batches log records and sends them to the collector.
+type otelExporter struct {
+	url      string
+	headers  map[string]string
+	resource otelResource
+	client   *http.Client
+	batch    int
+	maxQueue int
+
+	mu      sync.Mutex
+	pending []otelLogRecord
+
+	flush  chan struct{}
+	done   chan struct{}
+	wg     sync.WaitGroup
+	closed sync.Once
+}
+
+func newOTelExporter(beat string, cfg OTelConfig) (*otelExporter, error) {
+	defaults := defaultOTelConfig()
+	if cfg.Endpoint == "" {
+		cfg.Endpoint = defaults.Endpoint
+	}
+	if cfg.Timeout <= 0 {
+		cfg.Timeout = defaults.Timeout
+	}
+	if cfg.BatchSize <= 0 {
+		cfg.BatchSize = defaults.BatchSize
+	}
+	if cfg.FlushInterval <= 0 {
+		cfg.FlushInterval = defaults.FlushInterval
+	}
+	if cfg.MaxQueueSize <= 0 {
+		cfg.MaxQueueSize = defaults.MaxQueueSize
+	}
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+	logsURL, err := otelLogsURL(cfg.Endpoint)
+	if err != nil {
+		return nil, err
+	}
+
+	serviceName := beat
+	if serviceName == "" {
+		serviceName = filepath.Base(os.Args[0])
+	}
+	resource := otelResource{Attributes: []otelKeyValue{otelAttribute("service.name", serviceName)}}
+	for k, v := range cfg.ResourceAttributes {
+		resource.Attributes = append(resource.Attributes, otelAttribute(k, v))
+	}
+
+	e := &otelExporter{
+		url:      logsURL,
+		headers:  cfg.Headers,
+		resource: resource,
+		client:   &http.Client{Timeout: cfg.Timeout},
+		batch:    cfg.BatchSize,
+		maxQueue: cfg.MaxQueueSize,
+		flush:    make(chan struct{}, 1),
+		done:     make(chan struct{}),
+	}
+	e.wg.Add(1)
+	go e.run(cfg.FlushInterval)
+	return e, nil
+}
+
+func (e *otelExporter) run(interval time.Duration) {
+	defer e.wg.Done()
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-e.done:
+			return
+		case <-ticker.C:
+		case <-e.flush:
+		}
+		// The failed records are counted as dropped by send.
+		_ = e.send()
+	}
+}
+
+func (e *otelExporter) add(record otelLogRecord) {
+	e.mu.Lock()
+	if len(e.pending) >= e.maxQueue || e.isClosed() {
+		droppedEntries.Add(1)
+		e.mu.Unlock()
+		return
+	}
+	e.pending = append(e.pending, record)
+	full := len(e.pending) >= e.batch
+	e.mu.Unlock()
+
+	if full {
+		e.requestFlush()
+	}
+}
+
+// requestFlush makes the exporter goroutine send the pending records.
+func (e *otelExporter) requestFlush() {
+	select {
+	case e.flush <- struct{}{}:
+	default:
+	}
+}
+
+func (e *otelExporter) isClosed() bool {
+	select {
+	case <-e.done:
+		return true
+	default:
+		return false
+	}
+}
+
+// send sends all pending records, in batches. The records of the batches
+// that fail to be sent are dropped.
+func (e *otelExporter) send() error {
+	var errs []error
+	for {
+		e.mu.Lock()
+		n := len(e.pending)
+		if n > e.batch {
+			n = e.batch
+		}
+		records := e.pending[:n:n]
+		e.pending = e.pending[n:]
+		e.mu.Unlock()
+
+		if len(records) == 0 {
+			return errors.Join(errs...)
+		}
+		if err := e.post(records); err != nil {
+			droppedEntries.Add(uint64(len(records)))
+			errs = append(errs, err)
+		}
+	}
+}
+
+func (e *otelExporter) post(records []otelLogRecord) error {
+	body, err := json.Marshal(otelLogsRequest{ResourceLogs: []otelResourceLogs{{
+		Resource:  e.resource,
+		ScopeLogs: []otelScopeLogs{{Scope: otelScope{Name: "github.com/elastic/elastic-agent-libs/logp"}, LogRecords: records}},
+	}}})
+	if err != nil {
+		return fmt.Errorf("failed to encode otel logs: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(context.Background(), http.MethodPost, e.url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	for k, v := range e.headers {
+		req.Header.Set(k, v)
+	}
+
+	resp, err := e.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to send otel logs: %w", err)
+	}
+	defer resp.Body.Close()
+	_, _ = io.Copy(io.Discard, resp.Body)
+	if resp.StatusCode/100 != 2 {
+		return fmt.Errorf("failed to send otel logs: unexpected status %s", resp.Status)
+	}
+	return nil
+}
+
+func (e *otelExporter) Close() error {
+	var err error
+	e.closed.Do(func() {
+		close(e.done)
+		e.wg.Wait()
+		err = e.send()
+	})
+	return err
+}
+
+// otelExporters collects the exporters of a logger, so they are closed
+// when the logger is replaced. They are kept open while the logger is saved
+// by SaveGlobalLogger, as it may be restored.
+type otelExporters struct {
+	mu        sync.Mutex
+	exporters []*otelExporter
+	saved     int // Number of SaveGlobalLogger restores pending.
+}
+
+func (o *otelExporters) save() {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	o.saved++
+}
+
+func (o *otelExporters) restored() {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	o.saved--
+}
+
+// closeUnlessSaved closes the exporters unless their logger is saved.
+func (o *otelExporters) closeUnlessSaved() error {
+	o.mu.Lock()
+	saved := o.saved > 0
+	o.mu.Unlock()
+	if saved {
+		return nil
+	}
+	return o.close()
+}
+
+func (o *otelExporters) add(e *otelExporter) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	o.exporters = append(o.exporters, e)
+}
+
+func (o *otelExporters) close() error {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	var errs []error
+	for _, e := range o.exporters {
+		errs = append(errs, e.Close())
+	}
+	return errors.Join(errs...)
+}
+
+// otelCore converts entries to OTLP log records.
+type otelCore struct {
+	zapcore.LevelEnabler
+	exporter *otelExporter
+	fields   []zapcore.Field
+}
+
+func newOTelCore(exporter *otelExporter, enab zapcore.LevelEnabler) zapcore.Core {
+	return &otelCore{LevelEnabler: enab, exporter: exporter}
+}
+
+func (c *otelCore) With(fields []zapcore.Field) zapcore.Core {
+	clone := *c
+	clone.fields = make([]zapcore.Field, 0, len(c.fields)+len(fields))
+	clone.fields = append(clone.fields, c.fields...)
+	clone.fields = append(clone.fields, fields...)
+	return &clone
+}
+
+func (c *otelCore) Check(entry zapcore.Entry, checked *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	if c.Enabled(entry.Level) {
+		return checked.AddCore(entry, c)
+	}
+	return checked
+}
+
+func (c *otelCore) Write(entry zapcore.Entry, fields []zapcore.Field) error {
+	enc := zapcore.NewMapObjectEncoder()
+	for _, field := range c.fields {
+		field.AddTo(enc)
+	}
+	for _, field := range fields {
+		field.AddTo(enc)
+	}
+
+	record := otelLogRecord{
+		TimeUnixNano:   strconv.FormatInt(entry.Time.UnixNano(), 10),
+		SeverityNumber: otelSeverity(entry.Level),
+		SeverityText:   entry.Level.CapitalString(),
+		Body:           otelAnyValue{StringValue: &entry.Message},
+	}
+	if entry.LoggerName != "" {
+		record.Attributes = append(record.Attributes, otelAttribute("log.logger", entry.LoggerName))
+	}
+	if entry.Caller.Defined {
+		record.Attributes = append(record.Attributes,
+			otelAttribute("code.filepath", entry.Caller.File),
+			otelAttribute("code.lineno", entry.Caller.Line))
+	}
+	if entry.Stack != "" {
+		record.Attributes = append(record.Attributes, otelAttribute("exception.stacktrace", entry.Stack))
+	}
+	for k, v := range enc.Fields {
+		record.Attributes = append(record.Attributes, otelAttribute(k, v))
+	}
+
+	c.exporter.add(record)
+	return nil
+}
+
+// Sync asks the exporter to send the pending records without waiting for
+// them to be sent, so it does not block the caller for up to the timeout.
+func (c *otelCore) Sync() error {
+	c.exporter.requestFlush()
+	return nil
+}
+
+// Close sends the pending records and stops the exporter.
+func (c *otelCore) Close() error {
+	return c.exporter.Close()
+}
+
+func otelSeverity(level zapcore.Level) int {
+	switch level {
+	case zapcore.DebugLevel:
+		return 5
+	case zapcore.InfoLevel:
+		return 9
+	case zapcore.WarnLevel:
+		return 13
+	case zapcore.ErrorLevel:
+		return 17
+	case zapcore.DPanicLevel:
+		return 18
+	default:
+		return 21
+	}
+}
+
+// OTLP JSON encoding of the logs export request. See
+// https://opentelemetry.io/docs/specs/otlp/#json-protobuf-encoding
+type (
+	otelLogsRequest struct {
+		ResourceLogs []otelResourceLogs `json:"resourceLogs"`
+	}
+	otelResourceLogs struct {
+		Resource  otelResource    `json:"resource"`
+		ScopeLogs []otelScopeLogs `json:"scopeLogs"`
+	}
+	otelResource struct {
+		Attributes []otelKeyValue `json:"attributes"`
+	}
+	otelScopeLogs struct {
+		Scope      otelScope       `json:"scope"`
+		LogRecords []otelLogRecord `json:"logRecords"`
+	}
+	otelScope struct {
+		Name string `json:"name"`
+	}
+	otelLogRecord struct {
+		TimeUnixNano   string         `json:"timeUnixNano"`
+		SeverityNumber int            `json:"severityNumber"`
+		SeverityText   string         `json:"severityText"`
+		Body           otelAnyValue   `json:"body"`
+		Attributes     []otelKeyValue `json:"attributes,omitempty"`
+	}
+	otelKeyValue struct {
+		Key   string       `json:"key"`
+		Value otelAnyValue `json:"value"`
+	}
+	otelAnyValue struct {
+		StringValue *string  `json:"stringValue,omitempty"`
+		BoolValue   *bool    `json:"boolValue,omitempty"`
+		IntValue    *string  `json:"intValue,omitempty"`
+		DoubleValue *float64 `json:"doubleValue,omitempty"`
+	}
+)
+
+func otelAttribute(key string, value interface{}) otelKeyValue {
+	var v otelAnyValue
+	switch value := value.(type) {
+	case string:
+		v.StringValue = &value
+	case bool:
+		v.BoolValue = &value
+	case int:
+		s := strconv.FormatInt(int64(value), 10)
+		v.IntValue = &s
+	case int64:
+		s := strconv.FormatInt(value, 10)
+		v.IntValue = &s
+	case int32:
+		s := strconv.FormatInt(int64(value), 10)
+		v.IntValue = &s
+	case uint64:
+		s := strconv.FormatUint(value, 10)
+		v.IntValue = &s
+	case uint32:
+		s := strconv.FormatUint(uint64(value), 10)
+		v.IntValue = &s
+	case float64:
+		if !math.IsNaN(value) && !math.IsInf(value, 0) {
+			v.DoubleValue = &value
+			break
+		}
+		s := strconv.FormatFloat(value, 'g', -1, 64)
+		v.StringValue = &s
+	case fmt.Stringer:
+		s := value.String()
+		v.StringValue = &s
+	default:
+		b, err := json.Marshal(value)
+		s := string(b)
+		if err != nil {
+			s = fmt.Sprint(value)
+		}
+		v.StringValue = &s
+	}
+	return otelKeyValue{Key: key, Value: v}
+}
@@ -0,0 +1,192 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package logp
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestOTelOutput(t *testing.T) {
+	defer SaveGlobalLogger()()
+
+	var (
+		mu       sync.Mutex
+		requests []otelLogsRequest
+	)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/v1/logs", r.URL.Path)
+		assert.Equal(t, "application/json", r.Header.Get("Content-Type"))
+		assert.Equal(t, "secret", r.Header.Get("Authorization"))
+
+		var req otelLogsRequest
+		assert.NoError(t, json.NewDecoder(r.Body).Decode(&req))
+		mu.Lock()
+		requests = append(requests, req)
+		mu.Unlock()
+	}))
+	defer srv.Close()
+
+	cfg := DefaultConfig(DefaultEnvironment)
+	cfg.Beat = "testbeat"
+	cfg.OTel.Enabled = true
+	cfg.OTel.Endpoint = srv.URL
+	cfg.OTel.Headers = map[string]string{"Authorization": "secret"}
+	cfg.OTel.ResourceAttributes = map[string]string{"deployment.environment": "test"}
+	cfg.OTel.FlushInterval = time.Hour
+	ToObserverOutput()(&cfg)
+	require.NoError(t, Configure(cfg))
+
+	log := NewLogger("otel").With("component", "test")
+	log.Debug("dropped by level")
+	log.Warnw("warning entry", "count", 42, "ok", true)
+	require.NoError(t, log.Sync())
+
+	assert.Equal(t, 1, ObserverLogs().Len(), "the default output must keep receiving entries")
+
+	// Sync does not wait for the records to be sent.
+	assert.Eventually(t, func() bool {
+		mu.Lock()
+		defer mu.Unlock()
+		return len(requests) > 0
+	}, 5*time.Second, 10*time.Millisecond)
+
+	mu.Lock()
+	defer mu.Unlock()
+	require.Len(t, requests, 1)
+	require.Len(t, requests[0].ResourceLogs, 1)
+	resourceLogs := requests[0].ResourceLogs[0]
+	resource := otelAttributes(resourceLogs.Resource.Attributes)
+	assert.Equal(t, "testbeat", resource["service.name"])
+	assert.Equal(t, "test", resource["deployment.environment"])
+
+	require.Len(t, resourceLogs.ScopeLogs, 1)
+	records := resourceLogs.ScopeLogs[0].LogRecords
+	require.Len(t, records, 1)
+	record := records[0]
+	assert.Equal(t, "warning entry", *record.Body.StringValue)
+	assert.Equal(t, "WARN", record.SeverityText)
+	assert.Equal(t, 13, record.SeverityNumber)
+	assert.NotEmpty(t, record.TimeUnixNano)
+
+	attrs := otelAttributes(record.Attributes)
+	assert.Equal(t, "otel", attrs["log.logger"])
+	assert.Equal(t, "test", attrs["component"])
+	assert.Equal(t, "42", attrs["count"])
+	assert.Equal(t, true, attrs["ok"])
+	assert.Contains(t, attrs, "code.filepath")
+}
+
+func TestOTelFailedRecordsAreDropped(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer srv.Close()
+
+	cfg := defaultOTelConfig()
+	cfg.Endpoint = srv.URL
+	cfg.FlushInterval = time.Hour
+	exporter, err := newOTelExporter("testbeat", cfg)
+	require.NoError(t, err)
+
+	dropped := DroppedEntries()
+	exporter.add(otelLogRecord{SeverityText: "INFO"})
+	exporter.add(otelLogRecord{SeverityText: "INFO"})
+	assert.ErrorContains(t, exporter.Close(), "503")
+	assert.Equal(t, dropped+2, DroppedEntries())
+
+	// Records logged once the exporter is closed are dropped too.
+	exporter.add(otelLogRecord{SeverityText: "INFO"})
+	assert.Equal(t, dropped+3, DroppedEntries())
+}
+
+func TestOTelExporterClosedOnReconfigure(t *testing.T) {
+	defer SaveGlobalLogger()()
+
+	cfg := DefaultConfig(DefaultEnvironment)
+	cfg.OTel.Enabled = true
+	cfg.OTel.Endpoint = "http://127.0.0.1:1"
+	ToObserverOutput()(&cfg)
+	require.NoError(t, Configure(cfg))
+	exporters := loadLogger().exporters.exporters
+	require.Len(t, exporters, 1)
+
+	require.NoError(t, Configure(cfg))
+	assert.True(t, exporters[0].isClosed())
+}
+
+func TestOTelExporterKeptBySaveGlobalLogger(t *testing.T) {
+	defer SaveGlobalLogger()()
+
+	cfg := DefaultConfig(DefaultEnvironment)
+	cfg.OTel.Enabled = true
+	cfg.OTel.Endpoint = "http://127.0.0.1:1"
+	ToObserverOutput()(&cfg)
+	require.NoError(t, Configure(cfg))
+	exporters := loadLogger().exporters.exporters
+	require.Len(t, exporters, 1)
+
+	restore := SaveGlobalLogger()
+	require.NoError(t, DevelopmentSetup(ToObserverOutput()))
+	assert.False(t, exporters[0].isClosed(), "the saved logger may be restored")
+
+	restore()
+	assert.False(t, exporters[0].isClosed(), "the restored logger keeps its exporter")
+
+	require.NoError(t, DevelopmentSetup(ToObserverOutput()))
+	assert.True(t, exporters[0].isClosed(), "the exporter is closed once its logger is replaced")
+}
+
+func TestOTelConfigValidate(t *testing.T) {
+	cfg := defaultOTelConfig()
+	assert.NoError(t, cfg.Validate(), "disabled config must be valid")
+
+	cfg.Enabled = true
+	assert.NoError(t, cfg.Validate())
+
+	cfg.Protocol = "grpc"
+	assert.Error(t, cfg.Validate())
+
+	cfg.Protocol = otelProtocolHTTPJSON
+	cfg.Endpoint = "localhost:4318"
+	assert.Error(t, cfg.Validate())
+}
+
+func otelAttributes(kvs []otelKeyValue) map[string]interface{} {
+	attrs := make(map[string]interface{}, len(kvs))
+	for _, kv := range kvs {
+		switch {
+		case kv.Value.StringValue != nil:
+			attrs[kv.Key] = *kv.Value.StringValue
+		case kv.Value.IntValue != nil:
+			attrs[kv.Key] = *kv.Value.IntValue
+		case kv.Value.BoolValue != nil:
+			attrs[kv.Key] = *kv.Value.BoolValue
+		case kv.Value.DoubleValue != nil:
+			attrs[kv.Key] = *kv.Value.DoubleValue
+		}
+	}
+	return attrs
+}
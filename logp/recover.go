@@ -0,0 +1,92 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package logp
+
+import (
+	"fmt"
+	"runtime/debug"
+
+	"go.uber.org/zap"
+)
+
+// PanicCounter is incremented by RecoverPanic for each panic it recovers
+// from. monitoring.Int and monitoring.Uint implement it.
+type PanicCounter interface {
+	Inc()
+}
+
+type recoverConfig struct {
+	counter PanicCounter
+	repanic bool
+}
+
+// RecoverOption configures RecoverPanic.
+type RecoverOption func(*recoverConfig)
+
+// WithPanicCounter increments counter for each panic recovered.
+func WithPanicCounter(counter PanicCounter) RecoverOption {
+	return func(c *recoverConfig) {
+		c.counter = counter
+	}
+}
+
+// Repanic panics again with the same value once the panic is logged,
+// instead of swallowing it.
+func Repanic(repanic bool) RecoverOption {
+	return func(c *recoverConfig) {
+		c.repanic = repanic
+	}
+}
+
+// RecoverPanic recovers from a panic and logs it with logger, at the error
+// level, with the panic value in error.message, its type in error.type and
+// the stack trace of the goroutine in error.stack_trace. The caller of the
+// entry is the function that panicked.
+//
+// Unlike Recover, the panic is logged with ECS fields and can be counted
+// or propagated. RecoverPanic must be deferred directly, recover has no
+// effect otherwise. It is meant for the entry points of goroutines:
+//
+//	go func() {
+//		defer logp.RecoverPanic(logger, logp.WithPanicCounter(panics))
+//		...
+//	}()
+func RecoverPanic(logger *Logger, options ...RecoverOption) {
+	r := recover()
+	if r == nil {
+		return
+	}
+
+	var cfg recoverConfig
+	for _, option := range options {
+		option(&cfg)
+	}
+
+	// Skip RecoverPanic and the runtime panic frame.
+	logger.WithOptions(zap.AddCallerSkip(2)).Errorw("Recovered from panic",
+		"error.message", fmt.Sprint(r),
+		"error.type", fmt.Sprintf("%T", r),
+		"error.stack_trace", string(debug.Stack()),
+	)
+	if cfg.counter != nil {
+		cfg.counter.Inc()
+	}
+	if cfg.repanic {
+		panic(r)
+	}
+}
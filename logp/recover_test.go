@@ -0,0 +1,65 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package logp
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+type panicCounter struct {
+	count int
+}
+
+func (c *panicCounter) Inc() { c.count++ }
+
+func TestRecoverPanic(t *testing.T) {
+	logger, logs := NewInMemoryLogger(zap.AddCaller())
+	panics := &panicCounter{}
+
+	func() {
+		defer RecoverPanic(logger, WithPanicCounter(panics))
+		panic(errors.New("boom"))
+	}()
+
+	entries := logs.TakeAll()
+	require.Len(t, entries, 1)
+	entry := entries[0]
+	assert.Equal(t, "Recovered from panic", entry.Message)
+	assert.Equal(t, "logp/recover_test.go", strings.Split(entry.Caller.TrimmedPath(), ":")[0])
+	assert.Equal(t, "boom", entry.ContextMap()["error.message"])
+	assert.Equal(t, "*errors.errorString", entry.ContextMap()["error.type"])
+	assert.Contains(t, entry.ContextMap()["error.stack_trace"], "TestRecoverPanic")
+	assert.Equal(t, 1, panics.count)
+
+	assert.PanicsWithValue(t, "again", func() {
+		defer RecoverPanic(logger, Repanic(true))
+		panic("again")
+	})
+	assert.Equal(t, 1, logs.Len())
+
+	func() {
+		defer RecoverPanic(logger)
+	}()
+	assert.Equal(t, 1, logs.Len())
+}
@@ -0,0 +1,97 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package logp
+
+import (
+	"errors"
+	"os"
+	"os/signal"
+	"sync"
+
+	"github.com/elastic/elastic-agent-libs/file"
+)
+
+// fileReopeners holds the rotators of the file outputs of a logger, for
+// Reopen.
+type fileReopeners struct {
+	mu       sync.Mutex
+	rotators []*file.Rotator
+}
+
+func (f *fileReopeners) add(r *file.Rotator) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.rotators = append(f.rotators, r)
+}
+
+func (f *fileReopeners) reopen() error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	var errs []error
+	for _, r := range f.rotators {
+		errs = append(errs, r.Reopen())
+	}
+	return errors.Join(errs...)
+}
+
+// Reopen closes and reopens the files of the file outputs of the global
+// logger, creating them if they were moved or removed. It lets external
+// tools, like logrotate, rotate the log files.
+func Reopen() error {
+	reopeners := loadLogger().reopeners
+	if reopeners == nil {
+		return nil
+	}
+	return reopeners.reopen()
+}
+
+// ReopenOnSignal calls Reopen each time the process receives one of the
+// signals, until stop is called. Errors are logged by the global logger.
+// The signals are received by other signal handlers too: in a service using
+// service.HandleSignals, which stops on SIGHUP, use
+// service.ReopenLogsOnSIGHUP instead.
+func ReopenOnSignal(signals ...os.Signal) (stop func()) {
+	ch := make(chan os.Signal, 1)
+	done := make(chan struct{})
+	signal.Notify(ch, signals...)
+
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		for {
+			select {
+			case <-done:
+				return
+			case <-ch:
+				if err := Reopen(); err != nil {
+					L().Errorw("Failed to reopen the log files", Error(err))
+				}
+			}
+		}
+	}()
+
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			signal.Stop(ch)
+			close(done)
+			wg.Wait()
+		})
+	}
+}
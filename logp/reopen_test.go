@@ -0,0 +1,69 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package logp
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// configureFileOutput configures the global logger to write to a file in a
+// temporary directory and returns the path of the file.
+func configureFileOutput(t *testing.T) string {
+	t.Helper()
+
+	dir := t.TempDir()
+	cfg := DefaultConfig(DefaultEnvironment)
+	cfg.Beat = "testbeat"
+	cfg.Files.Path = dir
+	require.NoError(t, Configure(cfg))
+
+	L().Info("first entry")
+	require.NoError(t, L().Sync())
+	files, err := filepath.Glob(filepath.Join(dir, "testbeat*.ndjson"))
+	require.NoError(t, err)
+	require.Len(t, files, 1)
+	t.Cleanup(func() { _ = L().Close() })
+	return files[0]
+}
+
+func TestReopen(t *testing.T) {
+	defer SaveGlobalLogger()()
+
+	path := configureFileOutput(t)
+	moved := path + ".1"
+	require.NoError(t, os.Rename(path, moved))
+
+	require.NoError(t, Reopen())
+	L().Info("second entry")
+	require.NoError(t, L().Sync())
+
+	data, err := os.ReadFile(moved)
+	require.NoError(t, err)
+	assert.Equal(t, 1, strings.Count(string(data), "first entry"))
+	assert.NotContains(t, string(data), "second entry")
+
+	data, err = os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Equal(t, 1, strings.Count(string(data), "second entry"))
+}
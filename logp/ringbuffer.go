@@ -0,0 +1,148 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package logp
+
+import (
+	"fmt"
+	"io"
+	"sync"
+
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+// RingBufferConfig contains the configuration options for keeping the most
+// recent log entries in memory, so they can be retrieved with RecentEntries,
+// e.g. to include them in a diagnostics bundle. The entries are kept
+// regardless of the level and selectors of the other outputs.
+type RingBufferConfig struct {
+	Enabled bool  `config:"enabled" yaml:"enabled"`
+	Size    int   `config:"size" yaml:"size"`
+	Level   Level `config:"level" yaml:"level"`
+}
+
+const defaultRingBufferSize = 1000
+
+// ringBuffer keeps the last entries written to it.
+type ringBuffer struct {
+	mu      sync.Mutex
+	entries []observer.LoggedEntry
+	next    int
+	full    bool
+}
+
+func newRingBuffer(size int) *ringBuffer {
+	if size <= 0 {
+		size = defaultRingBufferSize
+	}
+	return &ringBuffer{entries: make([]observer.LoggedEntry, size)}
+}
+
+func (r *ringBuffer) add(entry observer.LoggedEntry) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.entries[r.next] = entry
+	r.next = (r.next + 1) % len(r.entries)
+	if r.next == 0 {
+		r.full = true
+	}
+}
+
+// all returns the entries from the oldest to the most recent.
+func (r *ringBuffer) all() []observer.LoggedEntry {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if !r.full {
+		return append([]observer.LoggedEntry(nil), r.entries[:r.next]...)
+	}
+	entries := make([]observer.LoggedEntry, 0, len(r.entries))
+	entries = append(entries, r.entries[r.next:]...)
+	return append(entries, r.entries[:r.next]...)
+}
+
+// ringCore writes entries to a ringBuffer.
+type ringCore struct {
+	zapcore.LevelEnabler
+	buffer *ringBuffer
+	fields []zapcore.Field
+}
+
+func (c *ringCore) With(fields []zapcore.Field) zapcore.Core {
+	clone := *c
+	clone.fields = make([]zapcore.Field, 0, len(c.fields)+len(fields))
+	clone.fields = append(clone.fields, c.fields...)
+	clone.fields = append(clone.fields, fields...)
+	return &clone
+}
+
+func (c *ringCore) Check(entry zapcore.Entry, checked *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	if c.Enabled(entry.Level) {
+		return checked.AddCore(entry, c)
+	}
+	return checked
+}
+
+func (c *ringCore) Write(entry zapcore.Entry, fields []zapcore.Field) error {
+	context := make([]zapcore.Field, 0, len(c.fields)+len(fields))
+	context = append(context, c.fields...)
+	context = append(context, fields...)
+	c.buffer.add(observer.LoggedEntry{Entry: entry, Context: context})
+	return nil
+}
+
+func (c *ringCore) Sync() error {
+	return nil
+}
+
+// withRingBuffer adds a ring buffer output to sink if it is enabled in cfg.
+func withRingBuffer(sink zapcore.Core, cfg RingBufferConfig) (zapcore.Core, *ringBuffer) {
+	if !cfg.Enabled {
+		return sink, nil
+	}
+	buffer := newRingBuffer(cfg.Size)
+	core := &ringCore{LevelEnabler: cfg.Level.ZapLevel(), buffer: buffer}
+	return newMultiCore(sink, core), buffer
+}
+
+// RecentEntries returns the most recent log entries, from the oldest to the
+// most recent, if the ring buffer is enabled in the configuration.
+func RecentEntries() []observer.LoggedEntry {
+	buffer := loadLogger().ringBuffer
+	if buffer == nil {
+		return nil
+	}
+	return buffer.all()
+}
+
+// WriteRecentEntries writes the most recent log entries to w as
+// newline-delimited JSON, using the same format as the file output.
+func WriteRecentEntries(w io.Writer) error {
+	enc := buildEncoder(Config{})
+	for _, entry := range RecentEntries() {
+		buf, err := enc.EncodeEntry(entry.Entry, entry.Context)
+		if err != nil {
+			return fmt.Errorf("failed to encode entry: %w", err)
+		}
+		_, err = w.Write(buf.Bytes())
+		buf.Free()
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
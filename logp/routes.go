@@ -0,0 +1,250 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package logp
+
+import (
+	"errors"
+	"fmt"
+	"io"
+
+	"go.uber.org/zap/zapcore"
+
+	"github.com/elastic/elastic-agent-libs/config"
+)
+
+// RouteConfig routes the entries of some selectors or levels to a dedicated
+// output instead of the default one. An entry matches a route if its
+// selector is one of Selectors and its level one of Levels; an empty list
+// matches everything. Entries are sent to the output of the first matching
+// route.
+type RouteConfig struct {
+	Selectors []string `config:"selectors" yaml:"selectors"`
+	Levels    []Level  `config:"levels" yaml:"levels"`
+	// Output is one of file, stderr, syslog, eventlog, journald, socket or
+	// discard. The socket output uses the socket settings.
+	Output string `config:"output" yaml:"output"`
+	// File configures the file output, it defaults to the default file
+	// settings but Name must be set.
+	File FileConfig `config:"file" yaml:"file"`
+}
+
+// Unpack unpacks a route, using the default file output settings for the
+// missing file settings.
+func (r *RouteConfig) Unpack(c *config.C) error {
+	type routeConfig RouteConfig
+	tmp := routeConfig{File: DefaultConfig(DefaultEnvironment).Files}
+	if err := c.Unpack(&tmp); err != nil {
+		return err
+	}
+	route := RouteConfig(tmp)
+	if err := route.Validate(); err != nil {
+		return err
+	}
+	*r = route
+	return nil
+}
+
+// Validate checks the output of the route.
+func (r *RouteConfig) Validate() error {
+	if r.Output == "" {
+		return errors.New("route output must be set")
+	}
+	return validateNamedOutput(r.Output, r.File)
+}
+
+// validateNamedOutput checks an output name, as used by the routes, and
+// the file settings of the file output.
+func validateNamedOutput(output string, file FileConfig) error {
+	switch output {
+	case "file":
+		if file.Name == "" {
+			return errors.New("file.name must be set for the file output")
+		}
+	case "stderr", "syslog", "eventlog", "journald", "socket", "discard":
+	default:
+		return fmt.Errorf("unknown output '%s'", output)
+	}
+	return nil
+}
+
+type route struct {
+	selectors map[string]struct{}
+	levels    map[zapcore.Level]struct{}
+	core      zapcore.Core
+}
+
+func (r *route) matches(entry zapcore.Entry) bool {
+	if len(r.selectors) > 0 {
+		if _, ok := r.selectors[entry.LoggerName]; !ok {
+			return false
+		}
+	}
+	if len(r.levels) > 0 {
+		if _, ok := r.levels[entry.Level]; !ok {
+			return false
+		}
+	}
+	return true
+}
+
+// routingCore sends each entry to the core of the first matching route, or
+// to the default core.
+type routingCore struct {
+	routes      []route
+	defaultCore zapcore.Core
+}
+
+// withoutOutputs returns a copy of cfg with all the outputs disabled, to
+// configure a single output.
+func withoutOutputs(cfg Config) Config {
+	cfg.toObserver, cfg.toIODiscard, cfg.toNop = false, false, false
+	cfg.ToStderr, cfg.ToSyslog, cfg.ToFiles = false, false, false
+	cfg.ToEventLog, cfg.ToJournald, cfg.ToSocket = false, false, false
+	cfg.Routes = nil
+	return cfg
+}
+
+// createNamedOutput builds the output with the given name, as used by the
+// routes, using file for the file output.
+func createNamedOutput(cfg Config, output string, file FileConfig, enab zapcore.LevelEnabler) (zapcore.Core, error) {
+	outputCfg := withoutOutputs(cfg)
+	switch output {
+	case "file":
+		outputCfg.ToFiles = true
+		outputCfg.Files = file
+		outputCfg.Files.RedirectStderr = false
+	case "stderr":
+		outputCfg.ToStderr = true
+	case "syslog":
+		outputCfg.ToSyslog = true
+	case "eventlog":
+		outputCfg.ToEventLog = true
+	case "journald":
+		outputCfg.ToJournald = true
+	case "socket":
+		outputCfg.ToSocket = true
+	case "discard":
+		outputCfg.toIODiscard = true
+	}
+	return createLogOutput(outputCfg, enab)
+}
+
+// routingWrapper builds the route outputs and returns a core routing the
+// entries between them and core.
+func routingWrapper(core zapcore.Core, cfg Config, enab zapcore.LevelEnabler) (zapcore.Core, error) {
+	if len(cfg.Routes) == 0 {
+		return core, nil
+	}
+
+	routes := make([]route, 0, len(cfg.Routes))
+	for i, routeCfg := range cfg.Routes {
+		if err := routeCfg.Validate(); err != nil {
+			return nil, fmt.Errorf("invalid route %d: %w", i, err)
+		}
+
+		routeCore, err := createNamedOutput(cfg, routeCfg.Output, routeCfg.File, enab)
+		if err != nil {
+			return nil, fmt.Errorf("failed to build the output of route %d: %w", i, err)
+		}
+
+		r := route{core: routeCore}
+		if len(routeCfg.Selectors) > 0 {
+			r.selectors = make(map[string]struct{}, len(routeCfg.Selectors))
+			for _, sel := range routeCfg.Selectors {
+				r.selectors[sel] = struct{}{}
+			}
+		}
+		if len(routeCfg.Levels) > 0 {
+			r.levels = make(map[zapcore.Level]struct{}, len(routeCfg.Levels))
+			for _, lvl := range routeCfg.Levels {
+				r.levels[lvl.ZapLevel()] = struct{}{}
+			}
+		}
+		routes = append(routes, r)
+	}
+
+	return &routingCore{routes: routes, defaultCore: core}, nil
+}
+
+func (c *routingCore) Enabled(level zapcore.Level) bool {
+	if c.defaultCore.Enabled(level) {
+		return true
+	}
+	for _, r := range c.routes {
+		if r.core.Enabled(level) {
+			return true
+		}
+	}
+	return false
+}
+
+func (c *routingCore) With(fields []zapcore.Field) zapcore.Core {
+	clone := &routingCore{
+		routes:      make([]route, len(c.routes)),
+		defaultCore: c.defaultCore.With(fields),
+	}
+	for i, r := range c.routes {
+		r.core = r.core.With(fields)
+		clone.routes[i] = r
+	}
+	return clone
+}
+
+func (c *routingCore) Check(entry zapcore.Entry, checked *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	return c.coreFor(entry).Check(entry, checked)
+}
+
+// Write writes the entry to the core of the matching route. It is called
+// when a wrapping core adds itself to the checked entry instead of
+// delegating Check.
+func (c *routingCore) Write(entry zapcore.Entry, fields []zapcore.Field) error {
+	return c.coreFor(entry).Write(entry, fields)
+}
+
+func (c *routingCore) coreFor(entry zapcore.Entry) zapcore.Core {
+	for _, r := range c.routes {
+		if r.matches(entry) {
+			return r.core
+		}
+	}
+	return c.defaultCore
+}
+
+func (c *routingCore) Sync() error {
+	errs := []error{c.defaultCore.Sync()}
+	for _, r := range c.routes {
+		errs = append(errs, r.core.Sync())
+	}
+	return errors.Join(errs...)
+}
+
+// Close calls Close on the cores that implement io.Closer.
+func (c *routingCore) Close() error {
+	cores := []zapcore.Core{c.defaultCore}
+	for _, r := range c.routes {
+		cores = append(cores, r.core)
+	}
+
+	var errs []error
+	for _, core := range cores {
+		if closer, ok := core.(io.Closer); ok {
+			errs = append(errs, closer.Close())
+		}
+	}
+	return errors.Join(errs...)
+}
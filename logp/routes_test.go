@@ -0,0 +1,99 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package logp
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/elastic/elastic-agent-libs/config"
+)
+
+func TestRoutes(t *testing.T) {
+	defer SaveGlobalLogger()()
+
+	dir := t.TempDir()
+	cfg := DefaultConfig(DefaultEnvironment)
+	cfg.Beat = "testbeat"
+	cfg.Level = DebugLevel
+	cfg.Selectors = []string{"*"}
+	cfg.Routes = []RouteConfig{
+		{Selectors: []string{"monitoring"}, Output: "file", File: FileConfig{
+			Path:        dir,
+			Name:        "monitoring",
+			MaxSize:     1024 * 1024,
+			Permissions: 0600,
+		}},
+		{Levels: []Level{DebugLevel}, Output: "discard"},
+	}
+	ToObserverOutput()(&cfg)
+	require.NoError(t, Configure(cfg))
+
+	NewLogger("monitoring").Info("monitoring entry")
+	NewLogger("monitoring").Debug("monitoring debug entry")
+	NewLogger("other").Debug("discarded entry")
+	NewLogger("other").Info("default entry")
+	require.NoError(t, L().Sync())
+
+	entries := ObserverLogs().TakeAll()
+	require.Len(t, entries, 1)
+	assert.Equal(t, "default entry", entries[0].Message)
+
+	files, err := filepath.Glob(filepath.Join(dir, "monitoring*.ndjson"))
+	require.NoError(t, err)
+	require.Len(t, files, 1)
+	data, err := os.ReadFile(files[0])
+	require.NoError(t, err)
+	lines := strings.Split(strings.TrimSpace(string(data)), "\n")
+	require.Len(t, lines, 2)
+	assert.Contains(t, lines[0], "monitoring entry")
+	assert.Contains(t, lines[1], "monitoring debug entry")
+}
+
+func TestRouteConfigUnpack(t *testing.T) {
+	c := config.MustNewConfigFrom(map[string]interface{}{
+		"routes": []interface{}{
+			map[string]interface{}{
+				"selectors": []string{"monitoring"},
+				"output":    "file",
+				"file":      map[string]interface{}{"name": "monitoring"},
+			},
+		},
+	})
+
+	cfg := DefaultConfig(DefaultEnvironment)
+	require.NoError(t, c.Unpack(&cfg))
+	require.Len(t, cfg.Routes, 1)
+	assert.Equal(t, "monitoring", cfg.Routes[0].File.Name)
+	assert.Equal(t, cfg.Files.MaxSize, cfg.Routes[0].File.MaxSize, "missing file settings must use the defaults")
+
+	for name, route := range map[string]map[string]interface{}{
+		"no output":      {"selectors": []string{"monitoring"}},
+		"unknown output": {"output": "kafka"},
+		"no file name":   {"output": "file"},
+	} {
+		c := config.MustNewConfigFrom(map[string]interface{}{"routes": []interface{}{route}})
+		cfg := DefaultConfig(DefaultEnvironment)
+		assert.Error(t, c.Unpack(&cfg), name)
+	}
+}
@@ -0,0 +1,177 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package logp
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"go.uber.org/zap/zapcore"
+)
+
+// SchemaConfig contains the configuration options for validating the
+// names of the fields logged. It is meant for development and CI: each
+// field not in the schema is reported once with a warning, and recorded
+// in SchemaViolations, so non-ECS field names are caught before they reach
+// production indices.
+type SchemaConfig struct {
+	// Validate enables the validation.
+	Validate bool `config:"validate" yaml:"validate"`
+	// ECS allows the fields from the ECS field sets.
+	ECS bool `config:"ecs" yaml:"ecs"`
+	// Fields lists the other allowed fields. A name ending with ".*" allows
+	// all the fields under it.
+	Fields []string `config:"fields" yaml:"fields"`
+}
+
+// ecsRoots are the ECS base fields and the ECS field sets that can be
+// used at the root of the documents.
+var ecsRoots = map[string]struct{}{
+	"@timestamp": {}, "labels": {}, "message": {}, "tags": {},
+	"agent": {}, "client": {}, "cloud": {}, "container": {}, "data_stream": {},
+	"destination": {}, "device": {}, "dll": {}, "dns": {}, "ecs": {},
+	"email": {}, "error": {}, "event": {}, "faas": {}, "file": {}, "group": {},
+	"host": {}, "http": {}, "log": {}, "network": {}, "observer": {},
+	"orchestrator": {}, "organization": {}, "package": {}, "process": {},
+	"registry": {}, "related": {}, "rule": {}, "server": {}, "service": {},
+	"source": {}, "span": {}, "threat": {}, "tls": {}, "trace": {},
+	"transaction": {}, "url": {}, "user": {}, "user_agent": {},
+	"vulnerability": {},
+}
+
+var schemaViolations = struct {
+	sync.Mutex
+	fields map[string]uint64
+}{fields: map[string]uint64{}}
+
+// SchemaViolations returns the fields logged that are not in the schema,
+// with the number of times they were logged, since the process started.
+func SchemaViolations() map[string]uint64 {
+	schemaViolations.Lock()
+	defer schemaViolations.Unlock()
+	violations := make(map[string]uint64, len(schemaViolations.fields))
+	for field, count := range schemaViolations.fields {
+		violations[field] = count
+	}
+	return violations
+}
+
+// recordSchemaViolation counts a violation, it returns true for the first
+// one of field.
+func recordSchemaViolation(field string) bool {
+	schemaViolations.Lock()
+	defer schemaViolations.Unlock()
+	schemaViolations.fields[field]++
+	return schemaViolations.fields[field] == 1
+}
+
+type fieldSchema struct {
+	ecs      bool
+	fields   map[string]struct{}
+	prefixes []string
+}
+
+func (s *fieldSchema) allows(name string) bool {
+	if _, ok := s.fields[name]; ok {
+		return true
+	}
+	for _, prefix := range s.prefixes {
+		if strings.HasPrefix(name, prefix) {
+			return true
+		}
+	}
+	if s.ecs {
+		root, _, _ := strings.Cut(name, ".")
+		_, ok := ecsRoots[root]
+		return ok
+	}
+	return false
+}
+
+// schemaCore validates the names of the fields of the entries, and the
+// fields added with With, before writing them to the wrapped core.
+type schemaCore struct {
+	zapcore.Core
+	schema    *fieldSchema
+	namespace string // Prefix of the fields, set by zap.Namespace.
+}
+
+// schemaWrapper wraps core to validate the field names if validation is
+// enabled.
+func schemaWrapper(core zapcore.Core, cfg SchemaConfig) zapcore.Core {
+	if !cfg.Validate {
+		return core
+	}
+	schema := &fieldSchema{ecs: cfg.ECS, fields: map[string]struct{}{}}
+	for _, field := range cfg.Fields {
+		if prefix, ok := strings.CutSuffix(field, "*"); ok {
+			schema.prefixes = append(schema.prefixes, prefix)
+			continue
+		}
+		schema.fields[field] = struct{}{}
+	}
+	return &schemaCore{Core: core, schema: schema}
+}
+
+func (c *schemaCore) With(fields []zapcore.Field) zapcore.Core {
+	namespace := c.validate("", c.namespace, fields)
+	return &schemaCore{Core: c.Core.With(fields), schema: c.schema, namespace: namespace}
+}
+
+func (c *schemaCore) Check(entry zapcore.Entry, checked *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	if c.Enabled(entry.Level) {
+		return checked.AddCore(entry, c)
+	}
+	return checked
+}
+
+func (c *schemaCore) Write(entry zapcore.Entry, fields []zapcore.Field) error {
+	c.validate(entry.LoggerName, c.namespace, fields)
+	if ce := c.Core.Check(entry, nil); ce != nil {
+		ce.Write(fields...)
+	}
+	return nil
+}
+
+// validate reports the fields not in the schema, and returns the namespace
+// of the fields following them.
+func (c *schemaCore) validate(logger, namespace string, fields []zapcore.Field) string {
+	for _, field := range fields {
+		if field.Type == zapcore.SkipType || field.Key == "" {
+			continue
+		}
+		name := namespace + field.Key
+		if field.Type == zapcore.NamespaceType {
+			namespace = name + "."
+		}
+		if c.schema.allows(name) || !recordSchemaViolation(name) {
+			continue
+		}
+		msg := fmt.Sprintf("Field '%s' is not in the logging schema", name)
+		if logger != "" {
+			msg = fmt.Sprintf("Field '%s' logged by '%s' is not in the logging schema", name, logger)
+		}
+		warning := zapcore.Entry{Level: zapcore.WarnLevel, Time: time.Now(), Message: msg}
+		if ce := c.Core.Check(warning, nil); ce != nil {
+			ce.Write()
+		}
+	}
+	return namespace
+}
@@ -0,0 +1,75 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package logp
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+func TestSchemaValidation(t *testing.T) {
+	defer SaveGlobalLogger()()
+	resetSchemaViolations(t)
+
+	cfg := Config{
+		Level: InfoLevel,
+		Schema: SchemaConfig{
+			Validate: true,
+			ECS:      true,
+			Fields:   []string{"component.*", "custom"},
+		},
+	}
+	ToObserverOutput()(&cfg)
+	require.NoError(t, Configure(cfg))
+
+	log := NewLogger("schema")
+	log.Infow("valid", "host.name", "test", "component.id", "filestream", "custom", 1)
+	log.Infow("invalid", "schema_test_field", 1)
+	log.Infow("invalid", "schema_test_field", 2)
+	log.With(zap.Namespace("schema_test_ns"), String("key", "value")).Info("namespaced")
+
+	assert.Equal(t, 1, ObserverLogs().FilterMessage("valid").Len())
+	assert.Equal(t, 2, ObserverLogs().FilterMessage("invalid").Len(), "entries are logged despite the violations")
+	assert.Equal(t, 1, ObserverLogs().FilterMessage("Field 'schema_test_field' logged by 'schema' is not in the logging schema").Len())
+	assert.Equal(t, 1, ObserverLogs().FilterMessage("Field 'schema_test_ns.key' is not in the logging schema").Len())
+
+	violations := SchemaViolations()
+	assert.Equal(t, uint64(2), violations["schema_test_field"])
+	assert.Equal(t, uint64(1), violations["schema_test_ns"])
+	assert.Equal(t, uint64(1), violations["schema_test_ns.key"])
+	assert.NotContains(t, violations, "host.name")
+	assert.NotContains(t, violations, "component.id")
+	assert.NotContains(t, violations, "custom")
+}
+
+// resetSchemaViolations clears the violations counted since the process
+// started, until the end of the test.
+func resetSchemaViolations(t *testing.T) {
+	schemaViolations.Lock()
+	saved := schemaViolations.fields
+	schemaViolations.fields = map[string]uint64{}
+	schemaViolations.Unlock()
+	t.Cleanup(func() {
+		schemaViolations.Lock()
+		schemaViolations.fields = saved
+		schemaViolations.Unlock()
+	})
+}
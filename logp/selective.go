@@ -19,14 +19,44 @@ package logp
 
 import (
 	"io"
+	"sort"
+	"sync"
 
 	"go.uber.org/zap/zapcore"
 )
 
+// activeSelectors is the set of the selectors of the loggers created so
+// far.
+var activeSelectors sync.Map
+
+func registerSelector(selector string) {
+	if selector == "" {
+		return
+	}
+	if _, found := activeSelectors.Load(selector); !found {
+		activeSelectors.Store(selector, struct{}{})
+	}
+}
+
+// ActiveSelectors returns the sorted selectors of the loggers created so far
+// by the process, with NewLogger or Logger.Named. They are the selectors an
+// operator can enable to get the debug entries of these loggers.
+func ActiveSelectors() []string {
+	var selectors []string
+	activeSelectors.Range(func(key, _ interface{}) bool {
+		selectors = append(selectors, key.(string))
+		return true
+	})
+	sort.Strings(selectors)
+	return selectors
+}
+
 type selectiveCore struct {
 	allSelectors bool
 	selectors    map[string]struct{}
+	levels       *levels
 	core         zapcore.Core
+	overridden   bool // Set by Logger.WithLevel.
 }
 
 // HasSelector returns true if the given selector was explicitly set.
@@ -35,12 +65,12 @@ func HasSelector(selector string) bool {
 	return found
 }
 
-func selectiveWrapper(core zapcore.Core, selectors map[string]struct{}) zapcore.Core {
+func selectiveWrapper(core zapcore.Core, selectors map[string]struct{}, levels *levels) zapcore.Core {
 	if len(selectors) == 0 {
 		return core
 	}
 	_, allSelectors := selectors["*"]
-	return &selectiveCore{selectors: selectors, core: core, allSelectors: allSelectors}
+	return &selectiveCore{selectors: selectors, levels: levels, core: core, allSelectors: allSelectors}
 }
 
 // Enabled returns whether a given logging level is enabled when logging a
@@ -51,7 +81,12 @@ func (c *selectiveCore) Enabled(level zapcore.Level) bool {
 
 // With adds structured context to the Core.
 func (c *selectiveCore) With(fields []zapcore.Field) zapcore.Core {
-	return selectiveWrapper(c.core.With(fields), c.selectors)
+	clone := *c
+	clone.core = c.core.With(fields)
+	if _, ok := levelOverride(fields); ok {
+		clone.overridden = true
+	}
+	return &clone
 }
 
 // Check determines whether the supplied Entry should be logged (using the
@@ -67,6 +102,10 @@ func (c *selectiveCore) Check(ent zapcore.Entry, ce *zapcore.CheckedEntry) *zapc
 				return ce.AddCore(ent, c)
 			} else if _, enabled := c.selectors[ent.LoggerName]; enabled {
 				return ce.AddCore(ent, c)
+			} else if _, overridden := c.levels.selectorLevel(ent.LoggerName); overridden || c.overridden {
+				// The level set with SetSelectorLevel or Logger.WithLevel
+				// was checked by levelCore.
+				return ce.AddCore(ent, c)
 			}
 			return ce
 		}
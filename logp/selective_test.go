@@ -31,6 +31,17 @@ func TestHasSelector(t *testing.T) {
 	assert.False(t, HasSelector("publish"))
 }
 
+func TestActiveSelectors(t *testing.T) {
+	NewLogger("active-selector").Named("child")
+	NewLogger("")
+
+	selectors := ActiveSelectors()
+	assert.Contains(t, selectors, "active-selector")
+	assert.Contains(t, selectors, "active-selector.child")
+	assert.NotContains(t, selectors, "")
+	assert.IsNonDecreasing(t, selectors)
+}
+
 func TestLoggerSelectors(t *testing.T) {
 	if err := DevelopmentSetup(WithSelectors("good", " padded "), ToObserverOutput()); err != nil {
 		t.Fatal(err)
@@ -0,0 +1,116 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package logp
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// Selector is the name of a logger, used to enable its debug entries with
+// the selectors of the logging configuration. Declaring the selectors of a
+// package as constants of this type and registering them lets the compiler
+// catch the typos in their names:
+//
+//	const Harvester logp.Selector = "harvester"
+//
+//	var _ = logp.RegisterSelector(Harvester, "Harvesting of the log files.")
+//
+//	log := Harvester.NewLogger()
+type Selector string
+
+// SelectorInfo describes a registered selector.
+type SelectorInfo struct {
+	Name        Selector
+	Description string
+}
+
+var selectorRegistry = struct {
+	mu        sync.RWMutex
+	selectors map[Selector]string
+}{selectors: map[Selector]string{}}
+
+var _ = RegisterSelector("stdlog", "Output of the standard library log package.")
+
+// RegisterSelector registers a selector with its description and returns
+// it. Registering a selector again with the same description does
+// nothing, it panics if the description is different.
+func RegisterSelector(selector Selector, description string) Selector {
+	selectorRegistry.mu.Lock()
+	defer selectorRegistry.mu.Unlock()
+	if previous, found := selectorRegistry.selectors[selector]; found && previous != description {
+		panic(fmt.Sprintf("logp: selector '%s' is already registered with the description '%s'", selector, previous))
+	}
+	selectorRegistry.selectors[selector] = description
+	return selector
+}
+
+// RegisteredSelectors returns the registered selectors sorted by name, e.g.
+// to list them in the documentation.
+func RegisteredSelectors() []SelectorInfo {
+	selectorRegistry.mu.RLock()
+	defer selectorRegistry.mu.RUnlock()
+	selectors := make([]SelectorInfo, 0, len(selectorRegistry.selectors))
+	for name, description := range selectorRegistry.selectors {
+		selectors = append(selectors, SelectorInfo{Name: name, Description: description})
+	}
+	sort.Slice(selectors, func(i, j int) bool { return selectors[i].Name < selectors[j].Name })
+	return selectors
+}
+
+// ValidateSelectors checks that the selectors of the logging configuration
+// are registered. The selectors of the children of a registered selector,
+// created with Logger.Named, and the * selector are valid too.
+func ValidateSelectors(selectors []string) error {
+	selectorRegistry.mu.RLock()
+	defer selectorRegistry.mu.RUnlock()
+
+	var unknown []string
+	for _, selector := range selectors {
+		selector = strings.TrimSpace(selector)
+		if selector != "*" && !registeredSelector(selector) {
+			unknown = append(unknown, selector)
+		}
+	}
+	if len(unknown) > 0 {
+		return fmt.Errorf("unknown logging selectors: %s", strings.Join(unknown, ", "))
+	}
+	return nil
+}
+
+// registeredSelector returns true if selector or one of its parents is
+// registered. It must be called with the registry lock held.
+func registeredSelector(selector string) bool {
+	for {
+		if _, found := selectorRegistry.selectors[Selector(selector)]; found {
+			return true
+		}
+		i := strings.LastIndexByte(selector, '.')
+		if i < 0 {
+			return false
+		}
+		selector = selector[:i]
+	}
+}
+
+// NewLogger returns a new Logger labeled with the selector, see NewLogger.
+func (s Selector) NewLogger(options ...LogOption) *Logger {
+	return newLogger(loadLogger().rootLogger, string(s), options...)
+}
@@ -0,0 +1,42 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package logp
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSelectorRegistry(t *testing.T) {
+	const registered Selector = "registry-test"
+	assert.Equal(t, registered, RegisterSelector(registered, "Registry test."))
+	assert.NotPanics(t, func() { RegisterSelector(registered, "Registry test.") })
+	assert.Panics(t, func() { RegisterSelector(registered, "Another description.") })
+
+	assert.Contains(t, RegisteredSelectors(), SelectorInfo{Name: registered, Description: "Registry test."})
+	assert.Contains(t, RegisteredSelectors(), SelectorInfo{Name: "stdlog", Description: "Output of the standard library log package."})
+
+	require.NoError(t, ValidateSelectors([]string{"*", "stdlog", " registry-test ", "registry-test.child"}))
+	err := ValidateSelectors([]string{"registry-test", "registry", "unknown"})
+	require.Error(t, err)
+	assert.Equal(t, "unknown logging selectors: registry, unknown", err.Error())
+
+	assert.Equal(t, "registry-test", registered.NewLogger().logger.Name())
+}
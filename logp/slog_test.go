@@ -0,0 +1,84 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package logp
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+func TestSlogHandler(t *testing.T) {
+	t.Parallel()
+
+	log, logs := NewInMemoryLogger()
+	logger := slog.New(NewSlogHandler(log.Named("lib")))
+
+	logger.Debug("debug entry", "count", 3)
+	logger.Log(context.Background(), slog.LevelWarn-1, "almost a warning")
+	logger.With("component", "test").WithGroup("sub").Warn("grouped entry",
+		slog.Group("req", "method", "GET", slog.Int("status", 200)))
+	logger.Error("error entry", "error", errors.New("boom"))
+
+	entries := logs.TakeAll()
+	require.Len(t, entries, 4)
+
+	assert.Equal(t, zapcore.DebugLevel, entries[0].Level)
+	assert.Equal(t, "lib", entries[0].LoggerName)
+	assert.Equal(t, int64(3), entries[0].ContextMap()["count"])
+
+	assert.Equal(t, zapcore.InfoLevel, entries[1].Level)
+
+	assert.Equal(t, zapcore.WarnLevel, entries[2].Level)
+	assert.Equal(t, "lib.sub", entries[2].LoggerName)
+	assert.Equal(t, map[string]interface{}{
+		"component": "test",
+		"req":       map[string]interface{}{"method": "GET", "status": int64(200)},
+	}, entries[2].ContextMap())
+
+	assert.Equal(t, zapcore.ErrorLevel, entries[3].Level)
+	assert.Equal(t, "boom", entries[3].ContextMap()["error"])
+}
+
+func TestSlogHandlerEnabled(t *testing.T) {
+	t.Parallel()
+
+	log, _ := NewInMemoryLogger(zap.IncreaseLevel(zapcore.WarnLevel))
+	h := NewSlogHandler(log)
+	assert.False(t, h.Enabled(context.Background(), slog.LevelInfo))
+	assert.True(t, h.Enabled(context.Background(), slog.LevelWarn))
+}
+
+func TestSlogHandlerCaller(t *testing.T) {
+	t.Parallel()
+
+	log, logs := NewInMemoryLogger(zap.AddCaller())
+	slog.New(NewSlogHandler(log)).Info("entry")
+
+	entries := logs.TakeAll()
+	require.Len(t, entries, 1)
+	assert.True(t, entries[0].Caller.Defined)
+	assert.Equal(t, "slog_test.go", filepath.Base(entries[0].Caller.File))
+}
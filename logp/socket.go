@@ -0,0 +1,171 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package logp
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"sync"
+	"time"
+
+	"go.uber.org/zap/zapcore"
+)
+
+// SocketConfig contains the configuration options for sending the logs, as
+// newline-delimited JSON, to a socket, like the one of a local collector.
+type SocketConfig struct {
+	// Address is the address of the socket, in the host:port form or the
+	// path of a unix socket.
+	Address string `config:"address" yaml:"address"`
+	// Network is one of tcp (default), udp or unix. Each entry is sent in its own
+	// datagram over udp.
+	Network string `config:"network" yaml:"network"`
+	// Backoff configures the time waited before reconnecting once the
+	// connection fails. Entries logged meanwhile are dropped.
+	Backoff SocketBackoffConfig `config:"backoff" yaml:"backoff"`
+}
+
+// SocketBackoffConfig configures an exponential backoff.
+type SocketBackoffConfig struct {
+	Init time.Duration `config:"init" yaml:"init"`
+	Max  time.Duration `config:"max" yaml:"max"`
+}
+
+const socketDialTimeout = 10 * time.Second
+
+func defaultSocketConfig() SocketConfig {
+	return SocketConfig{
+		Network: "tcp",
+		Backoff: SocketBackoffConfig{
+			Init: time.Second,
+			Max:  time.Minute,
+		},
+	}
+}
+
+// Validate checks the network and the backoff.
+func (c *SocketConfig) Validate() error {
+	switch c.Network {
+	case "", "tcp", "tcp4", "tcp6", "udp", "udp4", "udp6", "unix":
+	default:
+		return fmt.Errorf("unsupported socket network '%s'", c.Network)
+	}
+	if c.Backoff.Init < 0 || c.Backoff.Max < c.Backoff.Init {
+		return errors.New("socket backoff.init must be positive and not greater than backoff.max")
+	}
+	return nil
+}
+
+// socketWriter writes to a socket, reconnecting with an exponential
+// backoff when the connection fails.
+type socketWriter struct {
+	network string
+	address string
+	backoff SocketBackoffConfig
+
+	mu      sync.Mutex
+	conn    net.Conn
+	wait    time.Duration // Current backoff, 0 if connected.
+	retryAt time.Time
+}
+
+func newSocketWriter(cfg SocketConfig) *socketWriter {
+	return &socketWriter{network: cfg.Network, address: cfg.Address, backoff: cfg.Backoff}
+}
+
+func (w *socketWriter) Write(p []byte) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	// Retry once on a new connection, the peer may have closed the
+	// previous one.
+	var err error
+	for i := 0; i < 2; i++ {
+		if err = w.connect(); err != nil {
+			return 0, err
+		}
+		var n int
+		if n, err = w.conn.Write(p); err == nil {
+			return n, nil
+		}
+		w.conn.Close()
+		w.conn = nil
+	}
+	return 0, fmt.Errorf("failed to write to socket '%s': %w", w.address, err)
+}
+
+// connect connects to the socket if not connected and if the backoff is
+// over. It must be called with the lock held.
+func (w *socketWriter) connect() error {
+	if w.conn != nil {
+		return nil
+	}
+	now := time.Now()
+	if now.Before(w.retryAt) {
+		return fmt.Errorf("socket '%s' unavailable, reconnecting in %s", w.address, w.retryAt.Sub(now))
+	}
+
+	conn, err := net.DialTimeout(w.network, w.address, socketDialTimeout)
+	if err != nil {
+		w.wait = min(max(2*w.wait, w.backoff.Init), w.backoff.Max)
+		w.retryAt = now.Add(w.wait)
+		return fmt.Errorf("failed to connect to socket '%s': %w", w.address, err)
+	}
+	w.conn, w.wait, w.retryAt = conn, 0, time.Time{}
+	return nil
+}
+
+func (w *socketWriter) Sync() error {
+	return nil
+}
+
+func (w *socketWriter) Close() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.conn == nil {
+		return nil
+	}
+	err := w.conn.Close()
+	w.conn = nil
+	return err
+}
+
+func makeSocketOutput(cfg Config, enab zapcore.LevelEnabler) (zapcore.Core, error) {
+	if cfg.Socket.Address == "" {
+		return nil, errors.New("socket.address must be set to log to a socket")
+	}
+	if err := cfg.Socket.Validate(); err != nil {
+		return nil, err
+	}
+
+	defaults := defaultSocketConfig()
+	if cfg.Socket.Network == "" {
+		cfg.Socket.Network = defaults.Network
+	}
+	if cfg.Socket.Backoff.Init == 0 {
+		cfg.Socket.Backoff.Init = defaults.Backoff.Init
+	}
+	if cfg.Socket.Backoff.Max < cfg.Socket.Backoff.Init {
+		cfg.Socket.Backoff.Max = max(defaults.Backoff.Max, cfg.Socket.Backoff.Init)
+	}
+
+	writer := newSocketWriter(cfg.Socket)
+	core := newCore(buildOutputEncoder(cfg, EncoderConfig{Format: encoderFormatJSON}), writer, enab)
+	return &closerCore{Core: stacktraceWrapper(core, cfg, EncoderConfig{}), Closer: writer}, nil
+}
@@ -0,0 +1,111 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package logp
+
+import (
+	"bufio"
+	"encoding/json"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+func TestSocketOutput(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer ln.Close()
+
+	lines := make(chan string, 10)
+	go func() {
+		conn, err := ln.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		scanner := bufio.NewScanner(conn)
+		for scanner.Scan() {
+			lines <- scanner.Text()
+		}
+	}()
+
+	cfg := DefaultConfig(DefaultEnvironment)
+	cfg.ToFiles = false
+	cfg.ToSocket = true
+	cfg.Socket.Address = ln.Addr().String()
+
+	out, err := createLogOutput(cfg, zapcore.InfoLevel)
+	require.NoError(t, err)
+	logger := NewLogger("socket").WithOptions(zap.WrapCore(func(zapcore.Core) zapcore.Core { return out }))
+	t.Cleanup(func() { _ = logger.Close() })
+
+	logger.Infow("first", "count", 1)
+	logger.Info("second")
+
+	for _, msg := range []string{"first", "second"} {
+		select {
+		case line := <-lines:
+			var doc map[string]interface{}
+			require.NoError(t, json.Unmarshal([]byte(line), &doc))
+			assert.Equal(t, msg, doc["message"])
+			assert.Equal(t, "socket", doc["log.logger"])
+		case <-time.After(5 * time.Second):
+			t.Fatalf("timeout waiting for %q", msg)
+		}
+	}
+}
+
+func TestSocketWriterBackoff(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	addr := ln.Addr().String()
+	require.NoError(t, ln.Close())
+
+	w := newSocketWriter(SocketConfig{
+		Network: "tcp",
+		Address: addr,
+		Backoff: SocketBackoffConfig{Init: time.Hour, Max: 2 * time.Hour},
+	})
+	defer w.Close()
+
+	_, err = w.Write([]byte("line\n"))
+	assert.ErrorContains(t, err, "failed to connect")
+	assert.Equal(t, time.Hour, w.wait)
+
+	// No new connection is attempted until the backoff is over.
+	_, err = w.Write([]byte("line\n"))
+	assert.ErrorContains(t, err, "unavailable")
+
+	w.retryAt = time.Time{}
+	_, err = w.Write([]byte("line\n"))
+	assert.ErrorContains(t, err, "failed to connect")
+	assert.Equal(t, 2*time.Hour, w.wait)
+}
+
+func TestSocketConfigValidate(t *testing.T) {
+	assert.NoError(t, (&SocketConfig{}).Validate())
+	assert.Error(t, (&SocketConfig{Network: "sctp"}).Validate())
+	assert.Error(t, (&SocketConfig{Backoff: SocketBackoffConfig{Init: time.Minute, Max: time.Second}}).Validate())
+
+	_, err := makeSocketOutput(Config{}, zapcore.InfoLevel)
+	assert.Error(t, err)
+}
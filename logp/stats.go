@@ -0,0 +1,119 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package logp
+
+import (
+	"sync/atomic"
+
+	"go.uber.org/zap/buffer"
+	"go.uber.org/zap/zapcore"
+)
+
+// Stats contains counters about the logs written by the process since it
+// started, for all the loggers configured by the package.
+type Stats struct {
+	// Entries logged by level. Critical counts the DPanic, Panic and Fatal
+	// levels.
+	Debug    uint64
+	Info     uint64
+	Warn     uint64
+	Error    uint64
+	Critical uint64
+
+	// Dropped is the number of entries dropped by asynchronous outputs,
+	// see DroppedEntries.
+	Dropped uint64
+	// EncodeErrors is the number of entries that could not be encoded.
+	EncodeErrors uint64
+	// Rotations is the number of log file rotations.
+	Rotations uint64
+	// WriteFailures is the number of entries the default output failed to
+	// write and that were written to the fallback output.
+	WriteFailures uint64
+	// Throttled is the number of entries dropped by the throttling of
+	// their selector.
+	Throttled uint64
+}
+
+var stats struct {
+	debug, info, warn, error, critical atomic.Uint64
+	encodeErrors                       atomic.Uint64
+	rotations                          atomic.Uint64
+	writeFailures                      atomic.Uint64
+	throttled                          atomic.Uint64
+}
+
+// GetStats returns the current value of the counters.
+func GetStats() Stats {
+	return Stats{
+		Debug:         stats.debug.Load(),
+		Info:          stats.info.Load(),
+		Warn:          stats.warn.Load(),
+		Error:         stats.error.Load(),
+		Critical:      stats.critical.Load(),
+		Dropped:       DroppedEntries(),
+		EncodeErrors:  stats.encodeErrors.Load(),
+		Rotations:     stats.rotations.Load(),
+		WriteFailures: stats.writeFailures.Load(),
+		Throttled:     stats.throttled.Load(),
+	}
+}
+
+// withStats counts the entries logged by core. It must wrap the core that
+// receives the entries first, as the hook is only called if the check of
+// core adds a core to the entry.
+func withStats(core zapcore.Core) zapcore.Core {
+	return zapcore.RegisterHooks(core, countEntry)
+}
+
+func countEntry(entry zapcore.Entry) error {
+	switch entry.Level {
+	case zapcore.DebugLevel:
+		stats.debug.Add(1)
+	case zapcore.InfoLevel:
+		stats.info.Add(1)
+	case zapcore.WarnLevel:
+		stats.warn.Add(1)
+	case zapcore.ErrorLevel:
+		stats.error.Add(1)
+	default:
+		stats.critical.Add(1)
+	}
+	return nil
+}
+
+func countRotation() {
+	stats.rotations.Add(1)
+}
+
+// countingEncoder counts the entries that fail to be encoded.
+type countingEncoder struct {
+	zapcore.Encoder
+}
+
+func (e countingEncoder) Clone() zapcore.Encoder {
+	return countingEncoder{e.Encoder.Clone()}
+}
+
+func (e countingEncoder) EncodeEntry(entry zapcore.Entry, fields []zapcore.Field) (*buffer.Buffer, error) {
+	buf, err := e.Encoder.EncodeEntry(entry, fields)
+	if err != nil {
+		stats.encodeErrors.Add(1)
+	}
+	return buf, err
+}
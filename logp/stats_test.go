@@ -0,0 +1,67 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package logp
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap/buffer"
+	"go.uber.org/zap/zapcore"
+)
+
+func TestStats(t *testing.T) {
+	defer SaveGlobalLogger()()
+
+	cfg := Config{Level: InfoLevel}
+	ToObserverOutput()(&cfg)
+	require.NoError(t, Configure(cfg))
+
+	before := GetStats()
+	log := NewLogger("stats")
+	log.Debug("disabled")
+	log.Info("info")
+	log.Warn("warn")
+	log.Warn("warn")
+	log.Error("error")
+	log.DPanic("dpanic")
+	after := GetStats()
+
+	assert.Equal(t, uint64(0), after.Debug-before.Debug)
+	assert.Equal(t, uint64(1), after.Info-before.Info)
+	assert.Equal(t, uint64(2), after.Warn-before.Warn)
+	assert.Equal(t, uint64(1), after.Error-before.Error)
+	assert.Equal(t, uint64(1), after.Critical-before.Critical)
+}
+
+type failingEncoder struct {
+	zapcore.Encoder
+}
+
+func (failingEncoder) EncodeEntry(zapcore.Entry, []zapcore.Field) (*buffer.Buffer, error) {
+	return nil, errors.New("cannot encode")
+}
+
+func TestStatsEncodeErrors(t *testing.T) {
+	before := GetStats().EncodeErrors
+	_, err := countingEncoder{failingEncoder{}}.EncodeEntry(zapcore.Entry{}, nil)
+	require.Error(t, err)
+	assert.Equal(t, uint64(1), GetStats().EncodeErrors-before)
+}
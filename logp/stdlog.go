@@ -0,0 +1,108 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package logp
+
+import (
+	"bytes"
+	"io"
+	golog "log"
+	"strings"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// stdLogWriter writes the lines of the standard log package through a
+// Logger.
+type stdLogWriter struct {
+	logger *zap.Logger
+	level  zapcore.Level
+}
+
+// NewStdLogWriter returns a writer that logs each line written to it through
+// logger. A level prefix at the start of the line, like "[ERROR]",
+// "warning:" or "DEBUG ", sets the level of the entry and is removed from
+// the message; lines without a prefix are logged at info level. Fatal and
+// panic prefixes are logged at error level, the writer never stops the
+// process.
+func NewStdLogWriter(logger *Logger) io.Writer {
+	// Skip the frames of the standard log package and of the writer, so
+	// the caller is the code using the standard logger.
+	return &stdLogWriter{logger: logger.logger.WithOptions(zap.AddCallerSkip(2)), level: zapcore.InfoLevel}
+}
+
+// NewStdLogger returns a standard library logger writing through logger,
+// see NewStdLogWriter. It can be passed to third-party libraries accepting
+// a *log.Logger.
+func NewStdLogger(logger *Logger) *golog.Logger {
+	return golog.New(NewStdLogWriter(logger), "", 0)
+}
+
+// Write logs each line of p.
+func (w *stdLogWriter) Write(p []byte) (int, error) {
+	for _, line := range bytes.Split(bytes.TrimRight(p, "\n"), []byte("\n")) {
+		level, msg := parseStdLogLevel(string(line), w.level)
+		if ce := w.logger.Check(level, msg); ce != nil {
+			ce.Write()
+		}
+	}
+	return len(p), nil
+}
+
+var stdLogLevels = map[string]zapcore.Level{
+	"debug":    zapcore.DebugLevel,
+	"trace":    zapcore.DebugLevel,
+	"info":     zapcore.InfoLevel,
+	"notice":   zapcore.InfoLevel,
+	"warn":     zapcore.WarnLevel,
+	"warning":  zapcore.WarnLevel,
+	"err":      zapcore.ErrorLevel,
+	"error":    zapcore.ErrorLevel,
+	"crit":     zapcore.ErrorLevel,
+	"critical": zapcore.ErrorLevel,
+	"fatal":    zapcore.ErrorLevel,
+	"panic":    zapcore.ErrorLevel,
+}
+
+// parseStdLogLevel returns the level of the line prefix and the line
+// without it, or def and the line if there is no prefix.
+func parseStdLogLevel(line string, def zapcore.Level) (zapcore.Level, string) {
+	trimmed := strings.TrimLeft(line, " \t")
+
+	var name, rest string
+	switch {
+	case strings.HasPrefix(trimmed, "["):
+		end := strings.IndexByte(trimmed, ']')
+		if end < 0 {
+			return def, line
+		}
+		name, rest = trimmed[1:end], trimmed[end+1:]
+	default:
+		end := strings.IndexAny(trimmed, ": ")
+		if end < 0 {
+			return def, line
+		}
+		name, rest = trimmed[:end], trimmed[end+1:]
+	}
+
+	level, ok := stdLogLevels[strings.ToLower(name)]
+	if !ok {
+		return def, line
+	}
+	return level, strings.TrimLeft(rest, " \t:")
+}
@@ -0,0 +1,64 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package logp
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+func TestStdLogger(t *testing.T) {
+	t.Parallel()
+
+	log, logs := NewInMemoryLogger(zap.AddCaller())
+	std := NewStdLogger(log.Named("std"))
+
+	std.Print("plain message")
+	std.Print("[ERROR] something failed")
+	std.Print("warning: something is odd")
+	std.Printf("DEBUG details %d", 42)
+	std.Print("[FATAL] must not exit")
+	std.Print("[unknown] prefix kept\nsecond line")
+
+	entries := logs.TakeAll()
+	require.Len(t, entries, 7)
+
+	expected := []struct {
+		level   zapcore.Level
+		message string
+	}{
+		{zapcore.InfoLevel, "plain message"},
+		{zapcore.ErrorLevel, "something failed"},
+		{zapcore.WarnLevel, "something is odd"},
+		{zapcore.DebugLevel, "details 42"},
+		{zapcore.ErrorLevel, "must not exit"},
+		{zapcore.InfoLevel, "[unknown] prefix kept"},
+		{zapcore.InfoLevel, "second line"},
+	}
+	for i, e := range expected {
+		assert.Equal(t, e.level, entries[i].Level, e.message)
+		assert.Equal(t, e.message, entries[i].Message)
+		assert.Equal(t, "std", entries[i].LoggerName)
+	}
+	assert.Equal(t, "stdlog_test.go", filepath.Base(entries[0].Caller.File))
+}
@@ -0,0 +1,362 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package logp
+
+import (
+	"encoding/json"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"go.uber.org/zap/zapcore"
+)
+
+// SyslogConfig contains the configuration options for sending logs to a
+// remote syslog server. Messages use the RFC 5424 format, with the log
+// fields encoded as structured data. Logs are sent to the local syslog
+// daemon if Host is empty.
+type SyslogConfig struct {
+	// Host is the address of the syslog server, in the host:port form.
+	Host string `config:"host" yaml:"host"`
+	// Network is one of udp or tcp. Messages sent over tcp are framed
+	// using octet counting (RFC 6587).
+	Network string `config:"network" yaml:"network"`
+	// Facility is the syslog facility name, e.g. local0 or daemon.
+	Facility string `config:"facility" yaml:"facility"`
+	// Tag is the APP-NAME of the messages, it defaults to the process name.
+	Tag string `config:"tag" yaml:"tag"`
+}
+
+const (
+	defaultSyslogNetwork  = "udp"
+	defaultSyslogFacility = "local0"
+
+	syslogDialTimeout = 10 * time.Second
+
+	// The time waited before reconnecting grows from syslogBackoffInit to
+	// syslogBackoffMax while the server is unreachable.
+	syslogBackoffInit = time.Second
+	syslogBackoffMax  = time.Minute
+
+	// syslogSDID is the SD-ID of the structured data element holding the
+	// log fields. 32473 is the private enterprise number reserved for
+	// documentation by RFC 5612.
+	syslogSDID = "fields@32473"
+)
+
+var syslogFacilities = map[string]int{
+	"kern":     0,
+	"user":     1,
+	"mail":     2,
+	"daemon":   3,
+	"auth":     4,
+	"syslog":   5,
+	"lpr":      6,
+	"news":     7,
+	"uucp":     8,
+	"cron":     9,
+	"authpriv": 10,
+	"ftp":      11,
+	"local0":   16,
+	"local1":   17,
+	"local2":   18,
+	"local3":   19,
+	"local4":   20,
+	"local5":   21,
+	"local6":   22,
+	"local7":   23,
+}
+
+// Validate checks the network and the facility.
+func (c *SyslogConfig) Validate() error {
+	switch c.Network {
+	case "", "udp", "udp4", "udp6", "tcp", "tcp4", "tcp6":
+	default:
+		return fmt.Errorf("unsupported syslog network '%s'", c.Network)
+	}
+	if c.Facility != "" {
+		if _, ok := syslogFacilities[c.Facility]; !ok {
+			return fmt.Errorf("unknown syslog facility '%s'", c.Facility)
+		}
+	}
+	return nil
+}
+
+// remoteSyslogWriter sends messages to a syslog server, reconnecting when a
+// write fails. Messages written while waiting to reconnect are dropped.
+type remoteSyslogWriter struct {
+	network string
+	addr    string
+
+	mu      sync.Mutex
+	conn    net.Conn
+	wait    time.Duration // Current backoff, 0 if connected.
+	retryAt time.Time
+}
+
+func (w *remoteSyslogWriter) write(msg []byte) error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if !strings.HasPrefix(w.network, "udp") {
+		msg = append([]byte(strconv.Itoa(len(msg))+" "), msg...)
+	}
+
+	// Retry once on a new connection, the server may have closed the
+	// previous one.
+	var err error
+	for i := 0; i < 2; i++ {
+		if err = w.connect(); err != nil {
+			return err
+		}
+		if _, err = w.conn.Write(msg); err == nil {
+			return nil
+		}
+		w.conn.Close()
+		w.conn = nil
+	}
+	return fmt.Errorf("failed to write to syslog server: %w", err)
+}
+
+// connect connects to the server if not connected and if the backoff is
+// over. It must be called with the lock held.
+func (w *remoteSyslogWriter) connect() error {
+	if w.conn != nil {
+		return nil
+	}
+	now := time.Now()
+	if now.Before(w.retryAt) {
+		return fmt.Errorf("syslog server '%s' unavailable, reconnecting in %s", w.addr, w.retryAt.Sub(now))
+	}
+
+	conn, err := net.DialTimeout(w.network, w.addr, syslogDialTimeout)
+	if err != nil {
+		w.wait = min(max(2*w.wait, syslogBackoffInit), syslogBackoffMax)
+		w.retryAt = now.Add(w.wait)
+		return fmt.Errorf("failed to connect to syslog server: %w", err)
+	}
+	w.conn, w.wait, w.retryAt = conn, 0, time.Time{}
+	return nil
+}
+
+func (w *remoteSyslogWriter) Close() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.conn == nil {
+		return nil
+	}
+	err := w.conn.Close()
+	w.conn = nil
+	return err
+}
+
+type remoteSyslogCore struct {
+	zapcore.LevelEnabler
+	writer   *remoteSyslogWriter
+	facility int
+	hostname string
+	tag      string
+	pid      string
+	fields   []zapcore.Field
+}
+
+// newRemoteSyslog returns a new Core that outputs to a remote syslog server.
+func newRemoteSyslog(cfg SyslogConfig, enab zapcore.LevelEnabler) (zapcore.Core, error) {
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+	if cfg.Network == "" {
+		cfg.Network = defaultSyslogNetwork
+	}
+	if cfg.Facility == "" {
+		cfg.Facility = defaultSyslogFacility
+	}
+	if cfg.Tag == "" {
+		cfg.Tag = filepath.Base(os.Args[0])
+	}
+	hostname, err := os.Hostname()
+	if err != nil || hostname == "" {
+		hostname = "-"
+	}
+
+	return &remoteSyslogCore{
+		LevelEnabler: enab,
+		writer:       &remoteSyslogWriter{network: cfg.Network, addr: cfg.Host},
+		facility:     syslogFacilities[cfg.Facility],
+		hostname:     syslogHeaderField(hostname, 255),
+		tag:          syslogHeaderField(cfg.Tag, 48),
+		pid:          strconv.Itoa(os.Getpid()),
+	}, nil
+}
+
+func (c *remoteSyslogCore) With(fields []zapcore.Field) zapcore.Core {
+	clone := *c
+	clone.fields = make([]zapcore.Field, 0, len(c.fields)+len(fields))
+	clone.fields = append(clone.fields, c.fields...)
+	clone.fields = append(clone.fields, fields...)
+	return &clone
+}
+
+func (c *remoteSyslogCore) Check(entry zapcore.Entry, checked *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	if c.Enabled(entry.Level) {
+		return checked.AddCore(entry, c)
+	}
+	return checked
+}
+
+func (c *remoteSyslogCore) Write(entry zapcore.Entry, fields []zapcore.Field) error {
+	enc := zapcore.NewMapObjectEncoder()
+	for _, field := range c.fields {
+		field.AddTo(enc)
+	}
+	for _, field := range fields {
+		field.AddTo(enc)
+	}
+	return c.writer.write(c.format(entry, enc.Fields))
+}
+
+// format formats the entry as a RFC 5424 message:
+//
+//	<PRI>1 TIMESTAMP HOSTNAME APP-NAME PROCID MSGID STRUCTURED-DATA MSG
+func (c *remoteSyslogCore) format(entry zapcore.Entry, fields map[string]interface{}) []byte {
+	var b strings.Builder
+	pri := c.facility*8 + syslogSeverity(entry.Level)
+	msgID := "-"
+	if entry.LoggerName != "" {
+		msgID = syslogHeaderField(entry.LoggerName, 32)
+	}
+	fmt.Fprintf(&b, "<%d>1 %s %s %s %s %s ",
+		pri, entry.Time.Format(time.RFC3339Nano), c.hostname, c.tag, c.pid, msgID)
+
+	params := make(map[string]string, len(fields))
+	flattenSyslogParams(params, "", fields)
+	if len(params) == 0 {
+		b.WriteString("-")
+	} else {
+		names := make([]string, 0, len(params))
+		for name := range params {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+
+		b.WriteString("[" + syslogSDID)
+		for _, name := range names {
+			b.WriteString(" " + name + `="`)
+			b.WriteString(syslogParamValueReplacer.Replace(params[name]))
+			b.WriteString(`"`)
+		}
+		b.WriteString("]")
+	}
+
+	if entry.Message != "" {
+		b.WriteString(" " + entry.Message)
+	}
+	return []byte(b.String())
+}
+
+func (c *remoteSyslogCore) Sync() error {
+	return nil
+}
+
+// Close closes the connection to the syslog server.
+func (c *remoteSyslogCore) Close() error {
+	return c.writer.Close()
+}
+
+// syslogSeverity matches the severities used by the local syslog output.
+func syslogSeverity(level zapcore.Level) int {
+	switch level {
+	case zapcore.DebugLevel:
+		return 7
+	case zapcore.InfoLevel:
+		return 6
+	case zapcore.WarnLevel:
+		return 4
+	case zapcore.ErrorLevel:
+		return 3
+	default:
+		return 2
+	}
+}
+
+var syslogParamValueReplacer = strings.NewReplacer(`\`, `\\`, `"`, `\"`, `]`, `\]`)
+
+// flattenSyslogParams converts nested fields into dotted SD-PARAM names.
+func flattenSyslogParams(params map[string]string, prefix string, fields map[string]interface{}) {
+	for k, v := range fields {
+		name := prefix + k
+		if nested, ok := v.(map[string]interface{}); ok {
+			flattenSyslogParams(params, name+".", nested)
+			continue
+		}
+		params[syslogParamName(name)] = syslogParamValue(v)
+	}
+}
+
+func syslogParamValue(v interface{}) string {
+	switch v := v.(type) {
+	case string:
+		return v
+	case fmt.Stringer:
+		return v.String()
+	case bool, int, int8, int16, int32, int64, uint, uint8, uint16, uint32, uint64, float32, float64:
+		return fmt.Sprint(v)
+	default:
+		b, err := json.Marshal(v)
+		if err != nil {
+			return fmt.Sprint(v)
+		}
+		return string(b)
+	}
+}
+
+// syslogParamName replaces the characters not allowed in SD-PARAM names
+// and truncates them to 32 characters.
+func syslogParamName(name string) string {
+	b := []byte(name)
+	for i, c := range b {
+		if c <= ' ' || c > '~' || c == '=' || c == ']' || c == '"' {
+			b[i] = '_'
+		}
+	}
+	if len(b) > 32 {
+		b = b[:32]
+	}
+	return string(b)
+}
+
+// syslogHeaderField replaces the characters not allowed in header fields
+// and truncates them to max characters.
+func syslogHeaderField(s string, max int) string {
+	b := []byte(s)
+	for i, c := range b {
+		if c <= ' ' || c > '~' {
+			b[i] = '_'
+		}
+	}
+	if len(b) > max {
+		b = b[:max]
+	}
+	return string(b)
+}
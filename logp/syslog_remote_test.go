@@ -0,0 +1,146 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package logp
+
+import (
+	"bufio"
+	"net"
+	"regexp"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap/zapcore"
+)
+
+func TestRemoteSyslogUDP(t *testing.T) {
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer conn.Close()
+
+	core, err := newRemoteSyslog(SyslogConfig{
+		Host:     conn.LocalAddr().String(),
+		Facility: "daemon",
+		Tag:      "testbeat",
+	}, zapcore.DebugLevel)
+	require.NoError(t, err)
+	defer core.(*remoteSyslogCore).Close()
+
+	entry := zapcore.Entry{
+		Level:      zapcore.WarnLevel,
+		Time:       time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC),
+		LoggerName: "remote",
+		Message:    "something happened",
+	}
+	err = core.With([]zapcore.Field{{Key: "quote", Type: zapcore.StringType, String: `a "b" ]`}}).
+		Write(entry, []zapcore.Field{{Key: "count", Type: zapcore.Int64Type, Integer: 3}})
+	require.NoError(t, err)
+
+	buf := make([]byte, 2048)
+	require.NoError(t, conn.SetReadDeadline(time.Now().Add(5*time.Second)))
+	n, _, err := conn.ReadFrom(buf)
+	require.NoError(t, err)
+
+	// daemon (3) * 8 + warning (4) = 28
+	pattern := regexp.MustCompile(`^<28>1 2024-01-02T03:04:05Z \S+ testbeat \d+ remote ` +
+		regexp.QuoteMeta(`[fields@32473 count="3" quote="a \"b\" \]"] something happened`) + `$`)
+	assert.Regexp(t, pattern, string(buf[:n]))
+}
+
+func TestRemoteSyslogTCPReconnect(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer ln.Close()
+
+	messages := make(chan string, 10)
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			r := bufio.NewReader(conn)
+			// Read a single octet-counted message, then drop the
+			// connection.
+			size, err := r.ReadString(' ')
+			if err == nil {
+				n, _ := strconv.Atoi(strings.TrimSpace(size))
+				msg := make([]byte, n)
+				if _, err := r.Read(msg); err == nil {
+					messages <- string(msg)
+				}
+			}
+			conn.Close()
+		}
+	}()
+
+	core, err := newRemoteSyslog(SyslogConfig{Host: ln.Addr().String(), Network: "tcp"}, zapcore.DebugLevel)
+	require.NoError(t, err)
+	defer core.(*remoteSyslogCore).Close()
+
+	// The server closes the connection after each message, writes to the
+	// closed connection may succeed before the failure is detected, so
+	// keep writing until the message is received.
+	for _, msg := range []string{"first", "second"} {
+		entry := zapcore.Entry{Level: zapcore.InfoLevel, Time: time.Now(), Message: msg}
+		received := func() bool {
+			_ = core.Write(entry, nil)
+			select {
+			case got := <-messages:
+				assert.True(t, strings.HasPrefix(got, "<134>1 "), got)
+				return strings.HasSuffix(got, " - "+msg)
+			case <-time.After(100 * time.Millisecond):
+				return false
+			}
+		}
+		assert.Eventually(t, received, 5*time.Second, 10*time.Millisecond)
+	}
+}
+
+func TestRemoteSyslogBackoff(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	addr := ln.Addr().String()
+	require.NoError(t, ln.Close())
+
+	w := &remoteSyslogWriter{network: "tcp", addr: addr}
+	defer w.Close()
+
+	err = w.write([]byte("msg"))
+	assert.ErrorContains(t, err, "failed to connect")
+	assert.Equal(t, syslogBackoffInit, w.wait)
+
+	// No new connection is attempted until the backoff is over.
+	err = w.write([]byte("msg"))
+	assert.ErrorContains(t, err, "unavailable")
+
+	w.retryAt = time.Time{}
+	err = w.write([]byte("msg"))
+	assert.ErrorContains(t, err, "failed to connect")
+	assert.Equal(t, 2*syslogBackoffInit, w.wait)
+}
+
+func TestSyslogConfigValidate(t *testing.T) {
+	assert.NoError(t, (&SyslogConfig{}).Validate())
+	assert.NoError(t, (&SyslogConfig{Network: "tcp", Facility: "local7"}).Validate())
+	assert.Error(t, (&SyslogConfig{Network: "unix"}).Validate())
+	assert.Error(t, (&SyslogConfig{Facility: "local8"}).Validate())
+}
@@ -0,0 +1,165 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package logp
+
+import (
+	"errors"
+	"io"
+	"strings"
+	"sync"
+	"time"
+
+	"go.uber.org/zap/zapcore"
+
+	"github.com/elastic/elastic-agent-libs/config"
+)
+
+// ThrottleConfig contains the configuration options for throttling the
+// entries of a selector, and of its children created with Logger.Named,
+// so a noisy subsystem cannot starve the outputs. It is a token bucket:
+// up to Burst entries are logged at once, then the bucket is refilled at
+// the rate of Burst entries per Per, the entries logged while it is empty
+// are dropped. The number of dropped entries is reported by GetStats.
+type ThrottleConfig struct {
+	Selector string        `config:"selector" yaml:"selector" validate:"required"`
+	Burst    int           `config:"burst" yaml:"burst" validate:"min=1"`
+	Per      time.Duration `config:"per" yaml:"per"`
+}
+
+// ThrottleConfigs is the list of the throttled selectors. It is unpacked
+// from a list or from a single throttle configuration.
+type ThrottleConfigs []ThrottleConfig
+
+const defaultThrottlePer = time.Second
+
+// Unpack unpacks a list of throttle configurations or a single one.
+func (c *ThrottleConfigs) Unpack(cfg *config.C) error {
+	if cfg.IsArray() {
+		var cfgs []ThrottleConfig
+		if err := cfg.Unpack(&cfgs); err != nil {
+			return err
+		}
+		*c = cfgs
+		return nil
+	}
+	var single ThrottleConfig
+	if err := cfg.Unpack(&single); err != nil {
+		return err
+	}
+	*c = ThrottleConfigs{single}
+	return nil
+}
+
+// Validate checks the throttling period.
+func (c *ThrottleConfig) Validate() error {
+	if c.Per < 0 {
+		return errors.New("throttle per must be positive")
+	}
+	return nil
+}
+
+// throttleBucket is the token bucket of a selector.
+type throttleBucket struct {
+	selector string
+	burst    float64
+	rate     float64 // Tokens per second.
+
+	mu     sync.Mutex
+	tokens float64
+	last   time.Time
+}
+
+func (b *throttleBucket) matches(name string) bool {
+	return name == b.selector || strings.HasPrefix(name, b.selector+".")
+}
+
+// take takes a token from the bucket, returning false if it is empty.
+func (b *throttleBucket) take(now time.Time) bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if elapsed := now.Sub(b.last).Seconds(); elapsed > 0 {
+		b.tokens += elapsed * b.rate
+		if b.tokens > b.burst {
+			b.tokens = b.burst
+		}
+		b.last = now
+	}
+	if b.tokens < 1 {
+		return false
+	}
+	b.tokens--
+	return true
+}
+
+// throttleCore drops the entries of the throttled selectors whose bucket
+// is empty. The buckets are shared with the cores created by its With.
+type throttleCore struct {
+	zapcore.Core
+	buckets []*throttleBucket
+}
+
+// throttleWrapper wraps core to throttle the configured selectors. If
+// several configurations match a selector, the first one applies.
+func throttleWrapper(core zapcore.Core, cfgs ThrottleConfigs) zapcore.Core {
+	if len(cfgs) == 0 {
+		return core
+	}
+	buckets := make([]*throttleBucket, 0, len(cfgs))
+	for _, cfg := range cfgs {
+		per := cfg.Per
+		if per <= 0 {
+			per = defaultThrottlePer
+		}
+		burst := float64(cfg.Burst)
+		buckets = append(buckets, &throttleBucket{
+			selector: cfg.Selector,
+			burst:    burst,
+			rate:     burst / per.Seconds(),
+			tokens:   burst,
+		})
+	}
+	return &throttleCore{Core: core, buckets: buckets}
+}
+
+func (c *throttleCore) With(fields []zapcore.Field) zapcore.Core {
+	return &throttleCore{Core: c.Core.With(fields), buckets: c.buckets}
+}
+
+func (c *throttleCore) Check(entry zapcore.Entry, checked *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	if !c.Enabled(entry.Level) {
+		return checked
+	}
+	for _, bucket := range c.buckets {
+		if bucket.matches(entry.LoggerName) {
+			if !bucket.take(entry.Time) {
+				stats.throttled.Add(1)
+				return checked
+			}
+			break
+		}
+	}
+	return c.Core.Check(entry, checked)
+}
+
+// Close closes the wrapped core if it implements io.Closer.
+func (c *throttleCore) Close() error {
+	if closer, ok := c.Core.(io.Closer); ok {
+		return closer.Close()
+	}
+	return nil
+}
@@ -0,0 +1,89 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package logp
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/elastic/elastic-agent-libs/config"
+)
+
+func TestThrottle(t *testing.T) {
+	defer SaveGlobalLogger()()
+
+	cfg := Config{Level: InfoLevel, Throttle: ThrottleConfigs{{Selector: "noisy", Burst: 3, Per: time.Hour}}}
+	ToObserverOutput()(&cfg)
+	require.NoError(t, Configure(cfg))
+
+	throttledBefore := GetStats().Throttled
+	noisy, other := NewLogger("noisy"), NewLogger("other")
+	for i := 0; i < 5; i++ {
+		noisy.Info("noisy entry")
+		noisy.Named("child").Info("noisy child entry")
+		other.Info("other entry")
+	}
+
+	counts := map[string]int{}
+	for _, entry := range ObserverLogs().TakeAll() {
+		counts[entry.LoggerName]++
+	}
+	assert.Equal(t, map[string]int{"noisy": 2, "noisy.child": 1, "other": 5}, counts)
+	assert.Equal(t, throttledBefore+7, GetStats().Throttled)
+}
+
+func TestThrottleBucketRefill(t *testing.T) {
+	now := time.Now()
+	bucket := &throttleBucket{burst: 2, rate: 1, tokens: 2, last: now}
+
+	assert.True(t, bucket.take(now))
+	assert.True(t, bucket.take(now))
+	assert.False(t, bucket.take(now))
+	assert.True(t, bucket.take(now.Add(time.Second)))
+	assert.False(t, bucket.take(now.Add(time.Second)))
+	// The bucket holds at most burst tokens.
+	assert.True(t, bucket.take(now.Add(time.Hour)))
+	assert.True(t, bucket.take(now.Add(time.Hour)))
+	assert.False(t, bucket.take(now.Add(time.Hour)))
+}
+
+func TestThrottleConfig(t *testing.T) {
+	cfg := DefaultConfig(DefaultEnvironment)
+	c := config.MustNewConfigFrom(map[string]interface{}{
+		"throttle": map[string]interface{}{"selector": "harvester", "burst": 100, "per": "1m"},
+	})
+	require.NoError(t, c.Unpack(&cfg))
+	assert.Equal(t, ThrottleConfigs{{Selector: "harvester", Burst: 100, Per: time.Minute}}, cfg.Throttle)
+
+	c = config.MustNewConfigFrom(map[string]interface{}{
+		"throttle": []interface{}{
+			map[string]interface{}{"selector": "harvester", "burst": 10},
+			map[string]interface{}{"selector": "publisher", "burst": 20},
+		},
+	})
+	require.NoError(t, c.Unpack(&cfg))
+	assert.Equal(t, ThrottleConfigs{{Selector: "harvester", Burst: 10}, {Selector: "publisher", Burst: 20}}, cfg.Throttle)
+
+	c = config.MustNewConfigFrom(map[string]interface{}{
+		"throttle": []interface{}{map[string]interface{}{"selector": "harvester"}},
+	})
+	assert.Error(t, c.Unpack(&cfg), "burst is required")
+}
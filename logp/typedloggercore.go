@@ -44,11 +44,16 @@ const DefaultType = "default"
 // those log entries to a different file.
 const EventType = "event"
 
+// UserEventType is the type for log entries of the loggers created by
+// NewEventLogger, reporting events the user may act upon.
+const UserEventType = "user_event"
+
 // typedLoggerCore takes two cores and directs logs entries to one of them
 // with the value of the field defined by the pair `key` and `value`
 //
 // If `entry[key] == value` the typedCore is used, otherwise the
-// defaultCore  is used.
+// defaultCore  is used. If the entry has no `key` field, the last `key`
+// field added with With is used.
 // WARNING: The level of both cores must always be the same!
 // typedLoggerCore will only use the defaultCore level to decide
 // whether to log an entry or not
@@ -57,6 +62,7 @@ type typedLoggerCore struct {
 	defaultCore zapcore.Core
 	value       string
 	key         string
+	typed       *bool // Set if a `key` field was added with With.
 }
 
 func (t *typedLoggerCore) Enabled(l zapcore.Level) bool {
@@ -69,6 +75,13 @@ func (t *typedLoggerCore) With(fields []zapcore.Field) zapcore.Core {
 		typedCore:   t.typedCore.With(fields),
 		key:         t.key,
 		value:       t.value,
+		typed:       t.typed,
+	}
+	for _, f := range fields {
+		if f.Key == t.key {
+			typed := f.String == t.value
+			newCore.typed = &typed
+		}
 	}
 	return &newCore
 }
@@ -102,6 +115,9 @@ func (t *typedLoggerCore) Write(e zapcore.Entry, fields []zapcore.Field) error {
 		}
 	}
 
+	if t.typed != nil && *t.typed {
+		return t.typedCore.Write(e, fields)
+	}
 	return t.defaultCore.Write(e, fields)
 }
 
@@ -22,8 +22,10 @@ import (
 	"errors"
 	"fmt"
 	"io"
+	"math"
 	"sort"
 	"strings"
+	"time"
 
 	"go.uber.org/zap"
 	"go.uber.org/zap/zapcore"
@@ -41,6 +43,10 @@ const (
 var (
 	// ErrKeyNotFound indicates that the specified key was not found.
 	ErrKeyNotFound = errors.New("key not found")
+
+	// ErrTypeMismatch indicates that the value of the specified key is not
+	// of the requested type.
+	ErrTypeMismatch = errors.New("type mismatch")
 )
 
 // EventMetadata contains fields and tags that can be added to an event via
@@ -185,6 +191,113 @@ func (m M) GetValue(key string) (interface{}, error) {
 	return v, nil
 }
 
+// GetString gets a string from the map. If the key does not exist or its
+// value is not a string then an error is returned.
+func (m M) GetString(key string) (string, error) {
+	v, err := m.GetValue(key)
+	if err != nil {
+		return "", err
+	}
+	s, ok := v.(string)
+	if !ok {
+		return "", typeMismatch(key, "string", v)
+	}
+	return s, nil
+}
+
+// GetInt64 gets an integer from the map. Any integer type is accepted, as
+// well as the floats without a fractional part and json.Number, as decoded
+// from JSON, as long as the value fits in an int64. If the key does not exist
+// or its value is not such a number then an error is returned.
+func (m M) GetInt64(key string) (int64, error) {
+	v, err := m.GetValue(key)
+	if err != nil {
+		return 0, err
+	}
+	switch n := v.(type) {
+	case int:
+		return int64(n), nil
+	case int8:
+		return int64(n), nil
+	case int16:
+		return int64(n), nil
+	case int32:
+		return int64(n), nil
+	case int64:
+		return n, nil
+	case uint:
+		if uint64(n) <= math.MaxInt64 {
+			return int64(n), nil
+		}
+	case uint8:
+		return int64(n), nil
+	case uint16:
+		return int64(n), nil
+	case uint32:
+		return int64(n), nil
+	case uint64:
+		if n <= math.MaxInt64 {
+			return int64(n), nil
+		}
+	case float32:
+		if f := float64(n); f == math.Trunc(f) && f >= math.MinInt64 && f < math.MaxInt64 {
+			return int64(f), nil
+		}
+	case float64:
+		if n == math.Trunc(n) && n >= math.MinInt64 && n < math.MaxInt64 {
+			return int64(n), nil
+		}
+	case json.Number:
+		if i, err := n.Int64(); err == nil {
+			return i, nil
+		}
+	}
+	return 0, typeMismatch(key, "int64", v)
+}
+
+// GetBool gets a bool from the map. If the key does not exist or its value is
+// not a bool then an error is returned.
+func (m M) GetBool(key string) (bool, error) {
+	v, err := m.GetValue(key)
+	if err != nil {
+		return false, err
+	}
+	b, ok := v.(bool)
+	if !ok {
+		return false, typeMismatch(key, "bool", v)
+	}
+	return b, nil
+}
+
+// GetTime gets a time from the map. A time.Time is accepted, as well as a
+// string in the RFC 3339 format, as decoded from JSON. If the key does not
+// exist or its value is not such a time then an error is returned.
+func (m M) GetTime(key string) (time.Time, error) {
+	v, err := m.GetValue(key)
+	if err != nil {
+		return time.Time{}, err
+	}
+	switch t := v.(type) {
+	case time.Time:
+		return t, nil
+	case *time.Time:
+		if t != nil {
+			return *t, nil
+		}
+	case string:
+		parsed, err := time.Parse(time.RFC3339Nano, t)
+		if err != nil {
+			return time.Time{}, fmt.Errorf("%w: expected time for key %q: %w", ErrTypeMismatch, key, err)
+		}
+		return parsed, nil
+	}
+	return time.Time{}, typeMismatch(key, "time", v)
+}
+
+func typeMismatch(key, expected string, v interface{}) error {
+	return fmt.Errorf("%w: expected %s for key %q but type is %T", ErrTypeMismatch, expected, key, v)
+}
+
 // Put associates the specified value with the specified key. If the map
 // previously contained a mapping for the key, the old value is replaced and
 // returned. The key can be expressed in dot-notation (e.g. x.y) to put a value
@@ -22,8 +22,10 @@ package mapstr
 import (
 	"encoding/json"
 	"fmt"
+	"math"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -339,6 +341,72 @@ func TestMapStrGetValue(t *testing.T) {
 	}
 }
 
+func TestMapStrTypedGetters(t *testing.T) {
+	now := time.Date(2024, 3, 1, 12, 30, 0, 500, time.UTC)
+	m := M{
+		"str":     "a",
+		"int":     42,
+		"uint8":   uint8(7),
+		"float":   float64(3),
+		"number":  json.Number("-12"),
+		"bool":    true,
+		"time":    now,
+		"timestr": "2024-03-01T12:30:00.0000005Z",
+		"nested":  M{"str": "b", "int": int32(-1)},
+		"half":    1.5,
+		"big":     uint64(math.MaxUint64),
+		"badtime": "yesterday",
+	}
+
+	s, err := m.GetString("str")
+	assert.NoError(t, err)
+	assert.Equal(t, "a", s)
+	s, err = m.GetString("nested.str")
+	assert.NoError(t, err)
+	assert.Equal(t, "b", s)
+
+	for key, expected := range map[string]int64{"int": 42, "uint8": 7, "float": 3, "number": -12, "nested.int": -1} {
+		i, err := m.GetInt64(key)
+		assert.NoError(t, err, key)
+		assert.Equal(t, expected, i, key)
+	}
+
+	b, err := m.GetBool("bool")
+	assert.NoError(t, err)
+	assert.True(t, b)
+
+	for _, key := range []string{"time", "timestr"} {
+		ts, err := m.GetTime(key)
+		assert.NoError(t, err, key)
+		assert.True(t, now.Equal(ts), key)
+	}
+
+	// Type mismatches.
+	_, err = m.GetString("int")
+	assert.ErrorIs(t, err, ErrTypeMismatch)
+	assert.EqualError(t, err, `type mismatch: expected string for key "int" but type is int`)
+	for _, key := range []string{"str", "half", "big"} {
+		_, err = m.GetInt64(key)
+		assert.ErrorIs(t, err, ErrTypeMismatch, key)
+	}
+	_, err = m.GetBool("str")
+	assert.ErrorIs(t, err, ErrTypeMismatch)
+	for _, key := range []string{"int", "badtime"} {
+		_, err = m.GetTime(key)
+		assert.ErrorIs(t, err, ErrTypeMismatch, key)
+	}
+
+	// Missing keys.
+	_, err = m.GetString("nested.missing")
+	assert.ErrorIs(t, err, ErrKeyNotFound)
+	_, err = m.GetInt64("missing")
+	assert.ErrorIs(t, err, ErrKeyNotFound)
+	_, err = m.GetBool("missing")
+	assert.ErrorIs(t, err, ErrKeyNotFound)
+	_, err = m.GetTime("missing")
+	assert.ErrorIs(t, err, ErrKeyNotFound)
+}
+
 func TestClone(t *testing.T) {
 	assert := assert.New(t)
 
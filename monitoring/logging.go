@@ -0,0 +1,52 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package monitoring
+
+import (
+	"github.com/elastic/elastic-agent-libs/logp"
+)
+
+// RegisterLoggingMetrics registers the logging counters of the logp package
+// in the logging namespace of the given registry:
+//
+//	logging.entries.{debug,info,warning,error,critical}
+//	logging.dropped
+//	logging.encode_errors
+//	logging.rotations
+//	logging.write_failures
+//	logging.throttled
+func RegisterLoggingMetrics(r *Registry) {
+	NewFunc(r, "logging", func(_ Mode, v Visitor) {
+		v.OnRegistryStart()
+		defer v.OnRegistryFinished()
+
+		stats := logp.GetStats()
+		ReportNamespace(v, "entries", func() {
+			ReportInt(v, "debug", int64(stats.Debug))
+			ReportInt(v, "info", int64(stats.Info))
+			ReportInt(v, "warning", int64(stats.Warn))
+			ReportInt(v, "error", int64(stats.Error))
+			ReportInt(v, "critical", int64(stats.Critical))
+		})
+		ReportInt(v, "dropped", int64(stats.Dropped))
+		ReportInt(v, "encode_errors", int64(stats.EncodeErrors))
+		ReportInt(v, "rotations", int64(stats.Rotations))
+		ReportInt(v, "write_failures", int64(stats.WriteFailures))
+		ReportInt(v, "throttled", int64(stats.Throttled))
+	}, Report)
+}
@@ -0,0 +1,42 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package monitoring
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/elastic/elastic-agent-libs/logp"
+)
+
+func TestRegisterLoggingMetrics(t *testing.T) {
+	r := NewRegistry()
+	RegisterLoggingMetrics(r)
+
+	stats := logp.GetStats()
+	snapshot := CollectFlatSnapshot(r, Full, false)
+	require.Contains(t, snapshot.Ints, "logging.entries.info")
+	assert.Equal(t, int64(stats.Info), snapshot.Ints["logging.entries.info"])
+	assert.Equal(t, int64(stats.Error), snapshot.Ints["logging.entries.error"])
+	assert.Contains(t, snapshot.Ints, "logging.entries.critical")
+	assert.Contains(t, snapshot.Ints, "logging.dropped")
+	assert.Contains(t, snapshot.Ints, "logging.encode_errors")
+	assert.Contains(t, snapshot.Ints, "logging.rotations")
+}
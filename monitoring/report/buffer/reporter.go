@@ -24,6 +24,7 @@ import (
 	"sync"
 	"time"
 
+	"github.com/elastic/elastic-agent-libs/clock"
 	c "github.com/elastic/elastic-agent-libs/config"
 	"github.com/elastic/elastic-agent-libs/monitoring"
 )
@@ -34,13 +35,25 @@ type reporter struct {
 	wg         sync.WaitGroup
 	done       chan struct{}
 	registries map[string]*monitoring.Registry
+	clock      clock.Clock
 
 	// ring buffers for namespaces
 	entries map[string]*ringBuffer
 }
 
+// Option configures a reporter.
+type Option func(*reporter)
+
+// WithClock sets the clock driving the snapshot period. It is meant to be
+// used with clock.Fake in tests. Defaults to clock.Real().
+func WithClock(clk clock.Clock) Option {
+	return func(r *reporter) {
+		r.clock = clk
+	}
+}
+
 // MakeReporter creates and starts a reporter with the given config.
-func MakeReporter(cfg *c.C) (*reporter, error) {
+func MakeReporter(cfg *c.C, opts ...Option) (*reporter, error) {
 	config := defaultConfig()
 	if cfg != nil {
 		if err := cfg.Unpack(&config); err != nil {
@@ -53,6 +66,10 @@ func MakeReporter(cfg *c.C) (*reporter, error) {
 		done:       make(chan struct{}),
 		registries: map[string]*monitoring.Registry{},
 		entries:    map[string]*ringBuffer{},
+		clock:      clock.Real(),
+	}
+	for _, opt := range opts {
+		opt(r)
 	}
 
 	for _, ns := range r.config.Namespaces {
@@ -78,7 +95,7 @@ func (r *reporter) Stop() {
 
 // snapshotLoop will collect a snapshot for each monitored registry for the configured period and store them in the correct buffer.
 func (r *reporter) snapshotLoop() {
-	ticker := time.NewTicker(r.config.Period)
+	ticker := r.clock.NewTicker(r.config.Period)
 	defer ticker.Stop()
 
 	for {
@@ -86,7 +103,7 @@ func (r *reporter) snapshotLoop() {
 		select {
 		case <-r.done:
 			return
-		case ts = <-ticker.C:
+		case ts = <-ticker.C():
 		}
 
 		for name, reg := range r.registries {
@@ -0,0 +1,68 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package buffer
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/elastic/elastic-agent-libs/clock"
+	c "github.com/elastic/elastic-agent-libs/config"
+	"github.com/elastic/elastic-agent-libs/monitoring"
+)
+
+func TestReporterSnapshotPeriod(t *testing.T) {
+	ns := t.Name()
+	reg := monitoring.NewRegistry()
+	counter := monitoring.NewInt(reg, "counter")
+	monitoring.GetNamespace(ns).SetRegistry(reg)
+
+	start := time.Date(2024, 3, 26, 12, 0, 0, 0, time.UTC)
+	clk := clock.NewFake(start)
+	cfg := c.MustNewConfigFrom(map[string]interface{}{
+		"period":     "10s",
+		"size":       2,
+		"namespaces": []string{ns},
+	})
+	r, err := MakeReporter(cfg, WithClock(clk))
+	require.NoError(t, err)
+	defer r.Stop()
+
+	clk.BlockUntil(1)
+	assert.Empty(t, r.entries[ns].getAll())
+
+	for i := 1; i <= 3; i++ {
+		counter.Set(int64(i))
+		clk.Advance(10 * time.Second)
+		want := i
+		if want > 2 {
+			want = 2
+		}
+		require.Eventually(t, func() bool {
+			return len(r.entries[ns].getAll()) == want
+		}, time.Second, time.Millisecond)
+	}
+
+	entries := r.entries[ns].getAll()
+	require.Len(t, entries, 2)
+	assert.Equal(t, start.Add(20*time.Second), entries[0].(map[string]interface{})["@timestamp"])
+	assert.EqualValues(t, 2, entries[0].(map[string]interface{})["counter"])
+}
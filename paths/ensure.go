@@ -0,0 +1,98 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package paths
+
+import (
+	"errors"
+	"fmt"
+	"os"
+)
+
+// Owner identifies who owns the directories created by EnsureAll.
+type Owner struct {
+	// UID and GID of the owner on Unix, -1 keeps the current value.
+	UID int
+	GID int
+	// SID is the security identifier of the owner on Windows, e.g. S-1-5-18
+	// for the local system account. The owner, SYSTEM and the Administrators
+	// group are granted full control, access is removed for everyone else.
+	SID string
+}
+
+// EnsureAll creates the configured directories, see Path.EnsureAll.
+func EnsureAll(mode os.FileMode, owner *Owner) error {
+	return Paths.EnsureAll(mode, owner)
+}
+
+// EnsureAll creates the home, config, data and logs directories if they do
+// not exist. The directories it creates, as well as the data and logs
+// directories, are set to mode and, if owner is not nil, owned by owner. On
+// Windows mode is ignored and the permissions are set with an ACL instead.
+// It also checks the data and logs directories are writable. All errors are
+// returned together.
+func (paths *Path) EnsureAll(mode os.FileMode, owner *Owner) error {
+	var errs []error
+	for _, fileType := range []FileType{Home, Config, Data, Logs} {
+		path := *paths.fields()[fileType]
+		if path == "" {
+			continue
+		}
+		writable := fileType == Data || fileType == Logs
+		if err := ensureDir(path, mode, owner, writable); err != nil {
+			errs = append(errs, fmt.Errorf("%s path %s: %w", fileType, path, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+func ensureDir(path string, mode os.FileMode, owner *Owner, writable bool) error {
+	info, err := os.Stat(path)
+	switch {
+	case errors.Is(err, os.ErrNotExist):
+		if err := os.MkdirAll(path, mode); err != nil {
+			return err
+		}
+	case err != nil:
+		return err
+	case !info.IsDir():
+		return errors.New("not a directory")
+	case !writable:
+		// Existing read-only directories are provided by the installation,
+		// their permissions are left untouched.
+		return nil
+	}
+
+	if err := setPermissions(path, mode, owner); err != nil {
+		return fmt.Errorf("failed to set permissions: %w", err)
+	}
+	if writable {
+		return checkWritable(path)
+	}
+	return nil
+}
+
+// checkWritable checks a file can be created in the directory.
+func checkWritable(path string) error {
+	f, err := os.CreateTemp(path, ".write-check-*")
+	if err != nil {
+		return fmt.Errorf("directory is not writable: %w", err)
+	}
+	name := f.Name()
+	f.Close()
+	return os.Remove(name)
+}
@@ -0,0 +1,51 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package paths
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEnsureAll(t *testing.T) {
+	home := t.TempDir()
+	p := New()
+	require.NoError(t, p.initPaths(&Path{Home: home, Logs: filepath.Join(home, "var", "logs")}))
+
+	require.NoError(t, p.EnsureAll(0750, nil))
+	for _, dir := range []string{p.Data, p.Logs} {
+		info, err := os.Stat(dir)
+		require.NoError(t, err)
+		assert.True(t, info.IsDir())
+		if runtime.GOOS != "windows" {
+			assert.Equal(t, os.FileMode(0750), info.Mode().Perm(), dir)
+		}
+	}
+
+	// A file in place of a directory is reported.
+	require.NoError(t, os.RemoveAll(p.Data))
+	require.NoError(t, os.WriteFile(p.Data, nil, 0644))
+	err := p.EnsureAll(0750, nil)
+	assert.ErrorContains(t, err, "data path")
+	assert.ErrorContains(t, err, "not a directory")
+}
@@ -31,6 +31,12 @@
 // These settings can be set via the configuration file or via command line flags.
 // The CLI flags overwrite the configuration file options.
 //
+// When InitPaths is called with the WithEnvPrefix option, each path can also
+// be set with an environment variable named after the prefix and the path
+// type, e.g. ELASTIC_AGENT_HOME_PATH, ELASTIC_AGENT_CONFIG_PATH,
+// ELASTIC_AGENT_DATA_PATH and ELASTIC_AGENT_LOGS_PATH for the prefix
+// ELASTIC_AGENT. The environment variables overwrite all other settings.
+//
 // Use the Resolve function to resolve files to their absolute paths. For example,
 // to look for a file in the config path:
 //
@@ -41,6 +47,9 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"regexp"
+	"runtime"
+	"strings"
 )
 
 // Path tracks user-configurable path locations and directories
@@ -75,11 +84,76 @@ func New() *Path {
 	return &Path{}
 }
 
+// InitOption is a configuration option for InitPaths.
+type InitOption func(o *initOptions)
+
+type initOptions struct {
+	envPrefix string
+	vars      map[string]string
+	mode      Mode
+	name      string
+}
+
+// Mode selects where the data and logs paths are placed when they are not
+// configured.
+type Mode int
+
+const (
+	// HomeMode places the data and logs paths in the home path. It is the default.
+	HomeMode Mode = iota
+	// SystemMode places the data and logs paths in %ProgramData%\<name> on
+	// Windows, as expected for programs installed as a service. On other
+	// platforms it is the same as HomeMode.
+	SystemMode
+	// UserMode places the data and logs paths in %LocalAppData%\<name> on
+	// Windows, as expected for programs installed for a single user. On other
+	// platforms it is the same as HomeMode.
+	UserMode
+)
+
+// goos is the operating system the paths are resolved for, tests overwrite it.
+var goos = runtime.GOOS
+
+// WithEnvPrefix enables overwriting each path with an environment variable
+// named <prefix>_<TYPE>_PATH, e.g. ELASTIC_AGENT_LOGS_PATH. Environment
+// variables take precedence over the configured paths.
+func WithEnvPrefix(prefix string) InitOption {
+	return func(o *initOptions) {
+		o.envPrefix = prefix
+	}
+}
+
+// WithTemplateVars enables expanding placeholders like {name}, {version} or
+// {hostname} in the paths, so a configuration template can be shared by
+// several instances. The {hostname} placeholder is always available, its
+// value is the host name unless vars has a different one. InitPaths returns
+// an error if a path has a placeholder missing from vars.
+func WithTemplateVars(vars map[string]string) InitOption {
+	return func(o *initOptions) {
+		o.vars = vars
+	}
+}
+
+// WithMode configures where the data and logs paths are placed when they
+// are not configured, name is the directory created for the program.
+func WithMode(mode Mode, name string) InitOption {
+	return func(o *initOptions) {
+		o.mode = mode
+		o.name = name
+	}
+}
+
+// EnvVar returns the name of the environment variable overwriting the path
+// of fileType when InitPaths is called with WithEnvPrefix(prefix).
+func EnvVar(prefix string, fileType FileType) string {
+	return strings.ToUpper(prefix + "_" + string(fileType) + "_PATH")
+}
+
 // InitPaths sets the default paths in the configuration based on CLI flags,
 // configuration file and default values. It also tries to create the data
 // path with mode 0750 and returns an error on failure.
-func (paths *Path) InitPaths(cfg *Path) error {
-	err := paths.initPaths(cfg)
+func (paths *Path) InitPaths(cfg *Path, opts ...InitOption) error {
+	err := paths.initPaths(cfg, opts...)
 	if err != nil {
 		return err
 	}
@@ -96,33 +170,127 @@ func (paths *Path) InitPaths(cfg *Path) error {
 // InitPaths sets the default paths in the configuration based on CLI flags,
 // configuration file and default values. It also tries to create the data
 // path with mode 0750 and returns an error on failure.
-func InitPaths(cfg *Path) error {
-	return Paths.InitPaths(cfg)
+func InitPaths(cfg *Path, opts ...InitOption) error {
+	return Paths.InitPaths(cfg, opts...)
 }
 
 // initPaths sets the default paths in the configuration based on CLI flags,
 // configuration file and default values.
-func (paths *Path) initPaths(cfg *Path) error {
+func (paths *Path) initPaths(cfg *Path, opts ...InitOption) error {
+	var o initOptions
+	for _, opt := range opts {
+		opt(&o)
+	}
+
 	*paths = *cfg
 
+	if o.envPrefix != "" {
+		paths.applyEnv(o.envPrefix)
+	}
+
+	if o.vars != nil {
+		if err := paths.expand(o.vars); err != nil {
+			return err
+		}
+	}
+
 	// default for config path
 	if paths.Config == "" {
 		paths.Config = paths.Home
 	}
 
+	base := paths.Home
+	if dir := modeDir(o.mode); dir != "" {
+		base = filepath.Join(dir, o.name)
+	}
+
 	// default for data path
 	if paths.Data == "" {
-		paths.Data = filepath.Join(paths.Home, "data")
+		paths.Data = filepath.Join(base, "data")
 	}
 
 	// default for logs path
 	if paths.Logs == "" {
-		paths.Logs = filepath.Join(paths.Home, "logs")
+		paths.Logs = filepath.Join(base, "logs")
+	}
+
+	return nil
+}
+
+// fields returns pointers to the paths, indexed by their type.
+func (paths *Path) fields() map[FileType]*string {
+	return map[FileType]*string{
+		Home:   &paths.Home,
+		Config: &paths.Config,
+		Data:   &paths.Data,
+		Logs:   &paths.Logs,
+	}
+}
+
+// applyEnv overwrites the paths set in the environment variables.
+func (paths *Path) applyEnv(prefix string) {
+	for fileType, path := range paths.fields() {
+		if value, ok := os.LookupEnv(EnvVar(prefix, fileType)); ok && value != "" {
+			*path = value
+		}
+	}
+}
+
+// modeDir returns the directory the data and logs paths are placed in for
+// the mode, or an empty string if they are placed in the home path.
+func modeDir(mode Mode) string {
+	if goos != "windows" {
+		return ""
+	}
+	switch mode {
+	case SystemMode:
+		return os.Getenv("ProgramData")
+	case UserMode:
+		return os.Getenv("LocalAppData")
+	default:
+		return ""
+	}
+}
+
+var placeholder = regexp.MustCompile(`\{([A-Za-z0-9_.]+)\}`)
+
+// expand replaces the placeholders in the paths with their value in vars.
+func (paths *Path) expand(vars map[string]string) error {
+	if _, ok := vars["hostname"]; !ok {
+		hostname, err := os.Hostname()
+		if err != nil {
+			return fmt.Errorf("failed to get the hostname to expand paths: %w", err)
+		}
+		vars = copyVars(vars)
+		vars["hostname"] = hostname
 	}
 
+	for fileType, path := range paths.fields() {
+		var missing []string
+		*path = placeholder.ReplaceAllStringFunc(*path, func(match string) string {
+			name := match[1 : len(match)-1]
+			value, ok := vars[name]
+			if !ok {
+				missing = append(missing, match)
+				return match
+			}
+			return value
+		})
+		if len(missing) > 0 {
+			return fmt.Errorf("unknown variables %s in %s path %s", strings.Join(missing, ", "), fileType, *path)
+		}
+	}
 	return nil
 }
 
+func copyVars(vars map[string]string) map[string]string {
+	res := make(map[string]string, len(vars)+1)
+	for k, v := range vars {
+		res[k] = v
+	}
+	return res
+}
+
 // Resolve resolves a path to a location in one of the default
 // folders. For example, Resolve(Home, "test") returns an absolute
 // path for "test" in the home path.
@@ -174,3 +174,75 @@ func rootDir(path string) string {
 	}
 	return filepath.Join("/", path)
 }
+
+func TestEnvOverrides(t *testing.T) {
+	home := rootDir("/home")
+	logs := rootDir("/var/log/agent")
+	t.Setenv("ELASTIC_AGENT_LOGS_PATH", logs)
+	t.Setenv("ELASTIC_AGENT_DATA_PATH", "")
+
+	p := New()
+	err := p.initPaths(&Path{Home: home, Logs: rootDir("/configured")}, WithEnvPrefix("ELASTIC_AGENT"))
+	assert.NoError(t, err)
+	assert.Equal(t, logs, p.Logs, "the environment variable overwrites the configured path")
+	assert.Equal(t, filepath.Join(home, "data"), p.Data, "empty environment variables are ignored")
+
+	// Without the option the environment is not used.
+	err = p.initPaths(&Path{Home: home})
+	assert.NoError(t, err)
+	assert.Equal(t, filepath.Join(home, "logs"), p.Logs)
+
+	assert.Equal(t, "FILEBEAT_CONFIG_PATH", EnvVar("filebeat", Config))
+}
+
+func TestTemplateVars(t *testing.T) {
+	hostname, err := os.Hostname()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	p := New()
+	err = p.initPaths(&Path{
+		Home: rootDir("/opt/{name}"),
+		Data: rootDir("/var/lib/{name}-{version}/{hostname}"),
+	}, WithTemplateVars(map[string]string{"name": "filebeat", "version": "8.15.0"}))
+	assert.NoError(t, err)
+	assert.Equal(t, rootDir("/opt/filebeat"), p.Home)
+	assert.Equal(t, rootDir("/opt/filebeat"), p.Config)
+	assert.Equal(t, filepath.Join(rootDir("/var/lib/filebeat-8.15.0"), hostname), p.Data)
+	assert.Equal(t, filepath.Join(rootDir("/opt/filebeat"), "logs"), p.Logs)
+
+	err = p.initPaths(&Path{Home: rootDir("/opt/{unknown}")}, WithTemplateVars(map[string]string{}))
+	assert.ErrorContains(t, err, "{unknown}")
+}
+
+func TestMode(t *testing.T) {
+	defer func(os string) { goos = os }(goos)
+
+	home := rootDir("/opt/agent")
+	programData := rootDir("/ProgramData")
+	localAppData := rootDir("/Users/elastic/AppData/Local")
+	t.Setenv("ProgramData", programData)
+	t.Setenv("LocalAppData", localAppData)
+
+	tests := []struct {
+		goos string
+		mode Mode
+		base string
+	}{
+		{goos: "windows", mode: HomeMode, base: home},
+		{goos: "windows", mode: SystemMode, base: filepath.Join(programData, "Elastic Agent")},
+		{goos: "windows", mode: UserMode, base: filepath.Join(localAppData, "Elastic Agent")},
+		{goos: "linux", mode: SystemMode, base: home},
+	}
+
+	for _, test := range tests {
+		goos = test.goos
+		p := New()
+		err := p.initPaths(&Path{Home: home}, WithMode(test.mode, "Elastic Agent"))
+		assert.NoError(t, err)
+		assert.Equal(t, home, p.Config, "%+v", test)
+		assert.Equal(t, filepath.Join(test.base, "data"), p.Data, "%+v", test)
+		assert.Equal(t, filepath.Join(test.base, "logs"), p.Logs, "%+v", test)
+	}
+}
@@ -0,0 +1,228 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package service
+
+import (
+	"bytes"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"os/exec"
+	"regexp"
+	"sort"
+	"strings"
+	"text/template"
+)
+
+// InstallConfig describes a program to install as a system service with
+// Install. The service is started automatically at boot.
+type InstallConfig struct {
+	// Name of the service, it is used as systemd unit name, Windows service
+	// name and launchd label. Required.
+	Name string
+	// DisplayName is the human readable name of the service, only used on
+	// Windows. Defaults to Name.
+	DisplayName string
+	// Description of the service.
+	Description string
+	// Executable is the absolute path of the program to run. Required.
+	Executable string
+	// Arguments passed to the program.
+	Arguments []string
+	// User the service runs as. Defaults to the system account.
+	User string
+	// WorkingDirectory of the service.
+	WorkingDirectory string
+	// Environment variables set for the service, not supported on Windows.
+	Environment map[string]string
+}
+
+var validServiceName = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9_.@-]*$`)
+
+// Validate checks the configuration is usable on all platforms.
+func (c InstallConfig) Validate() error {
+	if err := validateName(c.Name); err != nil {
+		return err
+	}
+	if c.Executable == "" {
+		return errors.New("service executable is required")
+	}
+	for field, value := range map[string]string{
+		"display name":      c.DisplayName,
+		"description":       c.Description,
+		"user":              c.User,
+		"working directory": c.WorkingDirectory,
+	} {
+		if strings.ContainsAny(value, "\r\n") {
+			return fmt.Errorf("service %s must not contain line breaks", field)
+		}
+	}
+	return nil
+}
+
+var systemdUnitTemplate = template.Must(template.New("unit").Funcs(template.FuncMap{
+	"quote":     systemdQuote,
+	"quoteEnv":  systemdQuoteEnv,
+	"specifier": systemdEscapeSpecifiers,
+}).Parse(`[Unit]
+Description={{ .Description }}
+Wants=network-online.target
+After=network-online.target
+
+[Service]
+ExecStart={{ quote .Executable }}{{ range .Arguments }} {{ quote . }}{{ end }}
+{{- if .User }}
+User={{ .User }}
+{{- end }}
+{{- if .WorkingDirectory }}
+WorkingDirectory={{ specifier .WorkingDirectory }}
+{{- end }}
+{{- range .Env }}
+Environment={{ quoteEnv . }}
+{{- end }}
+Restart=always
+RestartSec=10
+
+[Install]
+WantedBy=multi-user.target
+`))
+
+// SystemdUnit returns the systemd unit file Install writes on Linux.
+func SystemdUnit(c InstallConfig) (string, error) {
+	if err := c.Validate(); err != nil {
+		return "", err
+	}
+	data := struct {
+		InstallConfig
+		Env []string
+	}{InstallConfig: c, Env: envList(c.Environment)}
+	if data.Description == "" {
+		data.Description = c.Name
+	}
+
+	var buf bytes.Buffer
+	if err := systemdUnitTemplate.Execute(&buf, data); err != nil {
+		return "", fmt.Errorf("failed to generate systemd unit: %w", err)
+	}
+	return buf.String(), nil
+}
+
+// systemdQuote quotes a single word of an ExecStart= command line, escaping
+// the specifiers and variables systemd would otherwise expand.
+func systemdQuote(s string) string {
+	r := strings.NewReplacer(`\`, `\\`, `"`, `\"`, "%", "%%", "$", "$$", "\n", `\n`)
+	return `"` + r.Replace(s) + `"`
+}
+
+// systemdQuoteEnv quotes an Environment= assignment. Variables are not
+// expanded in Environment=, only the specifiers are escaped.
+func systemdQuoteEnv(s string) string {
+	r := strings.NewReplacer(`\`, `\\`, `"`, `\"`, "%", "%%", "\n", `\n`)
+	return `"` + r.Replace(s) + `"`
+}
+
+// systemdEscapeSpecifiers escapes the specifiers of a path setting, like
+// WorkingDirectory=, which is neither unquoted nor expanded by systemd.
+func systemdEscapeSpecifiers(s string) string {
+	return strings.ReplaceAll(s, "%", "%%")
+}
+
+var launchdPlistTemplate = template.Must(template.New("plist").Funcs(template.FuncMap{
+	"xml": xmlEscape,
+}).Parse(`<?xml version="1.0" encoding="UTF-8"?>
+<!DOCTYPE plist PUBLIC "-//Apple//DTD PLIST 1.0//EN" "http://www.apple.com/DTDs/PropertyList-1.0.dtd">
+<plist version="1.0">
+<dict>
+	<key>Label</key>
+	<string>{{ xml .Name }}</string>
+	<key>ProgramArguments</key>
+	<array>
+		<string>{{ xml .Executable }}</string>
+		{{- range .Arguments }}
+		<string>{{ xml . }}</string>
+		{{- end }}
+	</array>
+	{{- if .User }}
+	<key>UserName</key>
+	<string>{{ xml .User }}</string>
+	{{- end }}
+	{{- if .WorkingDirectory }}
+	<key>WorkingDirectory</key>
+	<string>{{ xml .WorkingDirectory }}</string>
+	{{- end }}
+	{{- if .Environment }}
+	<key>EnvironmentVariables</key>
+	<dict>
+		{{- range $k, $v := .Environment }}
+		<key>{{ xml $k }}</key>
+		<string>{{ xml $v }}</string>
+		{{- end }}
+	</dict>
+	{{- end }}
+	<key>RunAtLoad</key>
+	<true/>
+	<key>KeepAlive</key>
+	<true/>
+</dict>
+</plist>
+`))
+
+// LaunchdPlist returns the launchd property list Install writes on macOS.
+func LaunchdPlist(c InstallConfig) (string, error) {
+	if err := c.Validate(); err != nil {
+		return "", err
+	}
+
+	var buf bytes.Buffer
+	if err := launchdPlistTemplate.Execute(&buf, c); err != nil {
+		return "", fmt.Errorf("failed to generate launchd plist: %w", err)
+	}
+	return buf.String(), nil
+}
+
+func xmlEscape(s string) string {
+	var buf bytes.Buffer
+	_ = xml.EscapeText(&buf, []byte(s))
+	return buf.String()
+}
+
+func validateName(name string) error {
+	if !validServiceName.MatchString(name) {
+		return fmt.Errorf("invalid service name %q", name)
+	}
+	return nil
+}
+
+func envList(env map[string]string) []string {
+	list := make([]string, 0, len(env))
+	for k, v := range env {
+		list = append(list, k+"="+v)
+	}
+	sort.Strings(list)
+	return list
+}
+
+// runCommand runs a service manager command, its output is added to the
+// returned error.
+func runCommand(name string, args ...string) error {
+	out, err := exec.Command(name, args...).CombinedOutput()
+	if err != nil {
+		return fmt.Errorf("%s %s failed: %w: %s", name, strings.Join(args, " "), err, bytes.TrimSpace(out))
+	}
+	return nil
+}
@@ -0,0 +1,73 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package service
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+const launchDaemonsDir = "/Library/LaunchDaemons"
+
+func plistPath(name string) string {
+	return filepath.Join(launchDaemonsDir, name+".plist")
+}
+
+// Install writes a launchd property list for the program and loads it.
+func Install(c InstallConfig) error {
+	plist, err := LaunchdPlist(c)
+	if err != nil {
+		return err
+	}
+	if err := os.WriteFile(plistPath(c.Name), []byte(plist), 0644); err != nil { //nolint:gosec // launchd requires world readable plists
+		return fmt.Errorf("failed to write launchd plist: %w", err)
+	}
+	return runCommand("launchctl", "bootstrap", "system", plistPath(c.Name))
+}
+
+// Uninstall unloads the service and removes its property list.
+func Uninstall(name string) error {
+	if err := validateName(name); err != nil {
+		return err
+	}
+	if err := runCommand("launchctl", "bootout", "system/"+name); err != nil {
+		return err
+	}
+	if err := os.Remove(plistPath(name)); err != nil {
+		return fmt.Errorf("failed to remove launchd plist: %w", err)
+	}
+	return nil
+}
+
+// Start starts the installed service.
+func Start(name string) error {
+	if err := validateName(name); err != nil {
+		return err
+	}
+	return runCommand("launchctl", "kickstart", "system/"+name)
+}
+
+// Stop stops the installed service. As the service is kept alive, launchd
+// starts it again unless it is uninstalled.
+func Stop(name string) error {
+	if err := validateName(name); err != nil {
+		return err
+	}
+	return runCommand("launchctl", "kill", "SIGTERM", "system/"+name)
+}
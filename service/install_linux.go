@@ -0,0 +1,75 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package service
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+const systemdUnitDir = "/etc/systemd/system"
+
+func unitPath(name string) string {
+	return filepath.Join(systemdUnitDir, name+".service")
+}
+
+// Install writes a systemd unit for the program and enables it.
+func Install(c InstallConfig) error {
+	unit, err := SystemdUnit(c)
+	if err != nil {
+		return err
+	}
+	if err := os.WriteFile(unitPath(c.Name), []byte(unit), 0644); err != nil { //nolint:gosec // unit files are world readable
+		return fmt.Errorf("failed to write systemd unit: %w", err)
+	}
+	if err := runCommand("systemctl", "daemon-reload"); err != nil {
+		return err
+	}
+	return runCommand("systemctl", "enable", c.Name+".service")
+}
+
+// Uninstall stops and disables the service and removes its systemd unit.
+func Uninstall(name string) error {
+	if err := validateName(name); err != nil {
+		return err
+	}
+	if err := runCommand("systemctl", "disable", "--now", name+".service"); err != nil {
+		return err
+	}
+	if err := os.Remove(unitPath(name)); err != nil {
+		return fmt.Errorf("failed to remove systemd unit: %w", err)
+	}
+	return runCommand("systemctl", "daemon-reload")
+}
+
+// Start starts the installed service.
+func Start(name string) error {
+	if err := validateName(name); err != nil {
+		return err
+	}
+	return runCommand("systemctl", "start", name+".service")
+}
+
+// Stop stops the installed service.
+func Stop(name string) error {
+	if err := validateName(name); err != nil {
+		return err
+	}
+	return runCommand("systemctl", "stop", name+".service")
+}
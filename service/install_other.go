@@ -0,0 +1,36 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+//go:build !linux && !darwin && !windows
+
+package service
+
+import "errors"
+
+var errInstallNotSupported = errors.New("installing services is not supported on this platform")
+
+// Install is not supported on this platform.
+func Install(InstallConfig) error { return errInstallNotSupported }
+
+// Uninstall is not supported on this platform.
+func Uninstall(string) error { return errInstallNotSupported }
+
+// Start is not supported on this platform.
+func Start(string) error { return errInstallNotSupported }
+
+// Stop is not supported on this platform.
+func Stop(string) error { return errInstallNotSupported }
@@ -0,0 +1,95 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package service
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSystemdUnit(t *testing.T) {
+	unit, err := SystemdUnit(InstallConfig{
+		Name:        "elastic-agent",
+		Description: "Elastic Agent",
+		Executable:  "/opt/Elastic/Agent/elastic-agent",
+		Arguments:   []string{"run", "--path.home=/opt/$HOME", "100%"},
+		User:        "elastic",
+		Environment: map[string]string{"B": "2", "A": "1"},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, `[Unit]
+Description=Elastic Agent
+Wants=network-online.target
+After=network-online.target
+
+[Service]
+ExecStart="/opt/Elastic/Agent/elastic-agent" "run" "--path.home=/opt/$$HOME" "100%%"
+User=elastic
+Environment="A=1"
+Environment="B=2"
+Restart=always
+RestartSec=10
+
+[Install]
+WantedBy=multi-user.target
+`, unit)
+}
+
+func TestSystemdUnitQuoting(t *testing.T) {
+	unit, err := SystemdUnit(InstallConfig{
+		Name:             "svc",
+		Executable:       "/opt/svc/bin",
+		Arguments:        []string{"$HOME"},
+		WorkingDirectory: "/opt/svc 100%",
+		Environment:      map[string]string{"TOKEN": `a$b"c%d`},
+	})
+	require.NoError(t, err)
+	assert.Contains(t, unit, "\nExecStart=\"/opt/svc/bin\" \"$$HOME\"\n")
+	assert.Contains(t, unit, "\nWorkingDirectory=/opt/svc 100%%\n", "WorkingDirectory= is not unquoted by systemd")
+	assert.Contains(t, unit, "\nEnvironment=\"TOKEN=a$b\\\"c%%d\"\n", "Environment= doesn't expand variables")
+}
+
+func TestLaunchdPlist(t *testing.T) {
+	plist, err := LaunchdPlist(InstallConfig{
+		Name:       "co.elastic.elastic-agent",
+		Executable: "/Library/Elastic/Agent/elastic-agent",
+		Arguments:  []string{"run", "<&>"},
+	})
+	require.NoError(t, err)
+	assert.Contains(t, plist, "<string>co.elastic.elastic-agent</string>")
+	assert.Contains(t, plist, `
+		<string>/Library/Elastic/Agent/elastic-agent</string>
+		<string>run</string>
+		<string>&lt;&amp;&gt;</string>
+	</array>`)
+	assert.NotContains(t, plist, "UserName")
+}
+
+func TestInstallConfigValidate(t *testing.T) {
+	assert.Error(t, InstallConfig{Name: "../etc/passwd", Executable: "/bin/true"}.Validate())
+	assert.Error(t, InstallConfig{Name: "svc"}.Validate())
+	assert.NoError(t, InstallConfig{Name: "svc", Executable: "/bin/true"}.Validate())
+	assert.Error(t, InstallConfig{Name: "svc", Executable: "/bin/true", Description: "svc\nExecStart=/bin/sh"}.Validate())
+	assert.Error(t, InstallConfig{Name: "svc", Executable: "/bin/true", User: "root\rGroup=root"}.Validate())
+	assert.Error(t, InstallConfig{Name: "svc", Executable: "/bin/true", DisplayName: "svc\n"}.Validate())
+
+	_, err := SystemdUnit(InstallConfig{Name: "svc", Executable: "/bin/true", Description: "svc\nExecStart=/bin/sh"})
+	assert.Error(t, err)
+}
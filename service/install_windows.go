@@ -0,0 +1,126 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package service
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"golang.org/x/sys/windows/svc"
+	"golang.org/x/sys/windows/svc/mgr"
+)
+
+// Install registers the program with the service control manager.
+func Install(c InstallConfig) error {
+	if err := c.Validate(); err != nil {
+		return err
+	}
+	if len(c.Environment) > 0 {
+		return errors.New("service environment variables are not supported on Windows")
+	}
+
+	m, err := mgr.Connect()
+	if err != nil {
+		return fmt.Errorf("failed to connect to the service manager: %w", err)
+	}
+	defer m.Disconnect() //nolint:errcheck // nothing to do on error
+
+	displayName := c.DisplayName
+	if displayName == "" {
+		displayName = c.Name
+	}
+	s, err := m.CreateService(c.Name, c.Executable, mgr.Config{
+		DisplayName:      displayName,
+		Description:      c.Description,
+		StartType:        mgr.StartAutomatic,
+		ServiceStartName: c.User,
+	}, c.Arguments...)
+	if err != nil {
+		return fmt.Errorf("failed to create service %s: %w", c.Name, err)
+	}
+	return s.Close()
+}
+
+// Uninstall stops the service and removes it from the service control manager.
+func Uninstall(name string) error {
+	return withService(name, func(s *mgr.Service) error {
+		if status, err := s.Query(); err == nil && status.State != svc.Stopped {
+			if err := stopService(s); err != nil {
+				return err
+			}
+		}
+		if err := s.Delete(); err != nil {
+			return fmt.Errorf("failed to delete service %s: %w", name, err)
+		}
+		return nil
+	})
+}
+
+// Start starts the installed service.
+func Start(name string) error {
+	return withService(name, func(s *mgr.Service) error {
+		if err := s.Start(); err != nil {
+			return fmt.Errorf("failed to start service %s: %w", name, err)
+		}
+		return nil
+	})
+}
+
+// Stop stops the installed service and waits up to 30 seconds for it to stop.
+func Stop(name string) error {
+	return withService(name, stopService)
+}
+
+func stopService(s *mgr.Service) error {
+	status, err := s.Control(svc.Stop)
+	if err != nil {
+		return fmt.Errorf("failed to stop service %s: %w", s.Name, err)
+	}
+	deadline := time.Now().Add(30 * time.Second)
+	for status.State != svc.Stopped {
+		if time.Now().After(deadline) {
+			return fmt.Errorf("timeout waiting for service %s to stop", s.Name)
+		}
+		time.Sleep(300 * time.Millisecond)
+		if status, err = s.Query(); err != nil {
+			return fmt.Errorf("failed to query service %s: %w", s.Name, err)
+		}
+	}
+	return nil
+}
+
+func withService(name string, fn func(s *mgr.Service) error) error {
+	if err := validateName(name); err != nil {
+		return err
+	}
+
+	m, err := mgr.Connect()
+	if err != nil {
+		return fmt.Errorf("failed to connect to the service manager: %w", err)
+	}
+	defer m.Disconnect() //nolint:errcheck // nothing to do on error
+
+	s, err := m.OpenService(name)
+	if err != nil {
+		return fmt.Errorf("failed to open service %s: %w", name, err)
+	}
+	defer s.Close()
+
+	return fn(s)
+}
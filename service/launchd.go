@@ -0,0 +1,153 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package service
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"os"
+	"runtime"
+	"sync"
+	"time"
+)
+
+// LaunchdExitTimeout is the time launchd waits by default after sending
+// SIGTERM before killing a job with SIGKILL, see ExitTimeOut in
+// launchd.plist(5). When running under launchd HandleSignals cancels the
+// shutdown hooks that are still running before it expires, leaving
+// launchdStopMargin for the stop function.
+const LaunchdExitTimeout = 20 * time.Second
+
+// launchdStopMargin is the part of LaunchdExitTimeout reserved for the stop
+// function passed to HandleSignals, which runs after the shutdown hooks.
+const launchdStopMargin = 5 * time.Second
+
+// RunningUnderLaunchd returns true if the process was started by launchd
+// on macOS.
+func RunningUnderLaunchd() bool {
+	if runtime.GOOS != "darwin" || os.Getppid() != 1 {
+		return false
+	}
+	// launchd sets it to the job label, it is "0" in terminal sessions.
+	name := os.Getenv("XPC_SERVICE_NAME")
+	return name != "" && name != "0"
+}
+
+// LaunchdListeners returns listeners for the sockets launchd created for
+// the entry name of the Sockets dictionary in the job's plist. It is only
+// supported on macOS.
+func LaunchdListeners(name string) ([]net.Listener, error) {
+	fds, err := launchdActivateSocket(name)
+	if err != nil {
+		return nil, err
+	}
+
+	listeners := make([]net.Listener, 0, len(fds))
+	for _, fd := range fds {
+		f := os.NewFile(uintptr(fd), fmt.Sprintf("launchd-%s-%d", name, fd))
+		l, err := net.FileListener(f)
+		// net.FileListener duplicates the file descriptor.
+		f.Close()
+		if err != nil {
+			for _, l := range listeners {
+				l.Close()
+			}
+			return nil, fmt.Errorf("failed to use launchd socket %q: %w", name, err)
+		}
+		listeners = append(listeners, l)
+	}
+	return listeners, nil
+}
+
+// shutdownContext returns the context the shutdown hooks run with.
+func shutdownContext() (context.Context, context.CancelFunc) {
+	return newShutdownContext(RunningUnderLaunchd())
+}
+
+func newShutdownContext(underLaunchd bool) (context.Context, context.CancelFunc) {
+	if underLaunchd {
+		return context.WithTimeout(context.Background(), LaunchdExitTimeout-launchdStopMargin)
+	}
+	return context.WithCancel(context.Background())
+}
+
+// IdleTracker calls a function once no work was done for a timeout. It
+// allows on-demand services, like launchd jobs started by socket activation,
+// to exit when idle and be started again on the next request.
+type IdleTracker struct {
+	mu      sync.Mutex
+	timeout time.Duration
+	onIdle  func()
+	active  int
+	timer   *time.Timer
+	stopped bool
+	fired   bool
+}
+
+// NewIdleTracker returns an IdleTracker calling onIdle once after no work
+// was in progress for timeout. The timeout starts immediately.
+func NewIdleTracker(timeout time.Duration, onIdle func()) *IdleTracker {
+	t := &IdleTracker{timeout: timeout, onIdle: onIdle}
+	t.timer = time.AfterFunc(timeout, t.fire)
+	return t
+}
+
+// Begin marks the start of some work, the tracker is not idle until the
+// matching End is called.
+func (t *IdleTracker) Begin() {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.active++
+	t.timer.Stop()
+}
+
+// End marks the end of some work started with Begin.
+func (t *IdleTracker) End() {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if t.active > 0 {
+		t.active--
+	}
+	if t.active == 0 && !t.stopped && !t.fired {
+		t.timer.Reset(t.timeout)
+	}
+}
+
+// Stop stops the tracker, onIdle is not called afterwards.
+func (t *IdleTracker) Stop() {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.stopped = true
+	t.timer.Stop()
+}
+
+func (t *IdleTracker) fire() {
+	t.mu.Lock()
+	if t.active > 0 || t.stopped || t.fired {
+		t.mu.Unlock()
+		return
+	}
+	t.fired = true
+	t.mu.Unlock()
+
+	t.onIdle()
+}
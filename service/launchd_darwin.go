@@ -0,0 +1,51 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+//go:build darwin && cgo
+
+package service
+
+/*
+#cgo CFLAGS: -Wno-deprecated-declarations
+#include <launch.h>
+#include <stdlib.h>
+*/
+import "C"
+
+import (
+	"fmt"
+	"syscall"
+	"unsafe"
+)
+
+func launchdActivateSocket(name string) ([]int, error) {
+	cname := C.CString(name)
+	defer C.free(unsafe.Pointer(cname))
+
+	var fds *C.int
+	var count C.size_t
+	if res := C.launch_activate_socket(cname, &fds, &count); res != 0 {
+		return nil, fmt.Errorf("launch_activate_socket(%q) failed: %w", name, syscall.Errno(res))
+	}
+	defer C.free(unsafe.Pointer(fds))
+
+	res := make([]int, 0, int(count))
+	for _, fd := range unsafe.Slice(fds, int(count)) {
+		res = append(res, int(fd))
+	}
+	return res, nil
+}
@@ -0,0 +1,66 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package service
+
+import (
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestIdleTracker(t *testing.T) {
+	var idle atomic.Int32
+	tracker := NewIdleTracker(50*time.Millisecond, func() { idle.Add(1) })
+	defer tracker.Stop()
+
+	tracker.Begin()
+	time.Sleep(100 * time.Millisecond)
+	assert.Zero(t, idle.Load(), "the tracker is not idle while work is in progress")
+
+	tracker.End()
+	assert.Eventually(t, func() bool { return idle.Load() == 1 }, 5*time.Second, 10*time.Millisecond)
+
+	tracker.Begin()
+	tracker.End()
+	time.Sleep(100 * time.Millisecond)
+	assert.Equal(t, int32(1), idle.Load(), "onIdle is called only once")
+}
+
+func TestLaunchdListenersNotAvailable(t *testing.T) {
+	if RunningUnderLaunchd() {
+		t.Skip("running under launchd")
+	}
+	_, err := LaunchdListeners("Listeners")
+	assert.Error(t, err)
+}
+
+func TestShutdownContext(t *testing.T) {
+	ctx, cancel := newShutdownContext(false)
+	defer cancel()
+	_, ok := ctx.Deadline()
+	assert.False(t, ok)
+
+	// Under launchd the hooks leave time for the stop function.
+	ctx, cancel = newShutdownContext(true)
+	defer cancel()
+	deadline, ok := ctx.Deadline()
+	assert.True(t, ok)
+	assert.WithinDuration(t, time.Now().Add(LaunchdExitTimeout-launchdStopMargin), deadline, time.Second)
+}
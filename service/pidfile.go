@@ -0,0 +1,169 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package service
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/elastic/elastic-agent-libs/logp"
+)
+
+// ErrAlreadyRunning is returned by CreatePIDFile when another instance
+// holds the pidfile.
+var ErrAlreadyRunning = errors.New("another instance is already running")
+
+// PIDFile is a file holding the process ID of the running instance. While
+// it is held it is locked, which guarantees only one instance runs at a time.
+type PIDFile struct {
+	path string
+	file *os.File
+}
+
+// CreatePIDFile locks the pidfile at path and writes the current process ID
+// to it. It returns an error wrapping ErrAlreadyRunning if another running
+// process holds it. A pidfile left behind by a process that crashed is
+// detected as stale and replaced.
+// File locks are used on Linux, macOS, the BSDs and Windows. On other
+// platforms only the process ID written to the file is checked.
+func CreatePIDFile(path string) (*PIDFile, error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return nil, fmt.Errorf("failed to create pidfile directory: %w", err)
+	}
+
+	f, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE, 0644) //nolint:gosec // the pidfile is meant to be readable
+	if err != nil {
+		return nil, fmt.Errorf("failed to open pidfile: %w", err)
+	}
+
+	pid, running, err := checkPIDFile(f)
+	if err == nil && running {
+		err = fmt.Errorf("%w with pid %d (pidfile %s)", ErrAlreadyRunning, pid, path)
+	}
+	if err != nil {
+		f.Close()
+		return nil, err
+	}
+	if pid != 0 {
+		logp.NewLogger("service").Infof("Replacing stale pidfile %s of process %d", path, pid)
+	}
+
+	if err := writePID(f); err != nil {
+		_ = unlockFile(f)
+		f.Close()
+		return nil, fmt.Errorf("failed to write pidfile: %w", err)
+	}
+	return &PIDFile{path: path, file: f}, nil
+}
+
+// ValidatePIDFile returns the process ID stored in the pidfile at path and
+// whether that process still holds it. A missing pidfile is not an error.
+func ValidatePIDFile(path string) (pid int, running bool, err error) {
+	f, err := os.OpenFile(path, os.O_RDWR, 0)
+	if errors.Is(err, os.ErrNotExist) {
+		return 0, false, nil
+	}
+	if err != nil {
+		return 0, false, fmt.Errorf("failed to open pidfile: %w", err)
+	}
+	defer f.Close()
+
+	pid, running, err = checkPIDFile(f)
+	if err == nil && !running {
+		err = unlockFile(f)
+	}
+	return pid, running, err
+}
+
+// checkPIDFile locks f and returns the process ID stored in it and whether
+// that process is still running. f remains locked if it is not running.
+func checkPIDFile(f *os.File) (int, bool, error) {
+	locked, err := lockFile(f)
+	if err != nil {
+		return 0, false, fmt.Errorf("failed to lock pidfile: %w", err)
+	}
+
+	pid, err := readPID(f)
+	if err != nil {
+		if locked {
+			_ = unlockFile(f)
+		}
+		return 0, false, err
+	}
+	if !locked {
+		return pid, true, nil
+	}
+	if !fileLocking && pid != 0 && pid != os.Getpid() && processRunning(pid) {
+		return pid, true, nil
+	}
+	return pid, false, nil
+}
+
+// Path returns the path of the pidfile.
+func (p *PIDFile) Path() string {
+	return p.path
+}
+
+// Remove releases the lock and removes the pidfile.
+func (p *PIDFile) Remove() error {
+	// The file must be removed while it is locked, otherwise another
+	// instance could lock it before it is removed.
+	rmErr := os.Remove(p.path)
+	unlockErr := unlockFile(p.file)
+	closeErr := p.file.Close()
+	if rmErr != nil {
+		// Windows does not allow removing open files.
+		rmErr = os.Remove(p.path)
+	}
+	return errors.Join(rmErr, unlockErr, closeErr)
+}
+
+func readPID(f *os.File) (int, error) {
+	if _, err := f.Seek(0, io.SeekStart); err != nil {
+		return 0, fmt.Errorf("failed to read pidfile: %w", err)
+	}
+	data, err := io.ReadAll(io.LimitReader(f, 64))
+	if err != nil {
+		return 0, fmt.Errorf("failed to read pidfile: %w", err)
+	}
+	content := strings.TrimSpace(string(data))
+	if content == "" {
+		return 0, nil
+	}
+	pid, err := strconv.Atoi(content)
+	if err != nil {
+		// Garbage in the file, it will be overwritten.
+		return 0, nil //nolint:nilerr // an invalid pidfile is stale
+	}
+	return pid, nil
+}
+
+func writePID(f *os.File) error {
+	if err := f.Truncate(0); err != nil {
+		return err
+	}
+	if _, err := f.WriteAt([]byte(strconv.Itoa(os.Getpid())+"\n"), 0); err != nil {
+		return err
+	}
+	return f.Sync()
+}
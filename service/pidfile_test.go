@@ -0,0 +1,87 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package service
+
+import (
+	"flag"
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPIDFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "run", "test.pid")
+
+	pid, running, err := ValidatePIDFile(path)
+	require.NoError(t, err)
+	assert.Zero(t, pid)
+	assert.False(t, running)
+
+	p, err := CreatePIDFile(path)
+	require.NoError(t, err)
+
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Equal(t, strconv.Itoa(os.Getpid())+"\n", string(data))
+
+	pid, running, err = ValidatePIDFile(path)
+	require.NoError(t, err)
+	assert.Equal(t, os.Getpid(), pid)
+	assert.True(t, running)
+
+	_, err = CreatePIDFile(path)
+	assert.ErrorIs(t, err, ErrAlreadyRunning)
+
+	require.NoError(t, p.Remove())
+	assert.NoFileExists(t, path)
+}
+
+func TestPIDFileStale(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "test.pid")
+	// Left behind by a process that crashed.
+	require.NoError(t, os.WriteFile(path, []byte("999999999\n"), 0644))
+
+	pid, running, err := ValidatePIDFile(path)
+	require.NoError(t, err)
+	assert.Equal(t, 999999999, pid)
+	assert.False(t, running)
+
+	p, err := CreatePIDFile(path)
+	require.NoError(t, err)
+	defer p.Remove()
+
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Equal(t, strconv.Itoa(os.Getpid())+"\n", string(data))
+}
+
+func TestRegisterPIDFileFlag(t *testing.T) {
+	defer SetPIDFile("")
+
+	// An application defining its own -pidfile flag is not affected.
+	assert.Nil(t, flag.Lookup("pidfile"))
+
+	fs := flag.NewFlagSet("test", flag.ContinueOnError)
+	RegisterPIDFileFlag(fs)
+	require.NoError(t, fs.Parse([]string{"-pidfile", "/run/test.pid"}))
+	assert.Equal(t, "/run/test.pid", pidfile)
+}
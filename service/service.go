@@ -38,29 +38,72 @@ import (
 
 // HandleSignals manages OS signals that ask the service/daemon to stop.
 // The stopFunction should break the loop in the Beat so that
-// the service shuts down gracefully.
+// the service shuts down gracefully. It is called after the hooks
+// registered with RegisterShutdownHook have run.
+// When running under systemd, the watchdog notifications are sent
+// until the service is stopped and systemd is notified when stopping. When running under launchd,
+// the shutdown hooks are cancelled before launchd kills the process.
 func HandleSignals(stopFunction func(), cancel context.CancelFunc) {
 	var callback sync.Once
 	logger := logp.NewLogger("service")
 
-	// On termination signals, gracefully stop the Beat
+	// The watchdog notifications are sent until the service is stopped.
+	watchdogCtx, stopWatchdog := context.WithCancel(context.Background())
+	go RunSystemdWatchdog(watchdogCtx)
+
+	stop := func() {
+		defer stopWatchdog()
+		ctx, cancel := shutdownContext()
+		defer cancel()
+		_ = RunShutdownHooks(ctx)
+		stopFunction()
+	}
+
+	// On termination signals, gracefully stop the Beat. Signals with a
+	// callback registered through OnSignal are dispatched to it instead.
+	// Calling HandleSignals again ends the previous signal loop.
 	sigc := make(chan os.Signal, 1)
-	signal.Notify(sigc, syscall.SIGINT, syscall.SIGTERM, syscall.SIGHUP)
+	signals.start(sigc)
+	signal.Notify(sigc, stopSignals...)
 	go func() {
-		sig := <-sigc
+		for sig := range sigc {
+			if signals.handle(sig) {
+				logger.Debugf("Handled signal %q", sig)
+				continue
+			}
+			if !isStopSignal(sig) {
+				// Its callbacks were removed.
+				continue
+			}
 
-		logger.Infof("Received signal %q, stopping", sig)
-		cancel()
-		callback.Do(stopFunction)
+			logger.Infof("Received signal %q, stopping", sig)
+			NotifyStopping()
+			cancel()
+			callback.Do(stop)
+			return
+		}
 	}()
 
 	// Handle the Windows service events
 	go ProcessWindowsControlEvents(func() {
 		logger.Info("Received Windows SVC stop/shutdown request")
-		callback.Do(stopFunction)
+		callback.Do(stop)
 	})
 }
 
+// stopSignals are the signals stopping the service, unless a callback is
+// registered for them with OnSignal.
+var stopSignals = []os.Signal{syscall.SIGINT, syscall.SIGTERM, syscall.SIGHUP}
+
+func isStopSignal(sig os.Signal) bool {
+	for _, s := range stopSignals {
+		if s == sig {
+			return true
+		}
+	}
+	return false
+}
+
 // NotifyTermination tells the OS that the service is stopped.
 func NotifyTermination() {
 	notifyWindowsServiceStopped()
@@ -70,12 +113,35 @@ func NotifyTermination() {
 var memprofile, cpuprofile, httpprof *string
 var cpuOut *os.File
 
+// pidfile is the path of the pidfile written by BeforeRun, set with
+// SetPIDFile or the flag registered by RegisterPIDFileFlag.
+var pidfile string
+var pidFile *PIDFile
+
 func init() {
 	memprofile = flag.String("memprofile", "", "Write memory profile to this file")
 	cpuprofile = flag.String("cpuprofile", "", "Write cpu profile to file")
 	httpprof = flag.String("httpprof", "", "Start pprof http server")
 }
 
+// SetPIDFile sets the path of the pidfile BeforeRun creates, refusing to
+// start if another instance holds it. No pidfile is created if path is
+// empty.
+func SetPIDFile(path string) {
+	pidfile = path
+}
+
+// RegisterPIDFileFlag registers the -pidfile flag, setting the pidfile
+// BeforeRun creates, on fs. flag.CommandLine is used if fs is nil. The flag
+// is not registered by default, so it does not conflict with the flags of
+// the application.
+func RegisterPIDFileFlag(fs *flag.FlagSet) {
+	if fs == nil {
+		fs = flag.CommandLine
+	}
+	fs.StringVar(&pidfile, "pidfile", pidfile, "Write the process ID to this file and refuse to start if another instance holds it")
+}
+
 // ProfileEnabled checks whether the beat should write a cpu or memory profile.
 func ProfileEnabled() bool {
 	return withMemProfile() || withCPUProfile()
@@ -85,9 +151,19 @@ func withMemProfile() bool { return *memprofile != "" }
 func withCPUProfile() bool { return *cpuprofile != "" }
 
 // BeforeRun takes care of necessary actions such as creating files
-// before the beat should run.
+// before the beat should run. If a pidfile is set it exits when
+// another instance is already running.
 func BeforeRun() {
 	logger := logp.NewLogger("service")
+	if pidfile != "" {
+		var err error
+		pidFile, err = CreatePIDFile(pidfile)
+		if err != nil {
+			logger.Errorf("Failed to create pidfile: %v", err)
+			os.Exit(1)
+		}
+	}
+
 	if withCPUProfile() {
 		cpuOut, err := os.Create(*cpuprofile)
 		if err != nil {
@@ -156,9 +232,16 @@ func metricsHandler(w http.ResponseWriter, r *http.Request) {
 }
 
 // Cleanup handles cleaning up the runtime and OS environments. This includes
-// tasks such as stopping the CPU profile if it is running.
+// tasks such as stopping the CPU profile if it is running and removing the pidfile.
 func Cleanup() {
 	logger := logp.NewLogger("service")
+	if pidFile != nil {
+		if err := pidFile.Remove(); err != nil {
+			logger.Errorf("Failed to remove pidfile: %v", err)
+		}
+		pidFile = nil
+	}
+
 	if withCPUProfile() {
 		pprof.StopCPUProfile()
 		cpuOut.Close()
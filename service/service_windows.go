@@ -43,7 +43,13 @@ var serviceInstance = &beatService{
 // Execute runs the beat service with the arguments and manages changes that
 // occur in the environment or runtime that may affect the beat.
 func (m *beatService) Execute(args []string, r <-chan svc.ChangeRequest, changes chan<- svc.Status) (ssec bool, errno uint32) {
-	const cmdsAccepted = svc.AcceptStop | svc.AcceptShutdown
+	cmdsAccepted := svc.AcceptStop | svc.AcceptShutdown
+	if controls.acceptsPauseAndContinue() {
+		cmdsAccepted |= svc.AcceptPauseAndContinue
+	}
+	if controls.acceptsPreShutdown() {
+		cmdsAccepted |= svc.AcceptPreShutdown
+	}
 	changes <- svc.Status{State: svc.StartPending}
 	changes <- svc.Status{State: svc.Running, Accepts: cmdsAccepted}
 
@@ -79,14 +85,35 @@ loop:
 		case svc.Shutdown:
 			log.Info("received state change 'svc.Shutdown' from windows service manager")
 			break loop
+		case svc.PreShutdown:
+			log.Info("received state change 'svc.PreShutdown' from windows service manager")
+			controls.run(&controls.preShutdown)
+			break loop
+		case svc.Pause:
+			log.Info("received state change 'svc.Pause' from windows service manager")
+			trySendState(svc.PausePending, cmdsAccepted, changes)
+			controls.run(&controls.pause)
+			trySendState(svc.Paused, cmdsAccepted, changes)
+		case svc.Continue:
+			log.Info("received state change 'svc.Continue' from windows service manager")
+			trySendState(svc.ContinuePending, cmdsAccepted, changes)
+			controls.run(&controls.resume)
+			trySendState(svc.Running, cmdsAccepted, changes)
 
 		default:
+			if callbacks := controls.callbacks(uint32(c.Cmd)); len(callbacks) > 0 {
+				log.Infof("received custom control request %d from windows service manager", c.Cmd)
+				for _, cb := range callbacks {
+					cb()
+				}
+				continue
+			}
 			log.Errorf("Unexpected control request: $%d. Ignored.", c)
 		}
 	}
 
-	trySendState(svc.StopPending, changes)
-	defer trySendState(svc.Stopped, changes)
+	trySendState(svc.StopPending, 0, changes)
+	defer trySendState(svc.Stopped, 0, changes)
 
 	log.Info("changed windows service state to svc.StopPending, invoking stopCallback")
 	m.stopCallback()
@@ -99,9 +126,9 @@ loop:
 	return ssec, errno
 }
 
-func trySendState(s svc.State, changes chan<- svc.Status) {
+func trySendState(s svc.State, accepts svc.Accepted, changes chan<- svc.Status) {
 	select {
-	case changes <- svc.Status{State: s}:
+	case changes <- svc.Status{State: s, Accepts: accepts}:
 	case <-time.After(500 * time.Millisecond): // should never happen, but don't make this blocking
 	}
 }
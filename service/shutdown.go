@@ -0,0 +1,147 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/elastic/elastic-agent-libs/logp"
+)
+
+// ShutdownHook is a function run when the service stops. The context is
+// cancelled once the timeout the hook was registered with expires.
+type ShutdownHook func(ctx context.Context) error
+
+// ShutdownHooks is an ordered list of named shutdown hooks.
+// The zero value is ready to use.
+type ShutdownHooks struct {
+	mu    sync.Mutex
+	hooks []shutdownHook
+	seq   int
+}
+
+type shutdownHook struct {
+	name     string
+	priority int
+	timeout  time.Duration
+	fn       ShutdownHook
+	seq      int
+}
+
+var defaultShutdownHooks = &ShutdownHooks{}
+
+// RegisterShutdownHook registers a hook that is run by HandleSignals when
+// a stop signal or a Windows stop request is received, see
+// ShutdownHooks.Register.
+func RegisterShutdownHook(name string, priority int, timeout time.Duration, hook ShutdownHook) {
+	defaultShutdownHooks.Register(name, priority, timeout, hook)
+}
+
+// RunShutdownHooks runs the hooks registered with RegisterShutdownHook, see
+// ShutdownHooks.Run. HandleSignals calls it, it only needs to be called
+// directly when the service stops for other reasons.
+func RunShutdownHooks(ctx context.Context) error {
+	return defaultShutdownHooks.Run(ctx)
+}
+
+// Register adds a hook. Hooks run in ascending order of priority, hooks
+// with the same priority run in the order they were registered. A hook
+// taking longer than timeout is abandoned and the next one is started,
+// a timeout of 0 means no timeout.
+func (h *ShutdownHooks) Register(name string, priority int, timeout time.Duration, hook ShutdownHook) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	h.seq++
+	h.hooks = append(h.hooks, shutdownHook{
+		name:     name,
+		priority: priority,
+		timeout:  timeout,
+		fn:       hook,
+		seq:      h.seq,
+	})
+}
+
+// Run runs all the registered hooks in order and removes them, so each hook
+// runs at most once. Failed and slow hooks are logged, the returned error
+// contains the errors of all failed hooks.
+// If ctx is done the remaining hooks are not run.
+func (h *ShutdownHooks) Run(ctx context.Context) error {
+	h.mu.Lock()
+	hooks := h.hooks
+	h.hooks = nil
+	h.mu.Unlock()
+
+	sort.SliceStable(hooks, func(i, j int) bool {
+		if hooks[i].priority != hooks[j].priority {
+			return hooks[i].priority < hooks[j].priority
+		}
+		return hooks[i].seq < hooks[j].seq
+	})
+
+	logger := logp.NewLogger("service")
+	var errs []error
+	for i, hook := range hooks {
+		if err := ctx.Err(); err != nil {
+			logger.Warnf("Skipping %d shutdown hooks: %v", len(hooks)-i, err)
+			errs = append(errs, err)
+			break
+		}
+
+		start := time.Now()
+		err := hook.run(ctx)
+		took := time.Since(start)
+		switch {
+		case errors.Is(err, context.DeadlineExceeded) && hook.timeout > 0 && took >= hook.timeout:
+			logger.Warnf("Shutdown hook %q did not finish within %s", hook.name, hook.timeout)
+			errs = append(errs, fmt.Errorf("shutdown hook %q timed out: %w", hook.name, err))
+		case err != nil:
+			logger.Errorf("Shutdown hook %q failed after %s: %v", hook.name, took, err)
+			errs = append(errs, fmt.Errorf("shutdown hook %q failed: %w", hook.name, err))
+		default:
+			logger.Debugf("Shutdown hook %q finished in %s", hook.name, took)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// run calls the hook and waits for it to return or for its timeout to expire.
+func (h shutdownHook) run(ctx context.Context) error {
+	if h.timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, h.timeout)
+		defer cancel()
+	}
+
+	done := make(chan error, 1)
+	go func() {
+		done <- h.fn(ctx)
+	}()
+
+	select {
+	case err := <-done:
+		return err
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
@@ -0,0 +1,84 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package service
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestShutdownHooks(t *testing.T) {
+	var hooks ShutdownHooks
+	var mu sync.Mutex
+	var order []string
+	record := func(name string) {
+		mu.Lock()
+		defer mu.Unlock()
+		order = append(order, name)
+	}
+	hook := func(name string, err error) ShutdownHook {
+		return func(context.Context) error {
+			record(name)
+			return err
+		}
+	}
+
+	failure := errors.New("failure")
+	hooks.Register("outputs", 10, 0, hook("outputs", nil))
+	hooks.Register("inputs", 0, time.Second, hook("inputs", nil))
+	hooks.Register("failing", 10, 0, hook("failing", failure))
+	hooks.Register("slow", 20, 10*time.Millisecond, func(ctx context.Context) error {
+		record("slow")
+		// Ignores cancellation on purpose.
+		time.Sleep(time.Second)
+		return nil
+	})
+	hooks.Register("last", 30, 0, hook("last", nil))
+
+	err := hooks.Run(context.Background())
+	assert.ErrorIs(t, err, failure)
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+	mu.Lock()
+	assert.Equal(t, []string{"inputs", "outputs", "failing", "slow", "last"}, order)
+	order = nil
+	mu.Unlock()
+
+	assert.NoError(t, hooks.Run(context.Background()), "hooks run only once")
+	mu.Lock()
+	assert.Empty(t, order)
+	mu.Unlock()
+}
+
+func TestShutdownHooksCancelled(t *testing.T) {
+	var hooks ShutdownHooks
+	called := false
+	hooks.Register("hook", 0, 0, func(context.Context) error {
+		called = true
+		return nil
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	assert.ErrorIs(t, hooks.Run(ctx), context.Canceled)
+	assert.False(t, called)
+}
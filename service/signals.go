@@ -0,0 +1,131 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package service
+
+import (
+	"os"
+	"os/signal"
+	"sync"
+	"syscall"
+
+	"github.com/elastic/elastic-agent-libs/logp"
+)
+
+// signalHandlers holds the callbacks registered with OnSignal.
+type signalHandlers struct {
+	mu       sync.Mutex
+	handlers map[os.Signal][]signalHandler
+	nextID   uint64
+	sigc     chan<- os.Signal
+}
+
+type signalHandler struct {
+	id       uint64
+	callback func(os.Signal)
+}
+
+var signals = newSignalHandlers()
+
+func newSignalHandlers() *signalHandlers {
+	return &signalHandlers{handlers: map[os.Signal][]signalHandler{}}
+}
+
+// OnSignal registers a callback called by the signal loop started by
+// HandleSignals whenever sig is received, e.g. SIGHUP to reload the
+// configuration or SIGUSR1 to dump diagnostics. Callbacks can be registered
+// before or after HandleSignals is called. They run one at a time on the
+// signal loop, so they must not block. The returned function removes the
+// callback.
+// Registering a callback for SIGHUP stops HandleSignals from treating
+// SIGHUP as a request to stop, until the callback is removed.
+func OnSignal(sig os.Signal, callback func(os.Signal)) (remove func()) {
+	signals.mu.Lock()
+	defer signals.mu.Unlock()
+
+	signals.nextID++
+	id := signals.nextID
+	signals.handlers[sig] = append(signals.handlers[sig], signalHandler{id: id, callback: callback})
+	if signals.sigc != nil {
+		signal.Notify(signals.sigc, sig)
+	}
+
+	var once sync.Once
+	return func() {
+		once.Do(func() { signals.remove(sig, id) })
+	}
+}
+
+// ReopenLogsOnSIGHUP makes the signal loop of HandleSignals reopen the log
+// files with logp.Reopen when SIGHUP is received, instead of stopping the
+// service. Services using HandleSignals must use it rather than
+// logp.ReopenOnSIGHUP. The returned function removes the callback.
+func ReopenLogsOnSIGHUP() (remove func()) {
+	return OnSignal(syscall.SIGHUP, func(os.Signal) {
+		if err := logp.Reopen(); err != nil {
+			logp.L().Errorw("Failed to reopen the log files", logp.Error(err))
+		}
+	})
+}
+
+func (s *signalHandlers) remove(sig os.Signal, id uint64) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	handlers := s.handlers[sig]
+	for i, h := range handlers {
+		if h.id == id {
+			handlers = append(handlers[:i:i], handlers[i+1:]...)
+			break
+		}
+	}
+	if len(handlers) == 0 {
+		delete(s.handlers, sig)
+		return
+	}
+	s.handlers[sig] = handlers
+}
+
+// start makes sigc receive all the signals a callback is registered for.
+// The channel of the previous signal loop, if any, stops receiving signals
+// and is closed, ending that loop.
+func (s *signalHandlers) start(sigc chan<- os.Signal) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.sigc != nil {
+		signal.Stop(s.sigc)
+		close(s.sigc)
+	}
+	s.sigc = sigc
+	for sig := range s.handlers {
+		signal.Notify(sigc, sig)
+	}
+}
+
+// handle calls the callbacks registered for sig. It returns false if there
+// are none.
+func (s *signalHandlers) handle(sig os.Signal) bool {
+	s.mu.Lock()
+	handlers := s.handlers[sig]
+	s.mu.Unlock()
+
+	for _, h := range handlers {
+		h.callback(sig)
+	}
+	return len(handlers) > 0
+}
@@ -0,0 +1,144 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+//go:build !windows
+
+package service
+
+import (
+	"context"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/elastic/elastic-agent-libs/logp"
+)
+
+// resetSignals gives the test an empty signal registry, and stops its
+// signal loop once the test is done.
+func resetSignals(t *testing.T) {
+	saved := signals
+	signals = newSignalHandlers()
+	t.Cleanup(func() {
+		signals.mu.Lock()
+		if signals.sigc != nil {
+			signal.Stop(signals.sigc)
+			close(signals.sigc)
+		}
+		signals.mu.Unlock()
+		signals = saved
+	})
+}
+
+func TestOnSignal(t *testing.T) {
+	resetSignals(t)
+	received := make(chan os.Signal, 1)
+	OnSignal(syscall.SIGUSR1, func(sig os.Signal) { received <- sig })
+
+	stopped := make(chan struct{})
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	HandleSignals(func() { close(stopped) }, cancel)
+
+	require.NoError(t, syscall.Kill(os.Getpid(), syscall.SIGUSR1))
+	select {
+	case sig := <-received:
+		assert.Equal(t, syscall.SIGUSR1, sig)
+	case <-time.After(5 * time.Second):
+		t.Fatal("callback was not called")
+	}
+
+	select {
+	case <-stopped:
+		t.Fatal("a signal with a callback must not stop the service")
+	case <-ctx.Done():
+		t.Fatal("a signal with a callback must not cancel the context")
+	default:
+	}
+}
+
+func TestHandleSignalsTwice(t *testing.T) {
+	resetSignals(t)
+	received := make(chan os.Signal, 10)
+	remove := OnSignal(syscall.SIGUSR2, func(sig os.Signal) { received <- sig })
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	HandleSignals(func() {}, cancel)
+	HandleSignals(func() {}, cancel)
+
+	require.NoError(t, syscall.Kill(os.Getpid(), syscall.SIGUSR2))
+	select {
+	case <-received:
+	case <-time.After(5 * time.Second):
+		t.Fatal("callback was not called")
+	}
+	select {
+	case <-received:
+		t.Fatal("the callback must be called once")
+	case <-time.After(100 * time.Millisecond):
+	}
+
+	// Once removed, the callback is not called and the signal doesn't stop
+	// the service.
+	remove()
+	require.NoError(t, syscall.Kill(os.Getpid(), syscall.SIGUSR2))
+	select {
+	case <-received:
+		t.Fatal("a removed callback must not be called")
+	case <-ctx.Done():
+		t.Fatal("a signal without callback must not cancel the context")
+	case <-time.After(100 * time.Millisecond):
+	}
+}
+
+func TestReopenLogsOnSIGHUP(t *testing.T) {
+	resetSignals(t)
+	defer logp.SaveGlobalLogger()()
+
+	dir := t.TempDir()
+	cfg := logp.DefaultConfig(logp.DefaultEnvironment)
+	cfg.Beat = "testbeat"
+	cfg.Files.Path = dir
+	require.NoError(t, logp.Configure(cfg))
+	logp.L().Info("first entry")
+	require.NoError(t, logp.L().Sync())
+	files, err := filepath.Glob(filepath.Join(dir, "testbeat*.ndjson"))
+	require.NoError(t, err)
+	require.Len(t, files, 1)
+	path := files[0]
+
+	remove := ReopenLogsOnSIGHUP()
+	defer remove()
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	HandleSignals(func() {}, cancel)
+
+	require.NoError(t, os.Rename(path, path+".1"))
+	require.NoError(t, syscall.Kill(os.Getpid(), syscall.SIGHUP))
+	assert.Eventually(t, func() bool {
+		_, err := os.Stat(path)
+		return err == nil
+	}, 5*time.Second, 10*time.Millisecond, "the log file must be reopened")
+	assert.NoError(t, ctx.Err(), "SIGHUP must not stop the service")
+}
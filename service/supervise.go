@@ -0,0 +1,181 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"runtime/debug"
+	"time"
+
+	"github.com/elastic/elastic-agent-libs/clock"
+	"github.com/elastic/elastic-agent-libs/logp"
+)
+
+// ErrTooManyRestarts is returned by Supervise when the supervised function
+// failed more often than allowed by MaxRestarts.
+var ErrTooManyRestarts = errors.New("too many restarts")
+
+// minRestartBackoff is the shortest time waited before restarting the
+// supervised function, so a function failing right away doesn't restart in
+// a tight loop.
+const minRestartBackoff = 10 * time.Millisecond
+
+// PanicError is the error a supervised function failed with when it panicked.
+type PanicError struct {
+	Value interface{}
+	Stack []byte
+}
+
+func (e *PanicError) Error() string {
+	return fmt.Sprintf("panic: %v", e.Value)
+}
+
+// SuperviseOption is a configuration option for Supervise.
+type SuperviseOption func(s *supervisor)
+
+// SupervisorName configures the name used to identify the supervised
+// function in logs.
+func SupervisorName(name string) SuperviseOption {
+	return func(s *supervisor) {
+		s.name = name
+	}
+}
+
+// RestartBackoff configures the time waited before restarting the supervised
+// function. It starts at initial and doubles on every consecutive failure up
+// to max. The defaults are 1 second and 1 minute. Both are raised to at
+// least 10 milliseconds.
+func RestartBackoff(initial, max time.Duration) SuperviseOption {
+	return func(s *supervisor) {
+		s.initialBackoff = initial
+		s.maxBackoff = max
+	}
+}
+
+// MaxRestarts configures how many consecutive failures are restarted before
+// Supervise gives up. The default is 0 for unlimited.
+func MaxRestarts(n int) SuperviseOption {
+	return func(s *supervisor) {
+		s.maxRestarts = n
+	}
+}
+
+// ResetAfter configures how long the supervised function must run before
+// a failure is not considered consecutive to the previous ones anymore,
+// resetting the backoff and the restarts count. The default is 5 minutes.
+func ResetAfter(d time.Duration) SuperviseOption {
+	return func(s *supervisor) {
+		s.resetAfter = d
+	}
+}
+
+// SupervisorClock configures the clock timing the backoff and the run
+// durations. The default is the real clock.
+func SupervisorClock(clk clock.Clock) SuperviseOption {
+	return func(s *supervisor) {
+		s.clock = clk
+	}
+}
+
+type supervisor struct {
+	clock          clock.Clock
+	name           string
+	initialBackoff time.Duration
+	maxBackoff     time.Duration
+	maxRestarts    int
+	resetAfter     time.Duration
+}
+
+// Supervise runs a long-lived worker and restarts it when it returns an
+// error or panics. Panics are recovered and logged with their stack trace.
+// Restarts are delayed with an exponential backoff, see RestartBackoff.
+// Supervise returns nil once run returns without error, ctx.Err() once ctx
+// is done, or an error wrapping ErrTooManyRestarts and the last failure when
+// the limit set by MaxRestarts is reached.
+func Supervise(ctx context.Context, run func(ctx context.Context) error, opts ...SuperviseOption) error {
+	s := &supervisor{
+		clock:          clock.Real(),
+		name:           "worker",
+		initialBackoff: time.Second,
+		maxBackoff:     time.Minute,
+		resetAfter:     5 * time.Minute,
+	}
+	for _, opt := range opts {
+		opt(s)
+	}
+	s.initialBackoff = max(s.initialBackoff, minRestartBackoff)
+	s.maxBackoff = max(s.maxBackoff, s.initialBackoff)
+
+	logger := logp.NewLogger("service").With("worker", s.name)
+	backoff := s.initialBackoff
+	restarts := 0
+	for {
+		start := s.clock.Now()
+		err := safeRun(ctx, run)
+		if err == nil {
+			return nil
+		}
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+
+		if s.clock.Since(start) >= s.resetAfter {
+			backoff = s.initialBackoff
+			restarts = 0
+		}
+
+		var panicErr *PanicError
+		if errors.As(err, &panicErr) {
+			logger.Errorw("Supervised worker panicked", "error.message", err.Error(), "error.stack_trace", string(panicErr.Stack))
+		} else {
+			logger.Errorw("Supervised worker failed", "error.message", err.Error())
+		}
+
+		if s.maxRestarts > 0 && restarts >= s.maxRestarts {
+			logger.Errorf("Supervised worker failed %d times in a row, giving up", restarts+1)
+			return fmt.Errorf("%w of %s: %w", ErrTooManyRestarts, s.name, err)
+		}
+		restarts++
+
+		logger.Infof("Restarting supervised worker in %s", backoff)
+		timer := s.clock.NewTimer(backoff)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return ctx.Err()
+		case <-timer.C():
+		}
+
+		backoff *= 2
+		if backoff > s.maxBackoff {
+			backoff = s.maxBackoff
+		}
+	}
+}
+
+// safeRun calls run, converting a panic into a *PanicError.
+func safeRun(ctx context.Context, run func(ctx context.Context) error) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = &PanicError{Value: r, Stack: debug.Stack()}
+		}
+	}()
+	return run(ctx)
+}
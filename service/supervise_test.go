@@ -0,0 +1,139 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/elastic/elastic-agent-libs/clock"
+)
+
+func TestSupervise(t *testing.T) {
+	clk := clock.NewFake(time.Now())
+	var starts []time.Time
+	done := make(chan error, 1)
+	go func() {
+		done <- Supervise(context.Background(), func(context.Context) error {
+			starts = append(starts, clk.Now())
+			switch len(starts) {
+			case 1:
+				panic("boom")
+			case 2:
+				return errors.New("failure")
+			default:
+				return nil
+			}
+		}, RestartBackoff(time.Second, 10*time.Second), SupervisorClock(clk))
+	}()
+
+	for _, backoff := range []time.Duration{time.Second, 2 * time.Second} {
+		clk.BlockUntil(1)
+		clk.Advance(backoff)
+	}
+	assert.NoError(t, <-done)
+	if assert.Len(t, starts, 3) {
+		assert.Equal(t, time.Second, starts[1].Sub(starts[0]))
+		assert.Equal(t, 2*time.Second, starts[2].Sub(starts[1]), "the backoff doubles")
+	}
+}
+
+func TestSuperviseMaxRestarts(t *testing.T) {
+	clk := clock.NewFake(time.Now())
+	calls := 0
+	done := make(chan error, 1)
+	go func() {
+		done <- Supervise(context.Background(), func(context.Context) error {
+			calls++
+			panic("boom")
+		}, RestartBackoff(time.Second, time.Second), MaxRestarts(2), SupervisorClock(clk))
+	}()
+
+	for i := 0; i < 2; i++ {
+		clk.BlockUntil(1)
+		clk.Advance(time.Second)
+	}
+	err := <-done
+	assert.ErrorIs(t, err, ErrTooManyRestarts)
+	var panicErr *PanicError
+	assert.ErrorAs(t, err, &panicErr)
+	assert.Equal(t, "boom", panicErr.Value)
+	assert.NotEmpty(t, panicErr.Stack)
+	assert.Equal(t, 3, calls)
+}
+
+func TestSuperviseResetAfter(t *testing.T) {
+	clk := clock.NewFake(time.Now())
+	calls := 0
+	done := make(chan error, 1)
+	go func() {
+		done <- Supervise(context.Background(), func(context.Context) error {
+			calls++
+			switch calls {
+			case 2:
+				// Running long enough resets the restarts count.
+				clk.Advance(time.Minute)
+			case 4:
+				return nil
+			}
+			return errors.New("failure")
+		}, RestartBackoff(time.Second, time.Second), MaxRestarts(2), ResetAfter(time.Minute), SupervisorClock(clk))
+	}()
+
+	for i := 0; i < 3; i++ {
+		clk.BlockUntil(1)
+		clk.Advance(time.Second)
+	}
+	assert.NoError(t, <-done)
+	assert.Equal(t, 4, calls)
+}
+
+func TestSuperviseMinBackoff(t *testing.T) {
+	clk := clock.NewFake(time.Now())
+	calls := 0
+	done := make(chan error, 1)
+	go func() {
+		done <- Supervise(context.Background(), func(context.Context) error {
+			calls++
+			if calls == 1 {
+				return errors.New("failure")
+			}
+			return nil
+		}, RestartBackoff(0, 0), SupervisorClock(clk))
+	}()
+
+	// A zero backoff still waits on the clock rather than restarting right
+	// away.
+	clk.BlockUntil(1)
+	clk.Advance(minRestartBackoff)
+	assert.NoError(t, <-done)
+	assert.Equal(t, 2, calls)
+}
+
+func TestSuperviseCancelled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	err := Supervise(ctx, func(context.Context) error {
+		cancel()
+		return errors.New("failure")
+	}, RestartBackoff(time.Hour, time.Hour))
+	assert.ErrorIs(t, err, context.Canceled)
+}
@@ -0,0 +1,103 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package service
+
+import (
+	"context"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/elastic/elastic-agent-libs/logp"
+)
+
+// States that can be sent to systemd with SystemdNotify, see sd_notify(3).
+const (
+	SystemdReady     = "READY=1"
+	SystemdStopping  = "STOPPING=1"
+	SystemdReloading = "RELOADING=1"
+	SystemdWatchdog  = "WATCHDOG=1"
+)
+
+// SystemdNotify sends state to the service manager when running as a
+// systemd service with Type=notify. It returns false if the notification
+// was not sent because the process is not running under systemd, which is
+// always the case on platforms other than Linux.
+func SystemdNotify(state string) (bool, error) {
+	return sdNotify(state)
+}
+
+// NotifyReady tells systemd that the service finished starting up. Errors
+// are logged, it does nothing when not running under systemd.
+func NotifyReady() {
+	notifySystemd(SystemdReady)
+}
+
+// NotifyStopping tells systemd that the service is shutting down. Errors
+// are logged, it does nothing when not running under systemd.
+// HandleSignals calls it when a stop signal is received.
+func NotifyStopping() {
+	notifySystemd(SystemdStopping)
+}
+
+func notifySystemd(state string) {
+	if _, err := SystemdNotify(state); err != nil {
+		logp.NewLogger("service").Warnf("Failed to send %q notification to systemd: %v", state, err)
+	}
+}
+
+// SystemdWatchdogInterval returns how often systemd expects to receive a
+// watchdog notification. It returns 0 if the watchdog is not enabled for
+// this process.
+func SystemdWatchdogInterval() time.Duration {
+	usec, err := strconv.ParseInt(os.Getenv("WATCHDOG_USEC"), 10, 64)
+	if err != nil || usec <= 0 {
+		return 0
+	}
+	if pid := os.Getenv("WATCHDOG_PID"); pid != "" && pid != strconv.Itoa(os.Getpid()) {
+		return 0
+	}
+	return time.Duration(usec) * time.Microsecond
+}
+
+// RunSystemdWatchdog sends watchdog notifications to systemd at half the
+// interval configured by WatchdogSec until ctx is done. It returns
+// immediately if the watchdog is not enabled. HandleSignals starts it.
+func RunSystemdWatchdog(ctx context.Context) {
+	interval := SystemdWatchdogInterval()
+	if interval == 0 {
+		return
+	}
+
+	logger := logp.NewLogger("service")
+	logger.Debugf("Sending systemd watchdog notifications every %s", interval/2)
+
+	ticker := time.NewTicker(interval / 2)
+	defer ticker.Stop()
+	for {
+		if _, err := SystemdNotify(SystemdWatchdog); err != nil {
+			logger.Warnf("Failed to send watchdog notification to systemd: %v", err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
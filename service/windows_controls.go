@@ -0,0 +1,114 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package service
+
+import (
+	"fmt"
+	"sync"
+)
+
+// Custom control codes sent to a Windows service must be in this range.
+const (
+	MinWindowsCustomControl = 128
+	MaxWindowsCustomControl = 255
+)
+
+// windowsControls holds the callbacks for the Windows service control
+// requests other than stop and shutdown, which are handled by
+// ProcessWindowsControlEvents.
+type windowsControls struct {
+	mu          sync.Mutex
+	pause       []func()
+	resume      []func()
+	preShutdown []func()
+	custom      map[uint32][]func()
+}
+
+var controls = &windowsControls{custom: map[uint32][]func(){}}
+
+// OnWindowsPause registers a callback called when the Windows service
+// manager asks the service to pause. Once a pause or continue callback is
+// registered the service accepts pause and continue requests.
+// Callbacks must be registered before ProcessWindowsControlEvents is called,
+// they are never called on other platforms.
+func OnWindowsPause(callback func()) {
+	controls.mu.Lock()
+	defer controls.mu.Unlock()
+	controls.pause = append(controls.pause, callback)
+}
+
+// OnWindowsContinue registers a callback called when the Windows service
+// manager asks a paused service to continue, see OnWindowsPause.
+func OnWindowsContinue(callback func()) {
+	controls.mu.Lock()
+	defer controls.mu.Unlock()
+	controls.resume = append(controls.resume, callback)
+}
+
+// OnWindowsPreShutdown registers a callback called when the system is about
+// to shut down. Once a callback is registered the service accepts pre-shutdown
+// requests, which give it more time to stop than the shutdown request.
+// The service is stopped after the callbacks return.
+func OnWindowsPreShutdown(callback func()) {
+	controls.mu.Lock()
+	defer controls.mu.Unlock()
+	controls.preShutdown = append(controls.preShutdown, callback)
+}
+
+// OnWindowsControl registers a callback for a custom control code, which
+// must be between MinWindowsCustomControl and MaxWindowsCustomControl.
+func OnWindowsControl(code uint32, callback func()) error {
+	if code < MinWindowsCustomControl || code > MaxWindowsCustomControl {
+		return fmt.Errorf("invalid custom control code %d, it must be between %d and %d",
+			code, MinWindowsCustomControl, MaxWindowsCustomControl)
+	}
+
+	controls.mu.Lock()
+	defer controls.mu.Unlock()
+	controls.custom[code] = append(controls.custom[code], callback)
+	return nil
+}
+
+func (c *windowsControls) acceptsPauseAndContinue() bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return len(c.pause) > 0 || len(c.resume) > 0
+}
+
+func (c *windowsControls) acceptsPreShutdown() bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return len(c.preShutdown) > 0
+}
+
+// callbacks returns the callbacks registered for a custom control code.
+func (c *windowsControls) callbacks(code uint32) []func() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.custom[code]
+}
+
+// run calls the callbacks of one of the lists held by c.
+func (c *windowsControls) run(callbacks *[]func()) {
+	c.mu.Lock()
+	cbs := *callbacks
+	c.mu.Unlock()
+	for _, cb := range cbs {
+		cb()
+	}
+}
@@ -0,0 +1,62 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package service
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestOnWindowsControl(t *testing.T) {
+	saved := controls
+	controls = &windowsControls{custom: map[uint32][]func(){}}
+	defer func() { controls = saved }()
+
+	for _, code := range []uint32{0, MinWindowsCustomControl - 1, MaxWindowsCustomControl + 1} {
+		assert.Error(t, OnWindowsControl(code, func() {}), "code %d", code)
+	}
+	assert.Empty(t, controls.custom)
+
+	called := 0
+	require.NoError(t, OnWindowsControl(MinWindowsCustomControl, func() { called++ }))
+	require.NoError(t, OnWindowsControl(MaxWindowsCustomControl, func() { called++ }))
+	require.NoError(t, OnWindowsControl(MaxWindowsCustomControl, func() { called++ }))
+	assert.Len(t, controls.callbacks(MinWindowsCustomControl), 1)
+	for _, cb := range controls.callbacks(MaxWindowsCustomControl) {
+		cb()
+	}
+	assert.Equal(t, 2, called)
+	assert.Empty(t, controls.callbacks(200))
+}
+
+func TestWindowsControlsAccepts(t *testing.T) {
+	c := &windowsControls{custom: map[uint32][]func(){}}
+	assert.False(t, c.acceptsPauseAndContinue())
+	assert.False(t, c.acceptsPreShutdown())
+
+	paused := false
+	c.pause = append(c.pause, func() { paused = true })
+	c.preShutdown = append(c.preShutdown, func() {})
+	assert.True(t, c.acceptsPauseAndContinue())
+	assert.True(t, c.acceptsPreShutdown())
+
+	c.run(&c.pause)
+	assert.True(t, paused)
+}
@@ -17,9 +17,17 @@
 
 package testing
 
-import "net"
+import (
+	"net"
+	"sync"
+	gotesting "testing"
+)
 
 // AvailableTCP4Port returns an unused TCP port for 127.0.0.1.
+//
+// The port is released before returning, so another process may take it
+// before the caller binds it. Prefer NewTCP4Listener, or ReserveTCP4Port
+// when the code under test must do the binding itself.
 func AvailableTCP4Port() (uint16, error) {
 	resolved, err := net.ResolveTCPAddr("tcp4", "127.0.0.1:0")
 	if err != nil {
@@ -36,3 +44,59 @@ func AvailableTCP4Port() (uint16, error) {
 
 	return tcpAddr, nil
 }
+
+// AvailableUDP4Port returns an unused UDP port for 127.0.0.1. The same
+// caveats as for AvailableTCP4Port apply.
+func AvailableUDP4Port() (uint16, error) {
+	conn, err := net.ListenUDP("udp4", &net.UDPAddr{IP: net.IPv4(127, 0, 0, 1)})
+	if err != nil {
+		return 0, err
+	}
+	defer conn.Close()
+
+	return uint16(conn.LocalAddr().(*net.UDPAddr).Port), nil
+}
+
+// NewTCP4Listener returns a TCP listener bound to a free port on 127.0.0.1.
+// The listener is closed at the end of the test. Handing the listener itself
+// to the code under test avoids races with other tests taking the port.
+func NewTCP4Listener(t gotesting.TB) net.Listener {
+	t.Helper()
+	l, err := net.Listen("tcp4", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("could not create TCP listener: %v", err)
+	}
+	t.Cleanup(func() { _ = l.Close() })
+	return l
+}
+
+// NewUDP4Conn returns a UDP connection bound to a free port on 127.0.0.1.
+// The connection is closed at the end of the test.
+func NewUDP4Conn(t gotesting.TB) *net.UDPConn {
+	t.Helper()
+	conn, err := net.ListenUDP("udp4", &net.UDPAddr{IP: net.IPv4(127, 0, 0, 1)})
+	if err != nil {
+		t.Fatalf("could not create UDP connection: %v", err)
+	}
+	t.Cleanup(func() { _ = conn.Close() })
+	return conn
+}
+
+// ReserveTCP4Port finds a free TCP port on 127.0.0.1 and keeps it bound
+// until release is called, so no other test can take it in the meantime.
+// Call release right before the code under test binds the port. The port
+// is released at the end of the test if release was not called.
+func ReserveTCP4Port(t gotesting.TB) (port uint16, release func()) {
+	t.Helper()
+	l, err := net.Listen("tcp4", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("could not reserve TCP port: %v", err)
+	}
+
+	var once sync.Once
+	release = func() {
+		once.Do(func() { _ = l.Close() })
+	}
+	t.Cleanup(release)
+	return uint16(l.Addr().(*net.TCPAddr).Port), release
+}
@@ -0,0 +1,83 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package testing
+
+import (
+	"net"
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAvailablePorts(t *testing.T) {
+	port, err := AvailableTCP4Port()
+	require.NoError(t, err)
+	assert.NotZero(t, port)
+
+	port, err = AvailableUDP4Port()
+	require.NoError(t, err)
+	assert.NotZero(t, port)
+}
+
+func TestNewTCP4Listener(t *testing.T) {
+	l := NewTCP4Listener(t)
+
+	go func() {
+		conn, err := l.Accept()
+		if err == nil {
+			conn.Close()
+		}
+	}()
+
+	conn, err := net.Dial("tcp4", l.Addr().String())
+	require.NoError(t, err)
+	conn.Close()
+}
+
+func TestNewUDP4Conn(t *testing.T) {
+	server := NewUDP4Conn(t)
+
+	client, err := net.DialUDP("udp4", nil, server.LocalAddr().(*net.UDPAddr))
+	require.NoError(t, err)
+	defer client.Close()
+
+	_, err = client.Write([]byte("ping"))
+	require.NoError(t, err)
+
+	buf := make([]byte, 4)
+	n, _, err := server.ReadFromUDP(buf)
+	require.NoError(t, err)
+	assert.Equal(t, "ping", string(buf[:n]))
+}
+
+func TestReserveTCP4Port(t *testing.T) {
+	port, release := ReserveTCP4Port(t)
+	addr := net.JoinHostPort("127.0.0.1", strconv.Itoa(int(port)))
+
+	_, err := net.Listen("tcp4", addr)
+	require.Error(t, err, "port must stay reserved until released")
+
+	release()
+	release()
+
+	l, err := net.Listen("tcp4", addr)
+	require.NoError(t, err)
+	l.Close()
+}
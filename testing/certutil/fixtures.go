@@ -0,0 +1,316 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package certutil
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"fmt"
+	"math/big"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// KeyType is the type of private key generated for a certificate.
+type KeyType int
+
+const (
+	// ECDSAP384 generates an ECDSA key on the P-384 curve. It is the default.
+	ECDSAP384 KeyType = iota
+	// ECDSAP256 generates an ECDSA key on the P-256 curve.
+	ECDSAP256
+	// RSA2048 generates a 2048 bits RSA key.
+	RSA2048
+	// RSA4096 generates a 4096 bits RSA key.
+	RSA4096
+	// Ed25519 generates an Ed25519 key.
+	Ed25519
+)
+
+// Option configures the certificates generated by NewCA, NewIntermediateCA,
+// NewServerCert and NewClientCert.
+type Option func(*options)
+
+type options struct {
+	commonName string
+	dnsNames   []string
+	ips        []net.IP
+	keyType    KeyType
+	notBefore  time.Time
+	notAfter   time.Time
+}
+
+// WithCommonName sets the subject common name of the certificate.
+func WithCommonName(name string) Option {
+	return func(o *options) {
+		o.commonName = name
+	}
+}
+
+// WithDNSNames sets the DNS subject alternative names of the certificate.
+func WithDNSNames(names ...string) Option {
+	return func(o *options) {
+		o.dnsNames = names
+	}
+}
+
+// WithIPs sets the IP subject alternative names of the certificate.
+func WithIPs(ips ...net.IP) Option {
+	return func(o *options) {
+		o.ips = ips
+	}
+}
+
+// WithKeyType sets the type of the generated private key.
+func WithKeyType(keyType KeyType) Option {
+	return func(o *options) {
+		o.keyType = keyType
+	}
+}
+
+// WithValidity sets the validity period of the certificate. Use a notAfter
+// in the past to generate an expired certificate.
+func WithValidity(notBefore, notAfter time.Time) Option {
+	return func(o *options) {
+		o.notBefore = notBefore
+		o.notAfter = notAfter
+	}
+}
+
+// WithExpiration makes the certificate valid from now until now+d.
+func WithExpiration(d time.Duration) Option {
+	return func(o *options) {
+		o.notBefore = time.Now()
+		o.notAfter = o.notBefore.Add(d)
+	}
+}
+
+func newOptions(opts []Option) options {
+	now := time.Now()
+	o := options{
+		commonName: "localhost",
+		dnsNames:   []string{"localhost"},
+		ips:        []net.IP{net.ParseIP("127.0.0.1")},
+		keyType:    ECDSAP384,
+		notBefore:  now,
+		notAfter:   now.Add(3 * time.Hour),
+	}
+	for _, opt := range opts {
+		opt(&o)
+	}
+	return o
+}
+
+// Cert is a generated certificate with its private key.
+type Cert struct {
+	Cert *x509.Certificate
+	Key  crypto.Signer
+	// Pair is the certificate and key in PEM format. The key is PKCS #8
+	// encoded.
+	Pair Pair
+	// TLS is the certificate and key ready to be used in a tls.Config.
+	TLS tls.Certificate
+}
+
+// CA is a certificate authority able to issue certificates.
+type CA struct {
+	Cert
+}
+
+// NewCA generates a self-signed root certificate authority. By default it is
+// valid for 3 hours and uses an ECDSA P-384 key.
+func NewCA(opts ...Option) (*CA, error) {
+	o := newOptions(opts)
+	template := o.template()
+	template.KeyUsage = x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign | x509.KeyUsageCRLSign
+	template.BasicConstraintsValid = true
+	template.IsCA = true
+
+	cert, err := issue(template, nil, nil, o.keyType)
+	if err != nil {
+		return nil, fmt.Errorf("could not create CA: %w", err)
+	}
+	return &CA{Cert: *cert}, nil
+}
+
+// NewIntermediateCA generates an intermediate certificate authority signed
+// by ca.
+func (ca *CA) NewIntermediateCA(opts ...Option) (*CA, error) {
+	o := newOptions(opts)
+	template := o.template()
+	template.KeyUsage = x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign | x509.KeyUsageCRLSign
+	template.BasicConstraintsValid = true
+	template.IsCA = true
+
+	cert, err := issue(template, ca.Cert.Cert, ca.Key, o.keyType)
+	if err != nil {
+		return nil, fmt.Errorf("could not create intermediate CA: %w", err)
+	}
+	return &CA{Cert: *cert}, nil
+}
+
+// NewServerCert generates a certificate for server authentication signed by
+// ca. By default it is issued for "localhost" and 127.0.0.1.
+func (ca *CA) NewServerCert(opts ...Option) (*Cert, error) {
+	return ca.newLeaf(x509.ExtKeyUsageServerAuth, opts)
+}
+
+// NewClientCert generates a certificate for client authentication signed by
+// ca.
+func (ca *CA) NewClientCert(opts ...Option) (*Cert, error) {
+	return ca.newLeaf(x509.ExtKeyUsageClientAuth, opts)
+}
+
+// CertPool returns a certificate pool containing only the CA certificate.
+func (ca *CA) CertPool() *x509.CertPool {
+	pool := x509.NewCertPool()
+	pool.AddCert(ca.Cert.Cert)
+	return pool
+}
+
+func (ca *CA) newLeaf(usage x509.ExtKeyUsage, opts []Option) (*Cert, error) {
+	o := newOptions(opts)
+	template := o.template()
+	template.KeyUsage = x509.KeyUsageDigitalSignature
+	if o.keyType == RSA2048 || o.keyType == RSA4096 {
+		template.KeyUsage |= x509.KeyUsageKeyEncipherment
+	}
+	template.ExtKeyUsage = []x509.ExtKeyUsage{usage}
+
+	cert, err := issue(template, ca.Cert.Cert, ca.Key, o.keyType)
+	if err != nil {
+		return nil, fmt.Errorf("could not create certificate: %w", err)
+	}
+	return cert, nil
+}
+
+func (o options) template() *x509.Certificate {
+	return &x509.Certificate{
+		DNSNames:    o.dnsNames,
+		IPAddresses: o.ips,
+		Subject: pkix.Name{
+			Organization: []string{"Gallifrey"},
+			CommonName:   o.commonName,
+		},
+		NotBefore: o.notBefore,
+		NotAfter:  o.notAfter,
+	}
+}
+
+// issue generates a key of keyType and a certificate from template signed
+// by parent. If parent is nil, the certificate is self-signed.
+func issue(template, parent *x509.Certificate, parentKey crypto.Signer, keyType KeyType) (*Cert, error) {
+	key, err := generateKey(keyType)
+	if err != nil {
+		return nil, fmt.Errorf("could not create private key: %w", err)
+	}
+
+	serial, err := rand.Int(rand.Reader, new(big.Int).Lsh(big.NewInt(1), 128))
+	if err != nil {
+		return nil, fmt.Errorf("could not generate serial number: %w", err)
+	}
+	template.SerialNumber = serial
+
+	if parent == nil {
+		parent, parentKey = template, key
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, template, parent, key.Public(), parentKey)
+	if err != nil {
+		return nil, fmt.Errorf("could not sign certificate: %w", err)
+	}
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		return nil, fmt.Errorf("could not parse certificate: %w", err)
+	}
+
+	keyDER, err := x509.MarshalPKCS8PrivateKey(key)
+	if err != nil {
+		return nil, fmt.Errorf("could not marshal private key: %w", err)
+	}
+
+	pair := Pair{
+		Cert: pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}),
+		Key:  pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: keyDER}),
+	}
+	return &Cert{
+		Cert: cert,
+		Key:  key,
+		Pair: pair,
+		TLS: tls.Certificate{
+			Certificate: [][]byte{der},
+			PrivateKey:  key,
+			Leaf:        cert,
+		},
+	}, nil
+}
+
+func generateKey(keyType KeyType) (crypto.Signer, error) {
+	switch keyType {
+	case ECDSAP384:
+		return ecdsa.GenerateKey(elliptic.P384(), rand.Reader)
+	case ECDSAP256:
+		return ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	case RSA2048:
+		return rsa.GenerateKey(rand.Reader, 2048)
+	case RSA4096:
+		return rsa.GenerateKey(rand.Reader, 4096)
+	case Ed25519:
+		_, key, err := ed25519.GenerateKey(rand.Reader)
+		return key, err
+	default:
+		return nil, fmt.Errorf("unknown key type %d", keyType)
+	}
+}
+
+// WriteFiles writes the certificate and key to dir as name.pem and
+// name_key.pem, and returns their paths.
+func (p Pair) WriteFiles(dir, name string) (certPath, keyPath string, err error) {
+	certPath = filepath.Join(dir, name+".pem")
+	keyPath = filepath.Join(dir, name+"_key.pem")
+
+	if err := os.WriteFile(certPath, p.Cert, 0o600); err != nil {
+		return "", "", fmt.Errorf("could not write certificate: %w", err)
+	}
+	if err := os.WriteFile(keyPath, p.Key, 0o600); err != nil {
+		return "", "", fmt.Errorf("could not write key: %w", err)
+	}
+	return certPath, keyPath, nil
+}
+
+// WriteTempFiles writes the certificate and key to a temporary directory
+// removed at the end of the test, and returns their paths.
+func (p Pair) WriteTempFiles(t testing.TB, name string) (certPath, keyPath string) {
+	t.Helper()
+	certPath, keyPath, err := p.WriteFiles(t.TempDir(), name)
+	if err != nil {
+		t.Fatalf("could not write %s certificate files: %v", name, err)
+	}
+	return certPath, keyPath
+}
@@ -0,0 +1,181 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package certutil
+
+import (
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/rsa"
+	"crypto/tls"
+	"crypto/x509"
+	"net"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewServerCert(t *testing.T) {
+	ca, err := NewCA()
+	require.NoError(t, err)
+	assert.True(t, ca.Cert.Cert.IsCA)
+
+	server, err := ca.NewServerCert(
+		WithCommonName("es01"),
+		WithDNSNames("es01", "es01.example.com"),
+		WithIPs(net.ParseIP("10.0.0.1")),
+	)
+	require.NoError(t, err)
+
+	_, err = server.Cert.Verify(x509.VerifyOptions{
+		DNSName: "es01.example.com",
+		Roots:   ca.CertPool(),
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "es01", server.Cert.Subject.CommonName)
+	assert.Equal(t, []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth}, server.Cert.ExtKeyUsage)
+
+	_, err = tls.X509KeyPair(server.Pair.Cert, server.Pair.Key)
+	require.NoError(t, err)
+}
+
+func TestIntermediateCA(t *testing.T) {
+	root, err := NewCA()
+	require.NoError(t, err)
+	intermediate, err := root.NewIntermediateCA(WithCommonName("intermediate"))
+	require.NoError(t, err)
+	client, err := intermediate.NewClientCert(WithCommonName("agent"))
+	require.NoError(t, err)
+
+	intermediates := x509.NewCertPool()
+	intermediates.AddCert(intermediate.Cert.Cert)
+	_, err = client.Cert.Verify(x509.VerifyOptions{
+		Roots:         root.CertPool(),
+		Intermediates: intermediates,
+		KeyUsages:     []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+	})
+	require.NoError(t, err)
+}
+
+func TestKeyTypes(t *testing.T) {
+	tests := map[string]struct {
+		keyType KeyType
+		check   func(t *testing.T, key interface{})
+	}{
+		"ecdsa p256": {ECDSAP256, func(t *testing.T, key interface{}) {
+			assert.Equal(t, 256, key.(*ecdsa.PrivateKey).Curve.Params().BitSize)
+		}},
+		"ecdsa p384": {ECDSAP384, func(t *testing.T, key interface{}) {
+			assert.Equal(t, 384, key.(*ecdsa.PrivateKey).Curve.Params().BitSize)
+		}},
+		"rsa 2048": {RSA2048, func(t *testing.T, key interface{}) {
+			assert.Equal(t, 2048, key.(*rsa.PrivateKey).N.BitLen())
+		}},
+		"ed25519": {Ed25519, func(t *testing.T, key interface{}) {
+			assert.IsType(t, ed25519.PrivateKey{}, key)
+		}},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			ca, err := NewCA(WithKeyType(test.keyType))
+			require.NoError(t, err)
+			test.check(t, ca.Key)
+
+			cert, err := ca.NewServerCert(WithKeyType(test.keyType))
+			require.NoError(t, err)
+			test.check(t, cert.Key)
+
+			pair, err := tls.X509KeyPair(cert.Pair.Cert, cert.Pair.Key)
+			require.NoError(t, err)
+			test.check(t, pair.PrivateKey)
+		})
+	}
+}
+
+func TestExpiredCert(t *testing.T) {
+	ca, err := NewCA()
+	require.NoError(t, err)
+
+	cert, err := ca.NewServerCert(WithValidity(time.Now().Add(-2*time.Hour), time.Now().Add(-time.Hour)))
+	require.NoError(t, err)
+
+	_, err = cert.Cert.Verify(x509.VerifyOptions{DNSName: "localhost", Roots: ca.CertPool()})
+	var invalid x509.CertificateInvalidError
+	require.ErrorAs(t, err, &invalid)
+	assert.Equal(t, x509.Expired, invalid.Reason)
+
+	cert, err = ca.NewServerCert(WithExpiration(time.Minute))
+	require.NoError(t, err)
+	assert.WithinDuration(t, time.Now().Add(time.Minute), cert.Cert.NotAfter, 5*time.Second)
+}
+
+func TestTLSHandshake(t *testing.T) {
+	ca, err := NewCA()
+	require.NoError(t, err)
+	server, err := ca.NewServerCert()
+	require.NoError(t, err)
+	client, err := ca.NewClientCert()
+	require.NoError(t, err)
+
+	l, err := tls.Listen("tcp", "127.0.0.1:0", &tls.Config{
+		Certificates: []tls.Certificate{server.TLS},
+		ClientCAs:    ca.CertPool(),
+		ClientAuth:   tls.RequireAndVerifyClientCert,
+		MinVersion:   tls.VersionTLS12,
+	})
+	require.NoError(t, err)
+	defer l.Close()
+
+	go func() {
+		conn, err := l.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		_ = conn.(*tls.Conn).Handshake()
+	}()
+
+	conn, err := tls.Dial("tcp", l.Addr().String(), &tls.Config{
+		Certificates: []tls.Certificate{client.TLS},
+		RootCAs:      ca.CertPool(),
+		ServerName:   "localhost",
+		MinVersion:   tls.VersionTLS12,
+	})
+	require.NoError(t, err)
+	require.NoError(t, conn.Handshake())
+	conn.Close()
+}
+
+func TestWriteTempFiles(t *testing.T) {
+	ca, err := NewCA()
+	require.NoError(t, err)
+
+	certPath, keyPath := ca.Pair.WriteTempFiles(t, "ca")
+	assert.FileExists(t, certPath)
+	assert.FileExists(t, keyPath)
+
+	cert, err := os.ReadFile(certPath)
+	require.NoError(t, err)
+	assert.Equal(t, ca.Pair.Cert, cert)
+
+	_, err = tls.LoadX509KeyPair(certPath, keyPath)
+	require.NoError(t, err)
+}
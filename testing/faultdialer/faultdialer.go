@@ -0,0 +1,322 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+// Package faultdialer provides a transport.Dialer that injects network
+// faults, like latency, limited bandwidth, connection resets, and
+// disconnects, into the connections it creates. It is meant to test the
+// reliability of clients without external network tooling.
+package faultdialer
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/elastic/elastic-agent-libs/transport"
+)
+
+// Fault describes the faults injected into the connections to a destination.
+// The zero value injects no fault.
+type Fault struct {
+	// DialError, if set, is returned instead of establishing a connection.
+	DialError error
+
+	// Latency is added when establishing the connection and to each write.
+	Latency time.Duration
+
+	// Bandwidth limits reads and writes to the given number of bytes per
+	// second. Zero means unlimited.
+	Bandwidth int
+
+	// ResetAfter resets the connection, as if a TCP RST was received, once
+	// the given number of bytes have been read and written. Zero disables
+	// resets.
+	ResetAfter int64
+
+	// DisconnectAfter closes the connection, as if the peer closed it, once
+	// the given number of bytes have been read and written. Zero disables
+	// disconnects.
+	DisconnectAfter int64
+}
+
+// Dialer creates connections through a forward dialer and injects the
+// faults configured for their destinations.
+type Dialer struct {
+	forward transport.Dialer
+
+	mu     sync.Mutex
+	faults map[string]Fault
+	conns  map[*conn]struct{}
+}
+
+var _ transport.Dialer = (*Dialer)(nil)
+
+// New creates a Dialer establishing the connections with forward. If forward
+// is nil, a net.Dialer is used.
+func New(forward transport.Dialer) *Dialer {
+	if forward == nil {
+		forward = &net.Dialer{}
+	}
+	return &Dialer{
+		forward: forward,
+		faults:  map[string]Fault{},
+		conns:   map[*conn]struct{}{},
+	}
+}
+
+// Set configures the faults for a destination. The destination is either a
+// "host:port" address, a host matching all ports, or "*" matching all
+// destinations. The most specific destination wins. The faults apply to new
+// connections only.
+func (d *Dialer) Set(destination string, f Fault) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.faults[destination] = f
+}
+
+// Clear removes the faults configured for a destination.
+func (d *Dialer) Clear(destination string) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	delete(d.faults, destination)
+}
+
+// Reset resets all the open connections to the destination, using the
+// same matching rules as Set. It returns the number of connections reset.
+func (d *Dialer) Reset(destination string) int {
+	d.mu.Lock()
+	var matching []*conn
+	for c := range d.conns {
+		if destination == "*" || c.address == destination || c.host == destination {
+			matching = append(matching, c)
+		}
+	}
+	d.mu.Unlock()
+
+	for _, c := range matching {
+		c.fail(errReset)
+	}
+	return len(matching)
+}
+
+// Dial connects to the address on the named network.
+func (d *Dialer) Dial(network, address string) (net.Conn, error) {
+	return d.DialContext(context.Background(), network, address)
+}
+
+// DialContext connects to the address on the named network, injecting the
+// faults configured for the address.
+func (d *Dialer) DialContext(ctx context.Context, network, address string) (net.Conn, error) {
+	host, _, err := net.SplitHostPort(address)
+	if err != nil {
+		host = address
+	}
+	fault := d.lookup(address, host)
+
+	if fault.DialError != nil {
+		return nil, &net.OpError{Op: "dial", Net: network, Err: fault.DialError}
+	}
+	if err := sleep(ctx, fault.Latency); err != nil {
+		return nil, err
+	}
+
+	c, err := d.forward.DialContext(ctx, network, address)
+	if err != nil {
+		return nil, err
+	}
+
+	fc := &conn{Conn: c, dialer: d, fault: fault, network: network, address: address, host: host}
+	d.mu.Lock()
+	d.conns[fc] = struct{}{}
+	d.mu.Unlock()
+	return fc, nil
+}
+
+func (d *Dialer) lookup(address, host string) Fault {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	for _, key := range []string{address, host, "*"} {
+		if f, ok := d.faults[key]; ok {
+			return f
+		}
+	}
+	return Fault{}
+}
+
+func (d *Dialer) remove(c *conn) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	delete(d.conns, c)
+}
+
+var (
+	errReset      = syscall.ECONNRESET
+	errDisconnect = io.EOF
+)
+
+type conn struct {
+	net.Conn
+	dialer  *Dialer
+	fault   Fault
+	network string
+	address string
+	host    string
+
+	mu          sync.Mutex
+	transferred int64
+	err         error
+}
+
+func (c *conn) Read(b []byte) (int, error) {
+	b, err := c.reserve(b)
+	if err != nil {
+		return 0, c.opError("read", err)
+	}
+	n, err := c.Conn.Read(b)
+	c.throttle(n)
+	if err == nil {
+		err = c.checkLimits()
+	} else if failure := c.failure(); failure != nil {
+		err = failure
+	}
+	return n, c.opError("read", err)
+}
+
+func (c *conn) Write(b []byte) (int, error) {
+	total := len(b)
+	b, err := c.reserve(b)
+	if err != nil {
+		return 0, c.opError("write", err)
+	}
+	time.Sleep(c.fault.Latency)
+	n, err := c.Conn.Write(b)
+	c.throttle(n)
+	if err == nil {
+		err = c.checkLimits()
+	} else if failure := c.failure(); failure != nil {
+		err = failure
+	}
+	if err == nil && n < total {
+		err = io.ErrShortWrite
+	}
+	return n, c.opError("write", err)
+}
+
+func (c *conn) Close() error {
+	c.dialer.remove(c)
+	return c.Conn.Close()
+}
+
+// reserve truncates b to the number of bytes that can be transferred before
+// the next reset or disconnect.
+func (c *conn) reserve(b []byte) ([]byte, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.err != nil {
+		return nil, c.err
+	}
+	for _, limit := range []int64{c.fault.ResetAfter, c.fault.DisconnectAfter} {
+		if limit > 0 && int64(len(b)) > limit-c.transferred {
+			b = b[:limit-c.transferred]
+		}
+	}
+	return b, nil
+}
+
+// checkLimits accounts for transferred bytes and fails the connection once
+// a limit is reached.
+func (c *conn) checkLimits() error {
+	c.mu.Lock()
+	transferred := c.transferred
+	c.mu.Unlock()
+
+	switch {
+	case c.fault.ResetAfter > 0 && transferred >= c.fault.ResetAfter:
+		return c.fail(errReset)
+	case c.fault.DisconnectAfter > 0 && transferred >= c.fault.DisconnectAfter:
+		return c.fail(errDisconnect)
+	}
+	return nil
+}
+
+// failure returns the error the connection was failed with, if any.
+func (c *conn) failure() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.err
+}
+
+func (c *conn) throttle(n int) {
+	c.mu.Lock()
+	c.transferred += int64(n)
+	c.mu.Unlock()
+
+	if c.fault.Bandwidth > 0 && n > 0 {
+		time.Sleep(time.Duration(n) * time.Second / time.Duration(c.fault.Bandwidth))
+	}
+}
+
+// fail closes the underlying connection. A reset closes TCP connections
+// without lingering, so the peer receives a RST.
+func (c *conn) fail(err error) error {
+	c.mu.Lock()
+	if c.err != nil {
+		err = c.err
+		c.mu.Unlock()
+		return err
+	}
+	c.err = err
+	c.mu.Unlock()
+
+	if tcp, ok := c.Conn.(*net.TCPConn); ok && errors.Is(err, errReset) {
+		_ = tcp.SetLinger(0)
+	}
+	_ = c.Close()
+	return err
+}
+
+func (c *conn) opError(op string, err error) error {
+	if err == nil || (op == "read" && errors.Is(err, io.EOF)) {
+		return err
+	}
+	if errors.Is(err, errDisconnect) {
+		err = syscall.EPIPE
+	}
+	var opErr *net.OpError
+	if errors.As(err, &opErr) {
+		return err
+	}
+	return &net.OpError{Op: op, Net: c.network, Addr: c.RemoteAddr(), Err: err}
+}
+
+func sleep(ctx context.Context, d time.Duration) error {
+	if d <= 0 {
+		return nil
+	}
+	t := time.NewTimer(d)
+	defer t.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-t.C:
+		return nil
+	}
+}
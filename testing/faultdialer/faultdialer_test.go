@@ -0,0 +1,162 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package faultdialer
+
+import (
+	"errors"
+	"io"
+	"net"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// echoServer starts a TCP server echoing everything it receives.
+func echoServer(t *testing.T) string {
+	t.Helper()
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	t.Cleanup(func() { l.Close() })
+
+	go func() {
+		for {
+			c, err := l.Accept()
+			if err != nil {
+				return
+			}
+			go func() {
+				defer c.Close()
+				_, _ = io.Copy(c, c)
+			}()
+		}
+	}()
+	return l.Addr().String()
+}
+
+func TestNoFault(t *testing.T) {
+	addr := echoServer(t)
+	d := New(nil)
+
+	c, err := d.Dial("tcp", addr)
+	require.NoError(t, err)
+	defer c.Close()
+
+	_, err = c.Write([]byte("hello"))
+	require.NoError(t, err)
+	buf := make([]byte, 5)
+	_, err = io.ReadFull(c, buf)
+	require.NoError(t, err)
+	assert.Equal(t, "hello", string(buf))
+}
+
+func TestDialError(t *testing.T) {
+	addr := echoServer(t)
+	d := New(nil)
+	d.Set("127.0.0.1", Fault{DialError: syscall.ECONNREFUSED})
+
+	_, err := d.Dial("tcp", addr)
+	assert.ErrorIs(t, err, syscall.ECONNREFUSED)
+
+	d.Clear("127.0.0.1")
+	c, err := d.Dial("tcp", addr)
+	require.NoError(t, err)
+	c.Close()
+}
+
+func TestLatencyAndBandwidth(t *testing.T) {
+	addr := echoServer(t)
+	d := New(nil)
+	d.Set(addr, Fault{Latency: 50 * time.Millisecond, Bandwidth: 1000})
+
+	start := time.Now()
+	c, err := d.Dial("tcp", addr)
+	require.NoError(t, err)
+	defer c.Close()
+	assert.GreaterOrEqual(t, time.Since(start), 50*time.Millisecond)
+
+	start = time.Now()
+	_, err = c.Write(make([]byte, 100))
+	require.NoError(t, err)
+	// 50ms latency plus 100 bytes at 1000 bytes per second.
+	assert.GreaterOrEqual(t, time.Since(start), 150*time.Millisecond)
+}
+
+func TestResetAfter(t *testing.T) {
+	addr := echoServer(t)
+	d := New(nil)
+	d.Set("*", Fault{ResetAfter: 8})
+
+	c, err := d.Dial("tcp", addr)
+	require.NoError(t, err)
+	defer c.Close()
+
+	n, err := c.Write([]byte("0123456789"))
+	assert.Equal(t, 8, n)
+	assert.ErrorIs(t, err, syscall.ECONNRESET)
+
+	var opErr *net.OpError
+	require.True(t, errors.As(err, &opErr))
+	assert.Equal(t, "write", opErr.Op)
+
+	_, err = c.Read(make([]byte, 1))
+	assert.ErrorIs(t, err, syscall.ECONNRESET)
+}
+
+func TestDisconnectAfter(t *testing.T) {
+	addr := echoServer(t)
+	d := New(nil)
+	d.Set(addr, Fault{DisconnectAfter: 6})
+
+	c, err := d.Dial("tcp", addr)
+	require.NoError(t, err)
+	defer c.Close()
+
+	_, err = c.Write([]byte("abc"))
+	require.NoError(t, err)
+
+	buf := make([]byte, 10)
+	n, err := io.ReadFull(c, buf)
+	assert.Equal(t, 3, n)
+	assert.ErrorIs(t, err, io.ErrUnexpectedEOF)
+
+	_, err = c.Write([]byte("abc"))
+	assert.ErrorIs(t, err, syscall.EPIPE)
+}
+
+func TestReset(t *testing.T) {
+	addr := echoServer(t)
+	d := New(nil)
+
+	c, err := d.Dial("tcp", addr)
+	require.NoError(t, err)
+	defer c.Close()
+
+	errs := make(chan error)
+	go func() {
+		_, err := c.Read(make([]byte, 1))
+		errs <- err
+	}()
+
+	assert.Equal(t, 0, d.Reset("example.com"))
+	require.Eventually(t, func() bool { return d.Reset(addr) == 1 }, time.Second, 10*time.Millisecond)
+	assert.ErrorIs(t, <-errs, syscall.ECONNRESET)
+	assert.Equal(t, 0, d.Reset("*"), "reset connections must be forgotten")
+}
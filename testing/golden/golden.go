@@ -0,0 +1,143 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+// Package golden compares test output against golden files.
+//
+// Run the tests with -update, or with GOLDEN_UPDATE=1 in the environment,
+// to write the current output to the golden files instead of comparing.
+//
+// The -update flag is registered when the package is initialized, unless a
+// flag with that name is already defined. Test packages must not define
+// their own -update flag after that, as flag.Bool would panic; they can read
+// it with flag.Lookup instead.
+package golden
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"os"
+	"path/filepath"
+	"regexp"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const updateFlag = "update"
+
+func init() {
+	if flag.Lookup(updateFlag) == nil {
+		flag.Bool(updateFlag, false, "update golden files")
+	}
+}
+
+// update returns true if the golden files must be updated.
+func update() bool {
+	if os.Getenv("GOLDEN_UPDATE") == "1" {
+		return true
+	}
+	f := flag.Lookup(updateFlag)
+	return f != nil && f.Value.String() == "true"
+}
+
+// Normalizer rewrites content before it is compared or written, e.g. to
+// replace values changing between runs with a placeholder.
+type Normalizer func([]byte) []byte
+
+var timestampRegexp = regexp.MustCompile(
+	`\d{4}-\d{2}-\d{2}[T ]\d{2}:\d{2}:\d{2}(\.\d+)?(Z|[+-]\d{2}:?\d{2})?`)
+
+// NormalizeTimestamps replaces RFC 3339 like timestamps with <TIMESTAMP>.
+func NormalizeTimestamps() Normalizer {
+	return Replace(timestampRegexp, "<TIMESTAMP>")
+}
+
+// NormalizeHostname replaces the hostname of the machine running the test
+// with <HOSTNAME>.
+func NormalizeHostname() Normalizer {
+	hostname, err := os.Hostname()
+	if err != nil || hostname == "" {
+		return func(b []byte) []byte { return b }
+	}
+	return Replace(regexp.MustCompile(regexp.QuoteMeta(hostname)), "<HOSTNAME>")
+}
+
+// Replace replaces all matches of re with repl. repl can refer to
+// submatches like regexp.Regexp.ReplaceAll.
+func Replace(re *regexp.Regexp, repl string) Normalizer {
+	return func(b []byte) []byte {
+		return re.ReplaceAll(b, []byte(repl))
+	}
+}
+
+func normalizeLineEndings(b []byte) []byte {
+	return bytes.ReplaceAll(b, []byte("\r\n"), []byte("\n"))
+}
+
+// Assert compares got with the content of the golden file at path after
+// applying the normalizers, and fails the test with a diff if they differ.
+// When updating, the normalized output is written to path instead, creating
+// parent directories as needed. Line endings are always normalized to \n.
+func Assert(t testing.TB, got []byte, path string, normalizers ...Normalizer) bool {
+	t.Helper()
+
+	got = normalize(got, normalizers)
+	if update() {
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatalf("could not create golden file directory: %v", err)
+		}
+		if err := os.WriteFile(path, got, 0o644); err != nil { //nolint:gosec // golden files are committed to the repo
+			t.Fatalf("could not update golden file: %v", err)
+		}
+		return true
+	}
+
+	want, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("could not read golden file, run with -%s or GOLDEN_UPDATE=1 to create it: %v", updateFlag, err)
+	}
+	want = normalize(want, normalizers)
+
+	return assert.Equal(t, string(want), string(got),
+		"output does not match golden file %s, run with -%s or GOLDEN_UPDATE=1 to update it", path, updateFlag)
+}
+
+// AssertString is like Assert for strings.
+func AssertString(t testing.TB, got string, path string, normalizers ...Normalizer) bool {
+	t.Helper()
+	return Assert(t, []byte(got), path, normalizers...)
+}
+
+// AssertJSON marshals v as indented JSON and compares it with the golden
+// file at path like Assert.
+func AssertJSON(t testing.TB, v interface{}, path string, normalizers ...Normalizer) bool {
+	t.Helper()
+	got, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		t.Fatalf("could not marshal value to JSON: %v", err)
+	}
+	return Assert(t, append(got, '\n'), path, normalizers...)
+}
+
+func normalize(b []byte, normalizers []Normalizer) []byte {
+	b = normalizeLineEndings(b)
+	for _, n := range normalizers {
+		b = n(b)
+	}
+	return b
+}
@@ -0,0 +1,105 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package golden
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// recorder records failures instead of failing the test.
+type recorder struct {
+	testing.TB
+	errors []string
+}
+
+func (r *recorder) Helper() {}
+
+func (r *recorder) Errorf(format string, args ...interface{}) {
+	r.errors = append(r.errors, fmt.Sprintf(format, args...))
+}
+
+// noUpdate disables updating golden files for the rest of the test, even
+// when the tests run with -update.
+func noUpdate(t *testing.T) {
+	t.Setenv("GOLDEN_UPDATE", "")
+	old := flag.Lookup(updateFlag).Value.String()
+	require.NoError(t, flag.Set(updateFlag, "false"))
+	t.Cleanup(func() { _ = flag.Set(updateFlag, old) })
+}
+
+func TestAssert(t *testing.T) {
+	hostname, err := os.Hostname()
+	require.NoError(t, err)
+
+	got := fmt.Sprintf("host: %s\r\ntime: %s\nmessage: hello\n", hostname, time.Now().Format(time.RFC3339Nano))
+	AssertString(t, got, "testdata/example.golden", NormalizeHostname(), NormalizeTimestamps())
+}
+
+func TestAssertJSON(t *testing.T) {
+	AssertJSON(t, map[string]interface{}{"name": "golden", "values": []int{1, 2}}, "testdata/example.json.golden")
+}
+
+func TestAssertMismatch(t *testing.T) {
+	noUpdate(t)
+	r := &recorder{TB: t}
+	ok := AssertString(r, "host: <HOSTNAME>\ntime: <TIMESTAMP>\nmessage: bye\n", "testdata/example.golden")
+	assert.False(t, ok)
+	require.Len(t, r.errors, 1)
+	assert.Contains(t, r.errors[0], "-message: hello")
+	assert.Contains(t, r.errors[0], "+message: bye")
+	assert.Contains(t, r.errors[0], "run with -update or GOLDEN_UPDATE=1")
+}
+
+func TestUpdate(t *testing.T) {
+	t.Setenv("GOLDEN_UPDATE", "1")
+	path := filepath.Join(t.TempDir(), "nested", "out.golden")
+
+	AssertString(t, "id: 1234\n", path, Replace(regexp.MustCompile(`\d+`), "<ID>"))
+
+	content, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Equal(t, "id: <ID>\n", string(content))
+
+	noUpdate(t)
+	r := &recorder{TB: t}
+	assert.True(t, AssertString(r, "id: 42\r\n", path, Replace(regexp.MustCompile(`\d+`), "<ID>")))
+	assert.Empty(t, r.errors)
+}
+
+func TestNormalizeTimestamps(t *testing.T) {
+	n := NormalizeTimestamps()
+	for _, ts := range []string{
+		"2024-03-26T12:00:00Z",
+		"2024-03-26T12:00:00.123456789+02:00",
+		"2024-03-26 12:00:00",
+		"2024-03-26T12:00:00.000-0700",
+	} {
+		assert.Equal(t, "at <TIMESTAMP>.", string(n([]byte("at "+ts+"."))), ts)
+	}
+	assert.Equal(t, "version 8.13.0", strings.TrimSpace(string(n([]byte("version 8.13.0")))))
+}
@@ -0,0 +1,160 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+// Package leaktest detects goroutines leaked by a test.
+package leaktest
+
+import (
+	"bytes"
+	"runtime"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+// Option configures Check.
+type Option func(*options)
+
+type options struct {
+	timeout time.Duration
+	ignore  []string
+}
+
+// Timeout sets how long Check waits for goroutines started by the test to
+// exit. The default is 5 seconds.
+func Timeout(d time.Duration) Option {
+	return func(o *options) {
+		o.timeout = d
+	}
+}
+
+// IgnoreFunction ignores goroutines with the given function anywhere in
+// their stack, e.g. "net/http.(*persistConn).readLoop". A prefix of the
+// function name is enough.
+func IgnoreFunction(names ...string) Option {
+	return func(o *options) {
+		o.ignore = append(o.ignore, names...)
+	}
+}
+
+// Check records the goroutines running when it is called and fails the test
+// if new goroutines are still running once the test and its cleanup
+// functions are done. Call it first in the test, so its check runs after
+// all other cleanup functions. Tests running in parallel must not use it.
+func Check(t testing.TB, opts ...Option) {
+	t.Helper()
+	o := options{timeout: 5 * time.Second}
+	for _, opt := range opts {
+		opt(&o)
+	}
+
+	before := map[int]bool{}
+	for _, g := range goroutines() {
+		before[g.id] = true
+	}
+
+	t.Cleanup(func() {
+		if t.Failed() {
+			return
+		}
+
+		var leaked []goroutine
+		deadline := time.Now().Add(o.timeout)
+		for delay := time.Millisecond; ; delay *= 2 {
+			leaked = leaked[:0]
+			for _, g := range goroutines() {
+				if !before[g.id] && !g.ignored(o.ignore) {
+					leaked = append(leaked, g)
+				}
+			}
+			if len(leaked) == 0 || time.Now().After(deadline) {
+				break
+			}
+			if delay > 100*time.Millisecond {
+				delay = 100 * time.Millisecond
+			}
+			time.Sleep(delay)
+		}
+
+		if len(leaked) > 0 {
+			stacks := make([]string, 0, len(leaked))
+			for _, g := range leaked {
+				stacks = append(stacks, g.stack)
+			}
+			t.Errorf("%d goroutines leaked:\n\n%s", len(leaked), strings.Join(stacks, "\n\n"))
+		}
+	})
+}
+
+type goroutine struct {
+	id    int
+	stack string
+}
+
+// ignored returns true if the goroutine must not be reported, either because
+// the caller asked for it or because it belongs to the Go runtime or test
+// framework.
+func (g goroutine) ignored(ignore []string) bool {
+	for _, list := range [][]string{ignore, alwaysIgnored} {
+		for _, fn := range list {
+			if strings.Contains(g.stack, "\n"+fn) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+var alwaysIgnored = []string{
+	"testing.tRunner",
+	"testing.(*T).Run",
+	"os/signal.signal_recv",
+	"os/signal.loop",
+}
+
+// goroutines returns all goroutines but the calling one.
+func goroutines() []goroutine {
+	buf := make([]byte, 64*1024)
+	for {
+		n := runtime.Stack(buf, true)
+		if n < len(buf) {
+			buf = buf[:n]
+			break
+		}
+		buf = make([]byte, 2*len(buf))
+	}
+
+	var result []goroutine
+	for i, stack := range bytes.Split(buf, []byte("\n\n")) {
+		if i == 0 {
+			// The first goroutine is the calling one.
+			continue
+		}
+		header, _, _ := bytes.Cut(stack, []byte("\n"))
+		fields := bytes.Fields(header)
+		if len(fields) < 2 {
+			continue
+		}
+		id, err := strconv.Atoi(string(fields[1]))
+		if err != nil {
+			continue
+		}
+		result = append(result, goroutine{id: id, stack: string(stack)})
+	}
+	return result
+}
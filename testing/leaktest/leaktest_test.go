@@ -0,0 +1,101 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package leaktest
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// recorder records failures instead of failing the test.
+type recorder struct {
+	testing.TB
+	errors   []string
+	cleanups []func()
+}
+
+func (r *recorder) Helper()          {}
+func (r *recorder) Failed() bool     { return false }
+func (r *recorder) Cleanup(f func()) { r.cleanups = append(r.cleanups, f) }
+func (r *recorder) runCleanups() {
+	for _, f := range r.cleanups {
+		f()
+	}
+}
+func (r *recorder) Errorf(format string, args ...interface{}) {
+	r.errors = append(r.errors, fmt.Sprintf(format, args...))
+}
+
+func blockForever(done chan struct{}) {
+	<-done
+}
+
+func TestCheckDetectsLeak(t *testing.T) {
+	done := make(chan struct{})
+	defer close(done)
+
+	r := &recorder{TB: t}
+	Check(r, Timeout(50*time.Millisecond))
+	go blockForever(done)
+	r.runCleanups()
+
+	require.Len(t, r.errors, 1)
+	assert.Contains(t, r.errors[0], "1 goroutines leaked")
+	assert.Contains(t, r.errors[0], "leaktest.blockForever")
+}
+
+func TestCheckWaitsForGoroutines(t *testing.T) {
+	r := &recorder{TB: t}
+	Check(r)
+	go func() {
+		time.Sleep(20 * time.Millisecond)
+	}()
+	r.runCleanups()
+	assert.Empty(t, r.errors)
+}
+
+func TestCheckIgnore(t *testing.T) {
+	done := make(chan struct{})
+	defer close(done)
+
+	r := &recorder{TB: t}
+	Check(r, Timeout(50*time.Millisecond), IgnoreFunction("github.com/elastic/elastic-agent-libs/testing/leaktest.blockForever"))
+	go blockForever(done)
+	r.runCleanups()
+	assert.Empty(t, r.errors)
+}
+
+func TestCheckExistingGoroutines(t *testing.T) {
+	done := make(chan struct{})
+	defer close(done)
+	go blockForever(done)
+
+	Check(t, Timeout(50*time.Millisecond))
+}
+
+func TestGoroutines(t *testing.T) {
+	for _, g := range goroutines() {
+		assert.NotZero(t, g.id)
+		assert.True(t, strings.HasPrefix(g.stack, "goroutine "), g.stack)
+	}
+}
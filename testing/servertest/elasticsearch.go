@@ -0,0 +1,203 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package servertest
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+// BulkItem is a single operation received by the bulk API of an
+// Elasticsearch server.
+type BulkItem struct {
+	// Action is the bulk action, e.g. "index" or "create".
+	Action string
+	Index  string
+	ID     string
+	// Document is the source document. It is empty for delete actions.
+	Document json.RawMessage
+}
+
+// Elasticsearch is a test server emulating an Elasticsearch cluster. It
+// handles:
+//
+//   - GET / with the cluster information
+//   - GET /_license with an active basic license
+//   - POST /_bulk and POST /{index}/_bulk
+type Elasticsearch struct {
+	*Server
+
+	bulkItems []BulkItem
+}
+
+// WithBulkItemStatus sets the function deciding the status code of each item
+// in a bulk request sent to an Elasticsearch server. The item index is
+// counted across all bulk requests received by the server. By default
+// every item succeeds.
+func WithBulkItemStatus(f func(item int) int) Option {
+	return func(s *Server) {
+		s.bulkItemStatus = f
+	}
+}
+
+// NewElasticsearch starts an Elasticsearch test server. The server reports
+// version 8.0.0 unless configured otherwise.
+func NewElasticsearch(t testing.TB, opts ...Option) *Elasticsearch {
+	t.Helper()
+	es := &Elasticsearch{Server: newServer(t, "8.0.0", elasticsearchError, opts)}
+
+	es.handle(http.MethodGet, "/", es.handleInfo)
+	es.handle(http.MethodGet, "/_license", es.handleLicense)
+	es.handle(http.MethodPost, "/_bulk", es.handleBulk)
+	es.handle(http.MethodPost, "/{index}/_bulk", es.handleBulk)
+	es.start()
+	return es
+}
+
+// BulkItems returns all bulk items received so far, including failed ones.
+func (es *Elasticsearch) BulkItems() []BulkItem {
+	es.mu.Lock()
+	defer es.mu.Unlock()
+	return append([]BulkItem(nil), es.bulkItems...)
+}
+
+func (es *Elasticsearch) handleInfo(w http.ResponseWriter, _ *http.Request, _ []byte, _ map[string]string) {
+	number, snapshot := es.versionNumber()
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"name":         "servertest",
+		"cluster_name": "servertest",
+		"cluster_uuid": "servertest-cluster-uuid",
+		"version": map[string]interface{}{
+			"number":         number,
+			"build_flavor":   "default",
+			"build_snapshot": snapshot,
+		},
+		"tagline": "You Know, for Search",
+	})
+}
+
+func (es *Elasticsearch) handleLicense(w http.ResponseWriter, _ *http.Request, _ []byte, _ map[string]string) {
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"license": map[string]interface{}{
+			"uid":    "servertest-license-uid",
+			"type":   "basic",
+			"status": "active",
+		},
+	})
+}
+
+func (es *Elasticsearch) handleBulk(w http.ResponseWriter, _ *http.Request, body []byte, params map[string]string) {
+	items, err := parseBulk(body, params["index"])
+	if err != nil {
+		es.writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	es.mu.Lock()
+	first := len(es.bulkItems)
+	es.bulkItems = append(es.bulkItems, items...)
+	es.mu.Unlock()
+
+	hasErrors := false
+	results := make([]map[string]interface{}, 0, len(items))
+	for i, item := range items {
+		status := http.StatusCreated
+		if es.bulkItemStatus != nil {
+			status = es.bulkItemStatus(first + i)
+		}
+		result := map[string]interface{}{
+			"_index": item.Index,
+			"status": status,
+		}
+		if item.ID != "" {
+			result["_id"] = item.ID
+		}
+		if status >= 300 {
+			hasErrors = true
+			result["error"] = map[string]interface{}{
+				"type":   errorType(status),
+				"reason": "injected failure",
+			}
+		}
+		results = append(results, map[string]interface{}{item.Action: result})
+	}
+
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"took":   1,
+		"errors": hasErrors,
+		"items":  results,
+	})
+}
+
+func parseBulk(body []byte, defaultIndex string) ([]BulkItem, error) {
+	var items []BulkItem
+	scanner := bufio.NewScanner(bytes.NewReader(body))
+	scanner.Buffer(nil, len(body)+1)
+	for scanner.Scan() {
+		line := bytes.TrimSpace(scanner.Bytes())
+		if len(line) == 0 {
+			continue
+		}
+
+		var meta map[string]struct {
+			Index string `json:"_index"`
+			ID    string `json:"_id"`
+		}
+		if err := json.Unmarshal(line, &meta); err != nil {
+			return nil, err
+		}
+		for action, m := range meta {
+			item := BulkItem{Action: action, Index: m.Index, ID: m.ID}
+			if item.Index == "" {
+				item.Index = defaultIndex
+			}
+			if action != "delete" && scanner.Scan() {
+				item.Document = append(json.RawMessage(nil), scanner.Bytes()...)
+			}
+			items = append(items, item)
+		}
+	}
+	return items, scanner.Err()
+}
+
+func elasticsearchError(status int, message string) interface{} {
+	return map[string]interface{}{
+		"error": map[string]interface{}{
+			"type":   errorType(status),
+			"reason": message,
+		},
+		"status": status,
+	}
+}
+
+func errorType(status int) string {
+	switch status {
+	case http.StatusTooManyRequests:
+		return "es_rejected_execution_exception"
+	case http.StatusNotFound:
+		return "resource_not_found_exception"
+	case http.StatusConflict:
+		return "version_conflict_engine_exception"
+	default:
+		return strings.ToLower(strings.ReplaceAll(http.StatusText(status), " ", "_")) + "_exception"
+	}
+}
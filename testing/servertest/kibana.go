@@ -0,0 +1,234 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package servertest
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"testing"
+)
+
+// SavedObject is a Kibana saved object stored by a Kibana server.
+type SavedObject struct {
+	Type       string                 `json:"type"`
+	ID         string                 `json:"id"`
+	Attributes map[string]interface{} `json:"attributes"`
+}
+
+// WithSavedObjects adds saved objects to a Kibana server before it starts.
+func WithSavedObjects(objects ...SavedObject) Option {
+	return func(s *Server) {
+		s.savedObjects = append(s.savedObjects, objects...)
+	}
+}
+
+// Kibana is a test server emulating Kibana. It handles:
+//
+//   - GET /api/status
+//   - GET /api/saved_objects/_find, optionally filtered by type
+//   - POST /api/saved_objects/_import with an NDJSON file
+//   - GET, POST and DELETE /api/saved_objects/{type}/{id}
+type Kibana struct {
+	*Server
+
+	objects map[string]SavedObject
+}
+
+// NewKibana starts a Kibana test server. The server reports version 8.0.0
+// unless configured otherwise.
+func NewKibana(t testing.TB, opts ...Option) *Kibana {
+	t.Helper()
+	kb := &Kibana{
+		Server:  newServer(t, "8.0.0", kibanaError, opts),
+		objects: map[string]SavedObject{},
+	}
+	for _, o := range kb.savedObjects {
+		kb.objects[objectKey(o.Type, o.ID)] = o
+	}
+
+	kb.handle(http.MethodGet, "/api/status", kb.handleStatus)
+	kb.handle(http.MethodGet, "/api/saved_objects/_find", kb.handleFind)
+	kb.handle(http.MethodPost, "/api/saved_objects/_import", kb.handleImport)
+	kb.handle(http.MethodGet, "/api/saved_objects/{type}/{id}", kb.handleGet)
+	kb.handle(http.MethodPost, "/api/saved_objects/{type}/{id}", kb.handleCreate)
+	kb.handle(http.MethodDelete, "/api/saved_objects/{type}/{id}", kb.handleDelete)
+	kb.start()
+	return kb
+}
+
+// SavedObjects returns the saved objects currently stored, sorted by type
+// and ID.
+func (kb *Kibana) SavedObjects() []SavedObject {
+	kb.mu.Lock()
+	defer kb.mu.Unlock()
+	return kb.sortedObjects("")
+}
+
+func (kb *Kibana) sortedObjects(typ string) []SavedObject {
+	objects := make([]SavedObject, 0, len(kb.objects))
+	for _, o := range kb.objects {
+		if typ == "" || o.Type == typ {
+			objects = append(objects, o)
+		}
+	}
+	sort.Slice(objects, func(i, j int) bool {
+		return objectKey(objects[i].Type, objects[i].ID) < objectKey(objects[j].Type, objects[j].ID)
+	})
+	return objects
+}
+
+func (kb *Kibana) handleStatus(w http.ResponseWriter, _ *http.Request, _ []byte, _ map[string]string) {
+	number, snapshot := kb.versionNumber()
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"name": "servertest",
+		"uuid": "servertest-kibana-uuid",
+		"version": map[string]interface{}{
+			"number":         number,
+			"build_snapshot": snapshot,
+		},
+		"status": map[string]interface{}{
+			"overall": map[string]interface{}{
+				"level": "available",
+			},
+		},
+	})
+}
+
+func (kb *Kibana) handleFind(w http.ResponseWriter, r *http.Request, _ []byte, _ map[string]string) {
+	kb.mu.Lock()
+	objects := kb.sortedObjects(r.URL.Query().Get("type"))
+	kb.mu.Unlock()
+
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"page":          1,
+		"per_page":      len(objects),
+		"total":         len(objects),
+		"saved_objects": objects,
+	})
+}
+
+func (kb *Kibana) handleImport(w http.ResponseWriter, r *http.Request, _ []byte, _ map[string]string) {
+	file, _, err := r.FormFile("file")
+	if err != nil {
+		kb.writeError(w, http.StatusBadRequest, fmt.Sprintf("missing file: %v", err))
+		return
+	}
+	defer file.Close()
+
+	var objects []SavedObject
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(nil, 10*1024*1024)
+	for scanner.Scan() {
+		if len(scanner.Bytes()) == 0 {
+			continue
+		}
+		var o SavedObject
+		if err := json.Unmarshal(scanner.Bytes(), &o); err != nil {
+			kb.writeError(w, http.StatusBadRequest, fmt.Sprintf("invalid saved object: %v", err))
+			return
+		}
+		objects = append(objects, o)
+	}
+	if err := scanner.Err(); err != nil {
+		kb.writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	kb.mu.Lock()
+	for _, o := range objects {
+		kb.objects[objectKey(o.Type, o.ID)] = o
+	}
+	kb.mu.Unlock()
+
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"success":      true,
+		"successCount": len(objects),
+	})
+}
+
+func (kb *Kibana) handleGet(w http.ResponseWriter, _ *http.Request, _ []byte, params map[string]string) {
+	kb.mu.Lock()
+	o, ok := kb.objects[objectKey(params["type"], params["id"])]
+	kb.mu.Unlock()
+
+	if !ok {
+		kb.writeNotFound(w, params)
+		return
+	}
+	writeJSON(w, http.StatusOK, o)
+}
+
+func (kb *Kibana) handleCreate(w http.ResponseWriter, r *http.Request, body []byte, params map[string]string) {
+	var req struct {
+		Attributes map[string]interface{} `json:"attributes"`
+	}
+	if err := json.Unmarshal(body, &req); err != nil {
+		kb.writeError(w, http.StatusBadRequest, fmt.Sprintf("invalid request body: %v", err))
+		return
+	}
+
+	o := SavedObject{Type: params["type"], ID: params["id"], Attributes: req.Attributes}
+	key := objectKey(o.Type, o.ID)
+
+	kb.mu.Lock()
+	_, exists := kb.objects[key]
+	if exists && r.URL.Query().Get("overwrite") != "true" {
+		kb.mu.Unlock()
+		kb.writeError(w, http.StatusConflict, fmt.Sprintf("Saved object [%s] conflict", key))
+		return
+	}
+	kb.objects[key] = o
+	kb.mu.Unlock()
+
+	writeJSON(w, http.StatusOK, o)
+}
+
+func (kb *Kibana) handleDelete(w http.ResponseWriter, _ *http.Request, _ []byte, params map[string]string) {
+	key := objectKey(params["type"], params["id"])
+
+	kb.mu.Lock()
+	_, ok := kb.objects[key]
+	delete(kb.objects, key)
+	kb.mu.Unlock()
+
+	if !ok {
+		kb.writeNotFound(w, params)
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]interface{}{})
+}
+
+func (kb *Kibana) writeNotFound(w http.ResponseWriter, params map[string]string) {
+	kb.writeError(w, http.StatusNotFound,
+		fmt.Sprintf("Saved object [%s] not found", objectKey(params["type"], params["id"])))
+}
+
+func objectKey(typ, id string) string {
+	return typ + "/" + id
+}
+
+func kibanaError(status int, message string) interface{} {
+	return map[string]interface{}{
+		"statusCode": status,
+		"error":      http.StatusText(status),
+		"message":    message,
+	}
+}
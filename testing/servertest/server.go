@@ -0,0 +1,308 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+// Package servertest provides HTTP test servers emulating the Elasticsearch
+// and Kibana endpoints commonly used by clients in this repository and its
+// consumers. Servers capture every request they receive and support
+// injecting failures, so retry and error handling can be tested without a
+// real cluster.
+package servertest
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// Request is a request captured by a Server.
+type Request struct {
+	Method string
+	Path   string
+	Query  url.Values
+	Header http.Header
+	Body   []byte
+}
+
+// Option configures a Server.
+type Option func(*Server)
+
+// WithVersion sets the product version reported by the server. A
+// "-SNAPSHOT" suffix is reported as a snapshot build.
+func WithVersion(version string) Option {
+	return func(s *Server) {
+		s.version = version
+	}
+}
+
+// WithResponse makes the server answer requests matching method and path
+// with the given status code and body, instead of the built-in response.
+// The body is sent with a JSON content type.
+func WithResponse(method, path string, status int, body string) Option {
+	return func(s *Server) {
+		s.canned[method+" "+path] = cannedResponse{status: status, body: []byte(body)}
+	}
+}
+
+// WithFixture works like WithResponse with a 200 status code, reading the
+// response body from filename. The test fails if the file cannot be read.
+func WithFixture(method, path, filename string) Option {
+	return func(s *Server) {
+		s.t.Helper()
+		body, err := os.ReadFile(filename)
+		if err != nil {
+			s.t.Fatalf("could not read fixture: %v", err)
+		}
+		s.canned[method+" "+path] = cannedResponse{status: http.StatusOK, body: body}
+	}
+}
+
+type cannedResponse struct {
+	status int
+	body   []byte
+}
+
+type handlerFunc func(w http.ResponseWriter, r *http.Request, body []byte, params map[string]string)
+
+type route struct {
+	method   string
+	segments []string
+	handler  handlerFunc
+}
+
+// Server is an HTTP test server. The embedded httptest.Server gives access
+// to the URL and client of the server. It is closed automatically at the
+// end of the test.
+type Server struct {
+	*httptest.Server
+
+	t         testing.TB
+	version   string
+	canned    map[string]cannedResponse
+	routes    []route
+	errorBody func(status int, message string) interface{}
+
+	bulkItemStatus func(item int) int
+	savedObjects   []SavedObject
+
+	mu       sync.Mutex
+	requests []Request
+	failures []failure
+	delays   []time.Duration
+}
+
+type failure struct {
+	status int
+}
+
+func newServer(t testing.TB, defaultVersion string, errorBody func(int, string) interface{}, opts []Option) *Server {
+	t.Helper()
+	s := &Server{
+		t:         t,
+		version:   defaultVersion,
+		canned:    map[string]cannedResponse{},
+		errorBody: errorBody,
+	}
+	for _, opt := range opts {
+		opt(s)
+	}
+	return s
+}
+
+func (s *Server) start() {
+	s.Server = httptest.NewServer(http.HandlerFunc(s.serveHTTP))
+	s.t.Cleanup(s.Close)
+}
+
+// handle registers a handler for method and pattern. Pattern segments in
+// braces, like /api/{id}, match any single path segment.
+func (s *Server) handle(method, pattern string, h handlerFunc) {
+	s.routes = append(s.routes, route{
+		method:   method,
+		segments: splitPath(pattern),
+		handler:  h,
+	})
+}
+
+// Requests returns the requests received so far, in order.
+func (s *Server) Requests() []Request {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]Request(nil), s.requests...)
+}
+
+// RequestsTo returns the requests received so far for method and path.
+func (s *Server) RequestsTo(method, path string) []Request {
+	var matching []Request
+	for _, r := range s.Requests() {
+		if r.Method == method && r.Path == path {
+			matching = append(matching, r)
+		}
+	}
+	return matching
+}
+
+// Reset discards captured requests and pending failures and delays.
+func (s *Server) Reset() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.requests = nil
+	s.failures = nil
+	s.delays = nil
+}
+
+// FailNext makes the next n requests fail with the given status code. A 429
+// status also sets a Retry-After header.
+func (s *Server) FailNext(n int, status int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for i := 0; i < n; i++ {
+		s.failures = append(s.failures, failure{status: status})
+	}
+}
+
+// DelayNext delays the response to the next n requests by d, e.g. to
+// trigger client timeouts. A delayed request returns early if the client
+// goes away.
+func (s *Server) DelayNext(n int, d time.Duration) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for i := 0; i < n; i++ {
+		s.delays = append(s.delays, d)
+	}
+}
+
+func (s *Server) serveHTTP(w http.ResponseWriter, r *http.Request) {
+	body, _ := io.ReadAll(r.Body)
+	r.Body = io.NopCloser(bytes.NewReader(body))
+
+	s.mu.Lock()
+	s.requests = append(s.requests, Request{
+		Method: r.Method,
+		Path:   r.URL.Path,
+		Query:  r.URL.Query(),
+		Header: r.Header.Clone(),
+		Body:   body,
+	})
+	var delay time.Duration
+	if len(s.delays) > 0 {
+		delay, s.delays = s.delays[0], s.delays[1:]
+	}
+	var fail *failure
+	if len(s.failures) > 0 {
+		fail = &s.failures[0]
+		s.failures = s.failures[1:]
+	}
+	s.mu.Unlock()
+
+	if delay > 0 {
+		timer := time.NewTimer(delay)
+		select {
+		case <-timer.C:
+		case <-r.Context().Done():
+			timer.Stop()
+			return
+		}
+	}
+
+	if fail != nil {
+		if fail.status == http.StatusTooManyRequests {
+			w.Header().Set("Retry-After", "1")
+		}
+		s.writeError(w, fail.status, "injected failure")
+		return
+	}
+
+	if c, ok := s.canned[r.Method+" "+r.URL.Path]; ok {
+		writeRaw(w, c.status, c.body)
+		return
+	}
+
+	segments := splitPath(r.URL.Path)
+	for _, rt := range s.routes {
+		if rt.method != r.Method {
+			continue
+		}
+		if params, ok := match(rt.segments, segments); ok {
+			rt.handler(w, r, body, params)
+			return
+		}
+	}
+	s.writeError(w, http.StatusNotFound, "no handler for "+r.Method+" "+r.URL.Path)
+}
+
+// versionNumber returns the version without the snapshot suffix and whether
+// it is a snapshot.
+func (s *Server) versionNumber() (string, bool) {
+	number := strings.TrimSuffix(s.version, "-SNAPSHOT")
+	return number, number != s.version
+}
+
+func splitPath(path string) []string {
+	path = strings.Trim(path, "/")
+	if path == "" {
+		return nil
+	}
+	return strings.Split(path, "/")
+}
+
+func match(pattern, segments []string) (map[string]string, bool) {
+	if len(pattern) != len(segments) {
+		return nil, false
+	}
+	params := map[string]string{}
+	for i, p := range pattern {
+		if strings.HasPrefix(p, "{") && strings.HasSuffix(p, "}") {
+			params[p[1:len(p)-1]] = segments[i]
+			continue
+		}
+		if p != segments[i] {
+			return nil, false
+		}
+	}
+	return params, true
+}
+
+func writeRaw(w http.ResponseWriter, status int, body []byte) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Content-Length", strconv.Itoa(len(body)))
+	w.WriteHeader(status)
+	_, _ = io.Copy(w, bytes.NewReader(body))
+}
+
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	body, err := json.Marshal(v)
+	if err != nil {
+		status = http.StatusInternalServerError
+		body = []byte(strconv.Quote(err.Error()))
+	}
+	writeRaw(w, status, body)
+}
+
+// writeError writes an error response in the format of the emulated
+// product.
+func (s *Server) writeError(w http.ResponseWriter, status int, message string) {
+	writeJSON(w, status, s.errorBody(status, message))
+}
@@ -0,0 +1,198 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package servertest_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/elastic/elastic-agent-libs/kibana"
+	"github.com/elastic/elastic-agent-libs/testing/servertest"
+)
+
+func get(t *testing.T, client *http.Client, url string) (int, map[string]interface{}) {
+	t.Helper()
+	return do(t, client, http.MethodGet, url, "")
+}
+
+func do(t *testing.T, client *http.Client, method, url, body string) (int, map[string]interface{}) {
+	t.Helper()
+	req, err := http.NewRequestWithContext(context.Background(), method, url, strings.NewReader(body))
+	require.NoError(t, err)
+	resp, err := client.Do(req)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+
+	var decoded map[string]interface{}
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&decoded))
+	return resp.StatusCode, decoded
+}
+
+func TestElasticsearchInfo(t *testing.T) {
+	es := servertest.NewElasticsearch(t, servertest.WithVersion("8.13.0-SNAPSHOT"))
+
+	status, body := get(t, es.Client(), es.URL+"/")
+	assert.Equal(t, http.StatusOK, status)
+	assert.Equal(t, "8.13.0", body["version"].(map[string]interface{})["number"])
+	assert.Equal(t, true, body["version"].(map[string]interface{})["build_snapshot"])
+
+	status, body = get(t, es.Client(), es.URL+"/_license")
+	assert.Equal(t, http.StatusOK, status)
+	assert.Equal(t, "active", body["license"].(map[string]interface{})["status"])
+}
+
+func TestElasticsearchBulk(t *testing.T) {
+	es := servertest.NewElasticsearch(t, servertest.WithBulkItemStatus(func(item int) int {
+		if item == 1 {
+			return http.StatusTooManyRequests
+		}
+		return http.StatusCreated
+	}))
+
+	bulk := `{"create":{"_index":"logs"}}
+{"message":"first"}
+{"index":{"_id":"2"}}
+{"message":"second"}
+{"delete":{"_index":"logs","_id":"3"}}
+`
+	status, body := do(t, es.Client(), http.MethodPost, es.URL+"/metrics/_bulk", bulk)
+	require.Equal(t, http.StatusOK, status)
+	assert.Equal(t, true, body["errors"])
+
+	items := body["items"].([]interface{})
+	require.Len(t, items, 3)
+	assert.EqualValues(t, 201, items[0].(map[string]interface{})["create"].(map[string]interface{})["status"])
+	assert.EqualValues(t, 429, items[1].(map[string]interface{})["index"].(map[string]interface{})["status"])
+
+	assert.Equal(t, []servertest.BulkItem{
+		{Action: "create", Index: "logs", Document: json.RawMessage(`{"message":"first"}`)},
+		{Action: "index", Index: "metrics", ID: "2", Document: json.RawMessage(`{"message":"second"}`)},
+		{Action: "delete", Index: "logs", ID: "3"},
+	}, es.BulkItems())
+
+	requests := es.RequestsTo(http.MethodPost, "/metrics/_bulk")
+	require.Len(t, requests, 1)
+	assert.Equal(t, bulk, string(requests[0].Body))
+}
+
+func TestFailureInjection(t *testing.T) {
+	es := servertest.NewElasticsearch(t)
+
+	es.FailNext(2, http.StatusTooManyRequests)
+	for i := 0; i < 2; i++ {
+		resp, err := es.Client().Get(es.URL + "/")
+		require.NoError(t, err)
+		resp.Body.Close()
+		assert.Equal(t, http.StatusTooManyRequests, resp.StatusCode)
+		assert.Equal(t, "1", resp.Header.Get("Retry-After"))
+	}
+	status, _ := get(t, es.Client(), es.URL+"/")
+	assert.Equal(t, http.StatusOK, status)
+
+	es.DelayNext(1, time.Minute)
+	client := &http.Client{Timeout: 50 * time.Millisecond}
+	_, err := client.Get(es.URL + "/") //nolint:noctx // test request
+	assert.Error(t, err)
+
+	assert.Len(t, es.Requests(), 4)
+	es.Reset()
+	assert.Empty(t, es.Requests())
+}
+
+func TestCannedResponses(t *testing.T) {
+	fixture := filepath.Join(t.TempDir(), "nodes.json")
+	require.NoError(t, os.WriteFile(fixture, []byte(`{"nodes":{}}`), 0o600))
+
+	es := servertest.NewElasticsearch(t,
+		servertest.WithResponse(http.MethodGet, "/", http.StatusUnauthorized, `{"error":"nope"}`),
+		servertest.WithFixture(http.MethodGet, "/_nodes", fixture),
+	)
+
+	status, body := get(t, es.Client(), es.URL+"/")
+	assert.Equal(t, http.StatusUnauthorized, status)
+	assert.Equal(t, "nope", body["error"])
+
+	status, body = get(t, es.Client(), es.URL+"/_nodes")
+	assert.Equal(t, http.StatusOK, status)
+	assert.Equal(t, map[string]interface{}{"nodes": map[string]interface{}{}}, body)
+
+	status, _ = get(t, es.Client(), es.URL+"/_unknown")
+	assert.Equal(t, http.StatusNotFound, status)
+}
+
+func TestKibanaClient(t *testing.T) {
+	kb := servertest.NewKibana(t, servertest.WithVersion("8.13.1"))
+
+	u, err := url.Parse(kb.URL)
+	require.NoError(t, err)
+	cfg := kibana.DefaultClientConfig()
+	cfg.Host = u.Host
+	client, err := kibana.NewClientWithConfig(&cfg, "test", "1.0.0", "", "")
+	require.NoError(t, err)
+
+	v := client.GetVersion()
+	assert.Equal(t, "8.13.1", v.String())
+	assert.Len(t, kb.RequestsTo(http.MethodGet, "/api/status"), 1)
+
+	err = client.ImportMultiPartFormFile("/api/saved_objects/_import", url.Values{}, "objects.ndjson",
+		`{"type":"dashboard","id":"d1","attributes":{"title":"One"}}
+{"type":"index-pattern","id":"p1","attributes":{"title":"logs-*"}}`)
+	require.NoError(t, err)
+
+	assert.Equal(t, []servertest.SavedObject{
+		{Type: "dashboard", ID: "d1", Attributes: map[string]interface{}{"title": "One"}},
+		{Type: "index-pattern", ID: "p1", Attributes: map[string]interface{}{"title": "logs-*"}},
+	}, kb.SavedObjects())
+}
+
+func TestKibanaSavedObjects(t *testing.T) {
+	kb := servertest.NewKibana(t, servertest.WithSavedObjects(
+		servertest.SavedObject{Type: "dashboard", ID: "d1", Attributes: map[string]interface{}{"title": "One"}},
+	))
+
+	status, body := get(t, kb.Client(), kb.URL+"/api/saved_objects/dashboard/d1")
+	assert.Equal(t, http.StatusOK, status)
+	assert.Equal(t, "d1", body["id"])
+
+	status, _ = do(t, kb.Client(), http.MethodPost, kb.URL+"/api/saved_objects/dashboard/d1", `{"attributes":{}}`)
+	assert.Equal(t, http.StatusConflict, status)
+
+	status, _ = do(t, kb.Client(), http.MethodPost, kb.URL+"/api/saved_objects/dashboard/d2", `{"attributes":{"title":"Two"}}`)
+	assert.Equal(t, http.StatusOK, status)
+
+	status, body = get(t, kb.Client(), kb.URL+"/api/saved_objects/_find?type=dashboard")
+	assert.Equal(t, http.StatusOK, status)
+	assert.EqualValues(t, 2, body["total"])
+
+	status, _ = do(t, kb.Client(), http.MethodDelete, kb.URL+"/api/saved_objects/dashboard/d1", "")
+	assert.Equal(t, http.StatusOK, status)
+
+	status, body = get(t, kb.Client(), kb.URL+"/api/saved_objects/dashboard/d1")
+	assert.Equal(t, http.StatusNotFound, status)
+	assert.Equal(t, "Saved object [dashboard/d1] not found", body["message"])
+}
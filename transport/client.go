@@ -46,6 +46,17 @@ type Config struct {
 	TLS     *tlscommon.TLSConfig
 	Timeout time.Duration
 	Stats   IOStatser
+
+	// MaxConnectionAge and MaxConnectionAgeJitter limit the lifetime of
+	// connections, see MaxAgeDialer.
+	MaxConnectionAge       time.Duration
+	MaxConnectionAgeJitter time.Duration
+
+	// IPFamily selects the IP addresses dialed when connecting to host names.
+	IPFamily IPFamily
+
+	// Hosts resolves host names to static addresses instead of using DNS.
+	Hosts HostOverrides
 }
 
 func NewClient(c Config, network, host string, defaultPort int) (*Client, error) {
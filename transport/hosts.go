@@ -0,0 +1,79 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package transport
+
+import (
+	"fmt"
+	"net"
+	"strings"
+)
+
+// HostOverride resolves a host name to a static address instead of using
+// DNS.
+type HostOverride struct {
+	// Host is the host name being overridden.
+	Host string `config:"host" yaml:"host" json:"host"`
+
+	// Address is the IP address to connect to, optionally followed by a port
+	// replacing the port being dialed, e.g. "10.0.0.1" or "10.0.0.1:9200".
+	Address string `config:"address" yaml:"address" json:"address"`
+}
+
+// HostOverrides is a list of static host name resolutions, like an embedded
+// /etc/hosts file. Host names are matched case insensitively.
+type HostOverrides []HostOverride
+
+// Validate checks that all overrides have a host and a valid address.
+func (h HostOverrides) Validate() error {
+	for _, override := range h {
+		if override.Host == "" {
+			return fmt.Errorf("host override for '%s' has no host", override.Address)
+		}
+		if _, _, err := parseOverrideAddress(override.Address); err != nil {
+			return fmt.Errorf("host override for '%s': %w", override.Host, err)
+		}
+	}
+	return nil
+}
+
+// lookup returns the IP address and port configured for host. The port is
+// empty if the override does not change the port.
+func (h HostOverrides) lookup(host string) (ip, port string, ok bool) {
+	for _, override := range h {
+		if !strings.EqualFold(strings.TrimSuffix(host, "."), strings.TrimSuffix(override.Host, ".")) {
+			continue
+		}
+		ip, port, err := parseOverrideAddress(override.Address)
+		if err != nil {
+			continue
+		}
+		return ip, port, true
+	}
+	return "", "", false
+}
+
+func parseOverrideAddress(address string) (ip, port string, err error) {
+	ip = address
+	if h, p, err := net.SplitHostPort(address); err == nil {
+		ip, port = h, p
+	}
+	if net.ParseIP(ip) == nil {
+		return "", "", fmt.Errorf("invalid address '%s', expected an IP address with an optional port", address)
+	}
+	return ip, port, nil
+}
@@ -0,0 +1,76 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package transport
+
+import (
+	"io"
+	"net"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHostOverridesLookup(t *testing.T) {
+	hosts := HostOverrides{
+		{Host: "es.example.com", Address: "10.0.0.1:9200"},
+		{Host: "kb.example.com.", Address: "::1"},
+	}
+	require.NoError(t, hosts.Validate())
+
+	ip, port, ok := hosts.lookup("ES.example.com")
+	assert.True(t, ok)
+	assert.Equal(t, "10.0.0.1", ip)
+	assert.Equal(t, "9200", port)
+
+	ip, port, ok = hosts.lookup("kb.example.com")
+	assert.True(t, ok)
+	assert.Equal(t, "::1", ip)
+	assert.Empty(t, port)
+
+	_, _, ok = hosts.lookup("example.com")
+	assert.False(t, ok)
+
+	assert.Error(t, HostOverrides{{Host: "es.example.com", Address: "not-an-ip"}}.Validate())
+	assert.Error(t, HostOverrides{{Address: "10.0.0.1"}}.Validate())
+}
+
+func TestNetDialerHostOverrides(t *testing.T) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer l.Close()
+	go func() {
+		c, err := l.Accept()
+		if err != nil {
+			return
+		}
+		_, _ = c.Write([]byte("ok"))
+		c.Close()
+	}()
+
+	dialer := NetDialer(0, WithHostOverrides(HostOverrides{
+		{Host: "es.example.invalid", Address: l.Addr().String()},
+	}))
+	c, err := dialer.Dial("tcp", "es.example.invalid:9200")
+	require.NoError(t, err)
+	defer c.Close()
+
+	data, err := io.ReadAll(c)
+	require.NoError(t, err)
+	assert.Equal(t, "ok", string(data))
+}
@@ -50,6 +50,21 @@ type HTTPTransportSettings struct {
 
 	IdleConnTimeout time.Duration `config:"idle_connection_timeout" yaml:"idle_connection_timeout,omitempty" json:"idle_connection_timeout,omitempty"`
 
+	// MaxConnectionAge closes connections older than the configured duration,
+	// plus a random jitter up to MaxConnectionAgeJitter, so new connections are
+	// established. See transport.MaxAgeDialer.
+	MaxConnectionAge       time.Duration `config:"max_connection_age" yaml:"max_connection_age,omitempty" json:"max_connection_age,omitempty"`
+	MaxConnectionAgeJitter time.Duration `config:"max_connection_age_jitter" yaml:"max_connection_age_jitter,omitempty" json:"max_connection_age_jitter,omitempty"`
+
+	// IPFamily selects the IP addresses dialed when connecting to host names:
+	// ipv4, ipv6, prefer_ipv4 or prefer_ipv6. All addresses are used by default.
+	IPFamily transport.IPFamily `config:"ip_family" yaml:"ip_family,omitempty" json:"ip_family,omitempty"`
+
+	// Hosts resolves host names to static addresses instead of using DNS,
+	// like an embedded /etc/hosts file. The host name is still used for TLS
+	// server name indication and verification.
+	Hosts transport.HostOverrides `config:"host_overrides" yaml:"host_overrides,omitempty" json:"host_overrides,omitempty"`
+
 	// Add more settings:
 	//  - DisableKeepAlive
 	//  - MaxIdleConns
@@ -168,12 +183,20 @@ func DefaultHTTPTransportSettings() HTTPTransportSettings {
 // Unpack reads a config object into the settings.
 func (settings *HTTPTransportSettings) Unpack(cfg *config.C) error {
 	tmp := struct {
-		TLS             *tlscommon.Config `config:"ssl"`
-		Timeout         time.Duration     `config:"timeout"`
-		IdleConnTimeout time.Duration     `config:"idle_connection_timeout"`
+		TLS                    *tlscommon.Config       `config:"ssl"`
+		Timeout                time.Duration           `config:"timeout"`
+		IdleConnTimeout        time.Duration           `config:"idle_connection_timeout"`
+		MaxConnectionAge       time.Duration           `config:"max_connection_age"`
+		MaxConnectionAgeJitter time.Duration           `config:"max_connection_age_jitter"`
+		IPFamily               transport.IPFamily      `config:"ip_family"`
+		Hosts                  transport.HostOverrides `config:"host_overrides"`
 	}{
-		Timeout:         settings.Timeout,
-		IdleConnTimeout: settings.IdleConnTimeout,
+		Timeout:                settings.Timeout,
+		IdleConnTimeout:        settings.IdleConnTimeout,
+		MaxConnectionAge:       settings.MaxConnectionAge,
+		MaxConnectionAgeJitter: settings.MaxConnectionAgeJitter,
+		IPFamily:               settings.IPFamily,
+		Hosts:                  settings.Hosts,
 	}
 
 	if err := cfg.Unpack(&tmp); err != nil {
@@ -191,10 +214,14 @@ func (settings *HTTPTransportSettings) Unpack(cfg *config.C) error {
 	}
 
 	*settings = HTTPTransportSettings{
-		TLS:             tmp.TLS,
-		Timeout:         tmp.Timeout,
-		Proxy:           proxy,
-		IdleConnTimeout: tmp.IdleConnTimeout,
+		TLS:                    tmp.TLS,
+		Timeout:                tmp.Timeout,
+		Proxy:                  proxy,
+		IdleConnTimeout:        tmp.IdleConnTimeout,
+		MaxConnectionAge:       tmp.MaxConnectionAge,
+		MaxConnectionAgeJitter: tmp.MaxConnectionAgeJitter,
+		IPFamily:               tmp.IPFamily,
+		Hosts:                  tmp.Hosts,
 	}
 	return nil
 }
@@ -220,7 +247,10 @@ func (settings *HTTPTransportSettings) RoundTripper(opts ...TransportOption) (ht
 	}
 
 	if dialer == nil {
-		dialer = transport.NetDialer(settings.Timeout)
+		dialer = transport.NetDialer(settings.Timeout,
+			transport.WithIPFamily(settings.IPFamily),
+			transport.WithHostOverrides(settings.Hosts),
+		)
 	}
 
 	tls, err := tlscommon.LoadTLSConfig(settings.TLS)
@@ -228,7 +258,13 @@ func (settings *HTTPTransportSettings) RoundTripper(opts ...TransportOption) (ht
 		return nil, err
 	}
 
-	tlsDialer := transport.TLSDialer(dialer, tls, settings.Timeout)
+	// The maximum age is enforced below TLS, so the TLS dialer still returns
+	// a *tls.Conn, which http.Transport needs for the connection state and
+	// the HTTP/2 negotiation.
+	tlsDialer := transport.TLSDialer(
+		transport.MaxAgeDialer(dialer, settings.MaxConnectionAge, settings.MaxConnectionAgeJitter),
+		tls, settings.Timeout)
+	dialer = transport.MaxAgeDialer(dialer, settings.MaxConnectionAge, settings.MaxConnectionAgeJitter)
 	for _, opt := range opts {
 		if dialOpt, ok := opt.(dialerModOption); ok {
 			dialer = dialOpt.applyDialer(settings, dialer)
@@ -19,15 +19,18 @@ package httpcommon
 
 import (
 	"bytes"
+	"encoding/pem"
 	"fmt"
 	"io"
 	"net/http"
+	"net/http/httptest"
 	"testing"
 	"time"
 
 	"github.com/stretchr/testify/require"
 
 	"github.com/elastic/elastic-agent-libs/config"
+	"github.com/elastic/elastic-agent-libs/transport"
 	"github.com/elastic/elastic-agent-libs/transport/tlscommon"
 )
 
@@ -56,6 +59,32 @@ timeout: 5s
 				Timeout:         5 * time.Second,
 			},
 		},
+		"maxConnectionAge": {
+			input: `
+max_connection_age: 5m
+max_connection_age_jitter: 30s
+`,
+			expected: HTTPTransportSettings{
+				MaxConnectionAge:       5 * time.Minute,
+				MaxConnectionAgeJitter: 30 * time.Second,
+			},
+		},
+		"hostOverrides": {
+			input: `
+host_overrides:
+  - host: es.example.com
+    address: 10.0.0.1:9200
+`,
+			expected: HTTPTransportSettings{
+				Hosts: transport.HostOverrides{{Host: "es.example.com", Address: "10.0.0.1:9200"}},
+			},
+		},
+		"ipFamily": {
+			input: `
+ip_family: prefer_ipv4
+`,
+			expected: HTTPTransportSettings{IPFamily: transport.PreferIPv4},
+		},
 		"ssl": {
 			input: `
 ssl:
@@ -243,3 +272,45 @@ func BenchmarkReadAll(b *testing.B) {
 		})
 	}
 }
+
+func TestHostOverridesKeepServerName(t *testing.T) {
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(r.Host))
+	}))
+	defer server.Close()
+
+	// The test server certificate is valid for example.com.
+	caPEM := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: server.Certificate().Raw})
+	settings := HTTPTransportSettings{
+		TLS:   &tlscommon.Config{CAs: []string{string(caPEM)}},
+		Hosts: transport.HostOverrides{{Host: "example.com", Address: server.Listener.Addr().String()}},
+	}
+	client, err := settings.Client()
+	require.NoError(t, err)
+
+	resp, err := client.Get("https://example.com/")
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	require.NoError(t, err)
+	require.Equal(t, "example.com", string(body))
+}
+
+func TestMaxConnectionAgeKeepsTLS(t *testing.T) {
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer server.Close()
+
+	caPEM := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: server.Certificate().Raw})
+	settings := HTTPTransportSettings{
+		TLS:              &tlscommon.Config{CAs: []string{string(caPEM)}},
+		MaxConnectionAge: time.Minute,
+	}
+	client, err := settings.Client()
+	require.NoError(t, err)
+
+	resp, err := client.Get(server.URL)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	// http.Transport only sets the connection state of a *tls.Conn.
+	require.NotNil(t, resp.TLS)
+}
@@ -20,24 +20,38 @@ package httpcommon
 import (
 	"net/http"
 	"net/url"
+	"strings"
+
+	"golang.org/x/net/http/httpproxy"
 
 	"github.com/elastic/elastic-agent-libs/config"
+	"github.com/elastic/elastic-agent-libs/transport"
 )
 
 // HTTPClientProxySettings provides common HTTP proxy setup support.
 //
 // Proxy usage will be disabled in general if Disable is set.
-// If URL is not set, the proxy configuration will default
-// to HTTP_PROXY, HTTPS_PROXY, and NO_PROXY.
+// The HTTP_PROXY, HTTPS_PROXY, and NO_PROXY environment variables are used as
+// defaults for URL and NoProxy. Explicitly configured settings take precedence
+// over the environment variables.
 //
 // The default (and zero) value of HTTPClientProxySettings has Proxy support
 // enabled, and will select the proxy per URL based on the environment variables.
 type HTTPClientProxySettings struct {
 	// Proxy URL to use for http connections. If the proxy url is configured,
-	// it is used for all connection attempts. All proxy related environment
-	// variables are ignored.
+	// it is used for all connection attempts not matching NoProxy. The
+	// HTTP_PROXY and HTTPS_PROXY environment variables are ignored.
 	URL *ProxyURI `config:"proxy_url" yaml:"proxy_url,omitempty"`
 
+	// NoProxy lists the destinations that are connected to directly, using
+	// the NO_PROXY format (see transport.NoProxy). If not set, the NO_PROXY
+	// environment variable is used.
+	NoProxy []string `config:"proxy_no_proxy" yaml:"proxy_no_proxy,omitempty"`
+
+	// Overrides selects a different proxy, or no proxy, for the matching
+	// destinations. Overrides take precedence over URL and NoProxy.
+	Overrides transport.ProxyOverrides `config:"proxy_overrides" yaml:"proxy_overrides,omitempty"`
+
 	// Headers configures additional headers that are send to the proxy
 	// during CONNECT requests.
 	Headers ProxyHeaders `config:"proxy_headers" yaml:"proxy_headers,omitempty"`
@@ -73,9 +87,11 @@ func DefaultHTTPClientProxySettings() HTTPClientProxySettings {
 // a field of type HTTPClientProxySettings.
 func (settings *HTTPClientProxySettings) Unpack(cfg *config.C) error {
 	tmp := struct {
-		URL     string            `config:"proxy_url"`
-		Disable bool              `config:"proxy_disable"`
-		Headers map[string]string `config:"proxy_headers"`
+		URL       string                   `config:"proxy_url"`
+		Disable   bool                     `config:"proxy_disable"`
+		Headers   map[string]string        `config:"proxy_headers"`
+		NoProxy   []string                 `config:"proxy_no_proxy"`
+		Overrides transport.ProxyOverrides `config:"proxy_overrides"`
 	}{}
 
 	if err := cfg.Unpack(&tmp); err != nil {
@@ -86,21 +102,72 @@ func (settings *HTTPClientProxySettings) Unpack(cfg *config.C) error {
 	if err != nil {
 		return err
 	}
+	s.NoProxy = tmp.NoProxy
+	s.Overrides = tmp.Overrides
 
 	*settings = *s
 	return nil
 }
 
 // ProxyFunc creates a function that can be used with http.Transport in order to
-// configure the HTTP proxy functionality.
+// configure the HTTP proxy functionality. The environment variables are read
+// when ProxyFunc is called, and NO_PROXY is matched with transport.NoProxy
+// even if no proxy setting is configured.
 func (settings *HTTPClientProxySettings) ProxyFunc() func(*http.Request) (*url.URL, error) {
 	if settings.Disable {
 		return nil
 	}
 
-	if settings.URL == nil {
-		return http.ProxyFromEnvironment
+	type override struct {
+		hosts transport.NoProxy
+		url   *url.URL
+		err   error
+	}
+	overrides := make([]override, 0, len(settings.Overrides))
+	for _, o := range settings.Overrides {
+		entry := override{hosts: transport.ParseNoProxy(strings.Join(o.Hosts, ","))}
+		if o.URL != "" {
+			entry.url, entry.err = url.Parse(o.URL)
+		}
+		overrides = append(overrides, entry)
 	}
 
-	return http.ProxyURL(settings.URL.URI())
+	noProxy := transport.NoProxyFromEnvironment()
+	if len(settings.NoProxy) > 0 {
+		noProxy = transport.ParseNoProxy(strings.Join(settings.NoProxy, ","))
+	}
+
+	var proxyURL func(*url.URL) (*url.URL, error)
+	if settings.URL != nil {
+		u := settings.URL.URI()
+		proxyURL = func(*url.URL) (*url.URL, error) { return u, nil }
+	} else {
+		// NO_PROXY is handled by noProxy, only keep the proxy URLs.
+		env := httpproxy.FromEnvironment()
+		env.NoProxy = ""
+		proxyURL = env.ProxyFunc()
+	}
+
+	return func(req *http.Request) (*url.URL, error) {
+		host, port := req.URL.Hostname(), requestPort(req.URL)
+		for _, o := range overrides {
+			if o.hosts.Match(host, port) {
+				return o.url, o.err
+			}
+		}
+		if noProxy.Match(host, port) {
+			return nil, nil
+		}
+		return proxyURL(req.URL)
+	}
+}
+
+func requestPort(u *url.URL) string {
+	if port := u.Port(); port != "" {
+		return port
+	}
+	if u.Scheme == "https" {
+		return "443"
+	}
+	return "80"
 }
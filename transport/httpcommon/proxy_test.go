@@ -0,0 +1,174 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package httpcommon
+
+import (
+	"net/http"
+	"net/url"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/elastic/elastic-agent-libs/config"
+)
+
+func TestProxyFunc(t *testing.T) {
+	t.Setenv("HTTP_PROXY", "http://env-proxy:3128")
+	t.Setenv("HTTPS_PROXY", "http://env-secure-proxy:3128")
+	t.Setenv("NO_PROXY", "env-direct.com,10.0.0.0/8")
+
+	proxyFor := func(t *testing.T, settings HTTPClientProxySettings, rawURL string) string {
+		t.Helper()
+		req, err := http.NewRequest(http.MethodGet, rawURL, nil)
+		require.NoError(t, err)
+		u, err := settings.ProxyFunc()(req)
+		require.NoError(t, err)
+		if u == nil {
+			return ""
+		}
+		return u.String()
+	}
+
+	configured, err := NewProxyURIFromString("http://configured:8080")
+	require.NoError(t, err)
+
+	tests := map[string]struct {
+		settings HTTPClientProxySettings
+		url      string
+		expected string
+	}{
+		"environment only": {
+			url:      "https://example.com",
+			expected: "http://env-secure-proxy:3128",
+		},
+		"environment only honors NO_PROXY": {
+			url: "http://api.env-direct.com",
+		},
+		"explicit url wins over the environment": {
+			settings: HTTPClientProxySettings{URL: configured},
+			url:      "https://example.com",
+			expected: "http://configured:8080",
+		},
+		"explicit url honors NO_PROXY from the environment": {
+			settings: HTTPClientProxySettings{URL: configured},
+			url:      "http://10.1.2.3:9200",
+		},
+		"explicit no proxy wins over the environment": {
+			settings: HTTPClientProxySettings{NoProxy: []string{"example.com:443"}},
+			url:      "https://api.example.com",
+		},
+		"explicit no proxy replaces NO_PROXY": {
+			settings: HTTPClientProxySettings{NoProxy: []string{"example.com:443"}},
+			url:      "http://env-direct.com",
+			expected: "http://env-proxy:3128",
+		},
+		"explicit no proxy port mismatch": {
+			settings: HTTPClientProxySettings{URL: configured, NoProxy: []string{"example.com:443"}},
+			url:      "http://example.com",
+			expected: "http://configured:8080",
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			assert.Equal(t, test.expected, proxyFor(t, test.settings, test.url))
+		})
+	}
+
+	disabled := HTTPClientProxySettings{URL: configured, Disable: true}
+	assert.Nil(t, disabled.ProxyFunc())
+}
+
+func TestProxyFuncReadsEnvironment(t *testing.T) {
+	// Clients without explicit proxy settings use the same NO_PROXY
+	// matching as the others, and read the environment each time a
+	// function is created instead of once per process.
+	req, err := http.NewRequest(http.MethodGet, "http://example.com:8080", nil)
+	require.NoError(t, err)
+
+	t.Setenv("HTTP_PROXY", "http://first:3128")
+	t.Setenv("NO_PROXY", "")
+	u, err := (&HTTPClientProxySettings{}).ProxyFunc()(req)
+	require.NoError(t, err)
+	assert.Equal(t, "http://first:3128", u.String())
+
+	t.Setenv("HTTP_PROXY", "http://second:3128")
+	u, err = (&HTTPClientProxySettings{}).ProxyFunc()(req)
+	require.NoError(t, err)
+	assert.Equal(t, "http://second:3128", u.String())
+
+	t.Setenv("NO_PROXY", "*.com:8080")
+	u, err = (&HTTPClientProxySettings{}).ProxyFunc()(req)
+	require.NoError(t, err)
+	assert.Nil(t, u)
+}
+
+func TestUnpackNoProxy(t *testing.T) {
+	cfg := config.MustNewConfigFrom(map[string]interface{}{
+		"proxy_url":      "http://proxy:3128",
+		"proxy_no_proxy": []string{"localhost", "10.0.0.0/8"},
+	})
+
+	var settings HTTPClientProxySettings
+	require.NoError(t, cfg.Unpack(&settings))
+	assert.Equal(t, []string{"localhost", "10.0.0.0/8"}, settings.NoProxy)
+	assert.Equal(t, &url.URL{Scheme: "http", Host: "proxy:3128"}, settings.URL.URI())
+}
+
+func TestProxyFuncOverrides(t *testing.T) {
+	t.Setenv("HTTP_PROXY", "")
+	t.Setenv("HTTPS_PROXY", "")
+	t.Setenv("NO_PROXY", "")
+
+	cfg := config.MustNewConfigFrom(map[string]interface{}{
+		"proxy_url":      "http://default:3128",
+		"proxy_no_proxy": []string{"direct.com"},
+		"proxy_overrides": []map[string]interface{}{
+			{"hosts": []string{"*.internal", "10.0.0.0/8"}},
+			{"hosts": []string{"*.elastic.co", "direct.com"}, "url": "http://corp:8080"},
+		},
+	})
+	var settings HTTPClientProxySettings
+	require.NoError(t, cfg.Unpack(&settings))
+
+	tests := map[string]string{
+		"https://es.internal":       "",
+		"http://10.1.1.1:9200":      "",
+		"https://cloud.elastic.co":  "http://corp:8080",
+		"https://direct.com":        "http://corp:8080",
+		"https://other.example.com": "http://default:3128",
+	}
+	proxy := settings.ProxyFunc()
+	for rawURL, expected := range tests {
+		req, err := http.NewRequest(http.MethodGet, rawURL, nil)
+		require.NoError(t, err)
+		u, err := proxy(req)
+		require.NoError(t, err)
+		if expected == "" {
+			assert.Nil(t, u, rawURL)
+			continue
+		}
+		assert.Equal(t, expected, u.String(), rawURL)
+	}
+
+	cfg = config.MustNewConfigFrom(map[string]interface{}{
+		"proxy_overrides": []map[string]interface{}{{"url": "http://corp:8080"}},
+	})
+	assert.Error(t, cfg.Unpack(&settings), "overrides without hosts must be rejected")
+}
@@ -0,0 +1,90 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package transport
+
+import (
+	"fmt"
+	"math/rand"
+	"net"
+)
+
+// IPFamily selects which IP addresses of a host name are dialed, and in
+// which order.
+type IPFamily string
+
+const (
+	// IPFamilyAny dials IPv4 and IPv6 addresses in random order.
+	IPFamilyAny IPFamily = ""
+	// IPv4Only only dials IPv4 addresses.
+	IPv4Only IPFamily = "ipv4"
+	// IPv6Only only dials IPv6 addresses.
+	IPv6Only IPFamily = "ipv6"
+	// PreferIPv4 dials IPv4 addresses before IPv6 addresses.
+	PreferIPv4 IPFamily = "prefer_ipv4"
+	// PreferIPv6 dials IPv6 addresses before IPv4 addresses.
+	PreferIPv6 IPFamily = "prefer_ipv6"
+)
+
+// Unpack validates and sets the IP family from a config string.
+func (f *IPFamily) Unpack(s string) error {
+	switch family := IPFamily(s); family {
+	case IPFamilyAny, IPv4Only, IPv6Only, PreferIPv4, PreferIPv6:
+		*f = family
+		return nil
+	default:
+		return fmt.Errorf("invalid ip family '%s', expected one of ipv4, ipv6, prefer_ipv4 or prefer_ipv6", s)
+	}
+}
+
+// Order returns the addresses to dial, in the order they must be dialed.
+// Addresses of the same family are shuffled to spread the load across
+// them. Addresses that are not IP addresses are kept for IPFamilyAny only.
+func (f IPFamily) Order(addresses []string) []string {
+	var v4, v6, other []string
+	for _, addr := range addresses {
+		ip := net.ParseIP(addr)
+		switch {
+		case ip == nil:
+			other = append(other, addr)
+		case ip.To4() != nil:
+			v4 = append(v4, addr)
+		default:
+			v6 = append(v6, addr)
+		}
+	}
+
+	switch f {
+	case IPv4Only:
+		return shuffle(v4)
+	case IPv6Only:
+		return shuffle(v6)
+	case PreferIPv4:
+		return append(shuffle(v4), shuffle(v6)...)
+	case PreferIPv6:
+		return append(shuffle(v6), shuffle(v4)...)
+	default:
+		return shuffle(append(append(v4, v6...), other...))
+	}
+}
+
+func shuffle(addresses []string) []string {
+	rand.Shuffle(len(addresses), func(i, j int) {
+		addresses[i], addresses[j] = addresses[j], addresses[i]
+	})
+	return addresses
+}
@@ -0,0 +1,96 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package transport
+
+import (
+	"context"
+	"errors"
+	"net"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/elastic/elastic-agent-libs/config"
+)
+
+func TestIPFamilyOrder(t *testing.T) {
+	addresses := []string{"10.0.0.1", "::1", "10.0.0.2", "fe80::1"}
+
+	assert.ElementsMatch(t, []string{"10.0.0.1", "10.0.0.2"}, IPv4Only.Order(addresses))
+	assert.ElementsMatch(t, []string{"::1", "fe80::1"}, IPv6Only.Order(addresses))
+	assert.ElementsMatch(t, addresses, IPFamilyAny.Order(append([]string(nil), addresses...)))
+
+	ordered := PreferIPv4.Order(addresses)
+	assert.ElementsMatch(t, []string{"10.0.0.1", "10.0.0.2"}, ordered[:2])
+	assert.ElementsMatch(t, []string{"::1", "fe80::1"}, ordered[2:])
+
+	ordered = PreferIPv6.Order(addresses)
+	assert.ElementsMatch(t, []string{"::1", "fe80::1"}, ordered[:2])
+	assert.ElementsMatch(t, []string{"10.0.0.1", "10.0.0.2"}, ordered[2:])
+}
+
+func TestIPFamilyUnpack(t *testing.T) {
+	var settings struct {
+		Family IPFamily `config:"ip_family"`
+	}
+	require.NoError(t, config.MustNewConfigFrom(map[string]interface{}{"ip_family": "ipv6"}).Unpack(&settings))
+	assert.Equal(t, IPv6Only, settings.Family)
+
+	err := config.MustNewConfigFrom(map[string]interface{}{"ip_family": "ipv5"}).Unpack(&settings)
+	assert.ErrorContains(t, err, "invalid ip family")
+}
+
+func TestDialWithFamily(t *testing.T) {
+	var dialed []string
+	dialer := DialerFunc(func(_ context.Context, _, address string) (net.Conn, error) {
+		dialed = append(dialed, address)
+		return nil, errors.New("connection refused")
+	})
+
+	_, err := DialWithFamily(context.Background(), dialer, "tcp", "example.com", []string{"::1", "10.0.0.1"}, "80", PreferIPv4)
+	assert.Error(t, err)
+	assert.Equal(t, []string{"10.0.0.1:80", "[::1]:80"}, dialed)
+
+	dialed = nil
+	_, err = DialWithFamily(context.Background(), dialer, "tcp", "example.com", []string{"::1"}, "80", IPv4Only)
+	assert.ErrorContains(t, err, "no ipv4 address for host example.com")
+	assert.Empty(t, dialed)
+}
+
+func TestNetDialerIPFamily(t *testing.T) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer l.Close()
+	go func() {
+		for {
+			c, err := l.Accept()
+			if err != nil {
+				return
+			}
+			c.Close()
+		}
+	}()
+
+	c, err := NetDialer(0, WithIPFamily(IPv4Only)).Dial("tcp", l.Addr().String())
+	require.NoError(t, err)
+	c.Close()
+
+	_, err = NetDialer(0, WithIPFamily(IPv6Only)).Dial("tcp", l.Addr().String())
+	assert.ErrorContains(t, err, "no ipv6 address")
+}
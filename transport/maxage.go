@@ -0,0 +1,85 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package transport
+
+import (
+	"context"
+	"errors"
+	"math/rand"
+	"net"
+	"sync"
+	"time"
+
+	"github.com/elastic/elastic-agent-libs/clock"
+)
+
+// ErrConnectionExpired is returned when writing to a connection created by
+// MaxAgeDialer that is older than its maximum age.
+var ErrConnectionExpired = errors.New("connection exceeded its maximum age")
+
+// MaxAgeDialer limits the lifetime of the connections created by d, so that
+// long-lived connections are re-established and rebalanced across the
+// backends of a load balancer.
+//
+// Each connection expires after maxAge plus a random duration in
+// [0, jitter), spreading reconnects when many connections are created at the
+// same time. An expired connection is closed on the next Write, which fails
+// with ErrConnectionExpired without writing any data. Callers can then dial
+// a new connection and retry safely; http.Transport does so automatically
+// for replayable requests. Reads are not affected, so in-flight responses
+// are completed.
+//
+// A maxAge <= 0 disables the limit and d is returned as is.
+func MaxAgeDialer(d Dialer, maxAge, jitter time.Duration) Dialer {
+	return maxAgeDialer(d, maxAge, jitter, clock.Real())
+}
+
+func maxAgeDialer(d Dialer, maxAge, jitter time.Duration, clk clock.Clock) Dialer {
+	if maxAge <= 0 {
+		return d
+	}
+	return DialerFunc(func(ctx context.Context, network, address string) (net.Conn, error) {
+		c, err := d.DialContext(ctx, network, address)
+		if err != nil {
+			return nil, err
+		}
+
+		lifetime := maxAge
+		if jitter > 0 {
+			//nolint:gosec // the jitter does not need a secure random source
+			lifetime += time.Duration(rand.Int63n(int64(jitter)))
+		}
+		return &maxAgeConn{Conn: c, clock: clk, expires: clk.Now().Add(lifetime)}, nil
+	})
+}
+
+type maxAgeConn struct {
+	net.Conn
+	clock   clock.Clock
+	expires time.Time
+
+	closeOnce sync.Once
+}
+
+func (c *maxAgeConn) Write(b []byte) (int, error) {
+	if !c.clock.Now().Before(c.expires) {
+		c.closeOnce.Do(func() { _ = c.Conn.Close() })
+		return 0, ErrConnectionExpired
+	}
+	return c.Conn.Write(b)
+}
@@ -0,0 +1,95 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package transport
+
+import (
+	"context"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/elastic/elastic-agent-libs/clock"
+)
+
+func TestMaxAgeDialer(t *testing.T) {
+	clk := clock.NewFake(time.Now())
+	forward := DialerFunc(func(context.Context, string, string) (net.Conn, error) {
+		client, server := net.Pipe()
+		go func() { _, _ = io.Copy(io.Discard, server) }()
+		return client, nil
+	})
+
+	dialer := maxAgeDialer(forward, time.Minute, 10*time.Second, clk)
+	conn, err := dialer.Dial("tcp", "localhost:9200")
+	require.NoError(t, err)
+
+	_, err = conn.Write([]byte("before"))
+	require.NoError(t, err)
+
+	clk.Advance(70 * time.Second)
+	n, err := conn.Write([]byte("after"))
+	assert.ErrorIs(t, err, ErrConnectionExpired)
+	assert.Zero(t, n)
+
+	_, err = conn.Read(make([]byte, 1))
+	assert.Error(t, err, "expired connections must be closed")
+
+	conn, err = maxAgeDialer(forward, 0, time.Second, clk).Dial("tcp", "localhost:9200")
+	require.NoError(t, err)
+	_, wrapped := conn.(*maxAgeConn)
+	assert.False(t, wrapped, "a zero max age must not wrap connections")
+}
+
+func TestMaxAgeDialerHTTPRetry(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte("ok"))
+	}))
+	defer server.Close()
+
+	clk := clock.NewFake(time.Now())
+	var dials atomic.Int32
+	forward := DialerFunc(func(ctx context.Context, network, address string) (net.Conn, error) {
+		dials.Add(1)
+		return (&net.Dialer{}).DialContext(ctx, network, address)
+	})
+	client := &http.Client{Transport: &http.Transport{
+		DialContext: maxAgeDialer(forward, time.Minute, 0, clk).DialContext,
+	}}
+
+	get := func() {
+		resp, err := client.Get(server.URL)
+		require.NoError(t, err)
+		_, _ = io.Copy(io.Discard, resp.Body)
+		resp.Body.Close()
+	}
+
+	get()
+	get()
+	assert.EqualValues(t, 1, dials.Load(), "connections must be reused before they expire")
+
+	clk.Advance(time.Minute)
+	get()
+	assert.EqualValues(t, 2, dials.Load(), "expired connections must be replaced")
+}
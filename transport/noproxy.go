@@ -0,0 +1,145 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package transport
+
+import (
+	"net"
+	"os"
+	"strings"
+)
+
+// NoProxy is a list of destinations that must be reached directly instead of
+// through a proxy. It follows the format of the NO_PROXY environment
+// variable, a comma separated list of:
+//
+//   - "*", matching all destinations.
+//   - IP addresses, e.g. "10.0.0.1", or CIDR ranges, e.g. "10.0.0.0/8",
+//     matching destinations given as IP addresses.
+//   - Domain names, e.g. "example.com", matching the domain and all its
+//     subdomains.
+//   - Domain names with a leading dot or "*.", e.g. ".example.com",
+//     matching the subdomains only.
+//
+// IP addresses and domain names can be followed by a port, e.g.
+// "example.com:8080", in which case only that port is matched. Matching is
+// case insensitive.
+type NoProxy struct {
+	all     bool
+	entries []noProxyEntry
+}
+
+type noProxyEntry struct {
+	network    *net.IPNet
+	ip         net.IP
+	domain     string
+	subdomains bool // only match subdomains of domain
+	port       string
+}
+
+// ParseNoProxy parses a NO_PROXY formatted list. Invalid entries are
+// ignored.
+func ParseNoProxy(s string) NoProxy {
+	var n NoProxy
+	for _, entry := range strings.Split(s, ",") {
+		entry = strings.ToLower(strings.TrimSpace(entry))
+		if entry == "" {
+			continue
+		}
+		if entry == "*" {
+			n.all = true
+			continue
+		}
+
+		if _, network, err := net.ParseCIDR(entry); err == nil {
+			n.entries = append(n.entries, noProxyEntry{network: network})
+			continue
+		}
+
+		host, port := entry, ""
+		if h, p, err := net.SplitHostPort(entry); err == nil {
+			host, port = h, p
+		}
+		if ip := net.ParseIP(strings.Trim(host, "[]")); ip != nil {
+			n.entries = append(n.entries, noProxyEntry{ip: ip, port: port})
+			continue
+		}
+
+		e := noProxyEntry{port: port}
+		switch {
+		case strings.HasPrefix(host, "*."):
+			e.domain, e.subdomains = host[2:], true
+		case strings.HasPrefix(host, "."):
+			e.domain, e.subdomains = host[1:], true
+		default:
+			e.domain = host
+		}
+		if e.domain != "" {
+			n.entries = append(n.entries, e)
+		}
+	}
+	return n
+}
+
+// NoProxyFromEnvironment parses the NO_PROXY, or no_proxy, environment
+// variable.
+func NoProxyFromEnvironment() NoProxy {
+	v := os.Getenv("NO_PROXY")
+	if v == "" {
+		v = os.Getenv("no_proxy")
+	}
+	return ParseNoProxy(v)
+}
+
+// IsEmpty returns true if n matches no destination.
+func (n NoProxy) IsEmpty() bool {
+	return !n.all && len(n.entries) == 0
+}
+
+// Match returns true if the destination host and port must not be proxied.
+// The port can be empty if it is not known.
+func (n NoProxy) Match(host, port string) bool {
+	if n.all {
+		return true
+	}
+
+	host = strings.ToLower(strings.TrimSuffix(strings.Trim(host, "[]"), "."))
+	ip := net.ParseIP(host)
+	for _, e := range n.entries {
+		if e.port != "" && e.port != port {
+			continue
+		}
+		switch {
+		case e.network != nil:
+			if ip != nil && e.network.Contains(ip) {
+				return true
+			}
+		case e.ip != nil:
+			if ip != nil && e.ip.Equal(ip) {
+				return true
+			}
+		case ip == nil:
+			if host == e.domain && !e.subdomains {
+				return true
+			}
+			if strings.HasSuffix(host, "."+e.domain) {
+				return true
+			}
+		}
+	}
+	return false
+}
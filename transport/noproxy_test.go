@@ -0,0 +1,126 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package transport
+
+import (
+	"context"
+	"errors"
+	"net"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/elastic/elastic-agent-libs/logp"
+)
+
+func TestNoProxyMatch(t *testing.T) {
+	noProxy := ParseNoProxy("10.0.0.0/8, 192.168.1.1, [::1]:9200, example.com, .sub.org, *.wild.net, internal:8080")
+
+	tests := []struct {
+		host, port string
+		match      bool
+	}{
+		{"10.1.2.3", "443", true},
+		{"11.1.2.3", "443", false},
+		{"192.168.1.1", "80", true},
+		{"192.168.1.2", "80", false},
+		{"::1", "9200", true},
+		{"::1", "9300", false},
+		{"example.com", "443", true},
+		{"EXAMPLE.com.", "443", true},
+		{"api.example.com", "443", true},
+		{"notexample.com", "443", false},
+		{"sub.org", "443", false},
+		{"a.sub.org", "443", true},
+		{"wild.net", "443", false},
+		{"a.b.wild.net", "443", true},
+		{"internal", "8080", true},
+		{"internal", "80", false},
+	}
+	for _, test := range tests {
+		assert.Equal(t, test.match, noProxy.Match(test.host, test.port), "%s:%s", test.host, test.port)
+	}
+
+	assert.True(t, ParseNoProxy("localhost,*").Match("anything", "1"))
+	assert.True(t, ParseNoProxy(" , ").IsEmpty())
+}
+
+func TestNoProxyFromEnvironment(t *testing.T) {
+	t.Setenv("NO_PROXY", "")
+	t.Setenv("no_proxy", "example.com")
+	assert.True(t, NoProxyFromEnvironment().Match("example.com", "80"))
+
+	t.Setenv("NO_PROXY", "example.org")
+	assert.False(t, NoProxyFromEnvironment().Match("example.com", "80"))
+	assert.True(t, NoProxyFromEnvironment().Match("example.org", "80"))
+}
+
+func TestProxyDialerNoProxy(t *testing.T) {
+	var dialed []string
+	forward := DialerFunc(func(_ context.Context, _, address string) (net.Conn, error) {
+		dialed = append(dialed, address)
+		return nil, errors.New("connection refused")
+	})
+
+	t.Setenv("NO_PROXY", "from-env.com")
+	config := &ProxyConfig{URL: "socks5://proxy.local:1080", NoProxy: []string{"direct.com"}}
+	dialer, err := ProxyDialer(logp.NewLogger("test"), config, forward)
+	require.NoError(t, err)
+
+	_, _ = dialer.Dial("tcp", "api.direct.com:443")
+	assert.Equal(t, []string{"api.direct.com:443"}, dialed, "destinations matching NoProxy must be dialed directly")
+
+	// Explicit configuration replaces the environment, so from-env.com goes
+	// through the proxy.
+	dialed = nil
+	_, _ = dialer.Dial("tcp", "from-env.com:443")
+	assert.Equal(t, []string{"proxy.local:1080"}, dialed)
+}
+
+func TestProxyDialerOverrides(t *testing.T) {
+	var dialed []string
+	forward := DialerFunc(func(_ context.Context, _, address string) (net.Conn, error) {
+		dialed = append(dialed, address)
+		return nil, errors.New("connection refused")
+	})
+
+	config := &ProxyConfig{
+		NoProxy: []string{"none"},
+		Overrides: ProxyOverrides{
+			{Hosts: []string{"*.internal"}},
+			{Hosts: []string{"*.elastic.co"}, URL: "socks5://corp:1080"},
+		},
+	}
+	require.NoError(t, config.Validate())
+	dialer, err := ProxyDialer(logp.NewLogger("test"), config, forward)
+	require.NoError(t, err)
+
+	for address, expected := range map[string]string{
+		"es.internal:9200":     "es.internal:9200",
+		"cloud.elastic.co:443": "corp:1080",
+		"example.com:443":      "example.com:443",
+	} {
+		dialed = nil
+		_, _ = dialer.Dial("tcp", address)
+		assert.Equal(t, []string{expected}, dialed, address)
+	}
+
+	invalid := &ProxyConfig{Overrides: ProxyOverrides{{Hosts: []string{"a"}, URL: "ftp://corp"}}}
+	assert.Error(t, invalid.Validate())
+}
@@ -21,6 +21,7 @@ import (
 	"context"
 	"net"
 	"net/url"
+	"strings"
 
 	"golang.org/x/net/proxy"
 
@@ -36,39 +37,79 @@ type ProxyConfig struct {
 
 	// Resolve names locally instead of on the SOCKS server.
 	LocalResolve bool `config:"proxy_use_local_resolver"`
+
+	// NoProxy lists the destinations that are connected to directly, using
+	// the NO_PROXY format (see NoProxy). If not set, the NO_PROXY environment
+	// variable is used.
+	NoProxy []string `config:"proxy_no_proxy"`
+
+	// Overrides selects a different proxy, or no proxy, for the matching
+	// destinations. Overrides take precedence over URL and NoProxy.
+	Overrides ProxyOverrides `config:"proxy_overrides"`
+}
+
+func (c *ProxyConfig) noProxy() NoProxy {
+	if len(c.NoProxy) > 0 {
+		return ParseNoProxy(strings.Join(c.NoProxy, ","))
+	}
+	return NoProxyFromEnvironment()
 }
 
 func (c *ProxyConfig) Validate() error {
-	if c.URL == "" {
-		return nil
+	if err := c.Overrides.Validate(); err != nil {
+		return err
 	}
+	for _, override := range c.Overrides {
+		if _, err := parseSOCKSURL(override.URL); err != nil {
+			return err
+		}
+	}
+
+	_, err := parseSOCKSURL(c.URL)
+	return err
+}
 
-	url, err := url.Parse(c.URL)
+// parseSOCKSURL parses and validates a proxy URL. It returns nil for an
+// empty URL.
+func parseSOCKSURL(raw string) (*url.URL, error) {
+	if raw == "" {
+		return nil, nil
+	}
+
+	url, err := url.Parse(raw)
 	if err != nil {
-		return err
+		return nil, err
 	}
 	if _, err := proxy.FromURL(url, nil); err != nil {
-		return err
+		return nil, err
 	}
-
-	return nil
+	return url, nil
 }
 
 func ProxyDialer(log *logp.Logger, config *ProxyConfig, forward Dialer) (Dialer, error) {
-	if config == nil || config.URL == "" {
+	if config == nil || (config.URL == "" && len(config.Overrides) == 0) {
 		return forward, nil
 	}
 
-	url, err := url.Parse(config.URL)
+	defaultURL, err := parseSOCKSURL(config.URL)
 	if err != nil {
 		return nil, err
 	}
 
-	if _, err := proxy.FromURL(url, nil); err != nil {
-		return nil, err
+	proxies := map[string]*url.URL{config.URL: defaultURL}
+	for _, override := range config.Overrides {
+		url, err := parseSOCKSURL(override.URL)
+		if err != nil {
+			return nil, err
+		}
+		proxies[override.URL] = url
 	}
 
-	log.Infof("proxy host: '%s'", url.Host)
+	overrides := config.Overrides.compile()
+	noProxy := config.noProxy()
+	if defaultURL != nil {
+		log.Infof("proxy host: '%s'", defaultURL.Host)
+	}
 	return DialerFunc(func(ctx context.Context, network, address string) (net.Conn, error) {
 		var err error
 		var addresses []string
@@ -78,6 +119,16 @@ func ProxyDialer(log *logp.Logger, config *ProxyConfig, forward Dialer) (Dialer,
 			return nil, err
 		}
 
+		url := defaultURL
+		if override, ok := overrides.lookup(host, port); ok {
+			url = proxies[override]
+		} else if noProxy.Match(host, port) {
+			url = nil
+		}
+		if url == nil {
+			return forward.DialContext(ctx, network, address)
+		}
+
 		if config.LocalResolve {
 			addresses, err = net.LookupHost(host)
 			if err != nil {
@@ -0,0 +1,93 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package transport
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+)
+
+// ProxyOverride routes the connections to the destinations matching Hosts
+// through URL instead of the default proxy. An empty URL means the matching
+// destinations are connected to directly.
+type ProxyOverride struct {
+	// Hosts lists the destinations the override applies to, using the
+	// NO_PROXY format (see NoProxy), e.g. "*.elastic.co" or "10.0.0.0/8".
+	Hosts []string `config:"hosts" yaml:"hosts"`
+
+	// URL of the proxy to use for the matching destinations.
+	URL string `config:"url" yaml:"url,omitempty"`
+}
+
+// ProxyOverrides is an ordered list of per destination proxy settings. The
+// first override matching a destination wins.
+type ProxyOverrides []ProxyOverride
+
+// Validate checks that all overrides have hosts and a valid proxy URL.
+func (o ProxyOverrides) Validate() error {
+	for i, override := range o {
+		if len(override.Hosts) == 0 {
+			return fmt.Errorf("proxy override %d: no hosts configured", i)
+		}
+		if override.URL == "" {
+			continue
+		}
+		if _, err := url.Parse(override.URL); err != nil {
+			return fmt.Errorf("proxy override %d: invalid url: %w", i, err)
+		}
+	}
+	return nil
+}
+
+// Lookup returns the proxy URL configured for the destination host and
+// port. The returned URL is empty if the destination must be connected to
+// directly. ok is false if no override matches the destination.
+func (o ProxyOverrides) Lookup(host, port string) (proxyURL string, ok bool) {
+	return o.compile().lookup(host, port)
+}
+
+type compiledOverride struct {
+	hosts NoProxy
+	url   string
+}
+
+type compiledOverrides []compiledOverride
+
+func (o ProxyOverrides) compile() compiledOverrides {
+	if len(o) == 0 {
+		return nil
+	}
+	compiled := make(compiledOverrides, 0, len(o))
+	for _, override := range o {
+		compiled = append(compiled, compiledOverride{
+			hosts: ParseNoProxy(strings.Join(override.Hosts, ",")),
+			url:   override.URL,
+		})
+	}
+	return compiled
+}
+
+func (c compiledOverrides) lookup(host, port string) (string, bool) {
+	for _, override := range c {
+		if override.hosts.Match(host, port) {
+			return override.url, true
+		}
+	}
+	return "", false
+}
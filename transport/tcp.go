@@ -28,11 +28,43 @@ import (
 	"github.com/elastic/elastic-agent-libs/testing"
 )
 
-func NetDialer(timeout time.Duration) Dialer {
-	return TestNetDialer(testing.NullDriver, timeout)
+// NetDialerOption configures the dialers created by NetDialer and
+// TestNetDialer.
+type NetDialerOption func(*netDialerConfig)
+
+type netDialerConfig struct {
+	family IPFamily
+	hosts  HostOverrides
+}
+
+// WithIPFamily selects the IP addresses dialed when connecting to host names.
+func WithIPFamily(family IPFamily) NetDialerOption {
+	return func(c *netDialerConfig) {
+		c.family = family
+	}
 }
 
-func TestNetDialer(d testing.Driver, timeout time.Duration) Dialer {
+// WithHostOverrides resolves the configured host names to static addresses
+// instead of using DNS.
+func WithHostOverrides(hosts HostOverrides) NetDialerOption {
+	return func(c *netDialerConfig) {
+		c.hosts = hosts
+	}
+}
+
+// NetDialer creates a Dialer resolving host names and dialing their
+// addresses, as configured by opts.
+func NetDialer(timeout time.Duration, opts ...NetDialerOption) Dialer {
+	return TestNetDialer(testing.NullDriver, timeout, opts...)
+}
+
+// TestNetDialer is like NetDialer, reporting the lookups to d.
+func TestNetDialer(d testing.Driver, timeout time.Duration, opts ...NetDialerOption) Dialer {
+	var config netDialerConfig
+	for _, opt := range opts {
+		opt(&config)
+	}
+
 	return DialerFunc(func(ctx context.Context, network, address string) (net.Conn, error) {
 		switch network {
 		case "tcp", "tcp4", "tcp6", "udp", "udp4", "udp6":
@@ -46,17 +78,27 @@ func TestNetDialer(d testing.Driver, timeout time.Duration) Dialer {
 		if err != nil {
 			return nil, err
 		}
-		addresses, err := net.LookupHost(host)
-		d.Fatal("dns lookup", err)
-		d.Info("addresses", strings.Join(addresses, ", "))
-		if err != nil {
-			logp.NewLogger(logSelector).Warnf(`DNS lookup failure "%s": %+v`, host, err)
-			return nil, err
+
+		var addresses []string
+		if ip, overridePort, ok := config.hosts.lookup(host); ok {
+			addresses = []string{ip}
+			if overridePort != "" {
+				port = overridePort
+			}
+			d.Info("host override", net.JoinHostPort(ip, port))
+		} else {
+			addresses, err = net.LookupHost(host)
+			d.Fatal("dns lookup", err)
+			d.Info("addresses", strings.Join(addresses, ", "))
+			if err != nil {
+				logp.NewLogger(logSelector).Warnf(`DNS lookup failure "%s": %+v`, host, err)
+				return nil, err
+			}
 		}
 
 		// dial via host IP by randomized iteration of known IPs
 		dialer := &net.Dialer{Timeout: timeout}
-		return DialWith(ctx, dialer, network, host, addresses, port)
+		return DialWithFamily(ctx, dialer, network, host, addresses, port, config.family)
 	})
 }
 
@@ -54,7 +54,7 @@ func DialContext(ctx context.Context, c Config, network, address string) (net.Co
 
 func MakeDialer(c Config) (Dialer, error) {
 	var err error
-	dialer := NetDialer(c.Timeout)
+	dialer := NetDialer(c.Timeout, WithIPFamily(c.IPFamily), WithHostOverrides(c.Hosts))
 	dialer, err = ProxyDialer(logp.NewLogger(logSelector), c.Proxy, dialer)
 	if err != nil {
 		return nil, err
@@ -64,7 +64,7 @@ func MakeDialer(c Config) (Dialer, error) {
 	}
 
 	if c.TLS != nil {
-		return TLSDialer(dialer, c.TLS, c.Timeout), nil
+		dialer = TLSDialer(dialer, c.TLS, c.Timeout)
 	}
-	return dialer, nil
+	return MaxAgeDialer(dialer, c.MaxConnectionAge, c.MaxConnectionAgeJitter), nil
 }
@@ -20,7 +20,6 @@ package transport
 import (
 	"context"
 	"fmt"
-	"math/rand"
 	"net"
 	"strings"
 )
@@ -49,12 +48,27 @@ func DialWith(
 	network, host string,
 	addresses []string,
 	port string,
+) (c net.Conn, err error) {
+	return DialWithFamily(ctx, dialer, network, host, addresses, port, IPFamilyAny)
+}
+
+// DialWithFamily dials one of a number of addresses with a given dialer,
+// selecting and ordering the addresses according to family.
+func DialWithFamily(
+	ctx context.Context,
+	dialer Dialer,
+	network, host string,
+	addresses []string,
+	port string,
+	family IPFamily,
 ) (c net.Conn, err error) {
 	switch len(addresses) {
 	case 0:
 		return nil, fmt.Errorf("no route to host %v", host)
 	case 1:
-		return dialer.DialContext(ctx, network, net.JoinHostPort(addresses[0], port))
+		if family == IPFamilyAny {
+			return dialer.DialContext(ctx, network, net.JoinHostPort(addresses[0], port))
+		}
 	}
 
 	// Use randomization on DNS reported addresses combined with timeout and ACKs
@@ -70,8 +84,12 @@ func DialWith(
 	// https://tools.ietf.org/html/rfc1794
 	// > "Clients, of course, may reorder this information" - with respect to
 	// > handling order of dns records in a response.forwarded. Really required?
-	for _, i := range rand.Perm(len(addresses)) {
-		c, err = dialer.DialContext(ctx, network, net.JoinHostPort(addresses[i], port))
+	ordered := family.Order(append([]string(nil), addresses...))
+	if len(ordered) == 0 {
+		return nil, fmt.Errorf("no %v address for host %v", family, host)
+	}
+	for _, addr := range ordered {
+		c, err = dialer.DialContext(ctx, network, net.JoinHostPort(addr, port))
 		if err == nil && c != nil {
 			return c, err
 		}
@@ -22,17 +22,62 @@ import (
 	"strings"
 )
 
+// Info holds optional attributes describing the environment the binary
+// runs in. Every non-empty attribute is rendered as an additional comment
+// of the user agent.
+type Info struct {
+	// OSName and OSVersion describe the operating system, e.g. "Ubuntu"
+	// and "22.04". They are rendered together as a single comment.
+	OSName    string
+	OSVersion string
+	// Arch overrides the architecture reported by the Go runtime, e.g.
+	// to report the host architecture when running under emulation.
+	Arch string
+	// ContainerRuntime is the container runtime the binary runs in, if any,
+	// e.g. "docker" or "containerd". Rendered as "container/<runtime>".
+	ContainerRuntime string
+	// FIPS reports whether the binary runs in FIPS mode. Rendered as "fips".
+	FIPS bool
+}
+
+func (i Info) comments() []string {
+	var comments []string
+	if osName := strings.TrimSpace(i.OSName + " " + i.OSVersion); osName != "" {
+		comments = append(comments, osName)
+	}
+	if i.ContainerRuntime != "" {
+		comments = append(comments, "container/"+i.ContainerRuntime)
+	}
+	if i.FIPS {
+		comments = append(comments, "fips")
+	}
+	return comments
+}
+
 // UserAgent takes the capitalized name of the current beat and returns
 // an RFC compliant user agent string for that beat.
 func UserAgent(binaryNameCapitalized string, version, commit, buildTime string, additionalComments ...string) string {
+	return UserAgentWithInfo(binaryNameCapitalized, version, commit, buildTime, Info{}, additionalComments...)
+}
+
+// UserAgentWithInfo works like UserAgent, but additionally renders the
+// attributes in info as comments, right after the build information and
+// before additionalComments.
+func UserAgentWithInfo(binaryNameCapitalized string, version, commit, buildTime string, info Info, additionalComments ...string) string {
+	arch := runtime.GOARCH
+	if info.Arch != "" {
+		arch = info.Arch
+	}
+
 	var builder strings.Builder
 	builder.WriteString("Elastic-" + binaryNameCapitalized + "/" + version + " ")
 	uaValues := []string{
 		runtime.GOOS,
-		runtime.GOARCH,
+		arch,
 		commit,
 		buildTime,
 	}
+	uaValues = append(uaValues, info.comments()...)
 	for _, val := range additionalComments {
 		if val != "" {
 			uaValues = append(uaValues, val)
@@ -19,6 +19,7 @@ package useragent
 
 import (
 	"regexp"
+	"runtime"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -37,3 +38,23 @@ func TestUserAgent(t *testing.T) {
 	ua2 := UserAgent("FakeBeat", v, commit, buildTime, "integration_name/1.2.3")
 	assert.Regexp(t, regexp.MustCompile(`; integration_name\/1\.2\.3\)$`), ua2)
 }
+
+func TestUserAgentWithInfo(t *testing.T) {
+	ua := UserAgentWithInfo("FakeBeat", v, commit, buildTime, Info{})
+	assert.Equal(t, UserAgent("FakeBeat", v, commit, buildTime), ua)
+
+	info := Info{
+		OSName:           "Ubuntu",
+		OSVersion:        "22.04",
+		Arch:             "arm64",
+		ContainerRuntime: "docker",
+		FIPS:             true,
+	}
+	ua = UserAgentWithInfo("FakeBeat", v, commit, buildTime, info, "integration_name/1.2.3")
+	assert.Equal(t,
+		"Elastic-FakeBeat/9.9.9 ("+runtime.GOOS+"; arm64; 1234abcd; 20001212; Ubuntu 22.04; container/docker; fips; integration_name/1.2.3)",
+		ua)
+
+	ua = UserAgentWithInfo("FakeBeat", v, commit, buildTime, Info{OSVersion: "10.0"})
+	assert.Regexp(t, regexp.MustCompile(`; 20001212; 10\.0\)$`), ua)
+}
@@ -0,0 +1,144 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package version
+
+import (
+	"strconv"
+	"strings"
+)
+
+// Snapshot is the pre-release identifier used by Elastic snapshot builds.
+const Snapshot = "SNAPSHOT"
+
+// IsSnapshot returns true if v is a snapshot build, e.g. 8.13.0-SNAPSHOT or
+// 8.13.0-beta1-SNAPSHOT.
+func (v *V) IsSnapshot() bool {
+	return v.Meta == Snapshot || strings.HasSuffix(v.Meta, "-"+Snapshot)
+}
+
+// Prerelease returns the pre-release part of v without the snapshot
+// identifier. It returns an empty string for releases and plain snapshots.
+func (v *V) Prerelease() string {
+	if v.Meta == Snapshot {
+		return ""
+	}
+	return strings.TrimSuffix(v.Meta, "-"+Snapshot)
+}
+
+// Compare returns -1, 0 or +1 depending on whether v is lower than, equal to
+// or greater than v1, following the semantic versioning precedence rules:
+// major, minor and bugfix are compared numerically and a pre-release sorts
+// before the corresponding release. Build metadata is ignored.
+func (v *V) Compare(v1 *V) int {
+	if c := compareInt(v.Major, v1.Major); c != 0 {
+		return c
+	}
+	if c := compareInt(v.Minor, v1.Minor); c != 0 {
+		return c
+	}
+	if c := compareInt(v.Bugfix, v1.Bugfix); c != 0 {
+		return c
+	}
+	return comparePrerelease(v.Meta, v1.Meta)
+}
+
+// Equal returns true if v and v1 have the same precedence. Build metadata is
+// ignored.
+func (v *V) Equal(v1 *V) bool {
+	return v.Compare(v1) == 0
+}
+
+// GreaterThan returns true if v has a higher precedence than v1.
+func (v *V) GreaterThan(v1 *V) bool {
+	return v.Compare(v1) > 0
+}
+
+// withoutSnapshot returns a copy of v without the snapshot identifier and
+// build metadata, so that 8.13.0-SNAPSHOT becomes 8.13.0.
+func (v *V) withoutSnapshot() *V {
+	s := strconv.Itoa(v.Major) + "." + strconv.Itoa(v.Minor) + "." + strconv.Itoa(v.Bugfix)
+	pre := v.Prerelease()
+	if pre != "" {
+		s += "-" + pre
+	}
+	return &V{
+		version: s,
+		Major:   v.Major,
+		Minor:   v.Minor,
+		Bugfix:  v.Bugfix,
+		Meta:    pre,
+	}
+}
+
+func compareInt(a, b int) int {
+	switch {
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	default:
+		return 0
+	}
+}
+
+// comparePrerelease compares two pre-release strings according to the
+// semantic versioning spec. An empty pre-release (a release) has a higher
+// precedence than any pre-release.
+func comparePrerelease(a, b string) int {
+	switch {
+	case a == b:
+		return 0
+	case a == "":
+		return 1
+	case b == "":
+		return -1
+	}
+
+	as, bs := strings.Split(a, "."), strings.Split(b, ".")
+	for i := 0; i < len(as) && i < len(bs); i++ {
+		if c := compareIdentifier(as[i], bs[i]); c != 0 {
+			return c
+		}
+	}
+	return compareInt(len(as), len(bs))
+}
+
+// compareIdentifier compares a single pre-release identifier. Numeric
+// identifiers are compared numerically and always have a lower precedence
+// than alphanumeric ones, which are compared lexically.
+func compareIdentifier(a, b string) int {
+	an, aErr := strconv.ParseUint(a, 10, 64)
+	bn, bErr := strconv.ParseUint(b, 10, 64)
+	switch {
+	case aErr == nil && bErr == nil:
+		switch {
+		case an < bn:
+			return -1
+		case an > bn:
+			return 1
+		default:
+			return 0
+		}
+	case aErr == nil:
+		return -1
+	case bErr == nil:
+		return 1
+	default:
+		return strings.Compare(a, b)
+	}
+}
@@ -0,0 +1,72 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package version
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCompare(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want int
+	}{
+		{"1.2.3", "1.2.3", 0},
+		{"1.2.3", "1.2.4", -1},
+		{"1.10.0", "1.9.0", 1},
+		{"2.0.0", "10.0.0", -1},
+		{"1.2.3-alpha", "1.2.3", -1},
+		{"1.2.3-alpha", "1.2.3-alpha.1", -1},
+		{"1.2.3-alpha.1", "1.2.3-alpha.beta", -1},
+		{"1.2.3-beta.2", "1.2.3-beta.11", -1},
+		{"1.2.3-rc1", "1.2.3-beta1", 1},
+		{"1.2.3+build1", "1.2.3+build2", 0},
+		{"8.13.0-SNAPSHOT", "8.13.0", -1},
+	}
+
+	for _, test := range tests {
+		t.Run(test.a+" vs "+test.b, func(t *testing.T) {
+			a, b := MustNew(test.a), MustNew(test.b)
+			assert.Equal(t, test.want, a.Compare(b))
+			assert.Equal(t, -test.want, b.Compare(a))
+			assert.Equal(t, test.want == 0, a.Equal(b))
+			assert.Equal(t, test.want > 0, a.GreaterThan(b))
+		})
+	}
+}
+
+func TestSnapshot(t *testing.T) {
+	tests := []struct {
+		version    string
+		snapshot   bool
+		prerelease string
+	}{
+		{"8.13.0", false, ""},
+		{"8.13.0-SNAPSHOT", true, ""},
+		{"8.13.0-rc1", false, "rc1"},
+		{"8.13.0-rc1-SNAPSHOT", true, "rc1"},
+	}
+
+	for _, test := range tests {
+		v := MustNew(test.version)
+		assert.Equal(t, test.snapshot, v.IsSnapshot(), test.version)
+		assert.Equal(t, test.prerelease, v.Prerelease(), test.version)
+	}
+}
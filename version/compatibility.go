@@ -0,0 +1,130 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package version
+
+import "fmt"
+
+// Compatibility describes whether two product versions can be used together.
+type Compatibility int
+
+const (
+	// Unsupported means the versions must not be used together.
+	Unsupported Compatibility = iota
+	// Deprecated means the versions work together, but the combination is
+	// only meant to be used during an upgrade.
+	Deprecated
+	// Supported means the versions are fully compatible.
+	Supported
+)
+
+func (c Compatibility) String() string {
+	switch c {
+	case Unsupported:
+		return "unsupported"
+	case Deprecated:
+		return "deprecated"
+	case Supported:
+		return "supported"
+	default:
+		return fmt.Sprintf("Compatibility(%d)", int(c))
+	}
+}
+
+// lastMinors holds the last minor release of each past major version. These
+// releases are the only ones that are compatible across major versions.
+var lastMinors = map[int]int{
+	5: 6,
+	6: 8,
+	7: 17,
+	8: 19,
+}
+
+// CompatibilityResult is the outcome of CheckCompatibility.
+type CompatibilityResult struct {
+	Compatibility Compatibility
+	Local         *V
+	Remote        *V
+	// Reason is a human readable explanation of the result.
+	Reason string
+}
+
+func (r CompatibilityResult) String() string {
+	return fmt.Sprintf("%s: %s", r.Compatibility, r.Reason)
+}
+
+// CheckCompatibility checks whether a client at version local can talk to a
+// remote product, e.g. Elasticsearch or Kibana, at version remote. It follows
+// the Elastic compatibility rules:
+//
+//   - A remote of the same major and the same or a newer minor is supported.
+//   - A remote of the same major but an older minor is unsupported.
+//   - A remote of the next major is supported if local is the last minor of
+//     its major, e.g. 7.17 talking to 8.x.
+//   - A remote of the previous major is deprecated if it is the last minor
+//     of its major, e.g. 8.x talking to 7.17, so that upgrades can happen
+//     in any order.
+//   - Everything else is unsupported.
+//
+// Bugfix, pre-release and build information are ignored.
+func CheckCompatibility(local *V, remote string) (CompatibilityResult, error) {
+	r, err := New(remote)
+	if err != nil {
+		return CompatibilityResult{}, fmt.Errorf("invalid remote version: %w", err)
+	}
+
+	res := CompatibilityResult{Local: local, Remote: r}
+	switch {
+	case r.Major == local.Major && r.Minor >= local.Minor:
+		res.Compatibility = Supported
+		res.Reason = fmt.Sprintf("remote version %d.%d is compatible with %d.%d", r.Major, r.Minor, local.Major, local.Minor)
+	case r.Major == local.Major:
+		res.Compatibility = Unsupported
+		res.Reason = fmt.Sprintf("remote version %d.%d is older than %d.%d, upgrade the remote to at least %d.%d",
+			r.Major, r.Minor, local.Major, local.Minor, local.Major, local.Minor)
+	case r.Major == local.Major+1 && isLastMinor(local):
+		res.Compatibility = Supported
+		res.Reason = fmt.Sprintf("%d.%d is compatible with the next major version %d", local.Major, local.Minor, r.Major)
+	case r.Major == local.Major+1:
+		res.Compatibility = Unsupported
+		res.Reason = fmt.Sprintf("remote version %d.%d is a newer major, upgrade to %s", r.Major, r.Minor, lastMinorString(local.Major))
+	case r.Major == local.Major-1 && isLastMinor(r):
+		res.Compatibility = Deprecated
+		res.Reason = fmt.Sprintf("remote version %d.%d is from the previous major, upgrade the remote to %d.%d or later",
+			r.Major, r.Minor, local.Major, local.Minor)
+	case r.Major == local.Major-1:
+		res.Compatibility = Unsupported
+		res.Reason = fmt.Sprintf("remote version %d.%d is too old, upgrade the remote to %s first",
+			r.Major, r.Minor, lastMinorString(r.Major))
+	default:
+		res.Compatibility = Unsupported
+		res.Reason = fmt.Sprintf("major versions %d and %d are not compatible", local.Major, r.Major)
+	}
+	return res, nil
+}
+
+func isLastMinor(v *V) bool {
+	last, ok := lastMinors[v.Major]
+	return ok && v.Minor == last
+}
+
+func lastMinorString(major int) string {
+	if last, ok := lastMinors[major]; ok {
+		return fmt.Sprintf("%d.%d", major, last)
+	}
+	return fmt.Sprintf("the last %d.x release", major)
+}
@@ -0,0 +1,69 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package version
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCheckCompatibility(t *testing.T) {
+	tests := []struct {
+		local, remote string
+		want          Compatibility
+	}{
+		{"8.13.0", "8.13.0", Supported},
+		{"8.13.0", "8.13.4-SNAPSHOT", Supported},
+		{"8.13.2", "8.13.0", Supported},
+		{"8.13.0", "8.15.1", Supported},
+		{"8.13.0", "8.12.2", Unsupported},
+		{"7.17.20", "8.13.0", Supported},
+		{"7.16.3", "8.13.0", Unsupported},
+		{"8.13.0", "7.17.20", Deprecated},
+		{"8.13.0", "7.16.0", Unsupported},
+		{"8.13.0", "9.0.0", Unsupported},
+		{"9.0.0", "7.17.0", Unsupported},
+		{"8.19.2", "9.1.0", Supported},
+		{"9.1.0", "8.19.2", Deprecated},
+		{"9.1.0", "8.18.0", Unsupported},
+	}
+
+	for _, test := range tests {
+		t.Run(test.local+" to "+test.remote, func(t *testing.T) {
+			res, err := CheckCompatibility(MustNew(test.local), test.remote)
+			require.NoError(t, err)
+			assert.Equal(t, test.want, res.Compatibility, res.Reason)
+			assert.Equal(t, test.remote, res.Remote.String())
+			assert.NotEmpty(t, res.Reason)
+		})
+	}
+}
+
+func TestCheckCompatibilityInvalid(t *testing.T) {
+	_, err := CheckCompatibility(MustNew("8.13.0"), "not-a-version")
+	assert.Error(t, err)
+}
+
+func TestCompatibilityString(t *testing.T) {
+	assert.Equal(t, "supported", Supported.String())
+	assert.Equal(t, "deprecated", Deprecated.String())
+	assert.Equal(t, "unsupported", Unsupported.String())
+	assert.Equal(t, "Compatibility(42)", Compatibility(42).String())
+}
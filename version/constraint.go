@@ -0,0 +1,179 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package version
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// Constraint is a set of version requirements, e.g. ">=8.13.0, <9.0.0".
+// Comparisons separated by a comma must all be satisfied, alternatives can
+// be given separated by "||", e.g. ">=7.17.0, <8.0.0 || >=8.13.0".
+//
+// Snapshot builds are checked as if they were the build they are a snapshot
+// of, so 8.13.0-SNAPSHOT satisfies ">=8.13.0" and 8.13.0-rc1-SNAPSHOT is
+// treated as 8.13.0-rc1. Other pre-releases keep their precedence, so
+// 8.13.0-beta1 does not satisfy ">=8.13.0".
+type Constraint struct {
+	raw    string
+	groups []comparisons
+}
+
+type operator string
+
+const (
+	opEqual          operator = "="
+	opNotEqual       operator = "!="
+	opGreater        operator = ">"
+	opGreaterOrEqual operator = ">="
+	opLess           operator = "<"
+	opLessOrEqual    operator = "<="
+)
+
+// operators is ordered so that two character operators are matched first.
+var operators = []operator{
+	opGreaterOrEqual,
+	opLessOrEqual,
+	opNotEqual,
+	opGreater,
+	opLess,
+	opEqual,
+}
+
+type comparison struct {
+	op      operator
+	version *V
+}
+
+func (c comparison) check(v *V) bool {
+	cmp := v.Compare(c.version)
+	switch c.op {
+	case opNotEqual:
+		return cmp != 0
+	case opGreater:
+		return cmp > 0
+	case opGreaterOrEqual:
+		return cmp >= 0
+	case opLess:
+		return cmp < 0
+	case opLessOrEqual:
+		return cmp <= 0
+	default:
+		return cmp == 0
+	}
+}
+
+// MustNewConstraint creates a constraint from the given string.
+// If the constraint is invalid, MustNewConstraint panics.
+func MustNewConstraint(constraint string) *Constraint {
+	c, err := NewConstraint(constraint)
+	if err != nil {
+		panic(err)
+	}
+	return c
+}
+
+// NewConstraint parses a constraint string. Each comparison consists of an
+// optional operator (=, !=, >, >=, < or <=, defaulting to =) followed by a
+// version. Versions in a constraint may omit the minor and bugfix numbers,
+// which then default to 0.
+func NewConstraint(constraint string) (*Constraint, error) {
+	if strings.TrimSpace(constraint) == "" {
+		return nil, errors.New("empty version constraint")
+	}
+
+	c := &Constraint{raw: constraint}
+	for _, alternative := range strings.Split(constraint, "||") {
+		var group comparisons
+		for _, part := range strings.Split(alternative, ",") {
+			cmp, err := parseComparison(strings.TrimSpace(part))
+			if err != nil {
+				return nil, fmt.Errorf("invalid version constraint %q: %w", constraint, err)
+			}
+			group = append(group, cmp)
+		}
+		c.groups = append(c.groups, group)
+	}
+	return c, nil
+}
+
+func parseComparison(s string) (comparison, error) {
+	if s == "" {
+		return comparison{}, errors.New("empty comparison")
+	}
+
+	op := opEqual
+	for _, candidate := range operators {
+		if strings.HasPrefix(s, string(candidate)) {
+			op = candidate
+			s = strings.TrimSpace(s[len(candidate):])
+			break
+		}
+	}
+
+	v, err := New(completeVersion(s))
+	if err != nil {
+		return comparison{}, err
+	}
+	return comparison{op: op, version: v.withoutSnapshot()}, nil
+}
+
+// completeVersion adds missing minor and bugfix numbers to s, so that 8 and
+// 8.13 become 8.0.0 and 8.13.0.
+func completeVersion(s string) string {
+	core, suffix := s, ""
+	if idx := strings.IndexAny(s, "-+"); idx >= 0 {
+		core, suffix = s[:idx], s[idx:]
+	}
+	switch strings.Count(core, ".") {
+	case 0:
+		core += ".0.0"
+	case 1:
+		core += ".0"
+	}
+	return core + suffix
+}
+
+// Check returns true if v satisfies the constraint.
+func (c *Constraint) Check(v *V) bool {
+	v = v.withoutSnapshot()
+	for _, group := range c.groups {
+		if group.check(v) {
+			return true
+		}
+	}
+	return false
+}
+
+// String returns the constraint as it was given to NewConstraint.
+func (c *Constraint) String() string {
+	return c.raw
+}
+
+type comparisons []comparison
+
+func (g comparisons) check(v *V) bool {
+	for _, cmp := range g {
+		if !cmp.check(v) {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,83 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package version
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestConstraint(t *testing.T) {
+	tests := []struct {
+		constraint string
+		match      []string
+		noMatch    []string
+	}{
+		{
+			constraint: ">=8.13.0, <9.0.0",
+			match:      []string{"8.13.0", "8.13.0-SNAPSHOT", "8.17.2", "8.99.99+abc"},
+			noMatch:    []string{"8.12.2", "8.13.0-beta1", "9.0.0", "9.0.0-SNAPSHOT"},
+		},
+		{
+			constraint: "8.13",
+			match:      []string{"8.13.0", "8.13.0-SNAPSHOT"},
+			noMatch:    []string{"8.13.1", "8.13.0-rc1"},
+		},
+		{
+			constraint: "!=8.13.1",
+			match:      []string{"8.13.0", "8.13.2"},
+			noMatch:    []string{"8.13.1", "8.13.1-SNAPSHOT"},
+		},
+		{
+			constraint: ">7.17.0, <=7.17.20 || >= 8.13",
+			match:      []string{"7.17.1", "7.17.20", "8.13.0", "9.1.0"},
+			noMatch:    []string{"7.17.0", "7.17.21", "8.0.0"},
+		},
+		{
+			constraint: ">=8.13.0-rc1",
+			match:      []string{"8.13.0-rc1", "8.13.0-rc2-SNAPSHOT", "8.13.0"},
+			noMatch:    []string{"8.13.0-beta1", "8.12.0"},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.constraint, func(t *testing.T) {
+			c, err := NewConstraint(test.constraint)
+			require.NoError(t, err)
+			assert.Equal(t, test.constraint, c.String())
+
+			for _, v := range test.match {
+				assert.True(t, c.Check(MustNew(v)), "%s should satisfy %s", v, test.constraint)
+			}
+			for _, v := range test.noMatch {
+				assert.False(t, c.Check(MustNew(v)), "%s should not satisfy %s", v, test.constraint)
+			}
+		})
+	}
+}
+
+func TestConstraintInvalid(t *testing.T) {
+	for _, constraint := range []string{"", ">=", ">=8.13.0,", "~8.13.0", ">=a.b.c", "8.13.0 || "} {
+		_, err := NewConstraint(constraint)
+		assert.Error(t, err, constraint)
+	}
+
+	assert.Panics(t, func() { MustNewConstraint("") })
+}
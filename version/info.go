@@ -0,0 +1,112 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package version
+
+import (
+	"encoding/json"
+	"runtime/debug"
+	"strconv"
+	"time"
+
+	"github.com/elastic/elastic-agent-libs/monitoring"
+)
+
+// Build information injected at link time, e.g.:
+//
+//	go build -ldflags "\
+//	  -X github.com/elastic/elastic-agent-libs/version.buildVersion=8.13.0 \
+//	  -X github.com/elastic/elastic-agent-libs/version.commit=$(git rev-parse HEAD) \
+//	  -X github.com/elastic/elastic-agent-libs/version.buildTime=$(date -u +%Y-%m-%dT%H:%M:%SZ) \
+//	  -X github.com/elastic/elastic-agent-libs/version.snapshot=true"
+//
+// When commit or buildTime are not set, they are taken from the VCS
+// information embedded by the Go toolchain, if available.
+var (
+	buildVersion string
+	commit       string
+	buildTime    string
+	snapshot     string
+)
+
+// BuildInfo describes the running binary.
+type BuildInfo struct {
+	Version   string    `json:"version"`
+	Commit    string    `json:"commit"`
+	BuildTime time.Time `json:"build_time"`
+	Snapshot  bool      `json:"snapshot"`
+}
+
+// Info returns the build information of the running binary.
+func Info() BuildInfo {
+	info := BuildInfo{
+		Version: buildVersion,
+		Commit:  commit,
+	}
+	info.Snapshot, _ = strconv.ParseBool(snapshot)
+	if t, err := time.Parse(time.RFC3339, buildTime); err == nil {
+		info.BuildTime = t
+	}
+
+	if bi, ok := debug.ReadBuildInfo(); ok {
+		if info.Version == "" && bi.Main.Version != "(devel)" {
+			info.Version = bi.Main.Version
+		}
+		for _, s := range bi.Settings {
+			switch s.Key {
+			case "vcs.revision":
+				if info.Commit == "" {
+					info.Commit = s.Value
+				}
+			case "vcs.time":
+				if t, err := time.Parse(time.RFC3339, s.Value); err == nil && info.BuildTime.IsZero() {
+					info.BuildTime = t
+				}
+			}
+		}
+	}
+	return info
+}
+
+// String returns the JSON representation of the build information.
+func (i BuildInfo) String() string {
+	b, err := json.Marshal(i)
+	if err != nil {
+		return ""
+	}
+	return string(b)
+}
+
+// RegisterMonitoring registers the build information under name in the
+// given monitoring registry. The information is reported as a namespace
+// holding the version, commit, build_time and snapshot fields.
+func RegisterMonitoring(r *monitoring.Registry, name string) {
+	info := Info()
+	monitoring.NewFunc(r, name, func(_ monitoring.Mode, v monitoring.Visitor) {
+		v.OnRegistryStart()
+		defer v.OnRegistryFinished()
+
+		monitoring.ReportString(v, "version", info.Version)
+		monitoring.ReportString(v, "commit", info.Commit)
+		if info.BuildTime.IsZero() {
+			monitoring.ReportString(v, "build_time", "")
+		} else {
+			monitoring.ReportString(v, "build_time", info.BuildTime.Format(time.RFC3339))
+		}
+		monitoring.ReportBool(v, "snapshot", info.Snapshot)
+	}, monitoring.Report)
+}
@@ -0,0 +1,84 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package version
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/elastic/elastic-agent-libs/monitoring"
+)
+
+func setBuildInfo(t *testing.T, v, c, bt, snap string) {
+	t.Helper()
+	oldVersion, oldCommit, oldBuildTime, oldSnapshot := buildVersion, commit, buildTime, snapshot
+	t.Cleanup(func() {
+		buildVersion, commit, buildTime, snapshot = oldVersion, oldCommit, oldBuildTime, oldSnapshot
+	})
+	buildVersion, commit, buildTime, snapshot = v, c, bt, snap
+}
+
+func TestInfo(t *testing.T) {
+	setBuildInfo(t, "8.13.0", "abc123", "2024-03-26T12:00:00Z", "true")
+
+	info := Info()
+	assert.Equal(t, BuildInfo{
+		Version:   "8.13.0",
+		Commit:    "abc123",
+		BuildTime: time.Date(2024, 3, 26, 12, 0, 0, 0, time.UTC),
+		Snapshot:  true,
+	}, info)
+
+	var decoded map[string]interface{}
+	require.NoError(t, json.Unmarshal([]byte(info.String()), &decoded))
+	assert.Equal(t, map[string]interface{}{
+		"version":    "8.13.0",
+		"commit":     "abc123",
+		"build_time": "2024-03-26T12:00:00Z",
+		"snapshot":   true,
+	}, decoded)
+}
+
+func TestInfoInvalidValues(t *testing.T) {
+	setBuildInfo(t, "8.13.0", "abc123", "yesterday", "maybe")
+
+	info := Info()
+	assert.False(t, info.Snapshot)
+	assert.Equal(t, "abc123", info.Commit)
+}
+
+func TestRegisterMonitoring(t *testing.T) {
+	setBuildInfo(t, "8.13.0", "abc123", "2024-03-26T12:00:00Z", "false")
+
+	reg := monitoring.NewRegistry()
+	RegisterMonitoring(reg, "build")
+
+	snapshot := monitoring.CollectStructSnapshot(reg, monitoring.Full, false)
+	assert.Equal(t, map[string]interface{}{
+		"build": map[string]interface{}{
+			"version":    "8.13.0",
+			"commit":     "abc123",
+			"build_time": "2024-03-26T12:00:00Z",
+			"snapshot":   false,
+		},
+	}, snapshot)
+}
@@ -30,6 +30,7 @@ type V struct {
 	Minor   int
 	Bugfix  int
 	Meta    string
+	Build   string
 }
 
 // MustNew creates a version from the given version string.
@@ -43,17 +44,22 @@ func MustNew(version string) *V {
 }
 
 // New expects a string in the format:
-// major.minor.bugfix(-meta)
+// major.minor.bugfix(-meta)(+build)
 func New(version string) (*V, error) {
 	v := V{
 		version: version,
 	}
 
+	// Check for build metadata
+	if idx := strings.Index(version, "+"); idx >= 0 {
+		v.Build = version[idx+1:]
+		version = version[:idx]
+	}
+
 	// Check for meta info
-	if strings.Contains(version, "-") {
-		tmp := strings.Split(version, "-")
-		version = tmp[0]
-		v.Meta = tmp[1]
+	if idx := strings.Index(version, "-"); idx >= 0 {
+		v.Meta = version[idx+1:]
+		version = version[:idx]
 	}
 
 	versions := strings.Split(version, ".")
@@ -45,6 +45,16 @@ func TestVersion(t *testing.T) {
 			err:     false,
 			result:  V{Major: 1, Minor: 3, Bugfix: 2, version: "1.3.2-alpha1", Meta: "alpha1"},
 		},
+		{
+			version: "8.13.0-beta1-SNAPSHOT",
+			err:     false,
+			result:  V{Major: 8, Minor: 13, Bugfix: 0, version: "8.13.0-beta1-SNAPSHOT", Meta: "beta1-SNAPSHOT"},
+		},
+		{
+			version: "8.13.0+build202401011200",
+			err:     false,
+			result:  V{Major: 8, Minor: 13, Bugfix: 0, version: "8.13.0+build202401011200", Build: "build202401011200"},
+		},
 		{
 			version: "alpha1",
 			err:     true,